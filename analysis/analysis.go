@@ -0,0 +1,226 @@
+// Package analysis finds flows that can never be hit or whose behavior is
+// undefined in a set of OpenFlow 1.5 flows: flows shadowed by a higher priority
+// flow, overlapping flows of the same priority, and goto-table instructions to
+// tables that do not exist or cannot be reached.
+//
+// A flow is only reported as shadowed when a single higher priority flow
+// matches all its packets. A flow whose packets are covered by several higher
+// priority flows together, such as tcp_dst=80 under tcp_dst=0/1 and
+// tcp_dst=1/1, is not detected.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"antrea.io/libOpenflow/openflow15"
+)
+
+// Kind is the kind of a Finding.
+type Kind int
+
+const (
+	// Shadowed is a flow that never matches because a higher priority flow
+	// in the same table matches all its packets.
+	Shadowed Kind = iota
+	// Overlap is a pair of flows with the same priority matching some
+	// packets in common. Which one applies is undefined.
+	Overlap
+	// MissingTable is a goto_table, resubmit or ct recirculation to a table
+	// without flows.
+	MissingTable
+	// BackwardGoto is a goto_table to a table that is not after the current
+	// one, which the switch rejects.
+	BackwardGoto
+	// UnreachableTable is a table with flows that no path from table 0 leads
+	// to.
+	UnreachableTable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Shadowed:
+		return "shadowed"
+	case Overlap:
+		return "overlap"
+	case MissingTable:
+		return "missing table"
+	case BackwardGoto:
+		return "backward goto"
+	case UnreachableTable:
+		return "unreachable table"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Finding is a problem found in a set of flows.
+type Finding struct {
+	Kind  Kind
+	Table uint8
+	// Flow is the flow the finding is about. It is nil for UnreachableTable.
+	Flow *openflow15.FlowMod
+	// Other is the shadowing flow for Shadowed and the other flow for Overlap.
+	Other *openflow15.FlowMod
+	// Target is the destination table for MissingTable and BackwardGoto.
+	Target uint8
+}
+
+func (f Finding) String() string {
+	switch f.Kind {
+	case Shadowed, Overlap:
+		return fmt.Sprintf("table %d: %s: priority %d flow and priority %d flow", f.Table, f.Kind, f.Flow.Priority, f.Other.Priority)
+	case MissingTable, BackwardGoto:
+		return fmt.Sprintf("table %d: %s: priority %d flow goes to table %d", f.Table, f.Kind, f.Flow.Priority, f.Target)
+	}
+	return fmt.Sprintf("table %d: %s", f.Table, f.Kind)
+}
+
+type flow struct {
+	mod   *openflow15.FlowMod
+	match flowMatch
+	// conjunctive flows only match together with the other clauses of their
+	// conjunction, so they neither shadow nor overlap other flows.
+	conjunctive bool
+}
+
+// Analyze returns the findings for flows, ordered by table. Flows are
+// FlowMods with the FC_ADD command, grouped by their TableId.
+func Analyze(flows []*openflow15.FlowMod) ([]Finding, error) {
+	tables := make(map[uint8][]*flow)
+	for _, f := range flows {
+		m, err := newFlowMatch(&f.Match)
+		if err != nil {
+			return nil, err
+		}
+		tables[f.TableId] = append(tables[f.TableId], &flow{mod: f, match: m, conjunctive: isConjunctive(f)})
+	}
+	tableIDs := make([]int, 0, len(tables))
+	for id := range tables {
+		tableIDs = append(tableIDs, int(id))
+	}
+	sort.Ints(tableIDs)
+
+	var findings []Finding
+	for _, id := range tableIDs {
+		findings = append(findings, analyzeTable(uint8(id), tables[uint8(id)])...)
+	}
+	findings = append(findings, analyzeTargets(tableIDs, tables)...)
+	return findings, nil
+}
+
+func analyzeTable(tableID uint8, flows []*flow) []Finding {
+	// Higher priorities first, keeping the order of the caller otherwise.
+	sorted := make([]*flow, len(flows))
+	copy(sorted, flows)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].mod.Priority > sorted[j].mod.Priority
+	})
+
+	var findings []Finding
+	for i, low := range sorted {
+		if low.conjunctive {
+			continue
+		}
+		for _, high := range sorted[:i] {
+			if high.conjunctive {
+				continue
+			}
+			if high.mod.Priority == low.mod.Priority {
+				if high.match.intersects(low.match) {
+					findings = append(findings, Finding{Kind: Overlap, Table: tableID, Flow: low.mod, Other: high.mod})
+				}
+				continue
+			}
+			if high.match.covers(low.match) {
+				findings = append(findings, Finding{Kind: Shadowed, Table: tableID, Flow: low.mod, Other: high.mod})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+type target struct {
+	table  uint8
+	isGoto bool
+}
+
+// targets returns the tables a flow sends packets to.
+func targets(f *openflow15.FlowMod) []target {
+	var result []target
+	addActions := func(actions []openflow15.Action) {
+		for _, a := range actions {
+			switch act := a.(type) {
+			case *openflow15.NXActionResubmitTable:
+				if act.TableID != openflow15.OFPTT_ALL {
+					result = append(result, target{table: act.TableID})
+				}
+			case *openflow15.NXActionConnTrack:
+				if act.RecircTable != openflow15.OFPTT_ALL {
+					result = append(result, target{table: act.RecircTable})
+				}
+			}
+		}
+	}
+	for _, instr := range f.Instructions {
+		switch i := instr.(type) {
+		case *openflow15.InstrGotoTable:
+			result = append(result, target{table: i.TableId, isGoto: true})
+		case *openflow15.InstrActions:
+			addActions(i.Actions)
+		}
+	}
+	return result
+}
+
+func isConjunctive(f *openflow15.FlowMod) bool {
+	for _, instr := range f.Instructions {
+		if i, ok := instr.(*openflow15.InstrActions); ok {
+			for _, a := range i.Actions {
+				if _, ok := a.(*openflow15.NXActionConjunction); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func analyzeTargets(tableIDs []int, tables map[uint8][]*flow) []Finding {
+	var findings []Finding
+	edges := make(map[uint8][]uint8)
+	for _, id := range tableIDs {
+		for _, f := range tables[uint8(id)] {
+			for _, t := range targets(f.mod) {
+				if t.isGoto && int(t.table) <= id {
+					findings = append(findings, Finding{Kind: BackwardGoto, Table: uint8(id), Flow: f.mod, Target: t.table})
+					continue
+				}
+				if _, ok := tables[t.table]; !ok {
+					findings = append(findings, Finding{Kind: MissingTable, Table: uint8(id), Flow: f.mod, Target: t.table})
+					continue
+				}
+				edges[uint8(id)] = append(edges[uint8(id)], t.table)
+			}
+		}
+	}
+
+	reached := map[uint8]bool{0: true}
+	queue := []uint8{0}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range edges[id] {
+			if !reached[next] {
+				reached[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	for _, id := range tableIDs {
+		if !reached[uint8(id)] {
+			findings = append(findings, Finding{Kind: UnreachableTable, Table: uint8(id)})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,119 @@
+package analysis
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/openflow15"
+)
+
+func newFlow(tableID uint8, priority uint16, fields ...*openflow15.MatchField) *openflow15.FlowMod {
+	f := openflow15.NewFlowMod()
+	f.TableId = tableID
+	f.Priority = priority
+	for _, field := range fields {
+		f.Match.AddField(*field)
+	}
+	return f
+}
+
+func gotoTable(f *openflow15.FlowMod, tableID uint8) *openflow15.FlowMod {
+	f.AddInstruction(openflow15.NewInstrGotoTable(tableID))
+	return f
+}
+
+func ipv4Src(ip string, mask string) *openflow15.MatchField {
+	if mask == "" {
+		return openflow15.NewIpv4SrcField(net.ParseIP(ip), nil)
+	}
+	m := net.ParseIP(mask).To4()
+	return openflow15.NewIpv4SrcField(net.ParseIP(ip).To4(), &m)
+}
+
+func kinds(findings []Finding) []Kind {
+	var result []Kind
+	for _, f := range findings {
+		result = append(result, f.Kind)
+	}
+	return result
+}
+
+func TestShadowed(t *testing.T) {
+	subnet := newFlow(0, 200, ipv4Src("10.0.0.0", "255.255.0.0"))
+	host := newFlow(0, 100, ipv4Src("10.0.1.1", ""), openflow15.NewIpProtoField(6))
+	other := newFlow(0, 100, ipv4Src("10.1.1.1", ""))
+	ipv6 := newFlow(0, 50, openflow15.NewIpv6SrcField(net.ParseIP("fe80::1"), nil))
+	ip := newFlow(0, 300, openflow15.NewEthTypeField(0x0800))
+
+	findings, err := Analyze([]*openflow15.FlowMod{subnet, host, other, ipv6})
+	require.NoError(t, err)
+	require.Equal(t, []Kind{Shadowed}, kinds(findings))
+	assert.Equal(t, host, findings[0].Flow)
+	assert.Equal(t, subnet, findings[0].Other)
+
+	// eth_type=0x0800 shadows all IPv4 flows through their prerequisites, not
+	// the IPv6 one.
+	findings, err = Analyze([]*openflow15.FlowMod{subnet, ipv6, ip})
+	require.NoError(t, err)
+	require.Equal(t, []Kind{Shadowed}, kinds(findings))
+	assert.Equal(t, subnet, findings[0].Flow)
+	assert.Equal(t, ip, findings[0].Other)
+
+	// The NXM form of eth_type is the same field.
+	nxmIP := openflow15.NewEthTypeField(0x0800)
+	nxmIP.Class = openflow15.OXM_CLASS_NXM_0
+	nxmIP.Field = openflow15.NXM_OF_ETH_TYPE
+	nxm := newFlow(0, 300, nxmIP)
+	findings, err = Analyze([]*openflow15.FlowMod{subnet, nxm})
+	require.NoError(t, err)
+	require.Equal(t, []Kind{Shadowed}, kinds(findings))
+	assert.Equal(t, nxm, findings[0].Other)
+
+	// A flow covered by several higher priority flows together is not
+	// detected.
+	low := newFlow(0, 250, ipv4Src("10.0.0.0", "255.255.255.0"))
+	high1 := newFlow(0, 300, ipv4Src("10.0.0.0", "255.255.255.128"))
+	high2 := newFlow(0, 400, ipv4Src("10.0.0.128", "255.255.255.128"))
+	findings, err = Analyze([]*openflow15.FlowMod{low, high1, high2})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestOverlap(t *testing.T) {
+	a := newFlow(0, 100, ipv4Src("10.0.0.0", "255.255.255.0"))
+	b := newFlow(0, 100, ipv4Src("10.0.0.0", "255.255.0.0"), openflow15.NewIpProtoField(17))
+	c := newFlow(0, 100, ipv4Src("10.1.0.0", "255.255.255.0"))
+	conj := newFlow(0, 100, ipv4Src("10.0.0.0", "255.0.0.0"))
+	instr := openflow15.NewInstrApplyActions()
+	instr.AddAction(openflow15.NewNXActionConjunction(1, 2, 10), false)
+	conj.AddInstruction(instr)
+
+	findings, err := Analyze([]*openflow15.FlowMod{a, b, c, conj})
+	require.NoError(t, err)
+	require.Equal(t, []Kind{Overlap}, kinds(findings))
+	assert.Equal(t, b, findings[0].Flow)
+	assert.Equal(t, a, findings[0].Other)
+}
+
+func TestTables(t *testing.T) {
+	flows := []*openflow15.FlowMod{
+		gotoTable(newFlow(0, 100), 10),
+		gotoTable(newFlow(10, 100, openflow15.NewInPortField(1)), 20),
+		gotoTable(newFlow(10, 90), 5),
+		newFlow(30, 100),
+	}
+	resubmit := openflow15.NewInstrApplyActions()
+	resubmit.AddAction(openflow15.NewNXActionResubmitTableAction(0xfff8, 40), false)
+	flows[0].AddInstruction(resubmit)
+
+	findings, err := Analyze(flows)
+	require.NoError(t, err)
+	require.Equal(t, []Kind{MissingTable, MissingTable, BackwardGoto, UnreachableTable}, kinds(findings))
+	assert.Equal(t, uint8(40), findings[0].Target)
+	assert.Equal(t, uint8(20), findings[1].Target)
+	assert.Equal(t, uint8(5), findings[2].Target)
+	assert.Equal(t, uint8(30), findings[3].Table)
+}
@@ -0,0 +1,111 @@
+package analysis
+
+// This file compares masked matches.
+
+import (
+	"antrea.io/libOpenflow/openflow15"
+)
+
+type fieldKey struct {
+	class        uint16
+	field        uint8
+	experimenter uint32
+}
+
+type maskedValue struct {
+	value []byte
+	mask  []byte
+}
+
+// flowMatch is a Match as a set of masked values. A field that is absent or
+// fully wildcarded matches any packet.
+type flowMatch map[fieldKey]maskedValue
+
+// newFlowMatch returns the flowMatch of m with its implied prerequisites, so
+// that ipv4_src=10.0.0.1 does not overlap with eth_type=0x86dd.
+func newFlowMatch(m *openflow15.Match) (flowMatch, error) {
+	normalized := openflow15.Match{Type: m.Type, Length: m.Length}
+	normalized.Fields = append(normalized.Fields, m.Fields...)
+	// Violations are not relevant here, only the inserted prerequisites.
+	_ = normalized.Normalize()
+
+	fm := make(flowMatch, len(normalized.Fields))
+	for i := range normalized.Fields {
+		f := &normalized.Fields[i]
+		value, err := f.Value.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		mask := make([]byte, len(value))
+		if f.HasMask && f.Mask != nil {
+			if mask, err = f.Mask.MarshalBinary(); err != nil {
+				return nil, err
+			}
+		} else {
+			for j := range mask {
+				mask[j] = 0xff
+			}
+		}
+		if isZero(mask) {
+			continue
+		}
+		// The NXM and OXM forms of a field, such as NXM_OF_ETH_TYPE and
+		// OXM_FIELD_ETH_TYPE, are the same field.
+		class, field := openflow15.CanonicalMatchField(f.Class, f.Field)
+		fm[fieldKey{class: class, field: field, experimenter: f.ExperimenterID}] = maskedValue{value: value, mask: mask}
+	}
+	return fm, nil
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// intersects reports whether a packet can match both a and b.
+func (a flowMatch) intersects(b flowMatch) bool {
+	for key, av := range a {
+		bv, ok := b[key]
+		if !ok {
+			continue
+		}
+		if len(av.value) != len(bv.value) || len(av.mask) != len(av.value) || len(bv.mask) != len(bv.value) {
+			// Not comparable, assume the worst.
+			continue
+		}
+		for i := range av.value {
+			common := av.mask[i] & bv.mask[i]
+			if av.value[i]&common != bv.value[i]&common {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// covers reports whether every packet matching b also matches a.
+func (a flowMatch) covers(b flowMatch) bool {
+	for key, av := range a {
+		bv, ok := b[key]
+		if !ok {
+			return false
+		}
+		if len(av.value) != len(bv.value) || len(av.mask) != len(av.value) || len(bv.mask) != len(bv.value) {
+			return false
+		}
+		for i := range av.value {
+			// b must be at least as specific as a on the bits a matches.
+			if av.mask[i]&^bv.mask[i] != 0 {
+				return false
+			}
+			if av.value[i]&av.mask[i] != bv.value[i]&av.mask[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -5,6 +5,7 @@ package common
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"sync/atomic"
 
 	"k8s.io/klog/v2"
@@ -55,8 +56,8 @@ func (h *Header) MarshalBinary() (data []byte, err error) {
 }
 
 func (h *Header) UnmarshalBinary(data []byte) error {
-	if len(data) < 4 {
-		return errors.New("The []byte is too short to unmarshel a full HelloElemHeader.")
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full Header.")
 	}
 	h.Version = data[0]
 	h.Type = data[1]
@@ -67,6 +68,23 @@ func (h *Header) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// MessageBytes returns the OpenFlow message at the start of data, as long as
+// the length in its header, which must be at least the header length and at
+// most the length of data.
+func MessageBytes(data []byte) ([]byte, error) {
+	if len(data) < 8 {
+		return nil, errors.New("The []byte is too short to unmarshal a full OpenFlow message.")
+	}
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if length < 8 {
+		return nil, fmt.Errorf("The OpenFlow message length %d is shorter than its header.", length)
+	}
+	if length > len(data) {
+		return nil, fmt.Errorf("The []byte of %d bytes is too short to unmarshal an OpenFlow message of %d bytes.", len(data), length)
+	}
+	return data[:length], nil
+}
+
 const (
 	reserved = iota //nolint:deadcode
 	HelloElemType_VersionBitmap
@@ -157,15 +175,17 @@ func (h *HelloElemVersionBitmap) MarshalBinary() (data []byte, err error) {
 }
 
 func (h *HelloElemVersionBitmap) UnmarshalBinary(data []byte) error {
-	length := len(data)
 	read := 0
-	if err := h.HelloElemHeader.UnmarshalBinary(data[:4]); err != nil {
+	if err := h.HelloElemHeader.UnmarshalBinary(data); err != nil {
 		return err
 	}
+	if h.Length < 4 || int(h.Length) > len(data) {
+		return fmt.Errorf("The HelloElemVersionBitmap length %d is invalid for %d bytes of data.", h.Length, len(data))
+	}
 	read += int(h.HelloElemHeader.Len())
 
 	h.Bitmaps = make([]uint32, 0)
-	for read < length {
+	for read+4 <= int(h.Length) {
 		h.Bitmaps = append(h.Bitmaps, binary.BigEndian.Uint32(data[read:read+4]))
 		read += 4
 	}
@@ -228,21 +248,32 @@ func (h *Hello) MarshalBinary() (data []byte, err error) {
 
 func (h *Hello) UnmarshalBinary(data []byte) error {
 	next := 0
-	err := h.Header.UnmarshalBinary(data[next:])
+	if err := h.Header.UnmarshalBinary(data[next:]); err != nil {
+		return err
+	}
 	next += int(h.Header.Len())
 
 	h.Elements = make([]HelloElem, 0)
 	for next < len(data) {
 		e := NewHelloElemHeader()
-		e.UnmarshalBinary(data[next:])
+		if err := e.UnmarshalBinary(data[next:]); err != nil {
+			return err
+		}
+		if e.Length < 4 || next+int(e.Length) > len(data) {
+			return fmt.Errorf("The HelloElem length %d is invalid for %d bytes of data.", e.Length, len(data)-next)
+		}
 
 		switch e.Type {
 		case HelloElemType_VersionBitmap:
 			v := NewHelloElemVersionBitmap()
-			err = v.UnmarshalBinary(data[next:])
-			next += int(v.Len())
+			if err := v.UnmarshalBinary(data[next : next+int(e.Length)]); err != nil {
+				return err
+			}
 			h.Elements = append(h.Elements, v)
 		}
+		// Elements are padded to a multiple of 8 bytes, and the ones which
+		// are not supported are skipped.
+		next += (int(e.Length) + 7) / 8 * 8
 	}
-	return err
+	return nil
 }
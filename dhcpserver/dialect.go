@@ -0,0 +1,47 @@
+package dhcpserver
+
+import (
+	"antrea.io/libOpenflow/openflow13"
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/packet"
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+// dialect builds the PacketOuts and reads the PacketIns of an OpenFlow
+// version.
+type dialect interface {
+	packetOut(port uint32, b *packet.Builder) (util.Message, error)
+	// packetIn returns the input port and the frame of a PacketIn.
+	packetIn(msg util.Message) (uint32, *protocol.Ethernet, bool)
+}
+
+type of15 struct{}
+
+func (of15) packetOut(port uint32, b *packet.Builder) (util.Message, error) {
+	return b.PacketOut15(openflow15.P_CONTROLLER, openflow15.NewActionOutput(port))
+}
+
+func (of15) packetIn(msg util.Message) (uint32, *protocol.Ethernet, bool) {
+	m, ok := msg.(*openflow15.PacketIn)
+	if !ok {
+		return 0, nil, false
+	}
+	inPort, frame, err := packet.PacketIn15(m)
+	return inPort, frame, err == nil && inPort != 0
+}
+
+type of13 struct{}
+
+func (of13) packetOut(port uint32, b *packet.Builder) (util.Message, error) {
+	return b.PacketOut13(openflow13.P_CONTROLLER, openflow13.NewActionOutput(port))
+}
+
+func (of13) packetIn(msg util.Message) (uint32, *protocol.Ethernet, bool) {
+	m, ok := msg.(*openflow13.PacketIn)
+	if !ok {
+		return 0, nil, false
+	}
+	inPort, frame := packet.PacketIn13(m)
+	return inPort, frame, inPort != 0
+}
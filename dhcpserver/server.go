@@ -0,0 +1,405 @@
+// Package dhcpserver answers the DHCP requests that switches send to the
+// controller in PacketIns. It leases the addresses of a configured pool, and
+// sends its replies back out of the port of the request in PacketOuts.
+package dhcpserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"antrea.io/libOpenflow/packet"
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+const (
+	// DefaultLeaseTime is the lease time of a Config without one.
+	DefaultLeaseTime = time.Hour
+	// offerTimeout is the time an offered address is kept for the client.
+	offerTimeout = time.Minute
+	// broadcastFlag is the flag of a client which cannot receive unicast
+	// replies before being configured.
+	broadcastFlag = 0x8000
+)
+
+// Conn sends messages to a switch, for instance through the Outbound channel
+// of a util.MessageStream.
+type Conn interface {
+	Send(msg util.Message) error
+}
+
+// Config is the configuration of the network served.
+type Config struct {
+	// ServerIP and ServerMAC are the addresses the replies are sent from.
+	ServerIP  net.IP
+	ServerMAC net.HardwareAddr
+	// PoolStart and PoolEnd are the first and the last addresses leased.
+	PoolStart  net.IP
+	PoolEnd    net.IP
+	SubnetMask net.IPMask
+	Routers    []net.IP
+	DNSServers []net.IP
+	// LeaseTime is DefaultLeaseTime if zero.
+	LeaseTime time.Duration
+}
+
+// Lease is an address leased to a client.
+type Lease struct {
+	IP     net.IP
+	HWAddr net.HardwareAddr
+	Expiry time.Time
+}
+
+type bindingState int
+
+const (
+	stateOffered bindingState = iota
+	stateBound
+	// stateDeclined is an address which a client found in use.
+	stateDeclined
+)
+
+type binding struct {
+	state    bindingState
+	clientID string
+	hwAddr   net.HardwareAddr
+	expiry   time.Time
+}
+
+// Server is the state of the leases of a pool. It is safe for concurrent use.
+type Server struct {
+	config     Config
+	start, end uint32
+
+	d        dialect
+	mutex    sync.Mutex
+	bindings map[uint32]*binding
+	now      func() time.Time
+}
+
+// NewServer15 returns a Server for OpenFlow 1.5 switches.
+func NewServer15(config Config) (*Server, error) {
+	return newServer(of15{}, config)
+}
+
+// NewServer13 returns a Server for OpenFlow 1.3 switches.
+func NewServer13(config Config) (*Server, error) {
+	return newServer(of13{}, config)
+}
+
+func newServer(d dialect, config Config) (*Server, error) {
+	if config.ServerIP.To4() == nil || len(config.ServerMAC) != 6 {
+		return nil, errors.New("the server needs an IPv4 address and a MAC address")
+	}
+	start, end := config.PoolStart.To4(), config.PoolEnd.To4()
+	if start == nil || end == nil || ipToUint32(start) > ipToUint32(end) {
+		return nil, fmt.Errorf("invalid address pool %v-%v", config.PoolStart, config.PoolEnd)
+	}
+	if _, bits := config.SubnetMask.Size(); bits != 32 {
+		return nil, fmt.Errorf("invalid subnet mask %v", config.SubnetMask)
+	}
+	if config.LeaseTime == 0 {
+		config.LeaseTime = DefaultLeaseTime
+	}
+	return &Server{
+		config:   config,
+		start:    ipToUint32(start),
+		end:      ipToUint32(end),
+		d:        d,
+		bindings: make(map[uint32]*binding),
+		now:      time.Now,
+	}, nil
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+func uint32ToIP(n uint32) net.IP {
+	return binary.BigEndian.AppendUint32(make(net.IP, 0, 4), n)
+}
+
+// HandlePacketIn answers the DHCP request carried by a PacketIn, sending the
+// reply through conn out of the port the request was received on. It returns
+// false for PacketIns which are not DHCP requests.
+func (s *Server) HandlePacketIn(conn Conn, msg util.Message) (bool, error) {
+	inPort, frame, ok := s.d.packetIn(msg)
+	if !ok {
+		return false, nil
+	}
+	req, ok := parseRequest(frame)
+	if !ok {
+		return false, nil
+	}
+	reply := s.handle(req)
+	if reply == nil {
+		return true, nil
+	}
+	out, err := s.d.packetOut(inPort, packet.NewBuilder().Layer(s.frame(frame, req, reply)))
+	if err != nil {
+		return true, fmt.Errorf("failed to build DHCP reply: %w", err)
+	}
+	if err := conn.Send(out); err != nil {
+		return true, fmt.Errorf("failed to send DHCP reply to %v: %w", req.ClientHWAddr, err)
+	}
+	return true, nil
+}
+
+// parseRequest returns the DHCP request carried by eth, if any.
+func parseRequest(eth *protocol.Ethernet) (*protocol.DHCP, bool) {
+	ip, ok := eth.Data.(*protocol.IPv4)
+	if !ok {
+		return nil, false
+	}
+	udp, ok := ip.Data.(*protocol.UDP)
+	if !ok || udp.PortDst != protocol.UDPPort_DHCPServer {
+		return nil, false
+	}
+	req, ok := udp.Payload.(*protocol.DHCP)
+	if !ok || req.Operation != protocol.DHCP_OP_BOOTREQUEST {
+		return nil, false
+	}
+	return req, true
+}
+
+// handle updates the leases for req and returns the reply, or nil if none is
+// due.
+func (s *Server) handle(req *protocol.DHCP) *protocol.DHCP {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	client := clientID(req)
+	switch req.MessageType() {
+	case protocol.DHCP_MSG_DISCOVER:
+		ip := s.offer(client, req.ClientHWAddr, req.RequestedIP())
+		if ip == nil {
+			// The pool is exhausted, the client will retry.
+			return nil
+		}
+		return s.reply(req, protocol.DHCP_MSG_OFFER, ip)
+	case protocol.DHCP_MSG_REQUEST:
+		if id := req.ServerID(); id != nil && !id.Equal(s.config.ServerIP) {
+			// The client accepted the offer of another server.
+			s.release(client, nil)
+			return nil
+		}
+		ip := req.RequestedIP()
+		if ip == nil {
+			// Renewing or rebinding.
+			ip = req.ClientIP
+		}
+		if !s.bind(client, req.ClientHWAddr, ip) {
+			return s.reply(req, protocol.DHCP_MSG_NAK, nil)
+		}
+		return s.reply(req, protocol.DHCP_MSG_ACK, ip.To4())
+	case protocol.DHCP_MSG_DECLINE:
+		s.decline(client, req.RequestedIP())
+	case protocol.DHCP_MSG_RELEASE:
+		s.release(client, req.ClientIP)
+	case protocol.DHCP_MSG_INFORM:
+		return s.reply(req, protocol.DHCP_MSG_ACK, nil)
+	}
+	return nil
+}
+
+// clientID identifies the client by its client identifier option, or else
+// its hardware address.
+func clientID(req *protocol.DHCP) string {
+	if id := req.ClientID(); len(id) > 0 {
+		return string(id)
+	}
+	return string(append([]byte{req.HardwareType}, req.ClientHWAddr...))
+}
+
+func (s *Server) inPool(ip net.IP) bool {
+	if ip.To4() == nil {
+		return false
+	}
+	n := ipToUint32(ip)
+	return n >= s.start && n <= s.end && !ip.Equal(s.config.ServerIP)
+}
+
+// available returns whether the address n can be leased to client.
+func (s *Server) available(n uint32, client string) bool {
+	b, ok := s.bindings[n]
+	if !ok || s.now().After(b.expiry) {
+		return true
+	}
+	return b.state != stateDeclined && b.clientID == client
+}
+
+// lookup returns the address bound or offered to client.
+func (s *Server) lookup(client string) (uint32, *binding) {
+	for n, b := range s.bindings {
+		if b.clientID == client && b.state != stateDeclined && !s.now().After(b.expiry) {
+			return n, b
+		}
+	}
+	return 0, nil
+}
+
+// offer reserves an address for client, preferably the one it already has,
+// then the one it requested, and returns it.
+func (s *Server) offer(client string, hwAddr net.HardwareAddr, requested net.IP) net.IP {
+	if n, b := s.lookup(client); b != nil {
+		if b.state == stateOffered {
+			b.expiry = s.now().Add(offerTimeout)
+		}
+		return uint32ToIP(n)
+	}
+	n, ok := uint32(0), false
+	if s.inPool(requested) && s.available(ipToUint32(requested), client) {
+		n, ok = ipToUint32(requested), true
+	}
+	for i := s.start; !ok && i <= s.end; i++ {
+		if s.inPool(uint32ToIP(i)) && s.available(i, client) {
+			n, ok = i, true
+		}
+		if i == s.end {
+			// Do not wrap around at the end of the address space.
+			break
+		}
+	}
+	if !ok {
+		return nil
+	}
+	s.bindings[n] = &binding{state: stateOffered, clientID: client, hwAddr: hwAddr, expiry: s.now().Add(offerTimeout)}
+	return uint32ToIP(n)
+}
+
+// bind leases ip to client, releasing any other address it has, and returns
+// false if ip cannot be leased to it.
+func (s *Server) bind(client string, hwAddr net.HardwareAddr, ip net.IP) bool {
+	if !s.inPool(ip) || !s.available(ipToUint32(ip), client) {
+		return false
+	}
+	s.release(client, nil)
+	s.bindings[ipToUint32(ip)] = &binding{state: stateBound, clientID: client, hwAddr: hwAddr, expiry: s.now().Add(s.config.LeaseTime)}
+	return true
+}
+
+// release frees the address of client, or any address it has if ip is nil.
+func (s *Server) release(client string, ip net.IP) {
+	for n, b := range s.bindings {
+		if b.clientID == client && b.state != stateDeclined && (ip == nil || ip.To4() != nil && n == ipToUint32(ip)) {
+			delete(s.bindings, n)
+		}
+	}
+}
+
+// decline marks ip as used by another host for a lease time.
+func (s *Server) decline(client string, ip net.IP) {
+	if !s.inPool(ip) {
+		return
+	}
+	n := ipToUint32(ip)
+	if b, ok := s.bindings[n]; ok && b.clientID != client && !s.now().After(b.expiry) {
+		return
+	}
+	s.bindings[n] = &binding{state: stateDeclined, expiry: s.now().Add(s.config.LeaseTime)}
+}
+
+// reply returns the reply of type t to req, leasing yourIP if not nil.
+func (s *Server) reply(req *protocol.DHCP, t protocol.DHCPOperation, yourIP net.IP) *protocol.DHCP {
+	reply := &protocol.DHCP{
+		Operation:    protocol.DHCP_OP_BOOTREPLY,
+		HardwareType: req.HardwareType,
+		HardwareLen:  req.HardwareLen,
+		Xid:          req.Xid,
+		Flags:        req.Flags,
+		ClientIP:     net.IPv4zero.To4(),
+		YourIP:       net.IPv4zero.To4(),
+		ServerIP:     net.IPv4zero.To4(),
+		GatewayIP:    req.GatewayIP,
+		ClientHWAddr: req.ClientHWAddr,
+	}
+	reply.SetMessageType(t)
+	reply.SetServerID(s.config.ServerIP)
+	if t != protocol.DHCP_MSG_NAK {
+		if req.MessageType() == protocol.DHCP_MSG_INFORM || req.MessageType() == protocol.DHCP_MSG_REQUEST {
+			reply.ClientIP = req.ClientIP
+		}
+		reply.ServerIP = s.config.ServerIP.To4()
+		reply.SetSubnetMask(s.config.SubnetMask)
+		if len(s.config.Routers) > 0 {
+			reply.SetRouters(s.config.Routers...)
+		}
+		if len(s.config.DNSServers) > 0 {
+			reply.SetDNSServers(s.config.DNSServers...)
+		}
+	}
+	if yourIP != nil {
+		reply.YourIP = yourIP
+		reply.SetLeaseTime(s.config.LeaseTime)
+		reply.SetRenewalTime(s.config.LeaseTime / 2)
+		reply.SetRebindingTime(s.config.LeaseTime * 7 / 8)
+	}
+	// Relay agents expect their information back.
+	if opt := req.Option(protocol.DHCP_OPT_RELAY_AGENT_INFO); opt != nil {
+		reply.SetOption(opt)
+	}
+	return reply
+}
+
+// frame returns the frame carrying reply, addressed as RFC 2131 section 4.1
+// requires: to the relay agent if any, then to the configured address of the
+// client, then broadcast if the client asks for it or the reply is a NAK.
+func (s *Server) frame(reqEth *protocol.Ethernet, req *protocol.DHCP, reply *protocol.DHCP) *protocol.Ethernet {
+	udp := protocol.NewUDP()
+	udp.PortSrc = protocol.UDPPort_DHCPServer
+	udp.PortDst = protocol.UDPPort_DHCPClient
+	udp.Payload = reply
+
+	ip := protocol.NewIPv4()
+	ip.Version = 4
+	ip.TTL = 64
+	ip.Protocol = protocol.Type_UDP
+	ip.NWSrc = s.config.ServerIP.To4()
+	ip.Data = udp
+
+	eth := protocol.NewEthernet()
+	eth.HWSrc = s.config.ServerMAC
+	eth.VLANID = reqEth.VLANID
+	eth.InnerVLANs = reqEth.InnerVLANs
+	eth.Ethertype = protocol.IPv4_MSG
+	eth.Data = ip
+
+	isNAK := reply.MessageType() == protocol.DHCP_MSG_NAK
+	switch {
+	case req.GatewayIP != nil && !req.GatewayIP.IsUnspecified():
+		udp.PortDst = protocol.UDPPort_DHCPServer
+		ip.NWDst = req.GatewayIP.To4()
+		eth.HWDst = reqEth.HWSrc
+		if isNAK {
+			reply.Flags |= broadcastFlag
+		}
+	case !isNAK && req.ClientIP != nil && !req.ClientIP.IsUnspecified():
+		ip.NWDst = req.ClientIP.To4()
+		eth.HWDst = reqEth.HWSrc
+	case isNAK || req.Flags&broadcastFlag != 0 || reply.YourIP.IsUnspecified():
+		ip.NWDst = net.IPv4bcast.To4()
+		eth.HWDst = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	default:
+		ip.NWDst = reply.YourIP
+		eth.HWDst = req.ClientHWAddr
+	}
+	return eth
+}
+
+// Leases returns the addresses currently leased, sorted.
+func (s *Server) Leases() []Lease {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var leases []Lease
+	for n, b := range s.bindings {
+		if b.state == stateBound && !s.now().After(b.expiry) {
+			leases = append(leases, Lease{IP: uint32ToIP(n), HWAddr: b.hwAddr, Expiry: b.expiry})
+		}
+	}
+	sort.Slice(leases, func(i, j int) bool { return ipToUint32(leases[i].IP) < ipToUint32(leases[j].IP) })
+	return leases
+}
@@ -0,0 +1,303 @@
+package dhcpserver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/openflow13"
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+var (
+	serverMAC, _ = net.ParseMAC("00:00:00:00:00:01")
+	clientMAC, _ = net.ParseMAC("aa:bb:cc:dd:ee:01")
+	otherMAC, _  = net.ParseMAC("aa:bb:cc:dd:ee:02")
+	relayMAC, _  = net.ParseMAC("aa:bb:cc:dd:ee:fe")
+)
+
+func newTestConfig() Config {
+	return Config{
+		ServerIP:   net.ParseIP("10.0.0.1"),
+		ServerMAC:  serverMAC,
+		PoolStart:  net.ParseIP("10.0.0.1"),
+		PoolEnd:    net.ParseIP("10.0.0.3"),
+		SubnetMask: net.CIDRMask(24, 32),
+		Routers:    []net.IP{net.ParseIP("10.0.0.254")},
+		DNSServers: []net.IP{net.ParseIP("10.0.0.53")},
+		LeaseTime:  time.Hour,
+	}
+}
+
+// sentFrame is a reply sent by the server.
+type sentFrame struct {
+	port  uint32
+	eth   *protocol.Ethernet
+	ip    *protocol.IPv4
+	udp   *protocol.UDP
+	reply *protocol.DHCP
+}
+
+type fakeConn struct {
+	t    *testing.T
+	sent []sentFrame
+}
+
+// Send decodes the frame sent by the PacketOut.
+func (c *fakeConn) Send(msg util.Message) error {
+	data, err := msg.MarshalBinary()
+	require.NoError(c.t, err)
+	require.NotEmpty(c.t, data)
+	var port uint32
+	var frame []byte
+	switch m := msg.(type) {
+	case *openflow15.PacketOut:
+		port = m.Actions[0].(*openflow15.ActionOutput).Port
+		frame, err = m.Data.MarshalBinary()
+	case *openflow13.PacketOut:
+		port = m.Actions[0].(*openflow13.ActionOutput).Port
+		frame, err = m.Data.MarshalBinary()
+	}
+	require.NoError(c.t, err)
+	eth := new(protocol.Ethernet)
+	require.NoError(c.t, eth.UnmarshalBinary(frame))
+	require.NoError(c.t, protocol.VerifyChecksums(eth))
+	ip := eth.Data.(*protocol.IPv4)
+	udp := ip.Data.(*protocol.UDP)
+	c.sent = append(c.sent, sentFrame{port: port, eth: eth, ip: ip, udp: udp, reply: udp.Payload.(*protocol.DHCP)})
+	return nil
+}
+
+// packetIn returns the PacketIn of a client request received on port.
+func packetIn(t *testing.T, version uint8, port uint32, hwSrc net.HardwareAddr, req *protocol.DHCP) util.Message {
+	udp := protocol.NewUDP()
+	udp.PortSrc = protocol.UDPPort_DHCPClient
+	udp.PortDst = protocol.UDPPort_DHCPServer
+	udp.Payload = req
+	ip := protocol.NewIPv4()
+	ip.Version = 4
+	ip.TTL = 64
+	ip.Protocol = protocol.Type_UDP
+	ip.NWDst = net.IPv4bcast.To4()
+	ip.Data = udp
+	eth := protocol.NewEthernet()
+	eth.HWSrc = hwSrc
+	eth.HWDst = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	eth.Data = ip
+	frame, err := protocol.Serialize(eth, protocol.SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+
+	var msg util.Message
+	if version == openflow15.VERSION {
+		p := openflow15.NewPacketIn()
+		p.Match.AddField(*openflow15.NewInPortField(port))
+		p.Data = util.NewBuffer(frame)
+		msg = p
+	} else {
+		p := openflow13.NewPacketIn()
+		p.Match.AddField(*openflow13.NewInPortField(port))
+		require.NoError(t, p.Data.UnmarshalBinary(frame))
+		msg = p
+	}
+	data, err := msg.MarshalBinary()
+	require.NoError(t, err)
+	if version == openflow15.VERSION {
+		msg, err = openflow15.Parse(data)
+	} else {
+		msg, err = openflow13.Parse(data)
+	}
+	require.NoError(t, err)
+	return msg
+}
+
+func TestServer(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		version   uint8
+		newServer func(Config) (*Server, error)
+	}{
+		{"OpenFlow 1.5", openflow15.VERSION, NewServer15},
+		{"OpenFlow 1.3", openflow13.VERSION, NewServer13},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := tc.newServer(newTestConfig())
+			require.NoError(t, err)
+			conn := &fakeConn{t: t}
+			handle := func(port uint32, req *protocol.DHCP) {
+				ok, err := s.HandlePacketIn(conn, packetIn(t, tc.version, port, req.ClientHWAddr, req))
+				require.NoError(t, err)
+				assert.True(t, ok)
+			}
+
+			discover, _ := protocol.NewDHCPDiscover(0x1111, clientMAC)
+			discover.Flags = broadcastFlag
+			handle(3, discover)
+			require.Len(t, conn.sent, 1)
+			offer := conn.sent[0]
+			assert.Equal(t, uint32(3), offer.port)
+			assert.Equal(t, serverMAC, offer.eth.HWSrc)
+			assert.Equal(t, net.IPv4bcast.To4(), offer.ip.NWDst)
+			assert.Equal(t, uint16(protocol.UDPPort_DHCPClient), offer.udp.PortDst)
+			assert.Equal(t, protocol.DHCP_MSG_OFFER, offer.reply.MessageType())
+			assert.Equal(t, uint32(0x1111), offer.reply.Xid)
+			// The server address is skipped.
+			assert.Equal(t, net.ParseIP("10.0.0.2").To4(), offer.reply.YourIP)
+			assert.Equal(t, net.CIDRMask(24, 32), offer.reply.SubnetMask())
+			assert.Equal(t, []net.IP{net.ParseIP("10.0.0.254").To4()}, offer.reply.Routers())
+			assert.Equal(t, []net.IP{net.ParseIP("10.0.0.53").To4()}, offer.reply.DNSServers())
+			lease, _ := offer.reply.LeaseTime()
+			assert.Equal(t, time.Hour, lease)
+			t1, _ := offer.reply.RenewalTime()
+			assert.Equal(t, 30*time.Minute, t1)
+			assert.Empty(t, s.Leases())
+
+			// Another client is offered the next address.
+			other, _ := protocol.NewDHCPDiscover(0x2222, otherMAC)
+			handle(4, other)
+			require.Len(t, conn.sent, 2)
+			assert.Equal(t, net.ParseIP("10.0.0.3").To4(), conn.sent[1].reply.YourIP)
+			// Unicast to the offered address.
+			assert.Equal(t, otherMAC, conn.sent[1].eth.HWDst)
+			assert.Equal(t, net.ParseIP("10.0.0.3").To4(), conn.sent[1].ip.NWDst)
+
+			request, _ := protocol.NewDHCPRequest(0x1111, clientMAC)
+			request.SetClientID(discover.ClientID())
+			request.SetRequestedIP(offer.reply.YourIP)
+			request.SetServerID(offer.reply.ServerID())
+			handle(3, request)
+			require.Len(t, conn.sent, 3)
+			assert.Equal(t, protocol.DHCP_MSG_ACK, conn.sent[2].reply.MessageType())
+			assert.Equal(t, net.ParseIP("10.0.0.2").To4(), conn.sent[2].reply.YourIP)
+			leases := s.Leases()
+			require.Len(t, leases, 1)
+			assert.Equal(t, net.ParseIP("10.0.0.2").To4(), leases[0].IP)
+			assert.Equal(t, clientMAC, leases[0].HWAddr)
+
+			// The other client takes the offer of another server.
+			otherRequest, _ := protocol.NewDHCPRequest(0x2222, otherMAC)
+			otherRequest.SetClientID(other.ClientID())
+			otherRequest.SetRequestedIP(net.ParseIP("10.0.0.3"))
+			otherRequest.SetServerID(net.ParseIP("10.0.0.100"))
+			handle(4, otherRequest)
+			require.Len(t, conn.sent, 3)
+
+			// Requesting the address of another client is refused.
+			otherRequest.SetRequestedIP(net.ParseIP("10.0.0.2"))
+			otherRequest.SetServerID(net.ParseIP("10.0.0.1"))
+			handle(4, otherRequest)
+			require.Len(t, conn.sent, 4)
+			nak := conn.sent[3]
+			assert.Equal(t, protocol.DHCP_MSG_NAK, nak.reply.MessageType())
+			assert.True(t, nak.reply.YourIP.IsUnspecified())
+			assert.Equal(t, net.IPv4bcast.To4(), nak.ip.NWDst)
+
+			// Renewing from the leased address.
+			renew, _ := protocol.NewDHCPRequest(0x3333, clientMAC)
+			renew.SetClientID(discover.ClientID())
+			renew.ClientIP = net.ParseIP("10.0.0.2").To4()
+			handle(3, renew)
+			require.Len(t, conn.sent, 5)
+			assert.Equal(t, protocol.DHCP_MSG_ACK, conn.sent[4].reply.MessageType())
+			assert.Equal(t, net.ParseIP("10.0.0.2").To4(), conn.sent[4].ip.NWDst)
+
+			release, _ := protocol.NewDHCP(0x4444, protocol.DHCP_OP_BOOTREQUEST, protocol.DHCP_HW_ETHERNET)
+			release.HardwareLen = 6
+			release.ClientHWAddr = clientMAC
+			release.ClientIP = net.ParseIP("10.0.0.2").To4()
+			release.SetMessageType(protocol.DHCP_MSG_RELEASE)
+			release.SetClientID(discover.ClientID())
+			handle(3, release)
+			require.Len(t, conn.sent, 5)
+			assert.Empty(t, s.Leases())
+		})
+	}
+}
+
+func TestServerDeclineAndExhaustion(t *testing.T) {
+	s, err := NewServer15(newTestConfig())
+	require.NoError(t, err)
+	conn := &fakeConn{t: t}
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	handle := func(req *protocol.DHCP) {
+		ok, err := s.HandlePacketIn(conn, packetIn(t, openflow15.VERSION, 1, req.ClientHWAddr, req))
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	decline, _ := protocol.NewDHCP(1, protocol.DHCP_OP_BOOTREQUEST, protocol.DHCP_HW_ETHERNET)
+	decline.HardwareLen = 6
+	decline.ClientHWAddr = clientMAC
+	decline.SetMessageType(protocol.DHCP_MSG_DECLINE)
+	decline.SetRequestedIP(net.ParseIP("10.0.0.2"))
+	handle(decline)
+	assert.Empty(t, conn.sent)
+
+	discover, _ := protocol.NewDHCPDiscover(2, clientMAC)
+	discover.SetRequestedIP(net.ParseIP("10.0.0.2"))
+	handle(discover)
+	require.Len(t, conn.sent, 1)
+	assert.Equal(t, net.ParseIP("10.0.0.3").To4(), conn.sent[0].reply.YourIP)
+
+	// No address left for another client until the offer times out.
+	other, _ := protocol.NewDHCPDiscover(3, otherMAC)
+	handle(other)
+	require.Len(t, conn.sent, 1)
+	now = now.Add(2 * offerTimeout)
+	handle(other)
+	require.Len(t, conn.sent, 2)
+	assert.Equal(t, net.ParseIP("10.0.0.3").To4(), conn.sent[1].reply.YourIP)
+
+	// The declined address is available again after a lease time.
+	now = now.Add(time.Hour)
+	handle(discover)
+	require.Len(t, conn.sent, 3)
+	assert.Equal(t, net.ParseIP("10.0.0.2").To4(), conn.sent[2].reply.YourIP)
+}
+
+func TestServerRelay(t *testing.T) {
+	s, err := NewServer13(newTestConfig())
+	require.NoError(t, err)
+	conn := &fakeConn{t: t}
+
+	discover, _ := protocol.NewDHCPDiscover(1, clientMAC)
+	discover.GatewayIP = net.ParseIP("10.0.0.254").To4()
+	require.NoError(t, discover.SetRelayAgentInfo(protocol.DHCPRelayAgentSubOption{Code: protocol.DHCP_RELAY_CIRCUIT_ID, Data: []byte("ge-0/0/1")}))
+	ok, err := s.HandlePacketIn(conn, packetIn(t, openflow13.VERSION, 7, relayMAC, discover))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.Len(t, conn.sent, 1)
+	offer := conn.sent[0]
+	assert.Equal(t, relayMAC, offer.eth.HWDst)
+	assert.Equal(t, net.ParseIP("10.0.0.254").To4(), offer.ip.NWDst)
+	assert.Equal(t, uint16(protocol.UDPPort_DHCPServer), offer.udp.PortDst)
+	relay, err := offer.reply.RelayAgentInfo()
+	require.NoError(t, err)
+	assert.Equal(t, []protocol.DHCPRelayAgentSubOption{{Code: protocol.DHCP_RELAY_CIRCUIT_ID, Data: []byte("ge-0/0/1")}}, relay)
+}
+
+func TestServerIgnoresOtherPackets(t *testing.T) {
+	s, err := NewServer15(newTestConfig())
+	require.NoError(t, err)
+	conn := &fakeConn{t: t}
+
+	// A reply from another server.
+	offer, _ := protocol.NewDHCPOffer(1, clientMAC)
+	ok, err := s.HandlePacketIn(conn, packetIn(t, openflow15.VERSION, 1, clientMAC, offer))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = s.HandlePacketIn(conn, openflow15.NewPacketIn())
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, conn.sent)
+
+	_, err = NewServer15(Config{ServerIP: net.ParseIP("10.0.0.1"), ServerMAC: serverMAC,
+		PoolStart: net.ParseIP("10.0.0.9"), PoolEnd: net.ParseIP("10.0.0.2"), SubnetMask: net.CIDRMask(24, 32)})
+	assert.Error(t, err)
+}
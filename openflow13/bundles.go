@@ -3,6 +3,7 @@ package openflow13
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"unsafe"
 
 	"antrea.io/libOpenflow/util"
@@ -119,7 +120,7 @@ func (p *BundlePropertyExperimenter) Len() uint16 {
 }
 
 func (p *BundlePropertyExperimenter) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, 0)
+	data = make([]byte, 12)
 	n := 0
 	binary.BigEndian.PutUint16(data[n:], p.Type)
 	n += 2
@@ -148,8 +149,12 @@ func (p *BundlePropertyExperimenter) UnmarshalBinary(data []byte) error {
 	n += 4
 	p.ExperimenterType = binary.BigEndian.Uint32(data[n:])
 	n += 4
-	if len(data) < int(p.Length) {
-		p.data = data[n:]
+	if p.Length < 12 || int(p.Length) > len(data) {
+		return fmt.Errorf("The BundlePropertyExperimenter length %d is invalid for %d bytes of data.", p.Length, len(data))
+	}
+	p.data = nil
+	if int(p.Length) > n {
+		p.data = append([]byte(nil), data[n:p.Length]...)
 	}
 	return nil
 }
@@ -213,6 +218,9 @@ func (b *BundleAdd) MarshalBinary() (data []byte, err error) {
 }
 
 func (b *BundleAdd) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full BundleAdd message")
+	}
 	var err error
 	n := 0
 	b.BundleID = binary.BigEndian.Uint32(data[n:])
@@ -225,7 +233,11 @@ func (b *BundleAdd) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
+	if b.Message == nil {
+		return errors.New("the message of BundleAdd is not supported")
+	}
 	n += int(b.Message.Len())
+	b.Properties = nil
 	if n < len(data) {
 		b.Properties = make([]BundlePropertyExperimenter, 0)
 		for n < len(data) {
@@ -286,6 +298,9 @@ func (e *VendorError) MarshalBinary() (data []byte, err error) {
 }
 
 func (e *VendorError) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full VendorError message.")
+	}
 	n := 0
 	e.ErrorMsg = new(ErrorMsg)
 	err := e.Header.UnmarshalBinary(data[n:])
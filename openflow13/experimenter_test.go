@@ -0,0 +1,174 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func TestExperimenterRegistry(t *testing.T) {
+	const experimenter = 0x00aabbcc
+	var decoded []string
+	RegisterExperimenterMessage(experimenter, 1, func() util.Message {
+		decoded = append(decoded, "message")
+		return new(util.Buffer)
+	})
+	RegisterExperimenterAction(experimenter, 2, func() Action {
+		decoded = append(decoded, "action")
+		return new(ActionUnknown)
+	})
+	RegisterExperimenterInstruction(experimenter, func() Instruction {
+		decoded = append(decoded, "instruction")
+		return new(InstrUnknown)
+	})
+	RegisterExperimenterField(experimenter, 3, func(length uint8) util.Message {
+		decoded = append(decoded, "field")
+		return &ByteArrayField{Length: length}
+	})
+	RegisterExperimenterMeterBand(experimenter, func() util.Message {
+		decoded = append(decoded, "meter band")
+		return new(PropUnknown)
+	})
+	RegisterExperimenterProperty(experimenter, 4, func() util.Message {
+		decoded = append(decoded, "property")
+		return new(PropUnknown)
+	})
+	defer func() {
+		RegisterExperimenterMessage(experimenter, 1, nil)
+		RegisterExperimenterAction(experimenter, 2, nil)
+		RegisterExperimenterInstruction(experimenter, nil)
+		RegisterExperimenterField(experimenter, 3, nil)
+		RegisterExperimenterMeterBand(experimenter, nil)
+		RegisterExperimenterProperty(experimenter, 4, nil)
+	}()
+
+	vendor := NewNXTVendorHeader(1)
+	vendor.Vendor = experimenter
+	vendor.VendorData = util.NewBuffer([]byte{1, 2, 3, 4})
+
+	actionFlow := NewFlowMod()
+	apply := NewInstrApplyActions()
+	apply.AddAction(&ActionUnknown{
+		ActionHeader: ActionHeader{Type: ActionType_Experimenter},
+		Data:         []byte{0, 0xaa, 0xbb, 0xcc, 0, 2, 1, 2, 3, 4, 5, 6},
+	}, false)
+	actionFlow.AddInstruction(apply)
+
+	instrFlow := NewFlowMod()
+	instrFlow.AddInstruction(&InstrUnknown{InstrHeader: InstrHeader{Type: InstrType_EXPERIMENTER}, Data: []byte{0, 0xaa, 0xbb, 0xcc}})
+
+	fieldFlow := NewFlowMod()
+	fieldFlow.Match.AddField(MatchField{
+		Class:          OXM_CLASS_EXPERIMENTER,
+		Field:          3,
+		HasMask:        true,
+		Length:         8,
+		ExperimenterID: experimenter,
+		Value:          &ByteArrayField{Data: []byte{1, 2}, Length: 2},
+		Mask:           &ByteArrayField{Data: []byte{0xff, 0xff}, Length: 2},
+	})
+
+	features := newTableFeatures()
+	features.Properties = append(features.Properties, &PropUnknown{
+		PropHeader: PropHeader{Type: OFPTFPT13_EXPERIMENTER},
+		Data:       []byte{0, 0xaa, 0xbb, 0xcc, 0, 0, 0, 4},
+	})
+	features.Length = features.Len()
+	mpFeatures := &MultipartRequest{
+		Header: NewOfp13Header(),
+		Type:   MultipartType_TableFeatures,
+		Body:   []util.Message{features},
+	}
+	mpFeatures.Header.Type = Type_MultiPartRequest
+
+	for name, msg := range map[string]util.Message{
+		"message":     vendor,
+		"action":      actionFlow,
+		"instruction": instrFlow,
+		"field":       fieldFlow,
+		"property":    mpFeatures,
+	} {
+		t.Run(name, func(t *testing.T) {
+			decoded = nil
+			data, err := msg.MarshalBinary()
+			require.NoError(t, err)
+			parsed, err := Parse(data)
+			require.NoError(t, err)
+			assert.Contains(t, decoded, name)
+			b, err := parsed.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, data, b)
+		})
+	}
+
+	t.Run("meter band", func(t *testing.T) {
+		decoded = nil
+		meter := NewMeterMod()
+		meter.AddMeterBand(&PropUnknown{
+			PropHeader: PropHeader{Type: OFPMBT13_EXPERIMENTER},
+			Data:       []byte{0, 0, 0, 1, 0, 0, 0, 2, 0, 0xaa, 0xbb, 0xcc, 1, 2, 3, 4, 5, 6, 7, 8},
+		})
+		data, err := meter.MarshalBinary()
+		require.NoError(t, err)
+		parsed := new(MeterMod)
+		require.NoError(t, parsed.UnmarshalBinary(data))
+		assert.Equal(t, []string{"meter band"}, decoded)
+		b, err := parsed.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, data, b)
+	})
+}
+
+type experimenterData struct {
+	Value uint32
+}
+
+func (d *experimenterData) Len() uint16 {
+	return 4
+}
+
+func (d *experimenterData) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, d.Value)
+	return data, nil
+}
+
+func (d *experimenterData) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("the []byte is too short to unmarshal experimenterData")
+	}
+	d.Value = binary.BigEndian.Uint32(data)
+	return nil
+}
+
+func TestExperimenterJSON(t *testing.T) {
+	const experimenter = 0x00aabbcc
+	RegisterExperimenterMessage(experimenter, 5, func() util.Message {
+		return new(experimenterData)
+	})
+	defer RegisterExperimenterMessage(experimenter, 5, nil)
+
+	vendor := NewNXTVendorHeader(5)
+	vendor.Vendor = experimenter
+	vendor.VendorData = &experimenterData{Value: 0x01020304}
+	wire, err := vendor.MarshalBinary()
+	require.NoError(t, err)
+	parsed, err := Parse(wire)
+	require.NoError(t, err)
+	require.IsType(t, new(experimenterData), parsed.(*VendorHeader).VendorData)
+
+	data, err := json.Marshal(JSONMessage{parsed})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"experimenterData":{"Value":16909060}`)
+	var decoded JSONMessage
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, wire, b)
+}
@@ -2,6 +2,8 @@ package openflow13
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
 
 	log "github.com/sirupsen/logrus"
 
@@ -124,8 +126,16 @@ func (f *FlowMod) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *FlowMod) UnmarshalBinary(data []byte) error {
+	if len(data) < 48 {
+		return errors.New("The []byte is too short to unmarshal a full FlowMod message.")
+	}
 	n := 0
-	f.Header.UnmarshalBinary(data[n:])
+	if err := f.Header.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
+	if int(f.Header.Length) > len(data) {
+		return fmt.Errorf("The FlowMod length %d is invalid for %d bytes of data.", f.Header.Length, len(data))
+	}
 	n += int(f.Header.Len())
 
 	f.Cookie = binary.BigEndian.Uint64(data[n:])
@@ -152,11 +162,17 @@ func (f *FlowMod) UnmarshalBinary(data []byte) error {
 	n += 2
 	n += 2 // for pad
 
-	f.Match.UnmarshalBinary(data[n:])
+	if err := f.Match.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
 	n += int(f.Match.Len())
 
+	f.Instructions = nil
 	for n < int(f.Header.Length) {
-		instr := DecodeInstr(data[n:])
+		instr, err := decodeInstr(data[n:f.Header.Length])
+		if err != nil {
+			return err
+		}
 		f.Instructions = append(f.Instructions, instr)
 		n += int(instr.Len())
 	}
@@ -258,6 +274,9 @@ func (f *FlowRemoved) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *FlowRemoved) UnmarshalBinary(data []byte) error {
+	if len(data) < 48 {
+		return errors.New("The []byte is too short to unmarshal a full FlowRemoved message.")
+	}
 	next := 0
 	if err := f.Header.UnmarshalBinary(data[next:]); err != nil {
 		return err
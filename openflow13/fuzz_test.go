@@ -0,0 +1,235 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/common"
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+// fuzzMessages returns the messages of the JSON tests, and the other messages
+// of the tests built with the same constructors, to seed the fuzz targets.
+func fuzzMessages(t testing.TB) []util.Message {
+	var msgs []util.Message
+	jsonMsgs := jsonTestMessages(t)
+	var names []string
+	for name := range jsonMsgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		msgs = append(msgs, jsonMsgs[name])
+	}
+
+	flow := NewFlowMod()
+	flow.Match.AddField(*NewEthTypeField(protocol.IPv6_MSG))
+	flow.Match.AddField(*NewIpProtoField(protocol.Type_IPv6ICMP))
+	flow.Match.AddField(*NewIpv6SrcField(net.ParseIP("2001:db8::1"), nil))
+	flow.Match.AddField(*NewRegMatchField(1, 0x10, nil))
+	apply := NewInstrApplyActions()
+	for _, act := range fuzzActions(t) {
+		apply.AddAction(act, false)
+	}
+	flow.AddInstruction(apply)
+	flow.AddInstruction(NewInstrWriteMetadata(1, 0xff))
+	flow.AddInstruction(NewInstrMeter(2))
+	write := NewInstrWriteActions()
+	write.AddAction(NewActionGroup(1), false)
+	flow.AddInstruction(write)
+	msgs = append(msgs, flow)
+
+	frame := protocol.NewEthernet()
+	frame.Ethertype = protocol.ARP_MSG
+	arp, err := protocol.NewARP(protocol.Type_Request)
+	require.NoError(t, err)
+	frame.Data = arp
+	packetIn := NewPacketIn()
+	packetIn.Match.AddField(*NewInPortField(3))
+	packetIn.Data = *frame
+	msgs = append(msgs, packetIn)
+
+	msgs = append(msgs, NewPacketIn2([]Property{
+		&PacketIn2PropPacket{PropHeader: &PropHeader{Type: NXPINT_PACKET}, Packet: *frame},
+		&PacketIn2PropFullLen{PropHeader: &PropHeader{Type: NXPINT_FULL_LEN}, FullLen: 42},
+		&PacketIn2PropTableID{PropHeader: &PropHeader{Type: NXPINT_TABLE_ID}, TableID: 3},
+		&PacketIn2PropCookie{PropHeader: &PropHeader{Type: NXPINT_COOKIE}, Cookie: 0x1234},
+		&PacketIn2PropMetadata{PropHeader: &PropHeader{Type: NXPINT_METADATA}, Fields: []MatchField{*NewInPortField(3)}},
+		&PacketIn2PropUserdata{PropHeader: &PropHeader{Type: NXPINT_USERDATA}, Userdata: []byte{1, 2, 3}},
+	}))
+
+	errMsg := NewErrorMsg()
+	errMsg.Header.Type = Type_Error
+	errMsg.Data = *util.NewBuffer([]byte{1, 2, 3, 4})
+	portStatus := NewPortStatus()
+	portStatus.Header.Type = Type_PortStatus
+	portStatus.Desc = *NewPhyPort()
+	mpRequest := &MultipartRequest{
+		Header: NewOfp13Header(),
+		Type:   MultipartType_TableFeatures,
+		Body:   []util.Message{newTableFeatures()},
+	}
+	mpRequest.Header.Type = Type_MultiPartRequest
+	msgs = append(msgs,
+		NewEchoRequest(),
+		errMsg,
+		portStatus,
+		NewFeaturesReply(),
+		NewSetControllerID(102),
+		NewSetPacketInFormet(OFPUTIL_PACKET_IN_NXT2),
+		NewTLVTableModMessage(prepareTLVTableMod()),
+		mpRequest,
+	)
+	return msgs
+}
+
+// fuzzActions returns an action of each kind decoded by DecodeAction.
+func fuzzActions(t testing.TB) []Action {
+	regLoad2Field, err := FindFieldHeaderByName("NXM_NX_REG1", false)
+	require.NoError(t, err)
+	regLoad2Field.Value = newUint32Message(0x1234)
+	controller := NewNXActionController(1001)
+	controller.MaxLen = 128
+	controller2 := NewNXActionController2()
+	controller2.AddMaxLen(128)
+	controller2.AddUserdata([]byte{1, 2, 3})
+	controller2.AddPause(true)
+	learn := NewNXActionLearn()
+	learn.IdleTimeout = 10
+	learn.TableID = 2
+	learn.LearnSpecs = prepareLearnSpecs()
+	return []Action{
+		NewActionOutput(1),
+		NewActionPushVlan(0x8100),
+		NewActionPopVlan(),
+		NewActionPopMpls(0x0800),
+		NewActionSetQueue(2),
+		NewActionDecNwTtl(),
+		NewActionSetField(*NewEthTypeField(0x0800)),
+		NewNXActionConjunction(1, 2, 3),
+		NewNXActionResubmit(1),
+		NewNXActionResubmitTableCT(1, 2),
+		NewNXActionRegMove(16, 0, 0, NewRegMatchField(1, 0, nil), NewRegMatchField(2, 0, nil)),
+		NewNXActionRegLoad2(regLoad2Field),
+		NewOutputFromField(NewRegMatchField(1, 0, nil), NewNXRange(0, 31).ToOfsBits()),
+		NewNXActionDecTTL(),
+		NewNXActionDecTTLCntIDs(2, 1, 2),
+		controller,
+		controller2,
+		learn,
+	}
+}
+
+// fuzzSeeds returns the wire format of msgs.
+func fuzzSeeds[M util.Message](t testing.TB, msgs []M) [][]byte {
+	var seeds [][]byte
+	for _, msg := range msgs {
+		data, err := msg.MarshalBinary()
+		require.NoError(t, err)
+		seeds = append(seeds, data)
+	}
+	return seeds
+}
+
+// fuzzMarshal checks that a decoded message can be encoded again.
+func fuzzMarshal(msg util.Message) {
+	msg.Len()
+	msg.MarshalBinary()
+}
+
+// fuzzParse parses data, and encodes the message again. Parse does not decode
+// all controller-to-switch messages.
+func fuzzParse(data []byte) {
+	if msg, err := Parse(data); err == nil && msg != nil {
+		fuzzMarshal(msg)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range fuzzSeeds(f, fuzzMessages(f)) {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzParse(data)
+	})
+}
+
+func FuzzMatch(f *testing.F) {
+	for _, msg := range fuzzMessages(f) {
+		if flow, ok := msg.(*FlowMod); ok {
+			f.Add(fuzzSeeds(f, []*Match{&flow.Match})[0])
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m := new(Match)
+		if m.UnmarshalBinary(data) == nil {
+			fuzzMarshal(m)
+		}
+	})
+}
+
+func FuzzDecodeAction(f *testing.F) {
+	for _, seed := range fuzzSeeds(f, fuzzActions(f)) {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if a, err := DecodeAction(data); err == nil {
+			fuzzMarshal(a)
+		}
+	})
+}
+
+func FuzzDecodeInstr(f *testing.F) {
+	for _, msg := range fuzzMessages(f) {
+		if flow, ok := msg.(*FlowMod); ok {
+			for _, seed := range fuzzSeeds(f, flow.Instructions) {
+				f.Add(seed)
+			}
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if instr, err := decodeInstr(data); err == nil {
+			fuzzMarshal(instr)
+		}
+	})
+}
+
+// TestParseMalformed parses every prefix of the seed messages, and the
+// messages with each byte replaced, with the header length set to the length
+// of the data, so that their bodies are decoded.
+func TestParseMalformed(t *testing.T) {
+	parse := func(data []byte) {
+		if len(data) >= 4 {
+			binary.BigEndian.PutUint16(data[2:], uint16(len(data)))
+		}
+		fuzzParse(data)
+	}
+	for _, seed := range fuzzSeeds(t, fuzzMessages(t)) {
+		for i := range seed {
+			parse(append([]byte(nil), seed[:i]...))
+		}
+		for i := 4; i < len(seed); i++ {
+			data := append([]byte(nil), seed...)
+			data[i] = 0xff
+			parse(data)
+		}
+	}
+
+	for _, data := range [][]byte{nil, {4}, {4, Type_Hello, 0, 0}, {4, Type_Hello, 0, 4, 0, 0, 0, 1}} {
+		_, err := Parse(data)
+		require.Error(t, err, "%v", data)
+	}
+	hello, err := common.NewHello(4)
+	require.NoError(t, err)
+	data, err := hello.MarshalBinary()
+	require.NoError(t, err)
+	_, err = Parse(append(data, 0))
+	require.NoError(t, err)
+	_, err = Parse(data[:len(data)-1])
+	require.Error(t, err)
+}
@@ -4,6 +4,8 @@ package openflow13
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
 
 	log "github.com/sirupsen/logrus"
 
@@ -103,8 +105,16 @@ func (g *GroupMod) MarshalBinary() (data []byte, err error) {
 }
 
 func (g *GroupMod) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full GroupMod message.")
+	}
 	n := 0
-	g.Header.UnmarshalBinary(data[n:])
+	if err := g.Header.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
+	if int(g.Header.Length) > len(data) {
+		return fmt.Errorf("The GroupMod length %d is invalid for %d bytes of data.", g.Header.Length, len(data))
+	}
 	n += int(g.Header.Len())
 
 	g.Command = binary.BigEndian.Uint16(data[n:])
@@ -116,9 +126,12 @@ func (g *GroupMod) UnmarshalBinary(data []byte) error {
 	g.GroupId = binary.BigEndian.Uint32(data[n:])
 	n += 4
 
+	g.Buckets = nil
 	for n < int(g.Header.Length) {
 		bkt := new(Bucket)
-		bkt.UnmarshalBinary(data[n:])
+		if err := bkt.UnmarshalBinary(data[n:g.Header.Length]); err != nil {
+			return err
+		}
 		g.Buckets = append(g.Buckets, *bkt)
 		n += int(bkt.Len())
 	}
@@ -189,8 +202,14 @@ func (b *Bucket) MarshalBinary() (data []byte, err error) {
 }
 
 func (b *Bucket) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full Bucket message.")
+	}
 	n := 0
 	b.Length = binary.BigEndian.Uint16(data[n:])
+	if b.Length < 16 || int(b.Length) > len(data) {
+		return fmt.Errorf("The Bucket length %d is invalid for %d bytes of data.", b.Length, len(data))
+	}
 	n += 2
 	b.Weight = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -200,8 +219,9 @@ func (b *Bucket) UnmarshalBinary(data []byte) error {
 	n += 4
 	n += 4 // for padding
 
+	b.Actions = nil
 	for n < int(b.Length) {
-		a, err := DecodeAction(data[n:])
+		a, err := DecodeAction(data[n:b.Length])
 		if err != nil {
 			return err
 		}
@@ -5,6 +5,7 @@ package openflow13
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 
 	"antrea.io/libOpenflow/util"
 )
@@ -51,8 +52,25 @@ func (a *InstrHeader) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// DecodeInstr decodes the instruction in data, or returns nil if it is
+// malformed.
+//
+// Deprecated: use Parse or FlowMod.UnmarshalBinary, which return the errors.
 func DecodeInstr(data []byte) Instruction {
+	instr, _ := decodeInstr(data)
+	return instr
+}
+
+func decodeInstr(data []byte) (Instruction, error) {
+	if len(data) < 4 {
+		return nil, errors.New("data too short to decode Instruction")
+	}
 	t := binary.BigEndian.Uint16(data[:2])
+	length := binary.BigEndian.Uint16(data[2:4])
+	if length < 4 || int(length) > len(data) {
+		return nil, fmt.Errorf("the Instruction length %d is invalid for %d bytes of data", length, len(data))
+	}
+	data = data[:length]
 	var a Instruction
 	switch t {
 	case InstrType_GOTO_TABLE:
@@ -68,10 +86,73 @@ func DecodeInstr(data []byte) Instruction {
 	case InstrType_METER:
 		a = new(InstrMeter)
 	case InstrType_EXPERIMENTER:
+		a = newExperimenterInstr(data)
 	}
 
-	a.UnmarshalBinary(data)
-	return a
+	if a == nil {
+		a = new(InstrUnknown)
+	}
+
+	if err := a.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	if a.Len() != length {
+		// The instruction would be encoded with another length, keep its
+		// bytes.
+		a = new(InstrUnknown)
+		if err := a.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// InstrUnknown is an instruction of a type which is not known. It keeps the
+// bytes following the instruction header, so that it is encoded again
+// unchanged.
+type InstrUnknown struct {
+	InstrHeader
+	Data []byte
+}
+
+func (instr *InstrUnknown) Len() (n uint16) {
+	return instr.InstrHeader.Len() + uint16(len(instr.Data))
+}
+
+func (instr *InstrUnknown) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(instr.Len()))
+	instr.Length = instr.Len()
+	b, err := instr.InstrHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	copy(data[n:], instr.Data)
+	return
+}
+
+func (instr *InstrUnknown) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full InstrUnknown message.")
+	}
+	if err := instr.InstrHeader.UnmarshalBinary(data[:4]); err != nil {
+		return err
+	}
+	if instr.Length < 4 || int(instr.Length) > len(data) {
+		return fmt.Errorf("the Instruction length %d is invalid for %d bytes of data", instr.Length, len(data))
+	}
+	instr.Data = make([]byte, int(instr.Length)-4)
+	copy(instr.Data, data[4:instr.Length])
+	return nil
+}
+
+// UnknownError implements util.Unknown.
+func (instr *InstrUnknown) UnknownError() error {
+	return fmt.Errorf("unknown instruction of type %d and length %d", instr.Type, instr.Length)
+}
+
+func (instr *InstrUnknown) AddAction(act Action, prepend bool) error {
+	return errors.New("Not supported on this instrction")
 }
 
 type InstrGotoTable struct {
@@ -96,6 +177,9 @@ func (instr *InstrGotoTable) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrGotoTable) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full InstrGotoTable message.")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
 	instr.TableId = data[4]
@@ -143,6 +227,9 @@ func (instr *InstrWriteMetadata) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrWriteMetadata) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full InstrWriteMetadata message.")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
 	copy(instr.pad, data[4:8])
@@ -200,11 +287,19 @@ func (instr *InstrActions) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrActions) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full InstrActions message.")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
+	if instr.Length < 8 || int(instr.Length) > len(data) {
+		return fmt.Errorf("The InstrActions length %d is invalid for %d bytes of data.", instr.Length, len(data))
+	}
+
 	n := 8
+	instr.Actions = nil
 	for n < int(instr.Length) {
-		act, err := DecodeAction(data[n:])
+		act, err := DecodeAction(data[n:instr.Length])
 		if err != nil {
 			return err
 		}
@@ -277,6 +372,9 @@ func (instr *InstrMeter) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrMeter) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full InstrMeter message.")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
 	instr.MeterId = binary.BigEndian.Uint32(data[4:8])
@@ -0,0 +1,352 @@
+package openflow13
+
+// This file converts messages to JSON and back.
+
+import (
+	"antrea.io/libOpenflow/common"
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+// jsonCodec knows all types that can be held by the interface fields of the
+// messages, such as match field values, actions, instructions, properties and
+// the packet layers of the protocol package. The experimenter registry adds
+// the types of the experimenter extensions.
+var jsonCodec = util.NewJSONCodec(
+	new(ActionDecNwTtl), new(ActionGroup), new(ActionHeader), new(ActionMplsTtl), new(ActionNwTtl),
+	new(ActionOutput), new(ActionPopMpls), new(ActionPopVlan), new(ActionProperty), new(ActionPush),
+	new(ActionSetField), new(ActionSetqueue), new(ActionUnknown), new(ActsetOutputField),
+	new(AggregateStats), new(AggregateStatsRequest), new(ArpOperField), new(ArpXHaField),
+	new(ArpXPaField), new(Bucket), new(BundleAdd), new(BundleControl),
+	new(BundlePropertyExperimenter), new(ByteArrayField), new(CTLabel),
+	new(ContinuationPropActionSet), new(ContinuationPropActions), new(ContinuationPropBridge),
+	new(ContinuationPropConntracked), new(ContinuationPropCookie), new(ContinuationPropMirrors),
+	new(ContinuationPropOdpPort), new(ContinuationPropStack), new(ContinuationPropTableID),
+	new(ControllerID), new(DescStats), new(ErrorMsg), new(EthDstField), new(EthSrcField),
+	new(EthTypeField), new(FieldUnknown), new(FlowMod), new(FlowRemoved), new(FlowStats),
+	new(FlowStatsRequest), new(GroupMod), new(IcmpCodeField), new(IcmpTypeField), new(InPortField),
+	new(InstrActions), new(InstrGotoTable), new(InstrHeader), new(InstrMeter), new(InstrUnknown),
+	new(InstrWriteMetadata), new(InstructionProperty), new(IpDscpField), new(IpProtoField),
+	new(Ipv4DstField), new(Ipv4SrcField), new(Ipv6DstField), new(Ipv6SrcField), new(Match),
+	new(MatchField), new(MetadataField), new(MeterBandDSCP), new(MeterBandDrop),
+	new(MeterBandExperimenter), new(MeterBandHeader), new(MeterMod), new(MplsBosField),
+	new(MplsLabelField), new(MplsTcField), new(MultipartReply), new(MultipartRequest),
+	new(NXActionCTNAT), new(NXActionConjunction), new(NXActionConnTrack), new(NXActionController),
+	new(NXActionController2), new(NXActionController2PropControllerID),
+	new(NXActionController2PropMaxLen), new(NXActionController2PropMeterId),
+	new(NXActionController2PropPause), new(NXActionController2PropReason),
+	new(NXActionController2PropUserdata), new(NXActionDecTTL), new(NXActionDecTTLCntIDs),
+	new(NXActionHeader), new(NXActionLearn), new(NXActionNote), new(NXActionOutputReg),
+	new(NXActionRegLoad), new(NXActionRegLoad2), new(NXActionRegMove), new(NXActionResubmit),
+	new(NXActionResubmitTable), new(NXLearnSpec), new(NXLearnSpecField), new(NXLearnSpecHeader),
+	new(NextTableProperty), new(OFPTableFeatures), new(OFTablePropertyHeader), new(PacketIn),
+	new(PacketIn2), new(PacketIn2PropBufferID), new(PacketIn2PropContinuation),
+	new(PacketIn2PropCookie), new(PacketIn2PropFullLen), new(PacketIn2PropMetadata),
+	new(PacketIn2PropPacket), new(PacketIn2PropReason), new(PacketIn2PropTableID),
+	new(PacketIn2PropUserdata), new(PacketInFormat), new(PacketOut), new(PhyPort), new(PortField),
+	new(PortMod), new(PortStats), new(PortStatsRequest), new(PortStatus), new(PropHeader),
+	new(PropUnknown), new(QueueStats), new(QueueStatsRequest), new(Resume), new(SetFieldProperty),
+	new(SwitchConfig), new(SwitchFeatures), new(TLVTableMap), new(TLVTableMod), new(TLVTableReply),
+	new(TableExperimenterProperty), new(TableStats), new(TcpFlagsField), new(TtlField),
+	new(TunnelIdField), new(TunnelIpv4DstField), new(TunnelIpv4SrcField), new(Uint16Message),
+	new(Uint32Message), new(VendorError), new(VendorHeader), new(VlanIdField),
+
+	new(common.Header), new(common.Hello), new(common.HelloElemHeader), new(common.HelloElemVersionBitmap),
+	new(util.Buffer), new(util.BufferUnknown),
+
+	new(protocol.ARP), new(protocol.ChassisTLV), new(protocol.DHCP), new(protocol.DHCPv6),
+	new(protocol.DHCPv6ClientID), new(protocol.DHCPv6DNSServers), new(protocol.DHCPv6DomainList),
+	new(protocol.DHCPv6IAAddress), new(protocol.DHCPv6IANA), new(protocol.DHCPv6IAPD),
+	new(protocol.DHCPv6IAPrefix), new(protocol.DHCPv6Option), new(protocol.DHCPv6Relay),
+	new(protocol.DHCPv6RelayMessage), new(protocol.DHCPv6ServerID), new(protocol.DHCPv6StatusCode),
+	new(protocol.DNS), new(protocol.DNSOverTCP), new(protocol.DNSRecordA),
+	new(protocol.DNSRecordAAAA), new(protocol.DNSRecordCNAME), new(protocol.DNSRecordPTR),
+	new(protocol.DNSRecordSRV), new(protocol.DNSRecordTXT), new(protocol.Ethernet),
+	new(protocol.FragmentHeader), new(protocol.GRE), new(protocol.Geneve),
+	new(protocol.GeneveOption), new(protocol.HopByHopHeader), new(protocol.ICMP),
+	new(protocol.ICMPDestinationUnreachable), new(protocol.ICMPEcho),
+	new(protocol.ICMPParameterProblem), new(protocol.ICMPRedirect), new(protocol.ICMPTimeExceeded),
+	new(protocol.ICMPv6EchoReqRpl), new(protocol.ICMPv6Header), new(protocol.IGMPv1or2),
+	new(protocol.IGMPv3GroupRecord), new(protocol.IGMPv3MembershipReport),
+	new(protocol.IGMPv3Query), new(protocol.IPv4), new(protocol.IPv6), new(protocol.LLDP),
+	new(protocol.LLDP8021PortVLANID), new(protocol.LLDP8021VLANName), new(protocol.LLDP8023MACPHY),
+	new(protocol.LLDP8023MaxFrameSize), new(protocol.LLDPManagementAddress),
+	new(protocol.LLDPOrgSpecific), new(protocol.LLDPPortDescription),
+	new(protocol.LLDPSystemCapabilities), new(protocol.LLDPSystemDescription),
+	new(protocol.LLDPSystemName), new(protocol.LLDPTLV), new(protocol.MLD), new(protocol.MLDQuery),
+	new(protocol.MLDv2Record), new(protocol.MLDv2Report), new(protocol.MPLS),
+	new(protocol.MPLSLabel), new(protocol.NDOptionLinkLayerAddress), new(protocol.NDOptionMTU),
+	new(protocol.NDOptionPrefixInformation), new(protocol.NDOptionRDNSS),
+	new(protocol.NDOptionRaw), new(protocol.NDOptionRedirectedHeader), new(protocol.NSH),
+	new(protocol.NSHTLV), new(protocol.NeighborAdvertisement), new(protocol.NeighborSolicitation),
+	new(protocol.Option), new(protocol.PortTLV), new(protocol.Redirect),
+	new(protocol.RouterAdvertisement), new(protocol.RouterSolicitation),
+	new(protocol.RoutingHeader), new(protocol.STT), new(protocol.TCP), new(protocol.TCPOptionMSS),
+	new(protocol.TCPOptionNOP), new(protocol.TCPOptionRaw), new(protocol.TCPOptionSACK),
+	new(protocol.TCPOptionSACKPermitted), new(protocol.TCPOptionTimestamps),
+	new(protocol.TCPOptionWindowScale), new(protocol.TTLTLV), new(protocol.UDP),
+	new(protocol.VLAN), new(protocol.VXLAN),
+)
+
+// JSONMessage wraps a message so that encoding/json writes its type along with
+// its fields, and can decode it back into a message of the same type:
+//
+//	{"FlowMod": {"Header": {...}, "Cookie": 0, ...}}
+type JSONMessage struct {
+	util.Message
+}
+
+func (m JSONMessage) MarshalJSON() ([]byte, error) {
+	return jsonCodec.MarshalMessage(m.Message)
+}
+
+func (m *JSONMessage) UnmarshalJSON(data []byte) (err error) {
+	m.Message, err = jsonCodec.UnmarshalMessage(data)
+	return
+}
+
+// Types with interface fields, directly or through embedded structs, are
+// encoded by jsonCodec too.
+
+func (a *AggregateStatsRequest) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(a)
+}
+
+func (a *AggregateStatsRequest) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, a)
+}
+
+func (b *Bucket) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(b)
+}
+
+func (b *Bucket) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, b)
+}
+
+func (b *BundleAdd) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(b)
+}
+
+func (b *BundleAdd) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, b)
+}
+
+func (c *ContinuationPropActionSet) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(c)
+}
+
+func (c *ContinuationPropActionSet) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, c)
+}
+
+func (c *ContinuationPropActions) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(c)
+}
+
+func (c *ContinuationPropActions) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, c)
+}
+
+func (f *FlowMod) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(f)
+}
+
+func (f *FlowMod) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, f)
+}
+
+func (f *FlowStats) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(f)
+}
+
+func (f *FlowStats) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, f)
+}
+
+func (i *InstrActions) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(i)
+}
+
+func (i *InstrActions) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, i)
+}
+
+func (m *Match) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(m)
+}
+
+func (m *Match) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, m)
+}
+
+func (m *MatchField) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(m)
+}
+
+func (m *MatchField) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, m)
+}
+
+func (m *MeterMod) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(m)
+}
+
+func (m *MeterMod) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, m)
+}
+
+func (m *MultipartReply) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(m)
+}
+
+func (m *MultipartReply) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, m)
+}
+
+func (m *MultipartRequest) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(m)
+}
+
+func (m *MultipartRequest) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, m)
+}
+
+func (o *OFPTableFeatures) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(o)
+}
+
+func (o *OFPTableFeatures) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, o)
+}
+
+func (p *PacketIn2) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(p)
+}
+
+func (p *PacketIn2) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, p)
+}
+
+func (p *PacketOut) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(p)
+}
+
+func (p *PacketOut) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, p)
+}
+
+func (r *Resume) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(r)
+}
+
+func (r *Resume) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, r)
+}
+
+func (v *VendorHeader) MarshalJSON() ([]byte, error) {
+	return jsonCodec.Marshal(v)
+}
+
+func (v *VendorHeader) UnmarshalJSON(data []byte) error {
+	return jsonCodec.Unmarshal(data, v)
+}
+
+// Types which keep their state in unexported fields are encoded as their wire
+// format.
+
+func (b *BundlePropertyExperimenter) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(b)
+}
+
+func (b *BundlePropertyExperimenter) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, b)
+}
+
+func (c *CTLabel) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(c)
+}
+
+func (c *CTLabel) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, c)
+}
+
+func (i *IpDscpField) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(i)
+}
+
+func (i *IpDscpField) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, i)
+}
+
+func (i *IpProtoField) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(i)
+}
+
+func (i *IpProtoField) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, i)
+}
+
+func (n *NXActionCTNAT) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(n)
+}
+
+func (n *NXActionCTNAT) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, n)
+}
+
+func (n *NXActionConnTrack) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(n)
+}
+
+func (n *NXActionConnTrack) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, n)
+}
+
+func (n *NXActionController2) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(n)
+}
+
+func (n *NXActionController2) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, n)
+}
+
+func (n *NXActionDecTTL) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(n)
+}
+
+func (n *NXActionDecTTL) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, n)
+}
+
+func (n *NXActionDecTTLCntIDs) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(n)
+}
+
+func (n *NXActionDecTTLCntIDs) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, n)
+}
+
+func (n *NXActionResubmitTable) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(n)
+}
+
+func (n *NXActionResubmitTable) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, n)
+}
+
+func (n *NXLearnSpecHeader) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(n)
+}
+
+func (n *NXLearnSpecHeader) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, n)
+}
+
+func (p *PortField) MarshalJSON() ([]byte, error) {
+	return util.MarshalBinaryJSON(p)
+}
+
+func (p *PortField) UnmarshalJSON(data []byte) error {
+	return util.UnmarshalBinaryJSON(data, p)
+}
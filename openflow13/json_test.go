@@ -0,0 +1,164 @@
+package openflow13
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/common"
+	"antrea.io/libOpenflow/util"
+)
+
+func jsonTestMessages(t testing.TB) map[string]util.Message {
+	flow := NewFlowMod()
+	flow.Cookie = 0x1234
+	flow.TableId = 3
+	flow.Priority = 200
+	flow.Match.AddField(*NewInPortField(5))
+	flow.Match.AddField(*NewEthTypeField(0x0800))
+	ipMask := net.ParseIP("255.255.255.0").To4()
+	flow.Match.AddField(*NewIpv4SrcField(net.ParseIP("10.0.0.0").To4(), &ipMask))
+	flow.Match.AddField(*NewIpv6DstField(net.ParseIP("fe80::1"), nil))
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	flow.Match.AddField(*NewEthDstField(mac, nil))
+	flow.Match.AddField(*NewRegMatchFieldWithMask(3, 0x10, 0xff))
+	ctStates := NewCTStates()
+	ctStates.SetNew()
+	ctStates.SetTrk()
+	flow.Match.AddField(*NewCTStateMatchField(ctStates))
+	flow.Match.AddField(*NewTunnelIdField(100))
+
+	ct := NewNXActionConnTrack().Commit().Table(10).ZoneImm(1)
+	nat := NewNXActionCTNAT()
+	require.NoError(t, nat.SetSNAT())
+	nat.SetRangeIPv4Min(net.ParseIP("1.1.1.1"))
+	nat.SetRangeIPv4Max(net.ParseIP("1.1.1.10"))
+	ct.AddAction(nat)
+	apply := NewInstrApplyActions()
+	apply.AddAction(ct, false)
+	apply.AddAction(NewActionSetField(*NewEthDstField(mac, nil)), false)
+	apply.AddAction(NewNXActionRegLoad(NewNXRange(0, 15).ToOfsBits(), NewRegMatchField(1, 0, nil), 0xabcd), false)
+	note := NewNXActionNote()
+	note.Note = []byte("note")
+	apply.AddAction(note, false)
+	apply.AddAction(NewActionOutput(2), false)
+	flow.AddInstruction(apply)
+	flow.AddInstruction(NewInstrGotoTable(4))
+
+	group := NewGroupMod()
+	group.GroupId = 7
+	group.Type = OFPGT_SELECT
+	bucket := NewBucket()
+	bucket.Weight = 50
+	bucket.AddAction(NewActionOutput(3))
+	group.AddBucket(*bucket)
+
+	meter := NewMeterMod()
+	meter.MeterId = 2
+	drop := &MeterBandDrop{MeterBandHeader: *NewMeterBandHeader()}
+	drop.Type = OFPMBT13_DROP
+	drop.Rate = 1000
+	meter.AddMeterBand(drop)
+
+	packetOut := NewPacketOut()
+	packetOut.InPort = 1
+	packetOut.AddAction(NewActionOutput(P_TABLE))
+	packetOut.Data = util.NewBuffer([]byte{0x01, 0x02, 0x03})
+
+	bundleCtrl := NewBundleControl(&BundleControl{BundleID: 1, Type: OFPBCT_COMMIT_REQUEST, Flags: OFPBCT_ATOMIC})
+	bundleAdd := NewBundleAdd(&BundleAdd{BundleID: 1, Flags: OFPBCT_ATOMIC, Message: flow})
+
+	reply := new(MultipartReply)
+	reply.Header = NewOfp13Header()
+	reply.Header.Type = Type_MultiPartReply
+	reply.Type = MultipartType_Flow
+	stats := NewFlowStats()
+	stats.Match.AddField(*NewInPortField(1))
+	stats.Instructions = append(stats.Instructions, NewInstrGotoTable(1))
+	stats.Length = stats.Len()
+	reply.Body = append(reply.Body, stats)
+
+	hello, err := common.NewHello(4)
+	require.NoError(t, err)
+
+	return map[string]util.Message{
+		"Hello":         hello,
+		"FlowMod":       flow,
+		"GroupMod":      group,
+		"MeterMod":      meter,
+		"PacketOut":     packetOut,
+		"BundleControl": bundleCtrl,
+		"BundleAdd":     bundleAdd,
+		"FlowStats":     reply,
+	}
+}
+
+func TestJSONMessage(t *testing.T) {
+	for name, msg := range jsonTestMessages(t) {
+		t.Run(name, func(t *testing.T) {
+			wire, err := msg.MarshalBinary()
+			require.NoError(t, err)
+			msgs := []util.Message{msg}
+			// Parse does not decode all controller-to-switch messages.
+			if parsed, err := Parse(wire); err == nil && parsed != nil {
+				msgs = append(msgs, parsed)
+			}
+
+			for _, m := range msgs {
+				data, err := json.Marshal(JSONMessage{m})
+				require.NoError(t, err)
+				var decoded JSONMessage
+				require.NoError(t, json.Unmarshal(data, &decoded))
+				b, err := decoded.MarshalBinary()
+				require.NoError(t, err)
+				assert.Equal(t, wire, b)
+			}
+		})
+	}
+}
+
+func TestJSONFlowMod(t *testing.T) {
+	flow := jsonTestMessages(t)["FlowMod"].(*FlowMod)
+	wire, err := flow.MarshalBinary()
+	require.NoError(t, err)
+
+	data, err := json.Marshal(flow)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"NXActionConnTrack":`)
+	assert.Contains(t, string(data), `"Ipv4SrcField":`)
+	decoded := new(FlowMod)
+	require.NoError(t, json.Unmarshal(data, decoded))
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, wire, b)
+
+	var m JSONMessage
+	assert.Error(t, json.Unmarshal([]byte(`{"NoSuchMessage":{}}`), &m))
+	assert.Error(t, json.Unmarshal([]byte(`{"FlowMod":{},"GroupMod":{}}`), &m))
+}
+
+// TestJSONCodecTypes checks that jsonCodec knows the types of the values which
+// Parse returns, by encoding the parsed test messages to JSON and back.
+func TestJSONCodecTypes(t *testing.T) {
+	msgs := fuzzMessages(t)
+	for _, msg := range unknownMessages() {
+		msgs = append(msgs, msg)
+	}
+	for _, wire := range fuzzSeeds(t, msgs) {
+		// Parse does not decode all controller-to-switch messages.
+		parsed, err := Parse(wire)
+		if err != nil || parsed == nil {
+			continue
+		}
+		data, err := json.Marshal(JSONMessage{parsed})
+		require.NoError(t, err, "%T", parsed)
+		var decoded JSONMessage
+		require.NoError(t, json.Unmarshal(data, &decoded), "%T", parsed)
+		b, err := decoded.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, wire, b, "%T", parsed)
+	}
+}
@@ -1,5 +1,8 @@
 package openflow13
 
+// match_prereq.go is shared with openflow15, which has the source.
+//go:generate sh -c "{ echo '// Code generated from ../openflow15/match_prereq.go by go generate. DO NOT EDIT.'; echo; sed 's/^package openflow15$/package openflow13/' ../openflow15/match_prereq.go; } > match_prereq.go"
+
 import (
 	"encoding/binary"
 	"errors"
@@ -52,6 +55,11 @@ func (m *Match) Len() (n uint16) {
 
 func (m *Match) MarshalBinary() (data []byte, err error) {
 	data = make([]byte, int(m.Len()))
+	// The length excludes the padding to a multiple of 8 bytes.
+	m.Length = 4
+	for _, a := range m.Fields {
+		m.Length += a.Len()
+	}
 
 	n := 0
 	binary.BigEndian.PutUint16(data[n:], m.Type)
@@ -80,16 +88,22 @@ func (m *Match) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *Match) UnmarshalBinary(data []byte) error {
-
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full Match message.")
+	}
 	n := 0
 	m.Type = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	m.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	if m.Length < 4 || int(m.Length) > len(data) {
+		return fmt.Errorf("The Match length %d is invalid for %d bytes of data.", m.Length, len(data))
+	}
 
+	m.Fields = nil
 	for n < int(m.Length) {
 		field := new(MatchField)
-		if err := field.UnmarshalBinary(data[n:]); err != nil {
+		if err := field.UnmarshalBinary(data[n:m.Length]); err != nil {
 			return err
 		}
 		m.Fields = append(m.Fields, *field)
@@ -135,6 +149,11 @@ func (m *MatchField) MarshalBinary() (data []byte, err error) {
 	data[n] = m.Length
 	n += 1
 
+	if m.ExperimenterID != 0 {
+		binary.BigEndian.PutUint32(data[n:], m.ExperimenterID)
+		n += 4
+	}
+
 	b, err := m.Value.MarshalBinary()
 	copy(data[n:], b)
 	n += len(b)
@@ -148,6 +167,9 @@ func (m *MatchField) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MatchField) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full MatchField message.")
+	}
 	var n uint16
 	var err error
 	m.Class = binary.BigEndian.Uint16(data[n:])
@@ -164,28 +186,59 @@ func (m *MatchField) UnmarshalBinary(data []byte) error {
 
 	m.Length = data[n]
 	n += 1
+	end := n + uint16(m.Length)
+	if int(end) > len(data) {
+		return fmt.Errorf("The MatchField length %d is invalid for %d bytes of data.", m.Length, len(data)-int(n))
+	}
+	data = data[:end]
 
 	if m.Class == OXM_CLASS_EXPERIMENTER {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full MatchField message.")
+		}
 		experimenterID := binary.BigEndian.Uint32(data[n:])
-		if experimenterID == ONF_EXPERIMENTER_ID {
-			n += 4
-			m.ExperimenterID = experimenterID
-		} else {
+		if experimenterID == 0 {
 			return fmt.Errorf("Unsupported experimenter id: %d in class: %d ", experimenterID, m.Class)
 		}
+		n += 4
+		m.ExperimenterID = experimenterID
 	}
 
-	if m.Value, err = DecodeMatchField(m.Class, m.Field, m.Length, m.HasMask, data[n:]); err != nil {
+	decode := func() (util.Message, error) {
+		if m.ExperimenterID != 0 {
+			// The length of experimenter fields includes the experimenter ID.
+			length := m.Length - 4
+			if m.HasMask {
+				length /= 2
+			}
+			if val := newExperimenterField(m.ExperimenterID, m.Field, length); val != nil {
+				if err := unmarshalFieldValue(val, data[n:]); err != nil {
+					return nil, err
+				}
+				return val, nil
+			}
+		}
+		if m.ExperimenterID != 0 && m.ExperimenterID != ONF_EXPERIMENTER_ID {
+			// The fields of other experimenters are not known, and their
+			// length includes the experimenter ID.
+			return unknownFieldValue(m.Length-4, m.HasMask, data[n:])
+		}
+		return DecodeMatchField(m.Class, m.Field, m.Length, m.HasMask, data[n:])
+	}
+	if m.Value, err = decode(); err != nil {
 		return err
 	}
 	n += m.Value.Len()
 
 	if m.HasMask {
-		if m.Mask, err = DecodeMatchField(m.Class, m.Field, m.Length, m.HasMask, data[n:]); err != nil {
+		if m.Mask, err = decode(); err != nil {
 			return err
 		}
 		n += m.Mask.Len()
 	}
+	if n != end {
+		return fmt.Errorf("The MatchField length %d does not match its %d bytes of payload.", m.Length, n-4)
+	}
 	return err
 }
 
@@ -303,10 +356,10 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 
 		if val == nil {
 			log.Printf("Bad pkt class: %v field: %v data: %v", class, field, data)
-			return nil, fmt.Errorf("Bad pkt class: %v field: %v data: %v", class, field, data)
+			return unknownFieldValue(length, hasMask, data)
 		}
 
-		err := val.UnmarshalBinary(data)
+		err := unmarshalFieldValue(val, data)
 		if err != nil {
 			return nil, err
 		}
@@ -449,10 +502,13 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 			val = msg
 		default:
 			log.Printf("Unhandled Field: %d in Class: %d", field, class)
-			return nil, fmt.Errorf("Bad pkt class: %v field: %v data: %v", class, field, data)
+			return unknownFieldValue(length, hasMask, data)
+		}
+		if val == nil {
+			return unknownFieldValue(length, hasMask, data)
 		}
 
-		err := val.UnmarshalBinary(data)
+		err := unmarshalFieldValue(val, data)
 		if err != nil {
 			return nil, err
 		}
@@ -464,17 +520,53 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 			val = new(TcpFlagsField)
 		case OXM_FIELD_ACTSET_OUTPUT:
 			val = new(ActsetOutputField)
+		default:
+			// The length of experimenter fields includes the experimenter ID.
+			return unknownFieldValue(length-4, hasMask, data)
 		}
-		err := val.UnmarshalBinary(data)
+		err := unmarshalFieldValue(val, data)
 		if err != nil {
 			return nil, err
 		}
 		return val, nil
-	} else {
-		log.Panicf("Unsupported match field: %d in class: %d", field, class)
 	}
 
-	return nil, nil
+	return unknownFieldValue(length, hasMask, data)
+}
+
+// unmarshalFieldValue decodes the value or the mask of a match field, after
+// checking that data holds a full one.
+func unmarshalFieldValue(val util.Message, data []byte) error {
+	if len(data) < int(val.Len()) {
+		return fmt.Errorf("The []byte is too short to unmarshal a full %T match field.", val)
+	}
+	return val.UnmarshalBinary(data)
+}
+
+// FieldUnknown is the value or the mask of a match field which is not known.
+// It keeps the bytes of the field, so that it is encoded again unchanged.
+type FieldUnknown struct {
+	ByteArrayField
+}
+
+// UnknownError implements util.Unknown.
+func (m *FieldUnknown) UnknownError() error {
+	return fmt.Errorf("unknown match field value of %d bytes", m.Length)
+}
+
+// unknownFieldValue decodes the value or the mask of a match field which is not
+// known into a FieldUnknown. length is the length of the payload of the field,
+// with the mask if it has one.
+func unknownFieldValue(length uint8, hasMask bool, data []byte) (util.Message, error) {
+	val := new(FieldUnknown)
+	val.Length = length
+	if hasMask {
+		val.Length = length / 2
+	}
+	if err := unmarshalFieldValue(val, data); err != nil {
+		return nil, err
+	}
+	return val, nil
 }
 
 // ofp_match_type 1.3
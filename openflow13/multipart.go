@@ -2,6 +2,7 @@ package openflow13
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	log "github.com/sirupsen/logrus"
@@ -55,10 +56,16 @@ func (s *MultipartRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *MultipartRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full MultipartRequest message.")
+	}
 	err := s.Header.UnmarshalBinary(data)
 	if err != nil {
 		return err
 	}
+	if int(s.Header.Length) > len(data) {
+		return fmt.Errorf("The MultipartRequest length %d is invalid for %d bytes of data.", s.Header.Length, len(data))
+	}
 	n := s.Header.Len()
 
 	s.Type = binary.BigEndian.Uint16(data[n:])
@@ -67,6 +74,7 @@ func (s *MultipartRequest) UnmarshalBinary(data []byte) error {
 	n += 2
 	n += 4 // for padding
 
+	s.Body = nil
 	for n < s.Header.Length {
 		var req util.Message
 		switch s.Type {
@@ -85,9 +93,11 @@ func (s *MultipartRequest) UnmarshalBinary(data []byte) error {
 			req = new(OFPTableFeatures)
 		}
 		if req == nil {
-			return fmt.Errorf("unsupported MultipartRequest type: %d", s.Type)
+			// Keep the body of experimenter requests, and of the requests
+			// which have none, as is.
+			req = new(util.BufferUnknown)
 		}
-		err = req.UnmarshalBinary(data[n:])
+		err = req.UnmarshalBinary(data[n:s.Header.Length])
 		if err != nil {
 			return err
 		}
@@ -136,7 +146,16 @@ func (s *MultipartReply) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *MultipartReply) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full MultipartReply message.")
+	}
 	err := s.Header.UnmarshalBinary(data)
+	if err != nil {
+		return err
+	}
+	if int(s.Header.Length) > len(data) {
+		return fmt.Errorf("The MultipartReply length %d is invalid for %d bytes of data.", s.Header.Length, len(data))
+	}
 	n := s.Header.Len()
 
 	s.Type = binary.BigEndian.Uint16(data[n:])
@@ -167,9 +186,14 @@ func (s *MultipartReply) UnmarshalBinary(data []byte) error {
 			repl = new(OFPTableFeatures)
 		}
 
-		err = repl.UnmarshalBinary(data[n:])
+		if repl == nil {
+			// Keep the body of experimenter replies as is.
+			repl = new(util.BufferUnknown)
+		}
+		err = repl.UnmarshalBinary(data[n:s.Header.Length])
 		if err != nil {
 			log.Printf("Error parsing stats reply")
+			return err
 		}
 		n += repl.Len()
 		req = append(req, repl)
@@ -309,6 +333,9 @@ func (s *DescStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *DescStats) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full DescStats message.")
+	}
 	n := 0
 	copy(s.MfrDesc, data[n:])
 	n += len(s.MfrDesc)
@@ -384,6 +411,9 @@ func (s *FlowStatsRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *FlowStatsRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 32 {
+		return errors.New("The []byte is too short to unmarshal a full FlowStatsRequest message.")
+	}
 	n := 0
 	s.TableId = data[n]
 	n += 1
@@ -486,8 +516,15 @@ func (s *FlowStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *FlowStats) UnmarshalBinary(data []byte) error {
+	if len(data) < 48 {
+		return errors.New("The []byte is too short to unmarshal a full FlowStats message.")
+	}
 	n := 0
 	s.Length = binary.BigEndian.Uint16(data[n:])
+	if s.Length < 48 || int(s.Length) > len(data) {
+		return fmt.Errorf("The FlowStats length %d is invalid for %d bytes of data.", s.Length, len(data))
+	}
+	data = data[:s.Length]
 	n += 2
 	s.TableId = data[n]
 	n += 1
@@ -513,15 +550,21 @@ func (s *FlowStats) UnmarshalBinary(data []byte) error {
 	n += 8
 	s.ByteCount = binary.BigEndian.Uint64(data[n:])
 	n += 8
-	err := s.Match.UnmarshalBinary(data[n:])
+	if err := s.Match.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
 	n += int(s.Match.Len())
 
+	s.Instructions = nil
 	for n < int(s.Length) {
-		instr := DecodeInstr(data[n:])
+		instr, err := decodeInstr(data[n:])
+		if err != nil {
+			return err
+		}
 		s.Instructions = append(s.Instructions, instr)
 		n += int(instr.Len())
 	}
-	return err
+	return nil
 }
 
 // ofp_aggregate_stats_request 1.3
@@ -573,6 +616,9 @@ func (s *AggregateStatsRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *AggregateStatsRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 32 {
+		return errors.New("The []byte is too short to unmarshal a full AggregateStatsRequest message.")
+	}
 	n := 0
 	s.TableId = data[n]
 	n += 1
@@ -589,9 +635,9 @@ func (s *AggregateStatsRequest) UnmarshalBinary(data []byte) error {
 	s.CookieMask = binary.BigEndian.Uint64(data[n:])
 	n += 8
 
-	s.Match.UnmarshalBinary(data[n:])
+	err := s.Match.UnmarshalBinary(data[n:])
 	n += int(s.Match.Len())
-	return nil
+	return err
 }
 
 // ofp_aggregate_stats_reply 1.3
@@ -627,6 +673,9 @@ func (s *AggregateStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *AggregateStats) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full AggregateStats message.")
+	}
 	n := 0
 	s.PacketCount = binary.BigEndian.Uint64(data[n:])
 	n += 8
@@ -685,6 +734,9 @@ func (s *TableStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *TableStats) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full TableStats message.")
+	}
 	n := 0
 	s.TableId = data[0]
 	n += 1
@@ -736,6 +788,9 @@ func (s *PortStatsRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *PortStatsRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full PortStatsRequest message.")
+	}
 	n := 0
 	s.PortNo = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -807,6 +862,9 @@ func (s *PortStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *PortStats) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full PortStats message.")
+	}
 	n := 0
 	s.PortNo = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -869,6 +927,9 @@ func (s *QueueStatsRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *QueueStatsRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full QueueStatsRequest message.")
+	}
 	n := 0
 	s.PortNo = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -912,6 +973,9 @@ func (s *QueueStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *QueueStats) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full QueueStats message.")
+	}
 	n := 0
 	s.PortNo = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -971,6 +1035,9 @@ func (s *PortStatus) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *PortStatus) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full PortStatus message.")
+	}
 	if err := s.Header.UnmarshalBinary(data); err != nil {
 		return err
 	}
@@ -979,7 +1046,7 @@ func (s *PortStatus) UnmarshalBinary(data []byte) error {
 	s.Reason = data[n]
 	n += 1
 	copy(s.pad, data[n:])
-	n += len(s.pad)
+	n += 7
 
 	return s.Desc.UnmarshalBinary(data[n:])
 }
@@ -1088,7 +1155,7 @@ func (p *InstructionProperty) UnmarshalBinary(data []byte) error {
 	}
 	n += 4
 	p.Instructions = make([]InstrHeader, 0)
-	for n < int(p.Length) {
+	for n+4 <= int(p.Length) {
 		instr := new(InstrHeader)
 		err := instr.UnmarshalBinary(data[n : n+4])
 		if err != nil {
@@ -1199,7 +1266,7 @@ func (p *ActionProperty) UnmarshalBinary(data []byte) error {
 	p.Actions = make([]ActionHeader, 0)
 	for n < int(p.Length) {
 		act := new(ActionHeader)
-		err := act.UnmarshalBinary(data[n:])
+		err := act.UnmarshalBinary(data[n:p.Length])
 		if err != nil {
 			return err
 		}
@@ -1252,7 +1319,7 @@ func (p *SetFieldProperty) UnmarshalBinary(data []byte) error {
 	}
 	n += 4
 	p.IDs = make([]uint32, 0)
-	for n < int(p.Length) {
+	for n+4 <= int(p.Length) {
 		p.IDs = append(p.IDs, binary.BigEndian.Uint32(data[n:]))
 		n += 4
 	}
@@ -1291,8 +1358,8 @@ func (p *TableExperimenterProperty) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *TableExperimenterProperty) UnmarshalBinary(data []byte) error {
-	if len(data) < 4 {
-		return fmt.Errorf("the []byte is too short to unmarshal OFTablePropertyHeader message")
+	if len(data) < 12 {
+		return errors.New("The []byte is too short to unmarshal a full TableExperimenterProperty message.")
 	}
 	n := 0
 	header := new(OFTablePropertyHeader)
@@ -1310,7 +1377,7 @@ func (p *TableExperimenterProperty) UnmarshalBinary(data []byte) error {
 	p.ExperimenterType = binary.BigEndian.Uint32(data[n:])
 	n += 4
 	p.ExperimenterData = make([]uint32, 0)
-	for n < int(p.Length) {
+	for n+4 <= int(p.Length) {
 		p.ExperimenterData = append(p.ExperimenterData, binary.BigEndian.Uint32(data[n:]))
 		n += 4
 	}
@@ -1371,14 +1438,17 @@ func (f *OFPTableFeatures) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *OFPTableFeatures) UnmarshalBinary(data []byte) error {
-	if len(data) < 2 {
-		return fmt.Errorf("the []byte is too short to unmarshal OFPTableFeatures message Length")
+	if len(data) < 64 {
+		return errors.New("The []byte is too short to unmarshal a full OFPTableFeatures message.")
 	}
 	n := 0
 	f.Length = binary.BigEndian.Uint16(data[n:])
 	if len(data) < int(f.Length) {
 		return fmt.Errorf("the []byte is too short to unmarshal a full OFPTableFeatures message")
 	}
+	if f.Length < 64 {
+		return fmt.Errorf("the OFPTableFeatures length %d is shorter than its fixed fields", f.Length)
+	}
 	n += 2
 	f.TableID = data[n]
 	n += 1
@@ -1399,6 +1469,9 @@ func (f *OFPTableFeatures) UnmarshalBinary(data []byte) error {
 	n += 4
 	f.Properties = make([]util.Message, 0)
 	for n < int(f.Length) {
+		if int(f.Length) < n+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		t := binary.BigEndian.Uint16(data[n:])
 		var p util.Message
 		switch t {
@@ -1433,9 +1506,13 @@ func (f *OFPTableFeatures) UnmarshalBinary(data []byte) error {
 		case OFPTFPT13_EXPERIMENTER:
 			fallthrough
 		case OFPTFPT13_EXPERIMENTER_MISS:
-			p = new(TableExperimenterProperty)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(TableExperimenterProperty)
+			}
+		default:
+			p = new(PropUnknown)
 		}
-		err := p.UnmarshalBinary(data[n:])
+		err := p.UnmarshalBinary(data[n:f.Length])
 		if err != nil {
 			return err
 		}
@@ -110,8 +110,23 @@ func NewNxActionHeader(subtype uint16) *NXActionHeader {
 	return &NXActionHeader{ActionHeader: actionHeader, Vendor: NxExperimenterID, Subtype: subtype}
 }
 
+// DecodeNxAction returns an empty action of the type of the Nicira action in
+// data, according to its subtype, to be decoded by its UnmarshalBinary. It
+// returns nil if data is too short, and an ActionUnknown if the subtype is not
+// known.
+//
+// Deprecated: use DecodeAction, which decodes the action and returns the
+// errors.
 func DecodeNxAction(data []byte) Action {
+	a, _ := decodeNxAction(data)
+	return a
+}
+
+func decodeNxAction(data []byte) (Action, error) {
 	var a Action
+	if len(data) < 10 {
+		return nil, errors.New("data too short to decode NxAction")
+	}
 	// Previous 8 bytes in the data includes type(2 byte), length(2 byte), and vendor(4 byte)
 	subtype := binary.BigEndian.Uint16(data[8:])
 	switch subtype {
@@ -177,7 +192,12 @@ func DecodeNxAction(data []byte) Action {
 	case NXAST_RAW_DECAP:
 	case NXAST_DEC_NSH_TTL:
 	}
-	return a
+
+	if a == nil {
+		a = new(ActionUnknown)
+	}
+
+	return a, nil
 }
 
 // NXActionConjunction is NX action to configure conjunctive match flows.
@@ -225,8 +245,11 @@ func (a *NXActionConjunction) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionConjunction message")
 	}
 	a.Clause = uint8(data[n])
@@ -290,8 +313,11 @@ func (a *NXActionConnTrack) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 24 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionConnTrack message")
 	}
 	a.Flags = binary.BigEndian.Uint16(data[n:])
@@ -307,8 +333,9 @@ func (a *NXActionConnTrack) UnmarshalBinary(data []byte) error {
 	a.Alg = binary.BigEndian.Uint16(data[n:])
 	n += 2
 
+	a.actions = nil
 	for n < int(a.Len()) {
-		act, err := DecodeAction(data[n:])
+		act, err := DecodeAction(data[n:a.Length])
 		if err != nil {
 			return errors.New("failed to decode actions")
 		}
@@ -410,7 +437,7 @@ func (a *NXActionRegLoad) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 24 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionRegLoad message")
 	}
 	a.OfsNbits = binary.BigEndian.Uint16(data[n:])
@@ -482,7 +509,7 @@ func (a *NXActionRegMove) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || a.Length < 24 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionRegMove message")
 	}
 	a.Nbits = binary.BigEndian.Uint16(data[n:])
@@ -544,9 +571,12 @@ func (a *NXActionResubmit) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
-		return errors.New("the []byte is too short to unmarshal a full NXActionConjunction message")
+	if len(data) < int(a.Len()) || a.Len() < 16 {
+		return errors.New("the []byte is too short to unmarshal a full NXActionResubmit message")
 	}
 	a.InPort = binary.BigEndian.Uint16(data[n:])
 
@@ -619,10 +649,14 @@ func (a *NXActionResubmitTable) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionResubmitTable message")
 	}
+	a.withCT = a.Subtype == NXAST_CT_RESUBMIT
 	a.InPort = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	a.TableID = data[n]
@@ -788,12 +822,39 @@ func (a *NXActionCTNAT) SetRangeProtoMax(protoMax *uint16) {
 	a.Length += 2
 }
 
+// natRangeLen returns the length of the NAT ranges present in rangePresent.
+func natRangeLen(rangePresent uint16) int {
+	n := 0
+	if rangePresent&NX_NAT_RANGE_IPV4_MIN != 0 {
+		n += 4
+	}
+	if rangePresent&NX_NAT_RANGE_IPV4_MAX != 0 {
+		n += 4
+	}
+	if rangePresent&NX_NAT_RANGE_IPV6_MIN != 0 {
+		n += 16
+	}
+	if rangePresent&NX_NAT_RANGE_IPV6_MAX != 0 {
+		n += 16
+	}
+	if rangePresent&NX_NAT_RANGE_PROTO_MIN != 0 {
+		n += 2
+	}
+	if rangePresent&NX_NAT_RANGE_PROTO_MAX != 0 {
+		n += 2
+	}
+	return n
+}
+
 func (a *NXActionCTNAT) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionCTNAT message")
 	}
 	// Skip padding bytes
@@ -802,6 +863,9 @@ func (a *NXActionCTNAT) UnmarshalBinary(data []byte) error {
 	n += 2
 	a.rangePresent = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	if int(a.Length) < n+natRangeLen(a.rangePresent) {
+		return errors.New("the []byte is too short to unmarshal the ranges of NXActionCTNAT")
+	}
 	if a.rangePresent&NX_NAT_RANGE_IPV4_MIN != 0 {
 		a.rangeIPv4Min = net.IPv4(data[n], data[n+1], data[n+2], data[n+3])
 		n += 4
@@ -875,7 +939,7 @@ func (a *NXActionOutputReg) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 24 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionOutputReg message")
 	}
 	a.OfsNbits = binary.BigEndian.Uint16(data[n:])
@@ -942,8 +1006,11 @@ func (a *NXActionDecTTL) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionDecTTL message")
 	}
 	a.controllers = binary.BigEndian.Uint16(data[n:])
@@ -995,14 +1062,21 @@ func (a *NXActionDecTTLCntIDs) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionDecTTLCntIDs message")
 	}
 	a.controllers = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	a.zeros = [4]uint8{}
 	n += 4
+	if int(a.Length) < n+2*int(a.controllers) {
+		return errors.New("the []byte is too short to unmarshal the controller IDs of NXActionDecTTLCntIDs")
+	}
+	a.cntIDs = nil
 	for i := 0; i < int(a.controllers); i++ {
 		id := binary.BigEndian.Uint16(data[n:])
 		a.cntIDs = append(a.cntIDs, id)
@@ -1189,6 +1263,9 @@ func (s *NXLearnSpec) UnmarshalBinary(data []byte) error {
 	if s.Header.src {
 		srcDataLength := 2 * ((s.Header.nBits + 15) / 16)
 		s.SrcValue = make([]byte, srcDataLength)
+		if len(data) < int(n+srcDataLength) {
+			return errors.New("the []byte is too short to unmarshal the value of NXLearnSpec")
+		}
 		copy(s.SrcValue, data[n:n+srcDataLength])
 		n += srcDataLength
 	} else {
@@ -1276,7 +1353,7 @@ func (a *NXActionLearn) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || a.Length < 32 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionLearn message")
 	}
 	n += int(a.NXActionHeader.Len())
@@ -1296,12 +1373,13 @@ func (a *NXActionLearn) UnmarshalBinary(data []byte) error {
 	n += 2
 	a.FinHardTimeout = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	a.LearnSpecs = nil
 	for n < int(a.Length) {
 		if int(a.Length)-n < 8 {
 			break
 		}
 		spec := new(NXLearnSpec)
-		err = spec.UnmarshalBinary(data[n:])
+		err = spec.UnmarshalBinary(data[n:a.Length])
 		if err != nil {
 			return err
 		}
@@ -1347,7 +1425,7 @@ func (a *NXActionNote) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || a.Length < NxActionHeaderLength {
 		return errors.New("the []byte is too short to unmarshal a full NXActionNote message")
 	}
 	n := a.NXActionHeader.Len()
@@ -1405,11 +1483,11 @@ func (a *NXActionRegLoad2) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || a.Length < NxActionHeaderLength {
 		return errors.New("the []byte is too short to unmarshal a full NXActionRegLoad2 message")
 	}
 	a.DstField = new(MatchField)
-	return a.DstField.UnmarshalBinary(data[n:])
+	return a.DstField.UnmarshalBinary(data[n:a.Length])
 }
 
 // NXActionController is NX action to output packet to the Controller set with a specified ID.
@@ -1452,7 +1530,7 @@ func (a *NXActionController) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController message")
 	}
 	n += int(a.NXActionHeader.Len())
@@ -1517,7 +1595,7 @@ func (a *NXActionController2PropMaxLen) UnmarshalBinary(data []byte) error {
 	if err := a.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController2PropMaxLen message")
 	}
 	n += int(a.PropHeader.Len())
@@ -1568,7 +1646,7 @@ func (a *NXActionController2PropControllerID) UnmarshalBinary(data []byte) error
 	if err := a.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController2PropControllerID message")
 	}
 	n += int(a.PropHeader.Len())
@@ -1619,7 +1697,7 @@ func (a *NXActionController2PropReason) UnmarshalBinary(data []byte) error {
 	if err := a.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController2PropReason message")
 	}
 	n += int(a.PropHeader.Len())
@@ -1768,7 +1846,7 @@ func (a *NXActionController2PropMeterId) UnmarshalBinary(data []byte) error {
 	if err := a.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController2PropMeterId message")
 	}
 	n += int(a.PropHeader.Len())
@@ -1788,6 +1866,9 @@ func NewMeterId(meterId uint32) *NXActionController2PropMeterId {
 
 // Decode Controller2 Property types.
 func DecodeController2Prop(data []byte) (Property, error) {
+	if len(data) < 4 {
+		return nil, errors.New("the []byte is too short to decode a Controller2 property")
+	}
 	t := binary.BigEndian.Uint16(data[:2])
 	var p Property
 	switch t {
@@ -1803,6 +1884,8 @@ func DecodeController2Prop(data []byte) (Property, error) {
 		p = new(NXActionController2PropPause)
 	case NXAC2PT_METER_ID:
 		p = new(NXActionController2PropMeterId)
+	default:
+		p = new(PropUnknown)
 	}
 	err := p.UnmarshalBinary(data)
 	if err != nil {
@@ -1860,14 +1943,15 @@ func (a *NXActionController2) UnmarshalBinary(data []byte) error {
 	if err := a.NXActionHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || a.Length < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController2 message")
 	}
 	n += int(a.NXActionHeader.Len())
 	n += 6
 
+	a.props = nil
 	for n < int(a.Length) {
-		prop, err := DecodeController2Prop(data[n:])
+		prop, err := DecodeController2Prop(data[n:a.Length])
 		if err != nil {
 			return errors.New("failed to decode property")
 		}
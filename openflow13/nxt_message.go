@@ -66,6 +66,9 @@ func (p *PacketInFormat) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PacketInFormat) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PacketInFormat message")
+	}
 	n := 0
 	p.Spif = binary.BigEndian.Uint32(data[n:])
 	return nil
@@ -189,6 +192,7 @@ func (t *TLVTableMod) UnmarshalBinary(data []byte) error {
 	n += 2
 	n += 6
 
+	t.TlvMaps = nil
 	for n < len(data) {
 		tlvMap := new(TLVTableMap)
 		err := tlvMap.UnmarshalBinary(data[n:])
@@ -249,6 +253,9 @@ func (t *TLVTableReply) MarshalBinary() (data []byte, err error) {
 }
 
 func (t *TLVTableReply) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full TLVTableReply message")
+	}
 	n := 0
 	t.MaxSpace = binary.BigEndian.Uint32(data[n:])
 	n += 4
@@ -257,6 +264,7 @@ func (t *TLVTableReply) UnmarshalBinary(data []byte) error {
 	t.reserved = [10]byte{}
 	copy(t.reserved[0:], data[n:n+10])
 	n += 10
+	t.TlvMaps = nil
 	for n < len(data) {
 		tlvMap := new(TLVTableMap)
 		err := tlvMap.UnmarshalBinary(data[n:])
@@ -322,7 +330,7 @@ func (p *ContinuationPropBridge) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropBridge message")
 	}
 	n += int(p.PropHeader.Len())
@@ -374,10 +382,7 @@ func (p *ContinuationPropStack) UnmarshalBinary(data []byte) error {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropStack message")
 	}
 	n += int(p.PropHeader.Len())
-	for _, eachStack := range p.Stack {
-		data[n] = eachStack
-		n++
-	}
+	p.Stack = append([]uint8(nil), data[n:p.Length]...)
 	return nil
 }
 
@@ -414,7 +419,7 @@ func (p *ContinuationPropMirrors) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropMirrors message")
 	}
 	n += int(p.PropHeader.Len())
@@ -453,7 +458,7 @@ func (p *ContinuationPropConntracked) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropConntracked message")
 	}
 	n += int(p.PropHeader.Len())
@@ -493,7 +498,7 @@ func (p *ContinuationPropTableID) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropTableID message")
 	}
 	n += int(p.PropHeader.Len())
@@ -535,7 +540,7 @@ func (p *ContinuationPropCookie) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropCookie message")
 	}
 	n += int(p.PropHeader.Len())
@@ -595,8 +600,9 @@ func (p *ContinuationPropActions) UnmarshalBinary(data []byte) error {
 	n += int(p.PropHeader.Len())
 	n += 4
 
+	p.Actions = nil
 	for n < int(p.Length) {
-		act, err := DecodeAction(data[n:])
+		act, err := DecodeAction(data[n:p.Length])
 		if err != nil {
 			return errors.New("failed to decode actions")
 		}
@@ -657,8 +663,9 @@ func (p *ContinuationPropActionSet) UnmarshalBinary(data []byte) error {
 	n += int(p.PropHeader.Len())
 	n += 4
 
+	p.ActionSet = nil
 	for n < int(p.Length) {
-		act, err := DecodeAction(data[n:])
+		act, err := DecodeAction(data[n:p.Length])
 		if err != nil {
 			return errors.New("failed to decode actions")
 		}
@@ -701,7 +708,7 @@ func (p *ContinuationPropOdpPort) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropOdpPort message")
 	}
 	n += int(p.PropHeader.Len())
@@ -712,6 +719,9 @@ func (p *ContinuationPropOdpPort) UnmarshalBinary(data []byte) error {
 
 // Decode Continuation Property types.
 func DecodeContinuationProp(data []byte) (Property, error) {
+	if len(data) < 4 {
+		return nil, errors.New("the []byte is too short to decode a Continuation property")
+	}
 	t := binary.BigEndian.Uint16(data[:2])
 	var p Property
 	switch t {
@@ -733,6 +743,8 @@ func DecodeContinuationProp(data []byte) (Property, error) {
 		p = new(ContinuationPropActionSet)
 	case NXCPT_ODP_PORT:
 		p = new(ContinuationPropOdpPort)
+	default:
+		p = new(PropUnknown)
 	}
 	err := p.UnmarshalBinary(data)
 	if err != nil {
@@ -843,7 +855,7 @@ func (p *PacketIn2PropFullLen) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropFullLen message")
 	}
 	n += int(p.PropHeader.Len())
@@ -885,7 +897,7 @@ func (p *PacketIn2PropBufferID) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropBufferID message")
 	}
 	n += int(p.PropHeader.Len())
@@ -927,7 +939,7 @@ func (p *PacketIn2PropTableID) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropTableID message")
 	}
 	n += int(p.PropHeader.Len())
@@ -970,7 +982,7 @@ func (p *PacketIn2PropCookie) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropCookie message")
 	}
 	n += int(p.PropHeader.Len())
@@ -1013,7 +1025,7 @@ func (p *PacketIn2PropReason) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropReason message")
 	}
 	n += int(p.PropHeader.Len())
@@ -1075,9 +1087,10 @@ func (p *PacketIn2PropMetadata) UnmarshalBinary(data []byte) error {
 	}
 	n += int(p.PropHeader.Len())
 
+	p.Fields = nil
 	for n < int(p.Length) {
 		field := new(MatchField)
-		if err := field.UnmarshalBinary(data[n:]); err != nil {
+		if err := field.UnmarshalBinary(data[n:p.Length]); err != nil {
 			return err
 		}
 		p.Fields = append(p.Fields, *field)
@@ -1176,6 +1189,9 @@ func (p *PacketIn2PropContinuation) UnmarshalBinary(data []byte) error {
 
 // Decode PacketIn2 Property types.
 func DecodePacketIn2Prop(data []byte) (Property, error) {
+	if len(data) < 4 {
+		return nil, errors.New("the []byte is too short to decode a PacketIn2 property")
+	}
 	t := binary.BigEndian.Uint16(data[:2])
 	var p Property
 	switch t {
@@ -1197,6 +1213,8 @@ func DecodePacketIn2Prop(data []byte) (Property, error) {
 		p = new(PacketIn2PropUserdata)
 	case NXPINT_CONTINUATION:
 		p = new(PacketIn2PropContinuation)
+	default:
+		p = new(PropUnknown)
 	}
 	err := p.UnmarshalBinary(data)
 	if err != nil {
@@ -1236,10 +1254,11 @@ func (p *PacketIn2) MarshalBinary() (data []byte, err error) {
 func (p *PacketIn2) UnmarshalBinary(data []byte) error {
 	n := 0
 
+	p.Props = nil
 	for n < len(data) {
 		prop, err := DecodePacketIn2Prop(data[n:])
 		if err != nil {
-			break
+			return err
 		}
 		p.Props = append(p.Props, prop)
 		n += int(prop.Len())
@@ -1287,10 +1306,11 @@ func (p *Resume) MarshalBinary() (data []byte, err error) {
 func (p *Resume) UnmarshalBinary(data []byte) error {
 	n := 0
 
+	p.Props = nil
 	for n < len(data) {
 		prop, err := DecodePacketIn2Prop(data[n:])
 		if err != nil {
-			break
+			return err
 		}
 		p.Props = append(p.Props, prop)
 		n += int(prop.Len())
@@ -1306,22 +1326,27 @@ func NewResume(props []Property) *VendorHeader {
 	return msg
 }
 
-func decodeVendorData(experimenterType uint32, data []byte) (msg util.Message, err error) {
-	switch experimenterType {
-	case Type_SetPacketInFormat:
-		msg = new(PacketInFormat)
-	case Type_SetControllerId:
-		msg = new(ControllerID)
-	case Type_TlvTableMod:
-		msg = new(TLVTableMod)
-	case Type_TlvTableReply:
-		msg = new(TLVTableReply)
-	case Type_BundleCtrl:
-		msg = new(BundleControl)
-	case Type_BundleAdd:
-		msg = new(BundleAdd)
-	case Type_PacketIn2:
-		msg = new(PacketIn2)
+func decodeVendorData(experimenter, experimenterType uint32, data []byte) (msg util.Message, err error) {
+	if msg = newExperimenterMessage(experimenter, experimenterType); msg == nil {
+		switch experimenterType {
+		case Type_SetPacketInFormat:
+			msg = new(PacketInFormat)
+		case Type_SetControllerId:
+			msg = new(ControllerID)
+		case Type_TlvTableMod:
+			msg = new(TLVTableMod)
+		case Type_TlvTableReply:
+			msg = new(TLVTableReply)
+		case Type_BundleCtrl:
+			msg = new(BundleControl)
+		case Type_BundleAdd:
+			msg = new(BundleAdd)
+		case Type_PacketIn2:
+			msg = new(PacketIn2)
+		default:
+			// Keep the data of unknown messages as is.
+			msg = new(util.BufferUnknown)
+		}
 	}
 	err = msg.UnmarshalBinary(data)
 	if err != nil {
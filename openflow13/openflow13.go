@@ -11,6 +11,7 @@ package openflow13
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"net"
 
 	"antrea.io/libOpenflow/common"
@@ -99,6 +100,10 @@ const (
 )
 
 func Parse(b []byte) (message util.Message, err error) {
+	b, err = common.MessageBytes(b)
+	if err != nil {
+		return nil, err
+	}
 	switch b[1] {
 	case Type_Hello:
 		message = new(common.Hello)
@@ -264,6 +269,9 @@ func (p *PacketOut) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PacketOut) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full PacketOut message.")
+	}
 	if err := p.Header.UnmarshalBinary(data); err != nil {
 		return err
 	}
@@ -278,8 +286,13 @@ func (p *PacketOut) UnmarshalBinary(data []byte) error {
 
 	n += 6 // for pad
 
-	for n < (n + p.ActionsLen) {
-		a, err := DecodeAction(data[n:])
+	end := n + p.ActionsLen
+	if int(end) > len(data) {
+		return fmt.Errorf("The PacketOut actions length %d exceeds the message.", p.ActionsLen)
+	}
+	p.Actions = nil
+	for n < end {
+		a, err := DecodeAction(data[n:end])
 		if err != nil {
 			return err
 		}
@@ -287,6 +300,9 @@ func (p *PacketOut) UnmarshalBinary(data []byte) error {
 		n += a.Len()
 	}
 
+	if p.Data == nil {
+		p.Data = new(util.Buffer)
+	}
 	return p.Data.UnmarshalBinary(data[n:])
 }
 
@@ -325,6 +341,7 @@ func (p *PacketIn) Len() (n uint16) {
 }
 
 func (p *PacketIn) MarshalBinary() (data []byte, err error) {
+	p.Header.Length = p.Len()
 	if data, err = p.Header.MarshalBinary(); err != nil {
 		return
 	}
@@ -339,7 +356,7 @@ func (p *PacketIn) MarshalBinary() (data []byte, err error) {
 	n += 1
 	b[n] = p.TableId
 	n += 1
-	binary.BigEndian.PutUint64(b, p.Cookie)
+	binary.BigEndian.PutUint64(b[n:], p.Cookie)
 	n += 8
 	data = append(data, b...)
 
@@ -360,6 +377,9 @@ func (p *PacketIn) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PacketIn) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full PacketIn message.")
+	}
 	if err := p.Header.UnmarshalBinary(data); err != nil {
 		return err
 	}
@@ -380,6 +400,9 @@ func (p *PacketIn) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += p.Match.Len()
+	if len(data) < int(n)+2 {
+		return errors.New("The []byte is too short to unmarshal a full PacketIn message.")
+	}
 
 	copy(p.pad, data[n:])
 	n += 2
@@ -452,6 +475,9 @@ func (c *SwitchConfig) MarshalBinary() (data []byte, err error) {
 }
 
 func (c *SwitchConfig) UnmarshalBinary(data []byte) error {
+	if len(data) < 12 {
+		return errors.New("The []byte is too short to unmarshal a full SwitchConfig message.")
+	}
 	var err error
 	next := 0
 
@@ -492,6 +518,7 @@ func (e *ErrorMsg) MarshalBinary() (data []byte, err error) {
 	var bytes []byte
 	next := 0
 
+	e.Header.Length = e.Len()
 	if bytes, err = e.Header.MarshalBinary(); err != nil {
 		return
 	}
@@ -511,13 +538,20 @@ func (e *ErrorMsg) MarshalBinary() (data []byte, err error) {
 
 func (e *ErrorMsg) UnmarshalBinary(data []byte) error {
 	next := 0
-	e.Header.UnmarshalBinary(data[next:])
+	if err := e.Header.UnmarshalBinary(data[next:]); err != nil {
+		return err
+	}
 	next += int(e.Header.Len())
+	if len(data) < next+4 {
+		return errors.New("data too short to unmarshal ErrorMsg")
+	}
 	e.Type = binary.BigEndian.Uint16(data[next:])
 	next += 2
 	e.Code = binary.BigEndian.Uint16(data[next:])
 	next += 2
-	e.Data.UnmarshalBinary(data[next:])
+	if err := e.Data.UnmarshalBinary(data[next:]); err != nil {
+		return err
+	}
 	next += int(e.Data.Len())
 	return nil
 }
@@ -747,10 +781,16 @@ func (s *SwitchFeatures) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *SwitchFeatures) UnmarshalBinary(data []byte) error {
+	if len(data) < 32 {
+		return errors.New("The []byte is too short to unmarshal a full SwitchFeatures message.")
+	}
 	var err error
 	next := 0
 
 	err = s.Header.UnmarshalBinary(data[next:])
+	if err != nil {
+		return err
+	}
 	next = int(s.Header.Len())
 	copy(s.DPID, data[next:])
 	next += len(s.DPID)
@@ -770,6 +810,9 @@ func (s *SwitchFeatures) UnmarshalBinary(data []byte) error {
 	for next < len(data) {
 		p := NewPhyPort()
 		err = p.UnmarshalBinary(data[next:])
+		if err != nil {
+			return err
+		}
 		next += int(p.Len())
 	}
 	return err
@@ -830,7 +873,13 @@ func (v *VendorHeader) UnmarshalBinary(data []byte) error {
 		return errors.New("The []byte the wrong size to unmarshal an " +
 			"VendorHeader message.")
 	}
-	v.Header.UnmarshalBinary(data)
+	if err := v.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if int(v.Header.Length) > len(data) {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"VendorHeader message.")
+	}
 	n := int(v.Header.Len())
 	v.Vendor = binary.BigEndian.Uint32(data[n:])
 	n += 4
@@ -838,7 +887,7 @@ func (v *VendorHeader) UnmarshalBinary(data []byte) error {
 	n += 4
 	if n < int(v.Header.Length) {
 		var err error
-		v.VendorData, err = decodeVendorData(v.ExperimenterType, data[n:v.Header.Length])
+		v.VendorData, err = decodeVendorData(v.Vendor, v.ExperimenterType, data[n:v.Header.Length])
 		if err != nil {
 			return err
 		}
@@ -887,5 +936,47 @@ func (p *PropHeader) UnmarshalBinary(data []byte) error {
 	}
 	p.Type = binary.BigEndian.Uint16(data[:2])
 	p.Length = binary.BigEndian.Uint16(data[2:4])
+	if p.Length < 4 || int(p.Length) > len(data) {
+		return fmt.Errorf("the property length %d is invalid for %d bytes of data", p.Length, len(data))
+	}
+	return nil
+}
+
+// PropUnknown is a property of a type which is not known. It keeps the bytes
+// following the property header, so that it is encoded again unchanged.
+type PropUnknown struct {
+	PropHeader
+	Data []byte
+}
+
+func (p *PropUnknown) Len() uint16 {
+	n := p.PropHeader.Len() + uint16(len(p.Data))
+	// Round it to closest multiple of 8
+	return ((n + 7) / 8) * 8
+}
+
+func (p *PropUnknown) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	p.Length = p.PropHeader.Len() + uint16(len(p.Data))
+	b, err := p.PropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	copy(data[n:], p.Data)
+	return
+}
+
+func (p *PropUnknown) UnmarshalBinary(data []byte) error {
+	if err := p.PropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	p.Data = make([]byte, int(p.Length)-int(p.PropHeader.Len()))
+	copy(p.Data, data[p.PropHeader.Len():p.Length])
 	return nil
 }
+
+// UnknownError implements util.Unknown.
+func (p *PropUnknown) UnknownError() error {
+	return fmt.Errorf("unknown property of type %d", p.Type)
+}
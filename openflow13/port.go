@@ -2,6 +2,7 @@ package openflow13
 
 import (
 	"encoding/binary"
+	"errors"
 	"net"
 
 	"antrea.io/libOpenflow/common"
@@ -75,14 +76,19 @@ func (p *PhyPort) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PhyPort) UnmarshalBinary(data []byte) error {
+	if len(data) < 64 {
+		return errors.New("The []byte is too short to unmarshal a full PhyPort message.")
+	}
 	p.PortNo = binary.BigEndian.Uint32(data)
 	n := 4
 	copy(p.pad, data[n:n+4])
 	n += 4
+	p.HWAddr = make([]byte, ETH_ALEN)
 	copy(p.HWAddr, data[n:n+6])
 	n += 6
 	copy(p.pad2, data[n:n+2])
 	n += 2
+	p.Name = make([]byte, 16)
 	copy(p.Name, data[n:n+16])
 	n += 16
 
@@ -161,6 +167,9 @@ func (p *PortMod) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PortMod) UnmarshalBinary(data []byte) error {
+	if len(data) < 40 {
+		return errors.New("The []byte is too short to unmarshal a full PortMod message.")
+	}
 	err := p.Header.UnmarshalBinary(data)
 	n := int(p.Header.Len())
 
@@ -168,6 +177,7 @@ func (p *PortMod) UnmarshalBinary(data []byte) error {
 	n += 4
 	copy(p.pad, data[n:n+4])
 	n += 4
+	p.HWAddr = make([]byte, ETH_ALEN)
 	copy(p.HWAddr, data[n:])
 	n += len(p.HWAddr)
 	copy(p.pad2, data[n:n+2])
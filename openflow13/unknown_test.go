@@ -0,0 +1,145 @@
+package openflow13
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// unknownMessages returns messages holding match fields, actions,
+// instructions, properties and experimenter data which are not known.
+func unknownMessages() map[string]util.Message {
+	flowWithAction := func(act Action) *FlowMod {
+		flow := NewFlowMod()
+		apply := NewInstrApplyActions()
+		apply.AddAction(NewActionOutput(1), false)
+		apply.AddAction(act, false)
+		flow.AddInstruction(apply)
+		return flow
+	}
+	flowWithField := func(field MatchField) *FlowMod {
+		flow := NewFlowMod()
+		flow.Match.AddField(*NewInPortField(1))
+		flow.Match.AddField(field)
+		return flow
+	}
+
+	unknownInstr := NewFlowMod()
+	unknownInstr.AddInstruction(&InstrUnknown{InstrHeader: InstrHeader{Type: 0x7fff}, Data: []byte{1, 2, 3, 4}})
+
+	vendor := NewNXTVendorHeader(0x7fff)
+	vendor.VendorData = util.NewBuffer([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+
+	mpRequest := &MultipartRequest{
+		Header: NewOfp13Header(),
+		Type:   MultipartType_Experimenter,
+		Body:   []util.Message{util.NewBuffer([]byte{0, 0, 0x23, 0x20, 0, 0, 0, 1})},
+	}
+	mpRequest.Header.Type = Type_MultiPartRequest
+
+	features := newTableFeatures()
+	features.Properties = append(features.Properties, &PropUnknown{PropHeader: PropHeader{Type: 0x7ff0}, Data: []byte{1, 2, 3, 4}})
+	features.Length = features.Len()
+	mpFeatures := &MultipartRequest{
+		Header: NewOfp13Header(),
+		Type:   MultipartType_TableFeatures,
+		Body:   []util.Message{features},
+	}
+	mpFeatures.Header.Type = Type_MultiPartRequest
+
+	return map[string]util.Message{
+		"action": flowWithAction(&ActionUnknown{ActionHeader: ActionHeader{Type: 0x7fff}, Data: []byte{1, 2, 3, 4}}),
+		"nx action": flowWithAction(&ActionUnknown{
+			ActionHeader: ActionHeader{Type: ActionType_Experimenter},
+			Data:         []byte{0, 0, 0x23, 0x20, 0x7f, 0xff, 1, 2, 3, 4, 5, 6},
+		}),
+		"instruction": unknownInstr,
+		"oxm field": flowWithField(MatchField{
+			Class:  OXM_CLASS_OPENFLOW_BASIC,
+			Field:  0x7f,
+			Length: 2,
+			Value:  &ByteArrayField{Data: []byte{1, 2}, Length: 2},
+		}),
+		"masked nxm field": flowWithField(MatchField{
+			Class:   OXM_CLASS_NXM_1,
+			Field:   0x7f,
+			HasMask: true,
+			Length:  4,
+			Value:   &ByteArrayField{Data: []byte{1, 2}, Length: 2},
+			Mask:    &ByteArrayField{Data: []byte{0xff, 0}, Length: 2},
+		}),
+		"experimenter field": flowWithField(MatchField{
+			Class:          OXM_CLASS_EXPERIMENTER,
+			Field:          1,
+			Length:         8,
+			ExperimenterID: NxExperimenterID,
+			Value:          &ByteArrayField{Data: []byte{1, 2, 3, 4}, Length: 4},
+		}),
+		"packetin2 property": NewPacketIn2([]Property{
+			&PacketIn2PropTableID{PropHeader: &PropHeader{Type: NXPINT_TABLE_ID}, TableID: 3},
+			&PropUnknown{PropHeader: PropHeader{Type: 0x7ff0}, Data: []byte{1, 2, 3}},
+		}),
+		"vendor message":         vendor,
+		"experimenter request":   mpRequest,
+		"table feature property": mpFeatures,
+	}
+}
+
+func TestUnknownRoundTrip(t *testing.T) {
+	for name, msg := range unknownMessages() {
+		t.Run(name, func(t *testing.T) {
+			data, err := msg.MarshalBinary()
+			require.NoError(t, err)
+			parsed, err := Parse(data)
+			require.NoError(t, err)
+			b, err := parsed.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, data, b)
+		})
+	}
+}
+
+// msgParser parses messages for util.StrictParser.
+type msgParser struct{}
+
+func (msgParser) Parse(b []byte) (util.Message, error) {
+	return Parse(b)
+}
+
+func TestUnknownStrict(t *testing.T) {
+	strict := util.StrictParser(msgParser{})
+	for name, msg := range unknownMessages() {
+		t.Run(name, func(t *testing.T) {
+			data, err := msg.MarshalBinary()
+			require.NoError(t, err)
+			_, err = strict.Parse(data)
+			assert.Error(t, err)
+		})
+	}
+
+	flow := NewFlowMod()
+	flow.Match.AddField(*NewInPortField(1))
+	apply := NewInstrApplyActions()
+	apply.AddAction(NewActionOutput(2), false)
+	flow.AddInstruction(apply)
+	data, err := flow.MarshalBinary()
+	require.NoError(t, err)
+	_, err = strict.Parse(data)
+	assert.NoError(t, err)
+}
+
+func TestPropUnknownZero(t *testing.T) {
+	data, err := new(PropUnknown).MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 4, 0, 0, 0, 0}, data)
+
+	var decoded PropUnknown
+	require.NoError(t, json.Unmarshal([]byte(`{"Type": 1, "Data": "AQID"}`), &decoded))
+	data, err = decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 1, 0, 7, 1, 2, 3, 0}, data)
+}
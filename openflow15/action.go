@@ -71,7 +71,15 @@ func (a *ActionHeader) UnmarshalBinary(data []byte) error {
 
 // Decode Action types.
 func DecodeAction(data []byte) (Action, error) {
+	if len(data) < 4 {
+		return nil, errors.New("the []byte is too short to decode an Action")
+	}
 	t := binary.BigEndian.Uint16(data[:2])
+	length := binary.BigEndian.Uint16(data[2:4])
+	if length < 4 || int(length) > len(data) {
+		return nil, fmt.Errorf("the Action length %d is invalid for %d bytes of data", length, len(data))
+	}
+	data = data[:length]
 	var a Action
 	var err error
 	switch t {
@@ -118,7 +126,7 @@ func DecodeAction(data []byte) (Action, error) {
 			return nil, errors.New("the []byte is too short to decode OpenFlow experimenter message")
 		}
 		v := binary.BigEndian.Uint32(data[4:8])
-		if v == NxExperimenterID {
+		if a = newExperimenterAction(data); a == nil && v == NxExperimenterID {
 			a, err = DecodeNxAction(data)
 			if err != nil {
 				//klog.ErrorS(err, "Failed to decode NxAction", "data", data)
@@ -129,12 +137,10 @@ func DecodeAction(data []byte) (Action, error) {
 				return nil, err
 			}
 		}
-	default:
-		return nil, fmt.Errorf("DecodeAction unknown type: %v", t)
 	}
 
 	if a == nil {
-		return nil, fmt.Errorf("DecodeAction unknown type: %v", t)
+		a = new(ActionUnknown)
 	}
 
 	err = a.UnmarshalBinary(data)
@@ -142,9 +148,57 @@ func DecodeAction(data []byte) (Action, error) {
 		klog.ErrorS(err, "Failed to unmarshal", "structure", a, "data", data)
 		return a, err
 	}
+	if a.Len() != length {
+		// The action would be encoded with another length, keep its bytes.
+		a = new(ActionUnknown)
+		if err = a.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+	}
 	return a, nil
 }
 
+// ActionUnknown is an action of a type, or an experimenter action of a
+// subtype, which is not known. It keeps the bytes following the action header,
+// so that it is encoded again unchanged.
+type ActionUnknown struct {
+	ActionHeader
+	Data []byte
+}
+
+func (a *ActionUnknown) Len() (n uint16) {
+	return a.ActionHeader.Len() + uint16(len(a.Data))
+}
+
+func (a *ActionUnknown) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(a.Len()))
+	a.Length = a.Len()
+	b, err := a.ActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	copy(data[n:], a.Data)
+	return
+}
+
+func (a *ActionUnknown) UnmarshalBinary(data []byte) error {
+	if err := a.ActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if a.Length < a.ActionHeader.Len() || int(a.Length) > len(data) {
+		return fmt.Errorf("the Action length %d is invalid for %d bytes of data", a.Length, len(data))
+	}
+	a.Data = make([]byte, int(a.Length)-int(a.ActionHeader.Len()))
+	copy(a.Data, data[a.ActionHeader.Len():a.Length])
+	return nil
+}
+
+// UnknownError implements util.Unknown.
+func (a *ActionUnknown) UnknownError() error {
+	return fmt.Errorf("unknown action of type %d and length %d", a.Type, a.Length)
+}
+
 // Action structure for OFPAT_OUTPUT, which sends packets out ’port’.
 // When the ’port’ is the OFPP_CONTROLLER, ’max_len’ indicates the max
 // number of bytes to send. A ’max_len’ of zero means no bytes of the
@@ -320,16 +374,22 @@ func (a *ActionMplsTtl) Len() uint16 {
 }
 
 func (a *ActionMplsTtl) MarshalBinary() (data []byte, err error) {
-	data, err = a.ActionHeader.MarshalBinary()
+	data = make([]byte, int(a.Len()))
+	b, err := a.ActionHeader.MarshalBinary()
 	if err != nil {
 		return
 	}
+	copy(data, b)
 	n := int(a.ActionHeader.Len())
 	data[n] = a.MplsTtl
 	return
 }
 
 func (a *ActionMplsTtl) UnmarshalBinary(data []byte) error {
+	if len(data) < int(a.Len()) {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"ActionMplsTtl message.")
+	}
 	n := 0
 	err := a.ActionHeader.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -370,6 +430,10 @@ func (a *ActionDecNwTtl) MarshalBinary() (data []byte, err error) {
 }
 
 func (a *ActionDecNwTtl) UnmarshalBinary(data []byte) error {
+	if len(data) < int(a.Len()) {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"ActionDecNwTtl message.")
+	}
 	return a.ActionHeader.UnmarshalBinary(data[:4])
 }
 
@@ -383,16 +447,22 @@ func (a *ActionNwTtl) Len() uint16 {
 }
 
 func (a *ActionNwTtl) MarshalBinary() (data []byte, err error) {
-	data, err = a.ActionHeader.MarshalBinary()
+	data = make([]byte, int(a.Len()))
+	b, err := a.ActionHeader.MarshalBinary()
 	if err != nil {
 		return
 	}
+	copy(data, b)
 	n := int(a.ActionHeader.Len())
 	data[n] = a.NwTtl
 	return
 }
 
 func (a *ActionNwTtl) UnmarshalBinary(data []byte) error {
+	if len(data) < int(a.Len()) {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"ActionNwTtl message.")
+	}
 	n := 0
 	err := a.ActionHeader.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -442,6 +512,10 @@ func (a *ActionPush) MarshalBinary() (data []byte, err error) {
 }
 
 func (a *ActionPush) UnmarshalBinary(data []byte) error {
+	if len(data) < int(a.Len()) {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"ActionPush message.")
+	}
 	a.ActionHeader.UnmarshalBinary(data[:4])
 	a.EtherType = binary.BigEndian.Uint16(data[4:])
 	return nil
@@ -477,6 +551,10 @@ func (a *ActionPopVlan) MarshalBinary() (data []byte, err error) {
 }
 
 func (a *ActionPopVlan) UnmarshalBinary(data []byte) error {
+	if len(data) < int(a.Len()) {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"ActionPopVlan message.")
+	}
 	a.ActionHeader.UnmarshalBinary(data[:4])
 	return nil
 }
@@ -514,6 +592,10 @@ func (a *ActionPopMpls) MarshalBinary() (data []byte, err error) {
 }
 
 func (a *ActionPopMpls) UnmarshalBinary(data []byte) error {
+	if len(data) < int(a.Len()) {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"ActionPopMpls message.")
+	}
 	a.ActionHeader.UnmarshalBinary(data[:4])
 	a.EtherType = binary.BigEndian.Uint16(data[4:])
 	return nil
@@ -649,6 +731,10 @@ func (a *ActionCopyField) MarshalBinary() (data []byte, err error) {
 }
 
 func (a *ActionCopyField) UnmarshalBinary(data []byte) error {
+	if len(data) < 12 {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"ActionCopyField message.")
+	}
 	var n uint16
 	err := a.ActionHeader.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -666,12 +752,18 @@ func (a *ActionCopyField) UnmarshalBinary(data []byte) error {
 	n += 2
 	n += 2 // Pad
 
+	a.OxmIdSrc = OxmId{}
 	err = a.OxmIdSrc.UnmarshalBinary(data[n:])
 	if err != nil {
 		return err
 	}
 	n += a.OxmIdSrc.Len()
+	if int(n) > len(data) {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"ActionCopyField message.")
+	}
 
+	a.OxmIdDst = OxmId{}
 	err = a.OxmIdDst.UnmarshalBinary(data[n:])
 	if err != nil {
 		return err
@@ -716,6 +808,10 @@ func (a *ActionMeter) MarshalBinary() (data []byte, err error) {
 	return
 }
 func (a *ActionMeter) UnmarshalBinary(data []byte) error {
+	if len(data) < int(a.Len()) {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"ActionMeter message.")
+	}
 	n := 0
 	err := a.ActionHeader.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -59,10 +59,13 @@ type NXActionEncap struct {
 	*NXActionHeader
 	HeaderSize uint16
 	PacketType uint32
+	// Properties are the encoded properties of the encap action, such as the
+	// MD type of NSH, each padded to a multiple of 8 bytes.
+	Properties []byte
 }
 
 func (a *NXActionEncap) Len() (n uint16) {
-	return a.Length
+	return 16 + uint16(len(a.Properties))
 }
 
 func (a *NXActionEncap) MarshalBinary() (data []byte, err error) {
@@ -70,6 +73,7 @@ func (a *NXActionEncap) MarshalBinary() (data []byte, err error) {
 	var b []byte
 	n := 0
 
+	a.Length = a.Len()
 	b, err = a.NXActionHeader.MarshalBinary()
 	copy(data[n:], b)
 	n += len(b)
@@ -80,6 +84,7 @@ func (a *NXActionEncap) MarshalBinary() (data []byte, err error) {
 	binary.BigEndian.PutUint32(data[n:], a.PacketType)
 	n += 4
 
+	copy(data[n:], a.Properties)
 	return
 }
 
@@ -90,7 +95,7 @@ func (a *NXActionEncap) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Length) || a.Length < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionEncap message")
 	}
 
@@ -100,6 +105,10 @@ func (a *NXActionEncap) UnmarshalBinary(data []byte) error {
 	a.PacketType = binary.BigEndian.Uint32(data[n:])
 	n += 4
 
+	a.Properties = nil
+	if int(a.Length) > n {
+		a.Properties = append([]byte(nil), data[n:a.Length]...)
+	}
 	return nil
 }
 
@@ -179,7 +188,7 @@ func (a *NXActionStack) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 24 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionStack message")
 	}
 	a.OfsNbits = binary.BigEndian.Uint16(data[n:])
@@ -122,7 +122,7 @@ func (p *BundlePropertyExperimenter) Len() uint16 {
 }
 
 func (p *BundlePropertyExperimenter) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, 0)
+	data = make([]byte, 12)
 	n := 0
 	binary.BigEndian.PutUint16(data[n:], p.Type)
 	n += 2
@@ -151,8 +151,12 @@ func (p *BundlePropertyExperimenter) UnmarshalBinary(data []byte) error {
 	n += 4
 	p.ExperimenterType = binary.BigEndian.Uint32(data[n:])
 	n += 4
-	if len(data) < int(p.Length) {
-		p.data = data[n:]
+	if p.Length < 12 || int(p.Length) > len(data) {
+		return fmt.Errorf("The BundlePropertyExperimenter length %d is invalid for %d bytes of data.", p.Length, len(data))
+	}
+	p.data = nil
+	if int(p.Length) > n {
+		p.data = append([]byte(nil), data[n:p.Length]...)
 	}
 	return nil
 }
@@ -216,6 +220,9 @@ func (b *BundleAdd) MarshalBinary() (data []byte, err error) {
 }
 
 func (b *BundleAdd) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full BundleAdd message")
+	}
 	var err error
 	n := 0
 	b.BundleID = binary.BigEndian.Uint32(data[n:])
@@ -229,6 +236,7 @@ func (b *BundleAdd) UnmarshalBinary(data []byte) error {
 		return fmt.Errorf("failed to parse BundleAdd's Message: %v", err)
 	}
 	n += int(b.Message.Len())
+	b.Properties = nil
 	if n < len(data) {
 		b.Properties = make([]BundlePropertyExperimenter, 0)
 		for n < len(data) {
@@ -291,6 +299,9 @@ func (e *VendorError) MarshalBinary() (data []byte, err error) {
 }
 
 func (e *VendorError) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full VendorError message.")
+	}
 	n := 0
 	e.ErrorMsg = new(ErrorMsg)
 	err := e.Header.UnmarshalBinary(data[n:])
@@ -0,0 +1,105 @@
+package openflow15
+
+// This file registers the decoders of the experimenter extensions
+
+import (
+	"antrea.io/libOpenflow/util"
+)
+
+// experimenters holds the decoders registered for the experimenter extensions
+// of OpenFlow 1.5. The types of their values are registered with jsonCodec, so
+// that the messages holding them are encoded to JSON.
+var experimenters = util.NewExperimenterRegistry(jsonCodec)
+
+// RegisterExperimenterMessage registers newMessage to decode the data of the
+// experimenter messages of the experimenter and of the type expType, which
+// follows the experimenter header. A nil newMessage removes the registration.
+func RegisterExperimenterMessage(experimenter, expType uint32, newMessage func() util.Message) {
+	experimenters.RegisterMessage(experimenter, expType, newMessage)
+}
+
+// RegisterExperimenterAction registers newAction to decode the experimenter
+// actions of the experimenter and of the subtype, which is the 16 bits
+// following the experimenter ID as in the Nicira and ONF extensions. A nil
+// newAction removes the registration.
+func RegisterExperimenterAction(experimenter uint32, subtype uint16, newAction func() Action) {
+	var newMessage func() util.Message
+	if newAction != nil {
+		newMessage = func() util.Message { return newAction() }
+	}
+	experimenters.RegisterAction(experimenter, subtype, newMessage)
+}
+
+// RegisterExperimenterInstruction registers newInstr to decode the
+// experimenter instructions of the experimenter. A nil newInstr removes the
+// registration.
+func RegisterExperimenterInstruction(experimenter uint32, newInstr func() Instruction) {
+	var newMessage func() util.Message
+	if newInstr != nil {
+		newMessage = func() util.Message { return newInstr() }
+	}
+	experimenters.RegisterInstruction(experimenter, newMessage)
+}
+
+// RegisterExperimenterField registers newValue to decode the values and the
+// masks of the match fields of the experimenter class with the experimenter ID
+// and the field. newValue is called with the length of the value, which
+// excludes the experimenter ID. A nil newValue removes the registration.
+func RegisterExperimenterField(experimenter uint32, field uint8, newValue func(length uint8) util.Message) {
+	experimenters.RegisterField(experimenter, field, newValue)
+}
+
+// RegisterExperimenterMeterBand registers newBand to decode the experimenter
+// meter bands of the experimenter. A nil newBand removes the registration.
+func RegisterExperimenterMeterBand(experimenter uint32, newBand func() util.Message) {
+	experimenters.RegisterMeterBand(experimenter, newBand)
+}
+
+// RegisterExperimenterProperty registers newProp to decode the experimenter
+// properties of the experimenter and of the type expType, in any list of
+// properties. A nil newProp removes the registration.
+func RegisterExperimenterProperty(experimenter, expType uint32, newProp func() util.Message) {
+	experimenters.RegisterProperty(experimenter, expType, newProp)
+}
+
+// newExperimenterMessage returns the data of a message registered for the
+// experimenter and the type expType, or nil if there is none.
+func newExperimenterMessage(experimenter, expType uint32) util.Message {
+	return experimenters.NewMessage(experimenter, expType)
+}
+
+// newExperimenterAction returns an action registered for the experimenter
+// action in data, or nil if there is none.
+func newExperimenterAction(data []byte) Action {
+	if a := experimenters.NewAction(data); a != nil {
+		return a.(Action)
+	}
+	return nil
+}
+
+// newExperimenterInstr returns an instruction registered for the experimenter
+// instruction in data, or nil if there is none.
+func newExperimenterInstr(data []byte) Instruction {
+	if instr := experimenters.NewInstruction(data); instr != nil {
+		return instr.(Instruction)
+	}
+	return nil
+}
+
+// newExperimenterField returns the value or the mask of length bytes of a match
+// field registered for the experimenter and the field, or nil if there is none.
+func newExperimenterField(experimenter uint32, field uint8, length uint8) util.Message {
+	return experimenters.NewField(experimenter, field, length)
+}
+
+// newExperimenterMeterBand returns a meter band registered for the experimenter
+// meter band in data, or nil if there is none.
+func newExperimenterMeterBand(data []byte) util.Message {
+	return experimenters.NewMeterBand(data)
+}
+
+// newExperimenterProp returns a property registered for the experimenter
+// property in data, or nil if there is none.
+func newExperimenterProp(data []byte) util.Message {
+	return experimenters.NewProperty(data)
+}
@@ -2,6 +2,7 @@ package openflow15
 
 import (
 	"encoding/binary"
+	"errors"
 
 	"k8s.io/klog/v2"
 
@@ -128,8 +129,16 @@ func (f *FlowMod) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *FlowMod) UnmarshalBinary(data []byte) error {
+	if len(data) < 48 {
+		return errors.New("The []byte is too short to unmarshal a full FlowMod message.")
+	}
 	n := 0
-	f.Header.UnmarshalBinary(data[n:])
+	if err := f.Header.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
+	if int(f.Header.Length) > len(data) {
+		return errors.New("The []byte is too short to unmarshal a full FlowMod message.")
+	}
 	n += int(f.Header.Len())
 
 	f.Cookie = binary.BigEndian.Uint64(data[n:])
@@ -164,8 +173,9 @@ func (f *FlowMod) UnmarshalBinary(data []byte) error {
 	}
 	n += int(f.Match.Len())
 
+	f.Instructions = nil
 	for n < int(f.Header.Length) {
-		instr, err := DecodeInstr(data[n:])
+		instr, err := DecodeInstr(data[n:f.Header.Length])
 		if err != nil {
 			klog.ErrorS(err, "Failed to decode FlowMod's instructions", "data", data[n:])
 			return err
@@ -274,6 +284,9 @@ func (f *FlowRemoved) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *FlowRemoved) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full FlowRemoved message.")
+	}
 	n := 0
 	var err error
 	err = f.Header.UnmarshalBinary(data[n:])
@@ -303,6 +316,9 @@ func (f *FlowRemoved) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(f.Match.Len())
+	if n > len(data) {
+		return errors.New("The []byte is too short to unmarshal a full FlowRemoved message.")
+	}
 
 	err = f.Stats.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -2,6 +2,7 @@ package openflow15
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"k8s.io/klog/v2"
@@ -54,11 +55,21 @@ func (s *Stats) MarshalBinary() (data []byte, err error) {
 
 func (s *Stats) UnmarshalBinary(data []byte) (err error) {
 	klog.V(7).InfoS("Stats Data", "data", data)
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full Stats message.")
+	}
 	n := 2 // 2 bytes Reserved
 	s.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	klog.V(7).InfoS("Stats Length", "len", s.Length)
+	if s.Length < 4 || int(s.Length) > len(data) {
+		return fmt.Errorf("The Stats length %d is invalid for %d bytes of data.", s.Length, len(data))
+	}
+	s.Fields = nil
 	for n < int(s.Length) {
+		if n+4 > int(s.Length) {
+			return errors.New("The []byte is too short to unmarshal a full Stats field.")
+		}
 		var f util.Message
 		klog.V(7).InfoS("Stats Field", "value", data[n+2]>>1)
 		switch data[n+2] >> 1 {
@@ -141,6 +152,9 @@ func (h *OXSStatHeader) MarshalBinary() (data []byte, err error) {
 }
 
 func (h *OXSStatHeader) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full OXSStatHeader message.")
+	}
 	h.Class = binary.BigEndian.Uint16(data[0:])
 	h.Field = data[2] >> 1
 	h.Length = data[3]
@@ -180,6 +194,9 @@ func (f *TimeStatField) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *TimeStatField) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(f.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full TimeStatField message.")
+	}
 	err = f.Header.UnmarshalBinary(data)
 	if err != nil {
 		return
@@ -239,6 +256,9 @@ func (f *FlowCountStatField) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *FlowCountStatField) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(f.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full FlowCountStatField message.")
+	}
 	err = f.Header.UnmarshalBinary(data)
 	if err != nil {
 		return
@@ -286,6 +306,9 @@ func (f *PBCountStatField) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *PBCountStatField) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(f.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full PBCountStatField message.")
+	}
 	err = f.Header.UnmarshalBinary(data)
 	if err != nil {
 		return
@@ -0,0 +1,244 @@
+package openflow15
+
+import (
+	"encoding/binary"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/common"
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+// fuzzMessages returns the messages of the JSON tests, and the other messages
+// of the tests built with the same constructors, to seed the fuzz targets.
+func fuzzMessages(t testing.TB) []util.Message {
+	var msgs []util.Message
+	jsonMsgs := jsonTestMessages(t)
+	var names []string
+	for name := range jsonMsgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		msgs = append(msgs, jsonMsgs[name])
+	}
+
+	flow := NewFlowMod()
+	flow.Match.AddField(*NewEthTypeField(protocol.IPv6_MSG))
+	flow.Match.AddField(*NewIpProtoField(protocol.Type_IPv6ICMP))
+	flow.Match.AddField(*NewIcmpv6TypeField(135))
+	flow.Match.AddField(*NewIpv6NDTargetField(net.ParseIP("2001:db8::1")))
+	fragMask := uint8(NX_IP_FRAG_ANY)
+	flow.Match.AddField(*NewIPFragMatchField(NX_IP_FRAG_ANY, &fragMask))
+	apply := NewInstrApplyActions()
+	for _, act := range fuzzActions(t) {
+		apply.AddAction(act, false)
+	}
+	flow.AddInstruction(apply)
+	flow.AddInstruction(NewInstrWriteMetadata(1, 0xff))
+	write := NewInstrWriteActions()
+	write.AddAction(NewActionGroup(1), false)
+	flow.AddInstruction(write)
+	msgs = append(msgs, flow)
+
+	frame := protocol.NewEthernet()
+	frame.Ethertype = protocol.ARP_MSG
+	arp, err := protocol.NewARP(protocol.Type_Request)
+	require.NoError(t, err)
+	frame.Data = arp
+	packetIn := NewPacketIn()
+	packetIn.Match.AddField(*NewInPortField(3))
+	packetIn.Data = frame
+	msgs = append(msgs, packetIn)
+
+	packetIn2 := NewNXTVendorHeader(Type_PacketIn2)
+	props := new(PacketIn2)
+	require.NoError(t, props.UnmarshalBinary(packetIn2Bytes))
+	packetIn2.VendorData = props
+	msgs = append(msgs, packetIn2)
+
+	errMsg := NewErrorMsg()
+	errMsg.Data = *util.NewBuffer([]byte{1, 2, 3, 4})
+	role := NewRoleRequest()
+	role.Role = CR_ROLE_MASTER
+	msgs = append(msgs,
+		NewEchoRequest(),
+		errMsg,
+		role,
+		NewFeaturesReply(),
+		NewSetAsync(),
+		NewSetControllerID(102),
+		NewSetPacketInFormat(OFPUTIL_PACKET_IN_NXT2),
+		NewTLVTableModMessage(prepareTLVTableMod()),
+		NewMpRequest(MultipartType_PortDesc),
+	)
+	return msgs
+}
+
+// fuzzActions returns an action of each kind decoded by DecodeAction.
+func fuzzActions(t testing.TB) []Action {
+	regLoad2Field, err := FindFieldHeaderByName("NXM_NX_CT_MARK", false)
+	require.NoError(t, err)
+	regLoad2Field.Value = newUint32Message(0x1234)
+	controller := NewNXActionController(1001)
+	controller.MaxLen = 128
+	controller2 := NewNXActionController2()
+	controller2.AddMaxLen(128)
+	controller2.AddUserdata([]byte{1, 2, 3})
+	controller2.AddPause(true)
+	learn := &NXActionLearn{
+		NXActionHeader: NewNxActionHeader(NXAST_LEARN),
+		IdleTimeout:    10,
+		TableID:        2,
+		LearnSpecs:     prepareLearnSpecs(),
+	}
+	return []Action{
+		NewActionOutput(1),
+		NewActionPushVlan(0x8100),
+		NewActionPopVlan(),
+		NewActionPopMpls(0x0800),
+		NewActionSetQueue(2),
+		NewActionDecNwTtl(),
+		NewActionMeter(3),
+		NewActionCopyField(16, 0, 0, *NewOxmId(OXM_CLASS_OPENFLOW_BASIC, OXM_FIELD_TCP_SRC, false, 2, 0), *NewOxmId(OXM_CLASS_OPENFLOW_BASIC, OXM_FIELD_TCP_DST, false, 2, 0)),
+		NewNXActionConjunction(1, 2, 3),
+		NewNXActionResubmit(1),
+		NewNXActionResubmitTableCT(1, 2),
+		NewNXActionRegMove(16, 0, 0, NewRegMatchField(1, 0, nil), NewRegMatchField(2, 0, nil)),
+		NewNXActionRegLoad2(regLoad2Field),
+		NewOutputFromField(NewRegMatchField(1, 0, nil), NewNXRange(0, 31).ToOfsBits()),
+		NewNXActionDecTTL(),
+		NewNXActionDecTTLCntIDs(2, 1, 2),
+		NewNXActionEncap(0x10000),
+		controller,
+		controller2,
+		learn,
+	}
+}
+
+// fuzzSeeds returns the wire format of msgs.
+func fuzzSeeds[M util.Message](t testing.TB, msgs []M) [][]byte {
+	var seeds [][]byte
+	for _, msg := range msgs {
+		data, err := msg.MarshalBinary()
+		require.NoError(t, err)
+		seeds = append(seeds, data)
+	}
+	return seeds
+}
+
+// fuzzMarshal checks that a decoded message can be encoded again.
+func fuzzMarshal(msg util.Message) {
+	msg.Len()
+	msg.MarshalBinary()
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range fuzzSeeds(f, fuzzMessages(f)) {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if msg, err := Parse(data); err == nil {
+			fuzzMarshal(msg)
+		}
+	})
+}
+
+func FuzzMatch(f *testing.F) {
+	for _, msg := range fuzzMessages(f) {
+		if flow, ok := msg.(*FlowMod); ok {
+			f.Add(fuzzSeeds(f, []*Match{&flow.Match})[0])
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m := new(Match)
+		if m.UnmarshalBinary(data) == nil {
+			fuzzMarshal(m)
+		}
+	})
+}
+
+func FuzzDecodeAction(f *testing.F) {
+	for _, seed := range fuzzSeeds(f, fuzzActions(f)) {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if a, err := DecodeAction(data); err == nil {
+			fuzzMarshal(a)
+		}
+	})
+}
+
+func FuzzDecodeInstr(f *testing.F) {
+	for _, msg := range fuzzMessages(f) {
+		if flow, ok := msg.(*FlowMod); ok {
+			for _, seed := range fuzzSeeds(f, flow.Instructions) {
+				f.Add(seed)
+			}
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if instr, err := DecodeInstr(data); err == nil {
+			fuzzMarshal(instr)
+		}
+	})
+}
+
+// TestParseMalformed parses every prefix of the seed messages, and the
+// messages with each byte replaced, with the header length set to the length
+// of the data, so that their bodies are decoded.
+func TestParseMalformed(t *testing.T) {
+	parse := func(data []byte) {
+		if len(data) >= 4 {
+			binary.BigEndian.PutUint16(data[2:], uint16(len(data)))
+		}
+		if msg, err := Parse(data); err == nil {
+			fuzzMarshal(msg)
+		}
+	}
+	for _, seed := range fuzzSeeds(t, fuzzMessages(t)) {
+		for i := range seed {
+			parse(append([]byte(nil), seed[:i]...))
+		}
+		for i := 4; i < len(seed); i++ {
+			data := append([]byte(nil), seed...)
+			data[i] = 0xff
+			parse(data)
+		}
+	}
+
+	for _, data := range [][]byte{nil, {4}, {6, Type_Hello, 0, 0}, {6, Type_Hello, 0, 4, 0, 0, 0, 1}} {
+		_, err := Parse(data)
+		require.Error(t, err, "%v", data)
+	}
+	hello, err := common.NewHello(6)
+	require.NoError(t, err)
+	data, err := hello.MarshalBinary()
+	require.NoError(t, err)
+	_, err = Parse(append(data, 0))
+	require.NoError(t, err)
+	_, err = Parse(data[:len(data)-1])
+	require.Error(t, err)
+}
+
+func TestRequestForwardLength(t *testing.T) {
+	r := NewRequestForward()
+	r.Request = NewOfp15Header()
+	r.Request.Type = Type_GroupMod
+	r.Request.Length = r.Request.Len()
+	data, err := r.MarshalBinary()
+	require.NoError(t, err)
+	_, err = Parse(data)
+	require.NoError(t, err)
+
+	// The request must be the rest of the message.
+	for _, length := range []uint16{0, 4, 24} {
+		binary.BigEndian.PutUint16(data[10:], length)
+		_, err = Parse(data)
+		require.Error(t, err, "%d", length)
+	}
+}
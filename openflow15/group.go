@@ -5,6 +5,7 @@ package openflow15
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 
 	"k8s.io/klog/v2"
 
@@ -141,6 +142,9 @@ func (g *GroupMod) MarshalBinary() (data []byte, err error) {
 }
 
 func (g *GroupMod) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full GroupMod message.")
+	}
 	var n uint16
 	err = g.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -161,10 +165,14 @@ func (g *GroupMod) UnmarshalBinary(data []byte) (err error) {
 	n += 2 // Pad
 	g.CommandBucketId = binary.BigEndian.Uint32(data[n:])
 	n += 4
+	if int(n)+int(g.BucketArrayLen) > len(data) {
+		return fmt.Errorf("The GroupMod bucket array length %d exceeds the message.", g.BucketArrayLen)
+	}
 
-	for n < g.Header.Length {
+	g.Buckets = nil
+	for n < 24+g.BucketArrayLen {
 		bkt := new(Bucket)
-		err = bkt.UnmarshalBinary(data[n:])
+		err = bkt.UnmarshalBinary(data[n : 24+g.BucketArrayLen])
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal GroupMod's Bucket", "data", data[n:])
 			return
@@ -173,14 +181,19 @@ func (g *GroupMod) UnmarshalBinary(data []byte) (err error) {
 		n += bkt.Len()
 	}
 
-	for n < g.Header.Length {
+	g.Properties = nil
+	for int(n) < len(data) {
 		var p util.Message
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full GroupMod property.")
+		}
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case GPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -284,6 +297,9 @@ func (b *Bucket) MarshalBinary() (data []byte, err error) {
 }
 
 func (b *Bucket) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full Bucket message.")
+	}
 	var n uint16
 	b.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -291,9 +307,14 @@ func (b *Bucket) UnmarshalBinary(data []byte) (err error) {
 	n += 2
 	b.BucketId = binary.BigEndian.Uint32(data[n:])
 	n += 4
+	if b.Length < 8 || int(b.Length) > len(data) || 8+int(b.ActionArrayLen) > int(b.Length) {
+		return fmt.Errorf("The Bucket length %d and action array length %d are invalid for %d bytes of data.", b.Length, b.ActionArrayLen, len(data))
+	}
+	data = data[:b.Length]
 
+	b.Actions = nil
 	for n < 8+b.ActionArrayLen {
-		a, err := DecodeAction(data[n:])
+		a, err := DecodeAction(data[n : 8+b.ActionArrayLen])
 		if err != nil {
 			klog.ErrorS(err, "Failed to decode Bucket action", "data", data[n:])
 			return err
@@ -302,7 +323,8 @@ func (b *Bucket) UnmarshalBinary(data []byte) (err error) {
 		n += a.Len()
 	}
 
-	for n < b.Length {
+	b.Properties = nil
+	for n+4 <= b.Length {
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case GBPT_WEIGHT:
@@ -312,10 +334,11 @@ func (b *Bucket) UnmarshalBinary(data []byte) (err error) {
 		case GBPT_WATCH_GROUP:
 			p = new(GroupBucketPropWatchGroup)
 		case GBPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -370,6 +393,9 @@ func (prop *GroupBucketPropWeight) UnmarshalBinary(data []byte) (err error) {
 		return
 	}
 	n = prop.Header.Len()
+	if len(data) < int(n)+2 {
+		return errors.New("The []byte is too short to unmarshal a full GroupBucketPropWeight message.")
+	}
 
 	prop.Weight = binary.BigEndian.Uint16(data[n:])
 
@@ -427,6 +453,9 @@ func (prop *GroupBucketPropWatch) UnmarshalBinary(data []byte) (err error) {
 		return
 	}
 	n = prop.Header.Len()
+	if len(data) < int(n)+4 {
+		return errors.New("The []byte is too short to unmarshal a full GroupBucketPropWatch message.")
+	}
 
 	prop.Watch = binary.BigEndian.Uint32(data[n:])
 
@@ -520,6 +549,10 @@ func (m *NTRSelectionMethod) UnmarshalBinary(data []byte) (err error) {
 	n += 16
 	m.SelectionParam = binary.BigEndian.Uint64(data[n:])
 	n += 8
+	if int(m.Length) > len(data) {
+		return fmt.Errorf("The NTRSelectionMethod length %d exceeds the %d bytes of data.", m.Length, len(data))
+	}
+	m.Fields = nil
 	for n < int(m.Length) {
 		field := new(MatchField)
 		err = field.UnmarshalBinary(data[n:])
@@ -57,10 +57,15 @@ func (a *InstrHeader) UnmarshalBinary(data []byte) error {
 }
 
 func DecodeInstr(data []byte) (Instruction, error) {
-	if len(data) < 2 {
+	if len(data) < 4 {
 		return nil, errors.New("data too short to decode Instruction")
 	}
 	t := binary.BigEndian.Uint16(data[:2])
+	length := binary.BigEndian.Uint16(data[2:4])
+	if length < 4 || int(length) > len(data) {
+		return nil, fmt.Errorf("the Instruction length %d is invalid for %d bytes of data", length, len(data))
+	}
+	data = data[:length]
 	var a Instruction
 	switch t {
 	case InstrType_GOTO_TABLE:
@@ -76,12 +81,11 @@ func DecodeInstr(data []byte) (Instruction, error) {
 	case InstrType_DEPRECATED:
 	case InstrType_STAT_TRIGGER:
 	case InstrType_EXPERIMENTER:
-	default:
-		return nil, fmt.Errorf("unknown Instrheader type: %v", t)
+		a = newExperimenterInstr(data)
 	}
 
 	if a == nil {
-		return nil, fmt.Errorf("No supported Instrheader type: %v", t)
+		a = new(InstrUnknown)
 	}
 
 	err := a.UnmarshalBinary(data)
@@ -89,9 +93,65 @@ func DecodeInstr(data []byte) (Instruction, error) {
 		klog.ErrorS(err, "Failed to unmarshal Instruction", "data", data)
 		return nil, err
 	}
+	if a.Len() != length {
+		// The instruction would be encoded with another length, keep its
+		// bytes.
+		a = new(InstrUnknown)
+		if err := a.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+	}
 	return a, nil
 }
 
+// InstrUnknown is an instruction of a type which is not known. It keeps the
+// bytes following the instruction header, so that it is encoded again
+// unchanged.
+type InstrUnknown struct {
+	InstrHeader
+	Data []byte
+}
+
+func (instr *InstrUnknown) Len() (n uint16) {
+	return instr.InstrHeader.Len() + uint16(len(instr.Data))
+}
+
+func (instr *InstrUnknown) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(instr.Len()))
+	instr.Length = instr.Len()
+	b, err := instr.InstrHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	copy(data[n:], instr.Data)
+	return
+}
+
+func (instr *InstrUnknown) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full InstrUnknown message.")
+	}
+	if err := instr.InstrHeader.UnmarshalBinary(data[:4]); err != nil {
+		return err
+	}
+	if instr.Length < 4 || int(instr.Length) > len(data) {
+		return fmt.Errorf("the Instruction length %d is invalid for %d bytes of data", instr.Length, len(data))
+	}
+	instr.Data = make([]byte, int(instr.Length)-4)
+	copy(instr.Data, data[4:instr.Length])
+	return nil
+}
+
+// UnknownError implements util.Unknown.
+func (instr *InstrUnknown) UnknownError() error {
+	return fmt.Errorf("unknown instruction of type %d and length %d", instr.Type, instr.Length)
+}
+
+func (instr *InstrUnknown) AddAction(act Action, prepend bool) error {
+	return errors.New("Not supported on this instrction")
+}
+
 type InstrGotoTable struct {
 	InstrHeader
 	TableId uint8
@@ -114,6 +174,9 @@ func (instr *InstrGotoTable) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrGotoTable) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full InstrGotoTable message.")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
 	instr.TableId = data[4]
@@ -161,6 +224,9 @@ func (instr *InstrWriteMetadata) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrWriteMetadata) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full InstrWriteMetadata message.")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
 	copy(instr.pad, data[4:8])
@@ -218,11 +284,19 @@ func (instr *InstrActions) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrActions) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full InstrActions message.")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
+	if instr.Length < 8 || int(instr.Length) > len(data) {
+		return fmt.Errorf("The InstrActions length %d is invalid for %d bytes of data.", instr.Length, len(data))
+	}
+
 	n := 8
+	instr.Actions = nil
 	for n < int(instr.Length) {
-		act, err := DecodeAction(data[n:])
+		act, err := DecodeAction(data[n:instr.Length])
 		if err != nil {
 			klog.ErrorS(err, "Failed to decode InstrActions's Actions", "data", data[n:])
 			return err
@@ -311,6 +385,9 @@ func (instr *InstrStatTrigger) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrStatTrigger) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full InstrStatTrigger message.")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 	instr.Flags = binary.BigEndian.Uint32(data[4:8])
 	err := instr.Thresholds.UnmarshalBinary(data[8:])
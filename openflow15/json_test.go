@@ -0,0 +1,170 @@
+package openflow15
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/common"
+	"antrea.io/libOpenflow/util"
+)
+
+func jsonTestMessages(t testing.TB) map[string]util.Message {
+	flow := NewFlowMod()
+	flow.Cookie = 0x1234
+	flow.TableId = 3
+	flow.Priority = 200
+	flow.Match.AddField(*NewInPortField(5))
+	flow.Match.AddField(*NewEthTypeField(0x0800))
+	ipMask := net.ParseIP("255.255.255.0").To4()
+	flow.Match.AddField(*NewIpv4SrcField(net.ParseIP("10.0.0.0").To4(), &ipMask))
+	flow.Match.AddField(*NewIpv6DstField(net.ParseIP("fe80::1"), nil))
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	flow.Match.AddField(*NewEthDstField(mac, nil))
+	flow.Match.AddField(*NewRegMatchFieldWithMask(3, 0x10, 0xff))
+	ctStates := NewCTStates()
+	ctStates.SetNew()
+	ctStates.SetTrk()
+	flow.Match.AddField(*NewCTStateMatchField(ctStates))
+	flow.Match.AddField(*NewTunnelIdField(100))
+
+	ct := NewNXActionConnTrack().Commit().Table(10).ZoneImm(1)
+	nat := NewNXActionCTNAT()
+	require.NoError(t, nat.SetSNAT())
+	nat.SetRangeIPv4Min(net.ParseIP("1.1.1.1"))
+	nat.SetRangeIPv4Max(net.ParseIP("1.1.1.10"))
+	ct.AddAction(nat)
+	apply := NewInstrApplyActions()
+	apply.AddAction(ct, false)
+	apply.AddAction(NewActionSetField(*NewEthDstField(mac, nil)), false)
+	apply.AddAction(NewNXActionRegLoad(NewNXRange(0, 15).ToOfsBits(), NewRegMatchField(1, 0, nil), 0xabcd), false)
+	note := NewNXActionNote()
+	note.Note = []byte("note")
+	apply.AddAction(note, false)
+	apply.AddAction(NewActionOutput(2), false)
+	flow.AddInstruction(apply)
+	flow.AddInstruction(NewInstrGotoTable(4))
+
+	group := NewGroupMod()
+	group.GroupId = 7
+	group.Type = GT_SELECT
+	bucket := NewBucket(1)
+	bucket.AddAction(NewActionOutput(3))
+	bucket.AddProperty(NewGroupBucketPropWeight(50))
+	group.AddBucket(*bucket)
+
+	meter := NewMeterMod()
+	meter.MeterId = 2
+	meter.Flags = MF_KBPS
+	drop := NewMeterBandDrop()
+	drop.Rate = 1000
+	meter.AddMeterBand(drop)
+
+	packetOut := NewPacketOut()
+	packetOut.Match.AddField(*NewInPortField(1))
+	packetOut.AddAction(NewActionOutput(P_TABLE))
+	packetOut.Data = util.NewBuffer([]byte{0x01, 0x02, 0x03})
+
+	bundleCtrl := NewBundleCtrl(1, OFPBCT_COMMIT_REQUEST, OFPBCT_ATOMIC|BF_TIME)
+	prop := &BundlePropTime{SchedTime: OfpTime{Seconds: 100, NanoSeconds: 5}}
+	prop.Header.Type = BPT_TIME
+	bundleCtrl.Properties = append(bundleCtrl.Properties, prop)
+
+	bundleAdd := NewBndleAdd(1, OFPBCT_ATOMIC)
+	bundleAdd.Message = group
+
+	reply := NewMpReply(MultipartType_FlowDesc)
+	desc := new(FlowDesc)
+	desc.Match = *NewMatch()
+	desc.Match.AddField(*NewInPortField(1))
+	desc.Instructions = append(desc.Instructions, NewInstrGotoTable(1))
+	reply.Body = append(reply.Body, desc)
+
+	fragFlow := NewFlowMod()
+	fragFlow.Match.AddField(*NewEthTypeField(0x0800))
+	fragMask := uint8(NX_IP_FRAG_ANY)
+	fragFlow.Match.AddField(*NewIPFragMatchField(NX_IP_FRAG_ANY, &fragMask))
+	fragFlow.AddInstruction(NewInstrGotoTable(1))
+
+	hello, err := common.NewHello(6)
+	require.NoError(t, err)
+
+	return map[string]util.Message{
+		"Hello":      hello,
+		"FlowMod":    flow,
+		"FragFlow":   fragFlow,
+		"GroupMod":   group,
+		"MeterMod":   meter,
+		"PacketOut":  packetOut,
+		"BundleCtrl": bundleCtrl,
+		"BndleAdd":   bundleAdd,
+		"FlowDesc":   reply,
+	}
+}
+
+func TestJSONMessage(t *testing.T) {
+	for name, msg := range jsonTestMessages(t) {
+		t.Run(name, func(t *testing.T) {
+			wire, err := msg.MarshalBinary()
+			require.NoError(t, err)
+			parsed, err := Parse(wire)
+			require.NoError(t, err)
+
+			for _, m := range []util.Message{msg, parsed} {
+				data, err := json.Marshal(JSONMessage{m})
+				require.NoError(t, err)
+				var decoded JSONMessage
+				require.NoError(t, json.Unmarshal(data, &decoded))
+				b, err := decoded.MarshalBinary()
+				require.NoError(t, err)
+				assert.Equal(t, wire, b)
+			}
+		})
+	}
+}
+
+func TestJSONFlowMod(t *testing.T) {
+	flow := jsonTestMessages(t)["FlowMod"].(*FlowMod)
+	wire, err := flow.MarshalBinary()
+	require.NoError(t, err)
+
+	data, err := json.Marshal(flow)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"NXActionConnTrack":`)
+	assert.Contains(t, string(data), `"Ipv4SrcField":`)
+	decoded := new(FlowMod)
+	require.NoError(t, json.Unmarshal(data, decoded))
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, wire, b)
+
+	var m JSONMessage
+	assert.Error(t, json.Unmarshal([]byte(`{"NoSuchMessage":{}}`), &m))
+	assert.Error(t, json.Unmarshal([]byte(`{"FlowMod":{},"GroupMod":{}}`), &m))
+}
+
+// TestJSONCodecTypes checks that jsonCodec knows the types of the values which
+// Parse returns, by encoding the parsed test messages to JSON and back.
+func TestJSONCodecTypes(t *testing.T) {
+	msgs := fuzzMessages(t)
+	for _, msg := range unknownMessages(t) {
+		msgs = append(msgs, msg)
+	}
+	for _, wire := range fuzzSeeds(t, msgs) {
+		// Parse does not decode all controller-to-switch messages.
+		parsed, err := Parse(wire)
+		if err != nil || parsed == nil {
+			continue
+		}
+		data, err := json.Marshal(JSONMessage{parsed})
+		require.NoError(t, err, "%T", parsed)
+		var decoded JSONMessage
+		require.NoError(t, json.Unmarshal(data, &decoded), "%T", parsed)
+		b, err := decoded.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, wire, b, "%T", parsed)
+	}
+}
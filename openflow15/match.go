@@ -62,6 +62,11 @@ func (m *Match) Len() (n uint16) {
 
 func (m *Match) MarshalBinary() (data []byte, err error) {
 	data = make([]byte, int(m.Len()))
+	// The length excludes the padding to a multiple of 8 bytes.
+	m.Length = 4
+	for _, a := range m.Fields {
+		m.Length += a.Len()
+	}
 
 	n := 0
 	binary.BigEndian.PutUint16(data[n:], m.Type)
@@ -82,16 +87,22 @@ func (m *Match) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *Match) UnmarshalBinary(data []byte) error {
-
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full Match message.")
+	}
 	n := 0
 	m.Type = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	m.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	if m.Length < 4 || int(m.Length) > len(data) {
+		return fmt.Errorf("The Match length %d is invalid for %d bytes of data.", m.Length, len(data))
+	}
 
+	m.Fields = nil
 	for n < int(m.Length) {
 		field := new(MatchField)
-		if err := field.UnmarshalBinary(data[n:]); err != nil {
+		if err := field.UnmarshalBinary(data[n:m.Length]); err != nil {
 			klog.ErrorS(err, "Failed to unmarshal MatchField", "data", data[n:])
 			return err
 		}
@@ -138,6 +149,11 @@ func (m *MatchField) MarshalBinary() (data []byte, err error) {
 	data[n] = m.Length
 	n += 1
 
+	if m.ExperimenterID != 0 {
+		binary.BigEndian.PutUint32(data[n:], m.ExperimenterID)
+		n += 4
+	}
+
 	b, err := m.Value.MarshalBinary()
 	if err != nil {
 		return
@@ -157,6 +173,9 @@ func (m *MatchField) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MatchField) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full MatchField message.")
+	}
 	var n uint16
 	var err error
 	m.Class = binary.BigEndian.Uint16(data[n:])
@@ -173,30 +192,61 @@ func (m *MatchField) UnmarshalBinary(data []byte) error {
 
 	m.Length = data[n]
 	n += 1
+	end := n + uint16(m.Length)
+	if int(end) > len(data) {
+		return fmt.Errorf("The MatchField length %d is invalid for %d bytes of data.", m.Length, len(data)-int(n))
+	}
+	data = data[:end]
 
 	if m.Class == OXM_CLASS_EXPERIMENTER {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full MatchField message.")
+		}
 		experimenterID := binary.BigEndian.Uint32(data[n:])
-		if experimenterID == ONF_EXPERIMENTER_ID {
-			n += 4
-			m.ExperimenterID = experimenterID
-		} else {
+		if experimenterID == 0 {
 			return fmt.Errorf("Unsupported experimenter id: %d in class: %d ", experimenterID, m.Class)
 		}
+		n += 4
+		m.ExperimenterID = experimenterID
 	}
 
-	if m.Value, err = DecodeMatchField(m.Class, m.Field, m.Length, m.HasMask, data[n:]); err != nil {
+	decode := func() (util.Message, error) {
+		if m.ExperimenterID != 0 {
+			// The length of experimenter fields includes the experimenter ID.
+			length := m.Length - 4
+			if m.HasMask {
+				length /= 2
+			}
+			if val := newExperimenterField(m.ExperimenterID, m.Field, length); val != nil {
+				if err := unmarshalFieldValue(val, data[n:]); err != nil {
+					return nil, err
+				}
+				return val, nil
+			}
+		}
+		if m.ExperimenterID != 0 && m.ExperimenterID != ONF_EXPERIMENTER_ID {
+			// The fields of other experimenters are not known, and their
+			// length includes the experimenter ID.
+			return unknownFieldValue(m.Length-4, m.HasMask, data[n:])
+		}
+		return DecodeMatchField(m.Class, m.Field, m.Length, m.HasMask, data[n:])
+	}
+	if m.Value, err = decode(); err != nil {
 		klog.ErrorS(err, "Failed to decode MatchField", "data", data[n:])
 		return err
 	}
 	n += m.Value.Len()
 
 	if m.HasMask {
-		if m.Mask, err = DecodeMatchField(m.Class, m.Field, m.Length, m.HasMask, data[n:]); err != nil {
+		if m.Mask, err = decode(); err != nil {
 			klog.ErrorS(err, "Failed to decode MatchField mask", "data", data[n:])
 			return err
 		}
 		n += m.Mask.Len()
 	}
+	if n != end {
+		return fmt.Errorf("The MatchField length %d does not match its %d bytes of payload.", m.Length, n-4)
+	}
 	return err
 }
 
@@ -293,6 +343,9 @@ func (o *OxmId) MarshalBinary() (data []byte, err error) {
 }
 
 func (o *OxmId) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full OxmId message.")
+	}
 	var n uint16
 	var err error
 	o.Class = binary.BigEndian.Uint16(data[n:])
@@ -311,13 +364,15 @@ func (o *OxmId) UnmarshalBinary(data []byte) error {
 	n += 1
 
 	if o.Class == OXM_CLASS_EXPERIMENTER {
+		if len(data) < 8 {
+			return errors.New("The []byte is too short to unmarshal a full OxmId message.")
+		}
 		experimenterID := binary.BigEndian.Uint32(data[n:])
-		if experimenterID == ONF_EXPERIMENTER_ID {
-			n += 4
-			o.ExperimenterID = experimenterID
-		} else {
+		if experimenterID == 0 {
 			return fmt.Errorf("Unsupported experimenter id: %d in class: %d ", experimenterID, o.Class)
 		}
+		n += 4
+		o.ExperimenterID = experimenterID
 	}
 
 	return err
@@ -413,12 +468,10 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 		case OXM_FIELD_ACTSET_OUTPUT:
 			val = new(ActsetOutputField)
 		default:
-			err := fmt.Errorf("unhandled Field: %d in Class: %d", field, class)
-			klog.ErrorS(err, "Received bad pkt class", "data", data)
-			return nil, err
+			return unknownFieldValue(length, hasMask, data)
 		}
 
-		err := val.UnmarshalBinary(data)
+		err := unmarshalFieldValue(val, data)
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal Oxm Field", "data", data)
 			return nil, err
@@ -431,11 +484,9 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 		case OXM_FIELD_IN_PORT:
 			val = new(NxmInportField)
 		default:
-			err := fmt.Errorf("unhandled Field: %d in Class: %d", field, class)
-			klog.ErrorS(err, "Received bad pkt class", "data", data)
-			return nil, err
+			return unknownFieldValue(length, hasMask, data)
 		}
-		err := val.UnmarshalBinary(data)
+		err := unmarshalFieldValue(val, data)
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal Oxm Field", "data", data)
 			return nil, err
@@ -496,6 +547,7 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 		case NXM_NX_ND_TLL:
 			val = new(EthSrcField)
 		case NXM_NX_IP_FRAG:
+			val = new(Uint8Message)
 		case NXM_NX_IPV6_LABEL:
 		case NXM_NX_IP_ECN:
 		case NXM_NX_IP_TTL:
@@ -579,17 +631,14 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 			}
 			val = msg
 		default:
-			err := fmt.Errorf("unknown field for nxm_1: %v", field)
-			klog.ErrorS(err, "Received invalid field", "data", data)
-			return nil, err
+			return unknownFieldValue(length, hasMask, data)
 		}
 
 		if val == nil {
-			err := fmt.Errorf("Not supported field for nxm_1: %v", field)
-			return nil, err
+			return unknownFieldValue(length, hasMask, data)
 		}
 
-		err := val.UnmarshalBinary(data)
+		err := unmarshalFieldValue(val, data)
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal Nxm Field", "data", data)
 			return nil, err
@@ -621,11 +670,9 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 			}
 			val = msg
 		default:
-			err := fmt.Errorf("unknown field for packet_regs: %v", field)
-			klog.ErrorS(err, "Received invalid field", "data", data)
-			return nil, err
+			return unknownFieldValue(length, hasMask, data)
 		}
-		err := val.UnmarshalBinary(data)
+		err := unmarshalFieldValue(val, data)
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal Oxm Field", "data", data)
 			return nil, err
@@ -637,19 +684,53 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 		case OXM_FIELD_TCP_FLAGS:
 			val = new(TcpFlagsField)
 		default:
-			err := fmt.Errorf("unknown field for experimenter: %v", field)
-			klog.ErrorS(err, "Received invalid field", "data", data)
-			return nil, err
+			// The length of experimenter fields includes the experimenter ID.
+			return unknownFieldValue(length-4, hasMask, data)
 		}
-		err := val.UnmarshalBinary(data)
+		err := unmarshalFieldValue(val, data)
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal Oxm Field", "data", data)
 			return nil, err
 		}
 		return val, nil
 	} else {
-		return nil, fmt.Errorf("Unsupported match field: %d in class: %d", field, class)
+		return unknownFieldValue(length, hasMask, data)
+	}
+}
+
+// unmarshalFieldValue decodes the value or the mask of a match field, after
+// checking that data holds a full one.
+func unmarshalFieldValue(val util.Message, data []byte) error {
+	if len(data) < int(val.Len()) {
+		return fmt.Errorf("The []byte is too short to unmarshal a full %T match field.", val)
+	}
+	return val.UnmarshalBinary(data)
+}
+
+// FieldUnknown is the value or the mask of a match field which is not known.
+// It keeps the bytes of the field, so that it is encoded again unchanged.
+type FieldUnknown struct {
+	ByteArrayField
+}
+
+// UnknownError implements util.Unknown.
+func (m *FieldUnknown) UnknownError() error {
+	return fmt.Errorf("unknown match field value of %d bytes", m.Length)
+}
+
+// unknownFieldValue decodes the value or the mask of a match field which is not
+// known into a FieldUnknown. length is the length of the payload of the field,
+// with the mask if it has one.
+func unknownFieldValue(length uint8, hasMask bool, data []byte) (util.Message, error) {
+	val := new(FieldUnknown)
+	val.Length = length
+	if hasMask {
+		val.Length = length / 2
 	}
+	if err := unmarshalFieldValue(val, data); err != nil {
+		return nil, err
+	}
+	return val, nil
 }
 
 // ofp_match_type 1.5
@@ -2219,6 +2300,42 @@ func (f *IcmpCodeField) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+func newIcmpMatchField(field uint8, value util.Message) *MatchField {
+	f := new(MatchField)
+	f.Class = OXM_CLASS_OPENFLOW_BASIC
+	f.Field = field
+	f.HasMask = false
+	f.Value = value
+	f.Length = uint8(value.Len())
+	return f
+}
+
+// Return a MatchField for ICMPv4 type matching
+func NewIcmpTypeField(icmpType uint8) *MatchField {
+	return newIcmpMatchField(OXM_FIELD_ICMPV4_TYPE, &IcmpTypeField{Type: icmpType})
+}
+
+// Return a MatchField for ICMPv4 code matching
+func NewIcmpCodeField(icmpCode uint8) *MatchField {
+	return newIcmpMatchField(OXM_FIELD_ICMPV4_CODE, &IcmpCodeField{Code: icmpCode})
+}
+
+// Return a MatchField for ICMPv6 type matching
+func NewIcmpv6TypeField(icmpType uint8) *MatchField {
+	return newIcmpMatchField(OXM_FIELD_ICMPV6_TYPE, &IcmpTypeField{Type: icmpType})
+}
+
+// Return a MatchField for ICMPv6 code matching
+func NewIcmpv6CodeField(icmpCode uint8) *MatchField {
+	return newIcmpMatchField(OXM_FIELD_ICMPV6_CODE, &IcmpCodeField{Code: icmpCode})
+}
+
+// Return a MatchField for the target address of IPv6 neighbor solicitations
+// and advertisements
+func NewIpv6NDTargetField(target net.IP) *MatchField {
+	return newIcmpMatchField(OXM_FIELD_IPV6_ND_TARGET, &Ipv6DstField{Ipv6Dst: target.To16()})
+}
+
 // PACKET_TYPE field
 type PacketTypeField struct {
 	Namespace uint16
@@ -0,0 +1,479 @@
+package openflow15
+
+// This file validates match fields against their prerequisites, masks and
+// value ranges, and inserts missing prerequisites.
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ethTypeIPv4      = 0x0800
+	ethTypeARP       = 0x0806
+	ethTypeIPv6      = 0x86dd
+	ethTypeMPLS      = 0x8847
+	ethTypeMPLSMcast = 0x8848
+	ethTypePBB       = 0x88e7
+
+	ipProtoICMP   = 1
+	ipProtoTCP    = 6
+	ipProtoUDP    = 17
+	ipProtoICMPv6 = 58
+	ipProtoSCTP   = 132
+
+	icmpv6NeighborSolicitation  = 135
+	icmpv6NeighborAdvertisement = 136
+
+	vlanTCIPresent = 0x1000
+)
+
+type matchFieldKey struct {
+	class uint16
+	field uint8
+}
+
+// matchFieldRule describes what a switch accepts for a match field. A field
+// with prerequisites is only valid if the match also has a field for each of
+// them, with one of the listed values.
+type matchFieldRule struct {
+	ethTypes    []uint16
+	ipProto     uint8
+	icmpv6Types []uint8
+	vlan        bool
+	// noMask is set for fields that cannot be masked.
+	noMask bool
+	// max is the largest valid value, 0 if all values of the field width are
+	// valid.
+	max uint64
+}
+
+var (
+	ethTypesIP   = []uint16{ethTypeIPv4, ethTypeIPv6}
+	ethTypesMPLS = []uint16{ethTypeMPLS, ethTypeMPLSMcast}
+)
+
+func basicField(field uint8) matchFieldKey {
+	return matchFieldKey{class: OXM_CLASS_OPENFLOW_BASIC, field: field}
+}
+
+func nxmField(field uint8) matchFieldKey {
+	return matchFieldKey{class: OXM_CLASS_NXM_1, field: field}
+}
+
+func nxm0Field(field uint8) matchFieldKey {
+	return matchFieldKey{class: OXM_CLASS_NXM_0, field: field}
+}
+
+// matchFieldAliases maps the NXM fields to the OXM basic fields with the same
+// value and mask encoding, which a switch handles as the same field.
+var matchFieldAliases = map[matchFieldKey]matchFieldKey{
+	nxm0Field(NXM_OF_ETH_DST):    basicField(OXM_FIELD_ETH_DST),
+	nxm0Field(NXM_OF_ETH_SRC):    basicField(OXM_FIELD_ETH_SRC),
+	nxm0Field(NXM_OF_ETH_TYPE):   basicField(OXM_FIELD_ETH_TYPE),
+	nxm0Field(NXM_OF_IP_PROTO):   basicField(OXM_FIELD_IP_PROTO),
+	nxm0Field(NXM_OF_IP_SRC):     basicField(OXM_FIELD_IPV4_SRC),
+	nxm0Field(NXM_OF_IP_DST):     basicField(OXM_FIELD_IPV4_DST),
+	nxm0Field(NXM_OF_TCP_SRC):    basicField(OXM_FIELD_TCP_SRC),
+	nxm0Field(NXM_OF_TCP_DST):    basicField(OXM_FIELD_TCP_DST),
+	nxm0Field(NXM_OF_UDP_SRC):    basicField(OXM_FIELD_UDP_SRC),
+	nxm0Field(NXM_OF_UDP_DST):    basicField(OXM_FIELD_UDP_DST),
+	nxm0Field(NXM_OF_ICMP_TYPE):  basicField(OXM_FIELD_ICMPV4_TYPE),
+	nxm0Field(NXM_OF_ICMP_CODE):  basicField(OXM_FIELD_ICMPV4_CODE),
+	nxm0Field(NXM_OF_ARP_OP):     basicField(OXM_FIELD_ARP_OP),
+	nxm0Field(NXM_OF_ARP_SPA):    basicField(OXM_FIELD_ARP_SPA),
+	nxm0Field(NXM_OF_ARP_TPA):    basicField(OXM_FIELD_ARP_TPA),
+	nxmField(NXM_NX_ARP_SHA):     basicField(OXM_FIELD_ARP_SHA),
+	nxmField(NXM_NX_ARP_THA):     basicField(OXM_FIELD_ARP_THA),
+	nxmField(NXM_NX_IPV6_SRC):    basicField(OXM_FIELD_IPV6_SRC),
+	nxmField(NXM_NX_IPV6_DST):    basicField(OXM_FIELD_IPV6_DST),
+	nxmField(NXM_NX_IPV6_LABEL):  basicField(OXM_FIELD_IPV6_FLABEL),
+	nxmField(NXM_NX_ICMPV6_TYPE): basicField(OXM_FIELD_ICMPV6_TYPE),
+	nxmField(NXM_NX_ICMPV6_CODE): basicField(OXM_FIELD_ICMPV6_CODE),
+	nxmField(NXM_NX_ND_TARGET):   basicField(OXM_FIELD_IPV6_ND_TARGET),
+	nxmField(NXM_NX_ND_SLL):      basicField(OXM_FIELD_IPV6_ND_SLL),
+	nxmField(NXM_NX_ND_TLL):      basicField(OXM_FIELD_IPV6_ND_TLL),
+	nxmField(NXM_NX_IP_ECN):      basicField(OXM_FIELD_IP_ECN),
+	nxmField(NXM_NX_TCP_FLAGS):   basicField(OXM_FIELD_TCP_FLAGS),
+}
+
+// CanonicalMatchField returns the OXM basic class and field of an NXM field
+// that has the same value and mask encoding, such as OXM_FIELD_ETH_TYPE for
+// NXM_OF_ETH_TYPE, and the class and the field unchanged otherwise.
+// NXM_OF_IN_PORT, NXM_OF_VLAN_TCI and NXM_OF_IP_TOS are left unchanged as they
+// are encoded differently from their OXM counterparts.
+func CanonicalMatchField(class uint16, field uint8) (uint16, uint8) {
+	key := canonicalKey(class, field)
+	return key.class, key.field
+}
+
+func canonicalKey(class uint16, field uint8) matchFieldKey {
+	key := matchFieldKey{class: class, field: field}
+	if alias, ok := matchFieldAliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+// matchFieldRules follows the OpenFlow specification and the NXM fields of
+// Open vSwitch. The NXM fields in matchFieldAliases follow the rules of their
+// OXM fields. Fields not listed have no prerequisite.
+var matchFieldRules = map[matchFieldKey]matchFieldRule{
+	basicField(OXM_FIELD_IN_PORT):        {noMask: true},
+	basicField(OXM_FIELD_IN_PHY_PORT):    {noMask: true},
+	basicField(OXM_FIELD_ETH_TYPE):       {noMask: true},
+	basicField(OXM_FIELD_VLAN_VID):       {max: 0x1fff},
+	basicField(OXM_FIELD_VLAN_PCP):       {vlan: true, noMask: true, max: 7},
+	basicField(OXM_FIELD_IP_DSCP):        {ethTypes: ethTypesIP, noMask: true, max: 0x3f},
+	basicField(OXM_FIELD_IP_ECN):         {ethTypes: ethTypesIP, noMask: true, max: 3},
+	basicField(OXM_FIELD_IP_PROTO):       {ethTypes: ethTypesIP, noMask: true},
+	basicField(OXM_FIELD_IPV4_SRC):       {ethTypes: []uint16{ethTypeIPv4}},
+	basicField(OXM_FIELD_IPV4_DST):       {ethTypes: []uint16{ethTypeIPv4}},
+	basicField(OXM_FIELD_TCP_SRC):        {ethTypes: ethTypesIP, ipProto: ipProtoTCP},
+	basicField(OXM_FIELD_TCP_DST):        {ethTypes: ethTypesIP, ipProto: ipProtoTCP},
+	basicField(OXM_FIELD_TCP_FLAGS):      {ethTypes: ethTypesIP, ipProto: ipProtoTCP, max: 0xfff},
+	basicField(OXM_FIELD_UDP_SRC):        {ethTypes: ethTypesIP, ipProto: ipProtoUDP},
+	basicField(OXM_FIELD_UDP_DST):        {ethTypes: ethTypesIP, ipProto: ipProtoUDP},
+	basicField(OXM_FIELD_SCTP_SRC):       {ethTypes: ethTypesIP, ipProto: ipProtoSCTP},
+	basicField(OXM_FIELD_SCTP_DST):       {ethTypes: ethTypesIP, ipProto: ipProtoSCTP},
+	basicField(OXM_FIELD_ICMPV4_TYPE):    {ethTypes: []uint16{ethTypeIPv4}, ipProto: ipProtoICMP, noMask: true},
+	basicField(OXM_FIELD_ICMPV4_CODE):    {ethTypes: []uint16{ethTypeIPv4}, ipProto: ipProtoICMP, noMask: true},
+	basicField(OXM_FIELD_ARP_OP):         {ethTypes: []uint16{ethTypeARP}, noMask: true},
+	basicField(OXM_FIELD_ARP_SPA):        {ethTypes: []uint16{ethTypeARP}},
+	basicField(OXM_FIELD_ARP_TPA):        {ethTypes: []uint16{ethTypeARP}},
+	basicField(OXM_FIELD_ARP_SHA):        {ethTypes: []uint16{ethTypeARP}},
+	basicField(OXM_FIELD_ARP_THA):        {ethTypes: []uint16{ethTypeARP}},
+	basicField(OXM_FIELD_IPV6_SRC):       {ethTypes: []uint16{ethTypeIPv6}},
+	basicField(OXM_FIELD_IPV6_DST):       {ethTypes: []uint16{ethTypeIPv6}},
+	basicField(OXM_FIELD_IPV6_FLABEL):    {ethTypes: []uint16{ethTypeIPv6}, max: 0xfffff},
+	basicField(OXM_FIELD_ICMPV6_TYPE):    {ethTypes: []uint16{ethTypeIPv6}, ipProto: ipProtoICMPv6, noMask: true},
+	basicField(OXM_FIELD_ICMPV6_CODE):    {ethTypes: []uint16{ethTypeIPv6}, ipProto: ipProtoICMPv6, noMask: true},
+	basicField(OXM_FIELD_IPV6_ND_TARGET): {ethTypes: []uint16{ethTypeIPv6}, ipProto: ipProtoICMPv6, icmpv6Types: []uint8{icmpv6NeighborSolicitation, icmpv6NeighborAdvertisement}},
+	basicField(OXM_FIELD_IPV6_ND_SLL):    {ethTypes: []uint16{ethTypeIPv6}, ipProto: ipProtoICMPv6, icmpv6Types: []uint8{icmpv6NeighborSolicitation}},
+	basicField(OXM_FIELD_IPV6_ND_TLL):    {ethTypes: []uint16{ethTypeIPv6}, ipProto: ipProtoICMPv6, icmpv6Types: []uint8{icmpv6NeighborAdvertisement}},
+	basicField(OXM_FIELD_MPLS_LABEL):     {ethTypes: ethTypesMPLS, noMask: true, max: 0xfffff},
+	basicField(OXM_FIELD_MPLS_TC):        {ethTypes: ethTypesMPLS, noMask: true, max: 7},
+	basicField(OXM_FIELD_MPLS_BOS):       {ethTypes: ethTypesMPLS, noMask: true, max: 1},
+	basicField(OXM_FIELD_PBB_ISID):       {ethTypes: []uint16{ethTypePBB}, max: 0xffffff},
+	basicField(OXM_FIELD_IPV6_EXTHDR):    {ethTypes: []uint16{ethTypeIPv6}, max: 0x1ff},
+
+	nxmField(NXM_NX_IP_FRAG):       {ethTypes: ethTypesIP, max: 3},
+	nxmField(NXM_NX_IP_TTL):        {ethTypes: ethTypesIP, noMask: true},
+	nxmField(NXM_NX_MPLS_TTL):      {ethTypes: ethTypesMPLS, noMask: true},
+	nxmField(NXM_NX_CT_NW_SRC):     {ethTypes: []uint16{ethTypeIPv4}},
+	nxmField(NXM_NX_CT_NW_DST):     {ethTypes: []uint16{ethTypeIPv4}},
+	nxmField(NXM_NX_CT_IPV6_SRC):   {ethTypes: []uint16{ethTypeIPv6}},
+	nxmField(NXM_NX_CT_IPV6_DST):   {ethTypes: []uint16{ethTypeIPv6}},
+	nxmField(NXM_NX_CT_NW_PROTO):   {ethTypes: ethTypesIP, noMask: true},
+	nxmField(NXM_NX_TUN_GBP_FLAGS): {noMask: true},
+
+	nxm0Field(NXM_OF_IN_PORT): {noMask: true},
+	nxm0Field(NXM_OF_IP_TOS):  {ethTypes: ethTypesIP, noMask: true},
+}
+
+// MatchViolation is a match field that the switch would reject.
+type MatchViolation struct {
+	Class uint16
+	Field uint8
+	// Code is the ofp_bad_match_code the switch would return.
+	Code   uint16
+	Reason string
+}
+
+func (v MatchViolation) Error() string {
+	return fmt.Sprintf("match field %d of class %#x: %s", v.Field, v.Class, v.Reason)
+}
+
+// MatchError lists the violations found in a Match.
+type MatchError struct {
+	Violations []MatchViolation
+}
+
+func (e *MatchError) Error() string {
+	reasons := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		reasons[i] = v.Error()
+	}
+	return "invalid match: " + strings.Join(reasons, "; ")
+}
+
+// matchContext holds the values of the fields other fields depend on.
+type matchContext struct {
+	ethType     *uint16
+	ipProto     *uint8
+	icmpv6Type  *uint8
+	vlanPresent bool
+}
+
+// fieldBytes returns the marshaled value and mask of a match field.
+func fieldBytes(f *MatchField) (value, mask []byte, err error) {
+	if value, err = f.Value.MarshalBinary(); err != nil {
+		return
+	}
+	if f.HasMask && f.Mask != nil {
+		mask, err = f.Mask.MarshalBinary()
+	}
+	return
+}
+
+func bytesToUint(b []byte) (uint64, bool) {
+	if len(b) > 8 {
+		return 0, false
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, true
+}
+
+func (m *Match) context() (*matchContext, error) {
+	ctx := new(matchContext)
+	for i := range m.Fields {
+		f := &m.Fields[i]
+		key := canonicalKey(f.Class, f.Field)
+		switch key {
+		case basicField(OXM_FIELD_ETH_TYPE), basicField(OXM_FIELD_IP_PROTO), basicField(OXM_FIELD_ICMPV6_TYPE),
+			basicField(OXM_FIELD_VLAN_VID), nxm0Field(NXM_OF_VLAN_TCI):
+		default:
+			continue
+		}
+		value, mask, err := fieldBytes(f)
+		if err != nil {
+			return nil, err
+		}
+		v, _ := bytesToUint(value)
+		switch key {
+		case basicField(OXM_FIELD_ETH_TYPE):
+			ethType := uint16(v)
+			ctx.ethType = &ethType
+		case basicField(OXM_FIELD_IP_PROTO):
+			ipProto := uint8(v)
+			ctx.ipProto = &ipProto
+		case basicField(OXM_FIELD_ICMPV6_TYPE):
+			icmpv6Type := uint8(v)
+			ctx.icmpv6Type = &icmpv6Type
+		case basicField(OXM_FIELD_VLAN_VID), nxm0Field(NXM_OF_VLAN_TCI):
+			// The CFI bit of a TCI is set when a VLAN header is present.
+			presentBit := uint64(OFPVID_PRESENT)
+			if key.class == OXM_CLASS_NXM_0 {
+				presentBit = vlanTCIPresent
+			}
+			present := v&presentBit != 0
+			if mask != nil {
+				maskValue, _ := bytesToUint(mask)
+				present = present && maskValue&presentBit != 0
+			}
+			ctx.vlanPresent = present
+		}
+	}
+	return ctx, nil
+}
+
+func containsUint16(values []uint16, v uint16) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUint8(values []uint8, v uint8) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEthTypes(ethTypes []uint16) string {
+	s := make([]string, len(ethTypes))
+	for i, t := range ethTypes {
+		s[i] = fmt.Sprintf("%#04x", t)
+	}
+	return strings.Join(s, " or ")
+}
+
+// Validate checks that every field of the match has its prerequisites, a mask
+// only if the field is maskable, no value bits outside of its mask and a value
+// in range. It returns a *MatchError listing all violations, or nil.
+func (m *Match) Validate() error {
+	ctx, err := m.context()
+	if err != nil {
+		return err
+	}
+
+	var violations []MatchViolation
+	seen := make(map[matchFieldKey]bool, len(m.Fields))
+	for i := range m.Fields {
+		f := &m.Fields[i]
+		key := canonicalKey(f.Class, f.Field)
+		violate := func(code uint16, format string, args ...interface{}) {
+			violations = append(violations, MatchViolation{Class: f.Class, Field: f.Field, Code: code, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		// Experimenter fields are identified by their experimenter ID too.
+		if f.Class != OXM_CLASS_EXPERIMENTER {
+			if seen[key] {
+				violate(BMC_DUP_FIELD, "duplicate field")
+			}
+			seen[key] = true
+		}
+
+		rule, ok := matchFieldRules[key]
+		if !ok {
+			continue
+		}
+		switch {
+		case len(rule.ethTypes) == 0:
+		case ctx.ethType == nil:
+			violate(BMC_BAD_PREREQ, "requires eth_type %s", formatEthTypes(rule.ethTypes))
+		case !containsUint16(rule.ethTypes, *ctx.ethType):
+			violate(BMC_BAD_PREREQ, "requires eth_type %s, not %#04x", formatEthTypes(rule.ethTypes), *ctx.ethType)
+		}
+		switch {
+		case rule.ipProto == 0:
+		case ctx.ipProto == nil:
+			violate(BMC_BAD_PREREQ, "requires ip_proto %d", rule.ipProto)
+		case *ctx.ipProto != rule.ipProto:
+			violate(BMC_BAD_PREREQ, "requires ip_proto %d, not %d", rule.ipProto, *ctx.ipProto)
+		}
+		switch {
+		case len(rule.icmpv6Types) == 0:
+		case ctx.icmpv6Type == nil:
+			violate(BMC_BAD_PREREQ, "requires icmpv6_type %v", rule.icmpv6Types)
+		case !containsUint8(rule.icmpv6Types, *ctx.icmpv6Type):
+			violate(BMC_BAD_PREREQ, "requires icmpv6_type %v, not %d", rule.icmpv6Types, *ctx.icmpv6Type)
+		}
+		if rule.vlan && !ctx.vlanPresent {
+			violate(BMC_BAD_PREREQ, "requires a VLAN header")
+		}
+
+		value, mask, err := fieldBytes(f)
+		if err != nil {
+			return err
+		}
+		if f.HasMask {
+			if rule.noMask {
+				violate(BMC_BAD_MASK, "field cannot be masked")
+			} else if len(mask) == len(value) {
+				for j := range value {
+					if value[j]&^mask[j] != 0 {
+						violate(BMC_BAD_WILDCARDS, "value has bits set outside of the mask")
+						break
+					}
+				}
+			}
+		}
+		if rule.max != 0 {
+			if v, ok := bytesToUint(value); ok && v > rule.max {
+				violate(BMC_BAD_VALUE, "value %#x is larger than %#x", v, rule.max)
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return &MatchError{Violations: violations}
+	}
+	return nil
+}
+
+// Normalize inserts the prerequisite fields missing from the match, when they
+// can be derived from the other fields, then validates it. For example,
+// NewTcpDstField with NewIpv4SrcField gets eth_type=0x0800 and ip_proto=6. The
+// prerequisites are inserted before all other fields.
+//
+// A field valid for both IPv4 and IPv6, such as NewTcpDstField alone, does not
+// tell which eth_type to insert; use NormalizeEthType to choose it.
+func (m *Match) Normalize() error {
+	return m.normalize(0)
+}
+
+// NormalizeEthType is Normalize with ethType inserted as the eth_type when the
+// fields are valid for more than one eth_type, such as 0x0800 or 0x86dd for
+// NewTcpDstField. ethType is not inserted if no field requires an eth_type or
+// if the fields are not valid for it.
+func (m *Match) NormalizeEthType(ethType uint16) error {
+	return m.normalize(ethType)
+}
+
+func (m *Match) normalize(ethTypeHint uint16) error {
+	ctx, err := m.context()
+	if err != nil {
+		return err
+	}
+
+	var ethTypes []uint16
+	var ipProto uint8
+	var icmpv6Types []uint8
+	for i := range m.Fields {
+		rule := matchFieldRules[canonicalKey(m.Fields[i].Class, m.Fields[i].Field)]
+		if len(rule.ethTypes) > 0 {
+			ethTypes = intersectUint16(ethTypes, rule.ethTypes)
+		}
+		if rule.ipProto != 0 {
+			ipProto = rule.ipProto
+		}
+		if len(rule.icmpv6Types) > 0 {
+			icmpv6Types = intersectUint8(icmpv6Types, rule.icmpv6Types)
+		}
+	}
+
+	var prereqs []MatchField
+	if ctx.ethType == nil {
+		if len(ethTypes) == 1 {
+			prereqs = append(prereqs, *NewEthTypeField(ethTypes[0]))
+		} else if ethTypeHint != 0 && containsUint16(ethTypes, ethTypeHint) {
+			prereqs = append(prereqs, *NewEthTypeField(ethTypeHint))
+		}
+	}
+	if ctx.ipProto == nil && ipProto != 0 {
+		prereqs = append(prereqs, *NewIpProtoField(ipProto))
+	}
+	if ctx.icmpv6Type == nil && len(icmpv6Types) == 1 {
+		f := new(MatchField)
+		f.Class = OXM_CLASS_OPENFLOW_BASIC
+		f.Field = OXM_FIELD_ICMPV6_TYPE
+		f.Value = &IcmpTypeField{Type: icmpv6Types[0]}
+		f.Length = uint8(f.Value.Len())
+		prereqs = append(prereqs, *f)
+	}
+	if len(prereqs) > 0 {
+		fields := m.Fields
+		m.Fields = make([]MatchField, 0, len(prereqs)+len(fields))
+		m.Length = 4
+		for _, f := range append(prereqs, fields...) {
+			m.AddField(f)
+		}
+	}
+	return m.Validate()
+}
+
+// intersectUint16 returns the values of b that are in a. A nil a stands for
+// all values.
+func intersectUint16(a, b []uint16) []uint16 {
+	if a == nil {
+		return b
+	}
+	result := []uint16{}
+	for _, v := range b {
+		if containsUint16(a, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func intersectUint8(a, b []uint8) []uint8 {
+	if a == nil {
+		return b
+	}
+	result := []uint8{}
+	for _, v := range b {
+		if containsUint8(a, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
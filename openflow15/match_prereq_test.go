@@ -0,0 +1,164 @@
+package openflow15
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func violationCodes(t *testing.T, err error) []uint16 {
+	if err == nil {
+		return nil
+	}
+	matchErr, ok := err.(*MatchError)
+	require.True(t, ok, "unexpected error type %T", err)
+	var codes []uint16
+	for _, v := range matchErr.Violations {
+		codes = append(codes, v.Code)
+	}
+	return codes
+}
+
+func TestMatchValidate(t *testing.T) {
+	ipMask := net.ParseIP("255.255.255.0").To4()
+	vlanMask := uint16(OFPVID_PRESENT)
+	nxm0 := func(f *MatchField, field uint8) *MatchField {
+		f.Class = OXM_CLASS_NXM_0
+		f.Field = field
+		return f
+	}
+	for _, tc := range []struct {
+		name   string
+		fields []*MatchField
+		codes  []uint16
+	}{
+		{
+			name:   "ipv4 with eth_type",
+			fields: []*MatchField{NewEthTypeField(0x0800), NewIpv4SrcField(net.ParseIP("10.0.0.1"), nil)},
+		},
+		{
+			name:   "ipv4 without eth_type",
+			fields: []*MatchField{NewIpv4SrcField(net.ParseIP("10.0.0.1"), nil)},
+			codes:  []uint16{BMC_BAD_PREREQ},
+		},
+		{
+			name:   "ipv4 with arp eth_type",
+			fields: []*MatchField{NewEthTypeField(0x0806), NewIpv4DstField(net.ParseIP("10.0.0.1"), nil)},
+			codes:  []uint16{BMC_BAD_PREREQ},
+		},
+		{
+			name:   "tcp without ip_proto",
+			fields: []*MatchField{NewEthTypeField(0x0800), NewTcpDstField(80)},
+			codes:  []uint16{BMC_BAD_PREREQ},
+		},
+		{
+			name:   "tcp with udp ip_proto",
+			fields: []*MatchField{NewEthTypeField(0x86dd), NewIpProtoField(17), NewTcpDstField(80)},
+			codes:  []uint16{BMC_BAD_PREREQ},
+		},
+		{
+			name:   "tcp",
+			fields: []*MatchField{NewEthTypeField(0x86dd), NewIpProtoField(6), NewTcpDstField(80)},
+		},
+		{
+			name:   "vlan_pcp without vlan",
+			fields: []*MatchField{NewVlanPcpField(3)},
+			codes:  []uint16{BMC_BAD_PREREQ},
+		},
+		{
+			name:   "vlan_pcp with any vlan",
+			fields: []*MatchField{NewVlanIdField(0, &vlanMask), NewVlanPcpField(3)},
+		},
+		{
+			name:   "vlan_pcp out of range",
+			fields: []*MatchField{NewVlanIdField(10, nil), NewVlanPcpField(8)},
+			codes:  []uint16{BMC_BAD_VALUE},
+		},
+		{
+			name:   "bits outside of mask",
+			fields: []*MatchField{NewEthTypeField(0x0800), NewIpv4SrcField(net.ParseIP("10.0.0.1"), &ipMask)},
+			codes:  []uint16{BMC_BAD_WILDCARDS},
+		},
+		{
+			name:   "masked ip_dscp",
+			fields: []*MatchField{NewEthTypeField(0x0800), NewIpDscpField(1, new(uint8))},
+			codes:  []uint16{BMC_BAD_MASK},
+		},
+		{
+			name:   "duplicate field",
+			fields: []*MatchField{NewInPortField(1), NewInPortField(2)},
+			codes:  []uint16{BMC_DUP_FIELD},
+		},
+		{
+			name: "tcp with nxm eth_type and ip_proto",
+			fields: []*MatchField{
+				nxm0(NewEthTypeField(0x0800), NXM_OF_ETH_TYPE),
+				nxm0(NewIpProtoField(6), NXM_OF_IP_PROTO),
+				NewTcpDstField(80),
+			},
+		},
+		{
+			name:   "nxm and oxm eth_type",
+			fields: []*MatchField{NewEthTypeField(0x0800), nxm0(NewEthTypeField(0x0800), NXM_OF_ETH_TYPE)},
+			codes:  []uint16{BMC_DUP_FIELD},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMatch()
+			for _, f := range tc.fields {
+				m.AddField(*f)
+			}
+			assert.Equal(t, tc.codes, violationCodes(t, m.Validate()))
+		})
+	}
+}
+
+func TestMatchNormalize(t *testing.T) {
+	m := NewMatch()
+	m.AddField(*NewIpv4SrcField(net.ParseIP("10.0.0.1"), nil))
+	m.AddField(*NewTcpDstField(443))
+	require.NoError(t, m.Normalize())
+	require.Len(t, m.Fields, 4)
+	assert.Equal(t, uint8(OXM_FIELD_ETH_TYPE), m.Fields[0].Field)
+	assert.Equal(t, uint16(0x0800), m.Fields[0].Value.(*EthTypeField).EthType)
+	assert.Equal(t, uint8(OXM_FIELD_IP_PROTO), m.Fields[1].Field)
+	assert.Equal(t, uint8(6), m.Fields[1].Value.(*IpProtoField).Protocol)
+	require.NoError(t, checkMatchSerializationConsistency(m))
+
+	// Neighbor advertisement target link-layer address.
+	tll, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	m = NewMatch()
+	f := &MatchField{Class: OXM_CLASS_OPENFLOW_BASIC, Field: OXM_FIELD_IPV6_ND_TLL, Value: &EthDstField{EthDst: tll}}
+	f.Length = uint8(f.Value.Len())
+	m.AddField(*f)
+	require.NoError(t, m.Normalize())
+	require.Len(t, m.Fields, 4)
+	assert.Equal(t, uint16(0x86dd), m.Fields[0].Value.(*EthTypeField).EthType)
+	assert.Equal(t, uint8(58), m.Fields[1].Value.(*IpProtoField).Protocol)
+	assert.Equal(t, uint8(136), m.Fields[2].Value.(*IcmpTypeField).Type)
+
+	// IPv4 or IPv6 cannot be guessed from a TCP port alone, neither for the
+	// port nor for the inserted ip_proto.
+	m = NewMatch()
+	m.AddField(*NewTcpSrcField(22))
+	err := m.Normalize()
+	assert.Equal(t, []uint16{BMC_BAD_PREREQ, BMC_BAD_PREREQ}, violationCodes(t, err))
+	require.Len(t, m.Fields, 2)
+	assert.Equal(t, uint8(OXM_FIELD_IP_PROTO), m.Fields[0].Field)
+
+	// The eth_type is chosen by the caller.
+	m = NewMatch()
+	m.AddField(*NewTcpSrcField(22))
+	require.NoError(t, m.NormalizeEthType(0x86dd))
+	require.Len(t, m.Fields, 3)
+	assert.Equal(t, uint16(0x86dd), m.Fields[0].Value.(*EthTypeField).EthType)
+	assert.Equal(t, uint8(6), m.Fields[1].Value.(*IpProtoField).Protocol)
+
+	// It is not inserted when the fields require another eth_type.
+	m = NewMatch()
+	m.AddField(*NewIpv4SrcField(net.ParseIP("10.0.0.1"), nil))
+	require.NoError(t, m.NormalizeEthType(0x86dd))
+	assert.Equal(t, uint16(0x0800), m.Fields[0].Value.(*EthTypeField).EthType)
+}
@@ -51,3 +51,33 @@ func checkMatchSerializationConsistency(ofMatch *Match) error {
 
 	return nil
 }
+
+func TestMatchICMPAndFragments(t *testing.T) {
+	ofMatch := NewMatch()
+	ofMatch.AddField(*NewEthTypeField(0x86dd))
+	ofMatch.AddField(*NewIpProtoField(58))
+	fragMask := uint8(NX_IP_FRAG_ANY | NX_IP_FRAG_LATER)
+	ofMatch.AddField(*NewIPFragMatchField(NX_IP_FRAG_ANY, &fragMask))
+	ofMatch.AddField(*NewIcmpv6TypeField(135))
+	ofMatch.AddField(*NewIcmpv6CodeField(0))
+	ofMatch.AddField(*NewIpv6NDTargetField(net.ParseIP("2001:db8::1")))
+
+	if err := ofMatch.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkMatchSerializationConsistency(ofMatch); err != nil {
+		t.Fatal(err)
+	}
+
+	ofMatch = NewMatch()
+	ofMatch.AddField(*NewEthTypeField(0x0800))
+	ofMatch.AddField(*NewIpProtoField(1))
+	ofMatch.AddField(*NewIcmpTypeField(8))
+	ofMatch.AddField(*NewIcmpCodeField(0))
+	if err := ofMatch.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkMatchSerializationConsistency(ofMatch); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,6 +4,7 @@ package openflow15
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"k8s.io/klog/v2"
@@ -81,6 +82,9 @@ func (m *MeterBandHeader) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MeterBandHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < METER_BAND_HEADER_LEN {
+		return errors.New("The []byte is too short to unmarshal a full MeterBandHeader message.")
+	}
 	n := 0
 	m.Type = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -123,6 +127,9 @@ func (m *MeterBandDrop) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MeterBandDrop) UnmarshalBinary(data []byte) error {
+	if len(data) < METER_BAND_LEN {
+		return errors.New("The []byte is too short to unmarshal a full MeterBandDrop message.")
+	}
 	n := 0
 	m.MeterBandHeader.UnmarshalBinary(data[n:])
 	n += int(m.MeterBandHeader.Len())
@@ -162,6 +169,9 @@ func (m *MeterBandDSCP) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MeterBandDSCP) UnmarshalBinary(data []byte) error {
+	if len(data) < METER_BAND_LEN {
+		return errors.New("The []byte is too short to unmarshal a full MeterBandDSCP message.")
+	}
 	n := 0
 	err := m.MeterBandHeader.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -196,6 +206,9 @@ func (m *MeterBandExperimenter) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MeterBandExperimenter) UnmarshalBinary(data []byte) error {
+	if len(data) < METER_BAND_LEN {
+		return errors.New("The []byte is too short to unmarshal a full MeterBandExperimenter message.")
+	}
 	n := 0
 	err := m.MeterBandHeader.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -276,8 +289,16 @@ func (m *MeterMod) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MeterMod) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full MeterMod message.")
+	}
 	n := 0
-	m.Header.UnmarshalBinary(data[n:])
+	if err := m.Header.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
+	if int(m.Header.Length) > len(data) {
+		return errors.New("The []byte is too short to unmarshal a full MeterMod message.")
+	}
 	n += int(m.Header.Len())
 
 	m.Command = binary.BigEndian.Uint16(data[n:])
@@ -287,7 +308,11 @@ func (m *MeterMod) UnmarshalBinary(data []byte) error {
 	m.MeterId = binary.BigEndian.Uint32(data[n:])
 	n += 4
 
+	m.MeterBands = nil
 	for n < int(m.Header.Length) {
+		if n+METER_BAND_LEN > int(m.Header.Length) {
+			return errors.New("The []byte is too short to unmarshal a full meter band.")
+		}
 		mbh := new(MeterBandHeader)
 		err := mbh.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -306,6 +331,19 @@ func (m *MeterMod) UnmarshalBinary(data []byte) error {
 			mbDscp.PrecLevel = data[n]
 			m.MeterBands = append(m.MeterBands, mbDscp)
 		case MBT_EXPERIMENTER:
+			if band := newExperimenterMeterBand(data[n-METER_BAND_HEADER_LEN:]); band != nil {
+				end := n - METER_BAND_HEADER_LEN + int(mbh.Length)
+				if int(mbh.Length) < METER_BAND_LEN || end > int(m.Header.Length) {
+					return fmt.Errorf("The meter band length %d is invalid for %d bytes of data.", mbh.Length, int(m.Header.Length)-n+METER_BAND_HEADER_LEN)
+				}
+				if err := band.UnmarshalBinary(data[n-METER_BAND_HEADER_LEN : end]); err != nil {
+					return err
+				}
+				m.MeterBands = append(m.MeterBands, band)
+				// The last 4 bytes are skipped below, as for the other bands.
+				n = end - 4
+				break
+			}
 			mbExp := new(MeterBandExperimenter)
 			mbExp.MeterBandHeader = *mbh
 			mbExp.Experimenter = binary.BigEndian.Uint32(data[n:])
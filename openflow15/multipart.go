@@ -57,10 +57,17 @@ func (s *MultipartRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *MultipartRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full MultipartRequest message.")
+	}
 	err := s.Header.UnmarshalBinary(data)
 	if err != nil {
 		return err
 	}
+	if int(s.Header.Length) > len(data) {
+		return fmt.Errorf("The MultipartRequest length %d is invalid for %d bytes of data.", s.Header.Length, len(data))
+	}
+	data = data[:s.Header.Length]
 	n := s.Header.Len()
 
 	s.Type = binary.BigEndian.Uint16(data[n:])
@@ -131,15 +138,18 @@ func (s *MultipartRequest) UnmarshalBinary(data []byte) error {
 		case MultipartType_Experimenter:
 		}
 
-		if req != nil {
-			err = req.UnmarshalBinary(data[n:])
-			if err != nil {
-				klog.ErrorS(err, "Failed to unmarshal MultipartRequest's Body", "data", data[n:])
-				return err
-			}
-			n += req.Len()
-			s.Body = append(s.Body, req)
+		if req == nil {
+			// Keep the body of experimenter requests, and of the requests
+			// which have none, as is.
+			req = new(util.BufferUnknown)
+		}
+		err = req.UnmarshalBinary(data[n:])
+		if err != nil {
+			klog.ErrorS(err, "Failed to unmarshal MultipartRequest's Body", "data", data[n:])
+			return err
 		}
+		n += req.Len()
+		s.Body = append(s.Body, req)
 	}
 	return nil
 }
@@ -184,10 +194,17 @@ func (s *MultipartReply) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *MultipartReply) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full MultipartReply message.")
+	}
 	err := s.Header.UnmarshalBinary(data)
 	if err != nil {
 		return err
 	}
+	if int(s.Header.Length) > len(data) {
+		return fmt.Errorf("The MultipartReply length %d is invalid for %d bytes of data.", s.Header.Length, len(data))
+	}
+	data = data[:s.Header.Length]
 	n := s.Header.Len()
 	s.Type = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -249,6 +266,9 @@ func (s *MultipartReply) UnmarshalBinary(data []byte) error {
 		case MultipartType_FlowMonitor:
 			// The reply body is an array of struct ofp_flow_update_header.
 			// switch on event
+			if len(data) < int(n)+4 {
+				return errors.New("The []byte is too short to unmarshal a full FlowUpdateHeader message.")
+			}
 			switch binary.BigEndian.Uint16(data[n+2:]) {
 			case FME_INITIAL:
 				repl = NewFlowUpdateFull(FME_INITIAL)
@@ -281,14 +301,15 @@ func (s *MultipartReply) UnmarshalBinary(data []byte) error {
 			break
 		}
 
+		if repl == nil {
+			// Keep the body of experimenter replies as is.
+			repl = new(util.BufferUnknown)
+		}
 		err = repl.UnmarshalBinary(data[n:])
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal MultipartReply's Body", "data", data[n:])
 			return err
 		}
-		if repl == nil {
-			return fmt.Errorf("reply structure is nil in MultipartReply UnmarshalBinary")
-		}
 		n += repl.Len()
 		req = append(req, repl)
 	}
@@ -476,6 +497,9 @@ func (s *DescStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *DescStats) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full DescStats message.")
+	}
 	n := 0
 	copy(s.MfrDesc, data[n:])
 	n += len(s.MfrDesc)
@@ -551,6 +575,9 @@ func (s *FlowStatsRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *FlowStatsRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 32 {
+		return errors.New("The []byte is too short to unmarshal a full FlowStatsRequest message.")
+	}
 	n := 0
 	s.TableId = data[n]
 	n += 1
@@ -637,8 +664,15 @@ func (s *FlowStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *FlowStats) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full FlowStats message.")
+	}
 	var n uint16
 	s.Length = binary.BigEndian.Uint16(data[n:])
+	if s.Length < 8 || int(s.Length) > len(data) {
+		return fmt.Errorf("The FlowStats length %d is invalid for %d bytes of data.", s.Length, len(data))
+	}
+	data = data[:s.Length]
 	n += 2
 	n += 2 // Pad2
 	s.TableId = data[n]
@@ -654,6 +688,7 @@ func (s *FlowStats) UnmarshalBinary(data []byte) error {
 	}
 	n += s.Match.Len()
 
+	s.Stats = nil
 	for n < s.Length {
 		stat := new(Stats)
 		err = stat.UnmarshalBinary(data[n:])
@@ -718,6 +753,9 @@ func (s *AggregateStatsRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *AggregateStatsRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 32 {
+		return errors.New("The []byte is too short to unmarshal a full AggregateStatsRequest message.")
+	}
 	n := 0
 	s.TableId = data[n]
 	n += 1
@@ -798,6 +836,9 @@ func (s *AggregateStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *AggregateStats) UnmarshalBinary(data []byte) error {
+	if len(data) < 20 {
+		return errors.New("The []byte is too short to unmarshal a full AggregateStats message.")
+	}
 	n := 0
 	s.PacketCount = binary.BigEndian.Uint64(data[n:])
 	n += 8
@@ -844,6 +885,9 @@ func (s *TableStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *TableStats) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full TableStats message.")
+	}
 	n := 0
 	s.TableId = data[0]
 	n += 1
@@ -885,6 +929,9 @@ func (s *PortMultipartRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *PortMultipartRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full PortMultipartRequest message.")
+	}
 	s.PortNo = binary.BigEndian.Uint32(data)
 	return nil
 }
@@ -966,6 +1013,9 @@ func (s *PortStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *PortStats) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 80 {
+		return errors.New("The []byte is too short to unmarshal a full PortStats message.")
+	}
 	var n uint16
 	s.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -994,6 +1044,9 @@ func (s *PortStats) UnmarshalBinary(data []byte) (err error) {
 	n += 8
 
 	for n < s.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case PSPT_ETHERNET:
@@ -1001,10 +1054,11 @@ func (s *PortStats) UnmarshalBinary(data []byte) (err error) {
 		case PSPT_OPTICAL:
 			p = new(PortStatsPropOptical)
 		case PSPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -1071,6 +1125,9 @@ func (prop *PortStatsPropEthernet) MarshalBinary() (data []byte, err error) {
 }
 
 func (prop *PortStatsPropEthernet) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(prop.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full PortStatsPropEthernet message.")
+	}
 	var n uint16
 	err = prop.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -1169,6 +1226,9 @@ func (prop *PortStatsPropOptical) MarshalBinary() (data []byte, err error) {
 }
 
 func (prop *PortStatsPropOptical) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(prop.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full PortStatsPropOptical message.")
+	}
 	var n uint16
 	err = prop.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -1229,6 +1289,9 @@ func (s *QueueMultipartRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *QueueMultipartRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full QueueMultipartRequest message.")
+	}
 	n := 0
 	s.PortNo = binary.BigEndian.Uint32(data[n:])
 	n += 4
@@ -1305,6 +1368,9 @@ func (s *QueueStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *QueueStats) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 48 {
+		return errors.New("The []byte is too short to unmarshal a full QueueStats message.")
+	}
 	var n uint16
 
 	s.Length = binary.BigEndian.Uint16(data[n:])
@@ -1326,13 +1392,17 @@ func (s *QueueStats) UnmarshalBinary(data []byte) (err error) {
 	n += 4
 
 	for n < s.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case QSPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -1518,7 +1588,7 @@ func (p *InstructionProperty) UnmarshalBinary(data []byte) error {
 	p.Instructions = make([]InstructionId, 0)
 	for n < int(p.Length) {
 		instr := new(InstructionId)
-		err := instr.UnmarshalBinary(data[n : n+4])
+		err := instr.UnmarshalBinary(data[n:p.Length])
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal InstructionProperty's Instructions", "data", data[n:])
 			return err
@@ -1563,14 +1633,20 @@ func (i *InstructionId) MarshalBinary() (data []byte, err error) {
 }
 
 func (i *InstructionId) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full InstructionId message.")
+	}
 	n := 0
 	i.Type = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	i.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	if i.Length < 4 || int(i.Length) > len(data) {
+		return fmt.Errorf("The InstructionId length %d is invalid for %d bytes of data.", i.Length, len(data))
+	}
 
 	i.Data = make([]byte, i.Length-4)
-	copy(i.Data, data[n:])
+	copy(i.Data, data[n:i.Length])
 
 	return
 }
@@ -1679,7 +1755,7 @@ func (p *ActionProperty) UnmarshalBinary(data []byte) error {
 	p.Actions = make([]ActionId, 0)
 	for n < int(p.Length) {
 		act := new(ActionId)
-		err := act.UnmarshalBinary(data[n:])
+		err := act.UnmarshalBinary(data[n:p.Length])
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal ActionProperty's Actions", "data", data[n:])
 			return err
@@ -1724,14 +1800,20 @@ func (a *ActionId) MarshalBinary() (data []byte, err error) {
 }
 
 func (a *ActionId) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full ActionId message.")
+	}
 	n := 0
 	a.Type = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	a.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	if a.Length < 4 || int(a.Length) > len(data) {
+		return fmt.Errorf("The ActionId length %d is invalid for %d bytes of data.", a.Length, len(data))
+	}
 
 	a.Data = make([]byte, a.Length-4)
-	copy(a.Data, data[n:])
+	copy(a.Data, data[n:a.Length])
 
 	return
 }
@@ -1779,7 +1861,7 @@ func (p *SetFieldProperty) UnmarshalBinary(data []byte) error {
 	}
 	n += 4
 	p.IDs = make([]uint32, 0)
-	for n < int(p.Length) {
+	for n+4 <= int(p.Length) {
 		p.IDs = append(p.IDs, binary.BigEndian.Uint32(data[n:]))
 		n += 4
 	}
@@ -1821,9 +1903,12 @@ func (p *SetFieldPacketTypes) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	p.OFTablePropertyHeader = *header
+	if len(data) < int(p.Length) {
+		return fmt.Errorf("the []byte is too short to unmarshal a full SetFieldPacketTypes message")
+	}
 	n += 4
 	p.OXMs = make([]uint32, 0)
-	for n < int(p.Length) {
+	for n+4 <= int(p.Length) {
 		p.OXMs = append(p.OXMs, binary.BigEndian.Uint32(data[n:]))
 		n += 4
 	}
@@ -1862,8 +1947,8 @@ func (p *TableExperimenterProperty) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *TableExperimenterProperty) UnmarshalBinary(data []byte) error {
-	if len(data) < 4 {
-		return fmt.Errorf("the []byte is too short to unmarshal OFTablePropertyHeader message")
+	if len(data) < 12 {
+		return errors.New("The []byte is too short to unmarshal a full TableExperimenterProperty message.")
 	}
 	n := 0
 	header := new(OFTablePropertyHeader)
@@ -1881,7 +1966,7 @@ func (p *TableExperimenterProperty) UnmarshalBinary(data []byte) error {
 	p.ExperimenterType = binary.BigEndian.Uint32(data[n:])
 	n += 4
 	p.ExperimenterData = make([]uint32, 0)
-	for n < int(p.Length) {
+	for n+4 <= int(p.Length) {
 		p.ExperimenterData = append(p.ExperimenterData, binary.BigEndian.Uint32(data[n:]))
 		n += 4
 	}
@@ -1966,14 +2051,17 @@ func (f *TableFeatures) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *TableFeatures) UnmarshalBinary(data []byte) error {
-	if len(data) < 2 {
-		return fmt.Errorf("the []byte is too short to unmarshal TableFeatures message Length")
+	if len(data) < 64 {
+		return errors.New("The []byte is too short to unmarshal a full TableFeatures message.")
 	}
 	n := 0
 	f.Length = binary.BigEndian.Uint16(data[n:])
 	if len(data) < int(f.Length) {
 		return fmt.Errorf("the []byte is too short to unmarshal a full TableFeatures message")
 	}
+	if f.Length < 64 {
+		return fmt.Errorf("the TableFeatures length %d is shorter than its fixed fields", f.Length)
+	}
 	n += 2
 	f.TableID = data[n]
 	n += 1
@@ -1994,6 +2082,9 @@ func (f *TableFeatures) UnmarshalBinary(data []byte) error {
 	n += 4
 	f.Properties = make([]util.Message, 0)
 	for n < int(f.Length) {
+		if int(f.Length) < n+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		t := binary.BigEndian.Uint16(data[n:])
 		var p util.Message
 		switch t {
@@ -2038,9 +2129,13 @@ func (f *TableFeatures) UnmarshalBinary(data []byte) error {
 		case TFPT_EXPERIMENTER:
 			fallthrough
 		case TFPT_EXPERIMENTER_MISS:
-			p = new(TableExperimenterProperty)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(TableExperimenterProperty)
+			}
+		default:
+			p = new(PropUnknown)
 		}
-		err := p.UnmarshalBinary(data[n:])
+		err := p.UnmarshalBinary(data[n:f.Length])
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal TableFeatures's Properties", "data", data[n:])
 			return err
@@ -2143,8 +2238,15 @@ func (f *FlowDesc) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *FlowDesc) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full FlowDesc message.")
+	}
 	var n uint16
 	f.Length = binary.BigEndian.Uint16(data[n:])
+	if f.Length < 24 || int(f.Length) > len(data) {
+		return fmt.Errorf("The FlowDesc length %d is invalid for %d bytes of data.", f.Length, len(data))
+	}
+	data = data[:f.Length]
 	n += 2
 	n += 2 // Pad
 
@@ -2178,6 +2280,9 @@ func (f *FlowDesc) UnmarshalBinary(data []byte) (err error) {
 	m_len := f.Match.Len()
 	klog.V(7).InfoS("Match Len", "value", m_len)
 	n += m_len
+	if int(n) > len(data) {
+		return errors.New("The []byte is too short to unmarshal a full FlowDesc message.")
+	}
 
 	klog.V(7).InfoS("Data passed to Stats UnmarshalBinary", "data", data[n:])
 	err = f.Stats.UnmarshalBinary(data[n:])
@@ -2187,6 +2292,7 @@ func (f *FlowDesc) UnmarshalBinary(data []byte) (err error) {
 	}
 	n += f.Stats.Len()
 
+	f.Instructions = nil
 	for n < f.Length {
 		i, err := DecodeInstr(data[n:])
 		if err != nil {
@@ -2223,6 +2329,9 @@ func (s *GroupMultipartRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *GroupMultipartRequest) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full GroupMultipartRequest message.")
+	}
 	s.GroupId = binary.BigEndian.Uint32(data)
 	return
 }
@@ -2296,6 +2405,9 @@ func (g *GroupStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (g *GroupStats) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 40 {
+		return errors.New("The []byte is too short to unmarshal a full GroupStats message.")
+	}
 	var n uint16
 	g.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -2360,6 +2472,9 @@ func (g *BucketCounter) MarshalBinary() (data []byte, err error) {
 }
 
 func (g *BucketCounter) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full BucketCounter message.")
+	}
 	var n uint16
 
 	g.PacketCount = binary.BigEndian.Uint64(data[n:])
@@ -2443,6 +2558,9 @@ func (g *GroupDesc) MarshalBinary() (data []byte, err error) {
 }
 
 func (g *GroupDesc) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full GroupDesc message.")
+	}
 	var n uint16
 	g.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -2468,13 +2586,17 @@ func (g *GroupDesc) UnmarshalBinary(data []byte) (err error) {
 	}
 
 	for n < g.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case GPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -2535,6 +2657,9 @@ func (g *GroupFeatures) MarshalBinary() (data []byte, err error) {
 }
 
 func (g *GroupFeatures) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 40 {
+		return errors.New("The []byte is too short to unmarshal a full GroupFeatures message.")
+	}
 	var n uint16
 
 	g.Types = binary.BigEndian.Uint32(data[n:])
@@ -2577,6 +2702,9 @@ func (m *MeterMultipartRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MeterMultipartRequest) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full MeterMultipartRequest message.")
+	}
 	m.MeterId = binary.BigEndian.Uint32(data)
 	return
 }
@@ -2647,6 +2775,9 @@ func (m *MeterStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MeterStats) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 40 {
+		return errors.New("The []byte is too short to unmarshal a full MeterStats message.")
+	}
 	var n uint16
 
 	m.MeterId = binary.BigEndian.Uint32(data[n:])
@@ -2705,6 +2836,9 @@ func (m *MeterBandStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MeterBandStats) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full MeterBandStats message.")
+	}
 	var n uint16
 
 	m.PacketBandCount = binary.BigEndian.Uint64(data[n:])
@@ -2765,16 +2899,27 @@ func (m *MeterDesc) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MeterDesc) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full MeterDesc message.")
+	}
 	var n uint16
 
 	m.Length = binary.BigEndian.Uint16(data[n:])
+	if m.Length < 8 || int(m.Length) > len(data) {
+		return fmt.Errorf("The MeterDesc length %d is invalid for %d bytes of data.", m.Length, len(data))
+	}
+	data = data[:m.Length]
 	n += 2
 	m.Flags = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	m.MeterId = binary.BigEndian.Uint32(data[n:])
 	n += 4
 
+	m.Bands = nil
 	for n < m.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full meter band.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case MBT_DROP:
@@ -2782,8 +2927,11 @@ func (m *MeterDesc) UnmarshalBinary(data []byte) (err error) {
 		case MBT_DSCP_REMARK:
 			p = new(MeterBandDSCP)
 		case MBT_EXPERIMENTER:
-			p = new(MeterBandExperimenter)
+			if p = newExperimenterMeterBand(data[n:]); p == nil {
+				p = new(MeterBandExperimenter)
+			}
 		default:
+			return fmt.Errorf("Unknown MeterDesc band type %d", binary.BigEndian.Uint16(data[n:]))
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -2848,6 +2996,9 @@ func (m *MeterFeatures) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MeterFeatures) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 20 {
+		return errors.New("The []byte is too short to unmarshal a full MeterFeatures message.")
+	}
 	var n uint16
 
 	m.MaxMeter = binary.BigEndian.Uint32(data[n:])
@@ -2921,6 +3072,9 @@ func (q *QueueDesc) MarshalBinary() (data []byte, err error) {
 }
 
 func (q *QueueDesc) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full QueueDesc message.")
+	}
 	var n uint16
 
 	q.PortNo = binary.BigEndian.Uint32(data[n:])
@@ -2933,6 +3087,9 @@ func (q *QueueDesc) UnmarshalBinary(data []byte) (err error) {
 	n += 6 // Pad
 
 	for n < q.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case QDPT_MIN_RATE:
@@ -2940,10 +3097,11 @@ func (q *QueueDesc) UnmarshalBinary(data []byte) (err error) {
 		case QDPT_MAX_RATE:
 			p = new(QueueDescPropMaxRate)
 		case QDPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -3013,6 +3171,9 @@ func (prop *QueueDescPropRate) MarshalBinary() (data []byte, err error) {
 }
 
 func (prop *QueueDescPropRate) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(prop.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full QueueDescPropRate message.")
+	}
 	var n uint16
 	err = prop.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -3081,6 +3242,9 @@ func (mon *FlowMonitorRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (mon *FlowMonitorRequest) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full FlowMonitorRequest message.")
+	}
 	var n uint16
 	mon.MonitorId = binary.BigEndian.Uint32(data[n:])
 	n += 4
@@ -3158,6 +3322,9 @@ func (f *FlowUpdateHeader) MarshalBinary() (data []byte, err error) {
 }
 
 func (f *FlowUpdateHeader) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full FlowUpdateHeader message.")
+	}
 	var n uint16
 	f.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -3247,11 +3414,18 @@ func (full *FlowUpdateFull) MarshalBinary() (data []byte, err error) {
 }
 
 func (full *FlowUpdateFull) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full FlowUpdateFull message.")
+	}
 	var n uint16
 	err = full.FlowUpdateHeader.UnmarshalBinary(data)
 	if err != nil {
 		return
 	}
+	if full.FlowUpdateHeader.Length < 24 || int(full.FlowUpdateHeader.Length) > len(data) {
+		return fmt.Errorf("The FlowUpdateFull length %d is invalid for %d bytes of data.", full.FlowUpdateHeader.Length, len(data))
+	}
+	data = data[:full.FlowUpdateHeader.Length]
 	n = full.FlowUpdateHeader.Len()
 	full.TableId = data[n]
 	n++
@@ -3274,6 +3448,10 @@ func (full *FlowUpdateFull) UnmarshalBinary(data []byte) (err error) {
 		return
 	}
 	n += full.Match.Len()
+	if int(n) > len(data) {
+		return errors.New("The []byte is too short to unmarshal a full FlowUpdateFull message.")
+	}
+	full.Instructions = nil
 	for n < full.FlowUpdateHeader.Length {
 		i, err := DecodeInstr(data[n:])
 		if err != nil {
@@ -3317,6 +3495,9 @@ func (abbr *FlowUpdateAbbrev) MarshalBinary() (data []byte, err error) {
 }
 
 func (abbr *FlowUpdateAbbrev) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full FlowUpdateAbbrev message.")
+	}
 	err = abbr.FlowUpdateHeader.UnmarshalBinary(data)
 	if err != nil {
 		return
@@ -3356,6 +3537,9 @@ func (pause *FlowUpdatePaused) MarshalBinary() (data []byte, err error) {
 }
 
 func (pause *FlowUpdatePaused) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(pause.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full FlowUpdatePaused message.")
+	}
 	err = pause.FlowUpdateHeader.UnmarshalBinary(data)
 	if err != nil {
 		return
@@ -3403,21 +3587,28 @@ func (b *BundleFeaturesRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (b *BundleFeaturesRequest) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full BundleFeaturesRequest message.")
+	}
 	var n uint16
 	b.FeaturesRequestFlag = binary.BigEndian.Uint32(data[n:])
 	n += 4
 	n += 4 // Pad
 
 	for n < uint16(len(data)) {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case TMPBF_TIME_CAPABILITY:
 			p = new(BundleFeaturesPropTime)
 		case TMPBF_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -3508,6 +3699,9 @@ func (prop *BundleFeaturesPropTime) MarshalBinary() (data []byte, err error) {
 }
 
 func (prop *BundleFeaturesPropTime) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(prop.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full BundleFeaturesPropTime message.")
+	}
 	var n uint16
 	err = prop.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -3590,21 +3784,28 @@ func (b *BundleFeatures) MarshalBinary() (data []byte, err error) {
 }
 
 func (b *BundleFeatures) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full BundleFeatures message.")
+	}
 	var n uint16
 	b.Capabilities = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	n += 6 // Pad
 
 	for n < uint16(len(data)) {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case TMPBF_TIME_CAPABILITY:
 			p = new(BundleFeaturesPropTime)
 		case TMPBF_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -3,7 +3,6 @@ package openflow15
 import (
 	"encoding/binary"
 	"errors"
-	"fmt"
 	"net"
 
 	"k8s.io/klog/v2"
@@ -186,15 +185,10 @@ func DecodeNxAction(data []byte) (Action, error) {
 		a = new(NXActionEncap)
 	case NXAST_RAW_DECAP:
 	case NXAST_DEC_NSH_TTL:
-	default:
-		err := fmt.Errorf("unknown NXActionHeader subtype: %v", subtype)
-		klog.ErrorS(err, "Received invalid NXActionHeader", "data", data)
-		return nil, err
 	}
 
 	if a == nil {
-		err := fmt.Errorf("unsupported subtype: %v", subtype)
-		return nil, err
+		a = new(ActionUnknown)
 	}
 
 	return a, nil
@@ -245,8 +239,11 @@ func (a *NXActionConjunction) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionConjunction message")
 	}
 	a.Clause = uint8(data[n])
@@ -310,8 +307,11 @@ func (a *NXActionConnTrack) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 24 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionConnTrack message")
 	}
 	a.Flags = binary.BigEndian.Uint16(data[n:])
@@ -327,8 +327,9 @@ func (a *NXActionConnTrack) UnmarshalBinary(data []byte) error {
 	a.Alg = binary.BigEndian.Uint16(data[n:])
 	n += 2
 
+	a.Actions = nil
 	for n < int(a.Len()) {
-		act, err := DecodeAction(data[n:])
+		act, err := DecodeAction(data[n:a.Length])
 		if err != nil {
 			klog.ErrorS(err, "Failed to decode NXActionConnTrack Actions", "data", data[n:])
 			return err
@@ -431,7 +432,7 @@ func (a *NXActionRegLoad) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 24 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionRegLoad message")
 	}
 	a.OfsNbits = binary.BigEndian.Uint16(data[n:])
@@ -504,7 +505,7 @@ func (a *NXActionRegMove) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || a.Length < 24 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionRegMove message")
 	}
 	a.Nbits = binary.BigEndian.Uint16(data[n:])
@@ -571,9 +572,12 @@ func (a *NXActionResubmit) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
-		return errors.New("the []byte is too short to unmarshal a full NXActionConjunction message")
+	if len(data) < int(a.Len()) || a.Len() < 16 {
+		return errors.New("the []byte is too short to unmarshal a full NXActionResubmit message")
 	}
 	a.InPort = binary.BigEndian.Uint16(data[n:])
 
@@ -646,10 +650,14 @@ func (a *NXActionResubmitTable) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionResubmitTable message")
 	}
+	a.withCT = a.Subtype == NXAST_CT_RESUBMIT
 	a.InPort = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	a.TableID = data[n]
@@ -815,12 +823,39 @@ func (a *NXActionCTNAT) SetRangeProtoMax(protoMax *uint16) {
 	a.Length += 2
 }
 
+// natRangeLen returns the length of the NAT ranges present in rangePresent.
+func natRangeLen(rangePresent uint16) int {
+	n := 0
+	if rangePresent&NX_NAT_RANGE_IPV4_MIN != 0 {
+		n += 4
+	}
+	if rangePresent&NX_NAT_RANGE_IPV4_MAX != 0 {
+		n += 4
+	}
+	if rangePresent&NX_NAT_RANGE_IPV6_MIN != 0 {
+		n += 16
+	}
+	if rangePresent&NX_NAT_RANGE_IPV6_MAX != 0 {
+		n += 16
+	}
+	if rangePresent&NX_NAT_RANGE_PROTO_MIN != 0 {
+		n += 2
+	}
+	if rangePresent&NX_NAT_RANGE_PROTO_MAX != 0 {
+		n += 2
+	}
+	return n
+}
+
 func (a *NXActionCTNAT) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionCTNAT message")
 	}
 	// Skip padding bytes
@@ -829,6 +864,9 @@ func (a *NXActionCTNAT) UnmarshalBinary(data []byte) error {
 	n += 2
 	a.RangePresent = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	if int(a.Length) < n+natRangeLen(a.RangePresent) {
+		return errors.New("the []byte is too short to unmarshal the ranges of NXActionCTNAT")
+	}
 	if a.RangePresent&NX_NAT_RANGE_IPV4_MIN != 0 {
 		a.RangeIPv4Min = net.IPv4(data[n], data[n+1], data[n+2], data[n+3])
 		n += 4
@@ -902,7 +940,7 @@ func (a *NXActionOutputReg) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 24 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionOutputReg message")
 	}
 	a.OfsNbits = binary.BigEndian.Uint16(data[n:])
@@ -970,8 +1008,11 @@ func (a *NXActionDecTTL) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionDecTTL message")
 	}
 	a.controllers = binary.BigEndian.Uint16(data[n:])
@@ -1023,14 +1064,21 @@ func (a *NXActionDecTTLCntIDs) UnmarshalBinary(data []byte) error {
 	n := 0
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if len(data) < int(a.Len()) || a.Len() < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionDecTTLCntIDs message")
 	}
 	a.controllers = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	a.zeros = [4]uint8{}
 	n += 4
+	if int(a.Length) < n+2*int(a.controllers) {
+		return errors.New("the []byte is too short to unmarshal the controller IDs of NXActionDecTTLCntIDs")
+	}
+	a.cntIDs = nil
 	for i := 0; i < int(a.controllers); i++ {
 		id := binary.BigEndian.Uint16(data[n:])
 		a.cntIDs = append(a.cntIDs, id)
@@ -1217,6 +1265,9 @@ func (s *NXLearnSpec) UnmarshalBinary(data []byte) error {
 	n := s.Header.Len()
 	if s.Header.Src {
 		srcDataLength := 2 * ((s.Header.NBits + 15) / 16)
+		if len(data) < int(n+srcDataLength) {
+			return errors.New("the []byte is too short to unmarshal the value of NXLearnSpec")
+		}
 		s.SrcValue = data[n : n+srcDataLength]
 		n += srcDataLength
 	} else {
@@ -1304,7 +1355,7 @@ func (a *NXActionLearn) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || a.Length < 32 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionLearn message")
 	}
 	n += int(a.NXActionHeader.Len())
@@ -1324,12 +1375,13 @@ func (a *NXActionLearn) UnmarshalBinary(data []byte) error {
 	n += 2
 	a.FinHardTimeout = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	a.LearnSpecs = nil
 	for n < int(a.Length) {
 		if int(a.Length)-n < 8 {
 			break
 		}
 		spec := new(NXLearnSpec)
-		err = spec.UnmarshalBinary(data[n:])
+		err = spec.UnmarshalBinary(data[n:a.Length])
 		if err != nil {
 			klog.ErrorS(err, "Failed to unmarshal NXActionLearn's LearnSpecs", "data", data[n:])
 			return err
@@ -1376,7 +1428,7 @@ func (a *NXActionNote) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || a.Length < NxActionHeaderLength {
 		return errors.New("the []byte is too short to unmarshal a full NXActionNote message")
 	}
 	n := a.NXActionHeader.Len()
@@ -1433,11 +1485,11 @@ func (a *NXActionRegLoad2) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || a.Length < NxActionHeaderLength {
 		return errors.New("the []byte is too short to unmarshal a full NXActionRegLoad2 message")
 	}
 	a.DstField = new(MatchField)
-	err := a.DstField.UnmarshalBinary(data[n:])
+	err := a.DstField.UnmarshalBinary(data[n:a.Length])
 	if err != nil {
 		klog.ErrorS(err, "Failed to unmarshal NXActionRegLoad2's DstField", "data", data[n:])
 		return err
@@ -1485,7 +1537,7 @@ func (a *NXActionController) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController message")
 	}
 	n += int(a.NXActionHeader.Len())
@@ -1550,7 +1602,7 @@ func (a *NXActionController2PropMaxLen) UnmarshalBinary(data []byte) error {
 	if err := a.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController2PropMaxLen message")
 	}
 	n += int(a.PropHeader.Len())
@@ -1601,7 +1653,7 @@ func (a *NXActionController2PropControllerID) UnmarshalBinary(data []byte) error
 	if err := a.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController2PropControllerID message")
 	}
 	n += int(a.PropHeader.Len())
@@ -1652,7 +1704,7 @@ func (a *NXActionController2PropReason) UnmarshalBinary(data []byte) error {
 	if err := a.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController2PropReason message")
 	}
 	n += int(a.PropHeader.Len())
@@ -1800,7 +1852,7 @@ func (a *NXActionController2PropMeterId) UnmarshalBinary(data []byte) error {
 	if err := a.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController2PropMeterId message")
 	}
 	n += int(a.PropHeader.Len())
@@ -1820,6 +1872,9 @@ func NewMeterId(meterId uint32) *NXActionController2PropMeterId {
 
 // Decode Controller2 Property types.
 func DecodeController2Prop(data []byte) (Property, error) {
+	if len(data) < 4 {
+		return nil, errors.New("the []byte is too short to decode a Controller2 property")
+	}
 	t := binary.BigEndian.Uint16(data[:2])
 	var p Property
 	switch t {
@@ -1835,6 +1890,8 @@ func DecodeController2Prop(data []byte) (Property, error) {
 		p = new(NXActionController2PropPause)
 	case NXAC2PT_METER_ID:
 		p = new(NXActionController2PropMeterId)
+	default:
+		p = new(PropUnknown)
 	}
 	err := p.UnmarshalBinary(data)
 	if err != nil {
@@ -1892,14 +1949,15 @@ func (a *NXActionController2) UnmarshalBinary(data []byte) error {
 	if err := a.NXActionHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if len(data) < int(a.Length) || a.Length < 16 {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController2 message")
 	}
 	n += int(a.NXActionHeader.Len())
 	n += 6
 
+	a.props = nil
 	for n < int(a.Length) {
-		prop, err := DecodeController2Prop(data[n:])
+		prop, err := DecodeController2Prop(data[n:a.Length])
 		if err != nil {
 			klog.ErrorS(err, "Failed to decode Controller2Prop", "data", data[n:])
 			return err
@@ -7,6 +7,30 @@ import (
 	"net"
 )
 
+type Uint8Message struct {
+	Data uint8
+}
+
+func newUint8Message(data uint8) *Uint8Message {
+	return &Uint8Message{Data: data}
+}
+
+func (m *Uint8Message) Len() uint16 {
+	return 1
+}
+
+func (m *Uint8Message) MarshalBinary() (data []byte, err error) {
+	return []byte{m.Data}, nil
+}
+
+func (m *Uint8Message) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return errors.New("the []byte is too short to unmarshal a full Uint8Message")
+	}
+	m.Data = data[0]
+	return nil
+}
+
 type Uint16Message struct {
 	Data uint16
 }
@@ -322,6 +346,19 @@ func NewCTLabelMatchField(label [16]byte, mask *[16]byte) *MatchField {
 	return field
 }
 
+// NewIPFragMatchField returns a MatchField for the NX_IP_FRAG_* bits of
+// ip_frag. For example, NX_IP_FRAG_ANY with mask NX_IP_FRAG_ANY matches all
+// fragments, and 0 with mask NX_IP_FRAG_ANY|NX_IP_FRAG_LATER only
+// unfragmented packets.
+func NewIPFragMatchField(frag uint8, mask *uint8) *MatchField {
+	field, _ := FindFieldHeaderByName("NXM_NX_IP_FRAG", mask != nil)
+	field.Value = newUint8Message(frag)
+	if mask != nil {
+		field.Mask = newUint8Message(*mask)
+	}
+	return field
+}
+
 func NewConjIDMatchField(conjID uint32) *MatchField {
 	field, _ := FindFieldHeaderByName("NXM_NX_CONJ_ID", false)
 	field.Value = newUint32Message(conjID)
@@ -22,6 +22,12 @@ const (
 	NX_CT_STATE_DNAT_OFS = 7
 )
 
+// NX_IP_FRAG bits
+const (
+	NX_IP_FRAG_ANY   = 1 << 0 /* Set for any IP fragment. */
+	NX_IP_FRAG_LATER = 1 << 1 /* Set for IP fragments with nonzero offset. */
+)
+
 // NX_CT Flags
 const (
 	NX_CT_F_COMMIT    = 1 << 0
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"reflect"
 	"testing"
 )
 
@@ -930,3 +931,65 @@ func testMatchFieldHeaderMarshalUnMarshal(tgtField *MatchField, t *testing.T) {
 		t.Errorf("Unmarshalled header has incorrect 'Length' field, expect: %d, actual: %d", testMFHeader.Length, tgtField.Length)
 	}
 }
+
+// TestDecodeOVSActions decodes variable-length actions as Open vSwitch encodes
+// them, and checks that they are encoded again unchanged.
+func TestDecodeOVSActions(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		data   string
+		action Action
+	}{
+		// note:01.02.03
+		{"note", "ffff0010" + "00002320" + "0008" + "010203000000", &NXActionNote{}},
+		// learn(table=10,idle_timeout=60,priority=100,NXM_OF_VLAN_TCI[0..11],
+		// NXM_OF_ETH_DST[]=NXM_OF_ETH_SRC[],output:NXM_OF_IN_PORT[])
+		{"learn", "ffff0048" + "00002320" + "0010" + "003c0000" + "0064" + "0000000000000000" + "0000" + "0a00" + "00000000" +
+			"000c" + "000008020000" + "000008020000" +
+			"0030" + "000004060000" + "000002060000" +
+			"1010" + "000000020000" + "00000000", &NXActionLearn{}},
+		// controller(max_len=65535,id=7,reason=action,userdata=01.02.03,pause,meter_id=5)
+		{"controller2", "ffff0040" + "00002320" + "0025" + "000000000000" +
+			"00000006ffff0000" + "0001000600070000" + "0002000501000000" +
+			"0003000701020300" + "0004000400000000" + "0005000800000005", &NXActionController2{}},
+		// encap(nsh(md_type=1))
+		{"encap", "ffff0018" + "00002320" + "002e" + "0000" + "0001894f" + "0004010801000000", &NXActionEncap{}},
+	} {
+		data, _ := hex.DecodeString(tc.data)
+		act, err := DecodeAction(data)
+		if err != nil {
+			t.Fatalf("Failed to decode %s action: %v", tc.name, err)
+		}
+		if reflect.TypeOf(act) != reflect.TypeOf(tc.action) {
+			t.Errorf("Decoded %s action as %T", tc.name, act)
+		}
+		b, err := act.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to encode %s action: %v", tc.name, err)
+		}
+		if !bytes.Equal(data, b) {
+			t.Errorf("%s action encoded as %x instead of %x", tc.name, b, data)
+		}
+	}
+}
+
+// TestDecodeActionLength decodes an action whose length does not match the
+// length it would be encoded with, which is kept unknown.
+func TestDecodeActionLength(t *testing.T) {
+	// A note which is not padded to 8 bytes.
+	data, _ := hex.DecodeString("ffff000c" + "00002320" + "0008" + "0102")
+	act, err := DecodeAction(data)
+	if err != nil {
+		t.Fatalf("Failed to decode action: %v", err)
+	}
+	if _, ok := act.(*ActionUnknown); !ok {
+		t.Errorf("Decoded action as %T", act)
+	}
+	b, err := act.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to encode action: %v", err)
+	}
+	if !bytes.Equal(data, b) {
+		t.Errorf("Action encoded as %x instead of %x", b, data)
+	}
+}
@@ -68,6 +68,9 @@ func (p *PacketInFormat) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PacketInFormat) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PacketInFormat message")
+	}
 	n := 0
 	p.Spif = binary.BigEndian.Uint32(data[n:])
 	return nil
@@ -193,6 +196,7 @@ func (t *TLVTableMod) UnmarshalBinary(data []byte) error {
 	n += 2
 	n += 6
 
+	t.TlvMaps = nil
 	for n < len(data) {
 		tlvMap := new(TLVTableMap)
 		err := tlvMap.UnmarshalBinary(data[n:])
@@ -254,6 +258,9 @@ func (t *TLVTableReply) MarshalBinary() (data []byte, err error) {
 }
 
 func (t *TLVTableReply) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full TLVTableReply message")
+	}
 	n := 0
 	t.MaxSpace = binary.BigEndian.Uint32(data[n:])
 	n += 4
@@ -262,6 +269,7 @@ func (t *TLVTableReply) UnmarshalBinary(data []byte) error {
 	t.reserved = [10]byte{}
 	copy(t.reserved[0:], data[n:n+10])
 	n += 10
+	t.TlvMaps = nil
 	for n < len(data) {
 		tlvMap := new(TLVTableMap)
 		err := tlvMap.UnmarshalBinary(data[n:])
@@ -328,7 +336,7 @@ func (p *ContinuationPropBridge) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropBridge message")
 	}
 	n += int(p.PropHeader.Len())
@@ -380,10 +388,7 @@ func (p *ContinuationPropStack) UnmarshalBinary(data []byte) error {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropStack message")
 	}
 	n += int(p.PropHeader.Len())
-	for _, eachStack := range p.Stack {
-		data[n] = eachStack
-		n++
-	}
+	p.Stack = append([]uint8(nil), data[n:p.Length]...)
 	return nil
 }
 
@@ -420,7 +425,7 @@ func (p *ContinuationPropMirrors) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropMirrors message")
 	}
 	n += int(p.PropHeader.Len())
@@ -459,7 +464,7 @@ func (p *ContinuationPropConntracked) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropConntracked message")
 	}
 	n += int(p.PropHeader.Len())
@@ -499,7 +504,7 @@ func (p *ContinuationPropTableID) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropTableID message")
 	}
 	n += int(p.PropHeader.Len())
@@ -541,7 +546,7 @@ func (p *ContinuationPropCookie) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropCookie message")
 	}
 	n += int(p.PropHeader.Len())
@@ -601,8 +606,9 @@ func (p *ContinuationPropActions) UnmarshalBinary(data []byte) error {
 	n += int(p.PropHeader.Len())
 	n += 4
 
+	p.Actions = nil
 	for n < int(p.Length) {
-		act, err := DecodeAction(data[n:])
+		act, err := DecodeAction(data[n:p.Length])
 		if err != nil {
 			klog.ErrorS(err, "Failed to decode ContinuationPropActions's Actions", "data", data[n:])
 			return err
@@ -665,8 +671,9 @@ func (p *ContinuationPropActionSet) UnmarshalBinary(data []byte) error {
 	n += int(p.PropHeader.Len())
 	n += 4
 
+	p.ActionSet = nil
 	for n < int(p.Length) {
-		act, err := DecodeAction(data[n:])
+		act, err := DecodeAction(data[n:p.Length])
 		if err != nil {
 			klog.ErrorS(err, "Failed to decode ContinuationPropActionSet's ActionSet", "data", data[n:])
 			return err
@@ -710,7 +717,7 @@ func (p *ContinuationPropOdpPort) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full ContinuationPropOdpPort message")
 	}
 	n += int(p.PropHeader.Len())
@@ -721,6 +728,9 @@ func (p *ContinuationPropOdpPort) UnmarshalBinary(data []byte) error {
 
 // Decode Continuation Property types.
 func DecodeContinuationProp(data []byte) (Property, error) {
+	if len(data) < 4 {
+		return nil, errors.New("the []byte is too short to decode a Continuation property")
+	}
 	t := binary.BigEndian.Uint16(data[:2])
 	var p Property
 	switch t {
@@ -742,6 +752,8 @@ func DecodeContinuationProp(data []byte) (Property, error) {
 		p = new(ContinuationPropActionSet)
 	case NXCPT_ODP_PORT:
 		p = new(ContinuationPropOdpPort)
+	default:
+		p = new(PropUnknown)
 	}
 	err := p.UnmarshalBinary(data)
 	if err != nil {
@@ -854,7 +866,7 @@ func (p *PacketIn2PropFullLen) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropFullLen message")
 	}
 	n += int(p.PropHeader.Len())
@@ -896,7 +908,7 @@ func (p *PacketIn2PropBufferID) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropBufferID message")
 	}
 	n += int(p.PropHeader.Len())
@@ -938,7 +950,7 @@ func (p *PacketIn2PropTableID) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropTableID message")
 	}
 	n += int(p.PropHeader.Len())
@@ -982,7 +994,7 @@ func (p *PacketIn2PropCookie) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropCookie message")
 	}
 	n += int(p.PropHeader.Len())
@@ -1026,7 +1038,7 @@ func (p *PacketIn2PropReason) UnmarshalBinary(data []byte) error {
 	if err := p.PropHeader.UnmarshalBinary(data[n:]); err != nil {
 		return err
 	}
-	if len(data) < int(p.Length) {
+	if len(data) < int(p.Length) || len(data) < int(p.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropReason message")
 	}
 	n += int(p.PropHeader.Len())
@@ -1088,9 +1100,10 @@ func (p *PacketIn2PropMetadata) UnmarshalBinary(data []byte) error {
 	}
 	n += int(p.PropHeader.Len())
 
+	p.Fields = nil
 	for n < int(p.Length) {
 		field := new(MatchField)
-		if err := field.UnmarshalBinary(data[n:]); err != nil {
+		if err := field.UnmarshalBinary(data[n:p.Length]); err != nil {
 			klog.ErrorS(err, "Failed to unmarshal PacketIn2PropMetadata's Fields", "data", data[n:])
 			return err
 		}
@@ -1191,6 +1204,9 @@ func (p *PacketIn2PropContinuation) UnmarshalBinary(data []byte) error {
 
 // Decode PacketIn2 Property types.
 func DecodePacketIn2Prop(data []byte) (Property, error) {
+	if len(data) < 4 {
+		return nil, errors.New("the []byte is too short to decode a PacketIn2 property")
+	}
 	t := binary.BigEndian.Uint16(data[:2])
 	var p Property
 	switch t {
@@ -1212,6 +1228,8 @@ func DecodePacketIn2Prop(data []byte) (Property, error) {
 		p = new(PacketIn2PropUserdata)
 	case NXPINT_CONTINUATION:
 		p = new(PacketIn2PropContinuation)
+	default:
+		p = new(PropUnknown)
 	}
 	err := p.UnmarshalBinary(data)
 	if err != nil {
@@ -1252,10 +1270,11 @@ func (p *PacketIn2) MarshalBinary() (data []byte, err error) {
 func (p *PacketIn2) UnmarshalBinary(data []byte) error {
 	n := 0
 
+	p.Props = nil
 	for n < len(data) {
 		prop, err := DecodePacketIn2Prop(data[n:])
 		if err != nil {
-			break
+			return err
 		}
 		p.Props = append(p.Props, prop)
 		n += int(prop.Len())
@@ -1303,10 +1322,11 @@ func (p *Resume) MarshalBinary() (data []byte, err error) {
 func (p *Resume) UnmarshalBinary(data []byte) error {
 	n := 0
 
+	p.Props = nil
 	for n < len(data) {
 		prop, err := DecodePacketIn2Prop(data[n:])
 		if err != nil {
-			break
+			return err
 		}
 		p.Props = append(p.Props, prop)
 		n += int(prop.Len())
@@ -1322,22 +1342,27 @@ func NewResume(props []Property) *VendorHeader {
 	return msg
 }
 
-func decodeVendorData(experimenterType uint32, data []byte) (msg util.Message, err error) {
-	switch experimenterType {
-	case Type_SetPacketInFormat:
-		msg = new(PacketInFormat)
-	case Type_SetControllerId:
-		msg = new(ControllerID)
-	case Type_TlvTableMod:
-		msg = new(TLVTableMod)
-	case Type_TlvTableReply:
-		msg = new(TLVTableReply)
-	case Type_BundleCtrl:
-		msg = new(BundleControl)
-	case Type_BundleAdd:
-		msg = new(BundleAdd)
-	case Type_PacketIn2:
-		msg = new(PacketIn2)
+func decodeVendorData(experimenter, experimenterType uint32, data []byte) (msg util.Message, err error) {
+	if msg = newExperimenterMessage(experimenter, experimenterType); msg == nil {
+		switch experimenterType {
+		case Type_SetPacketInFormat:
+			msg = new(PacketInFormat)
+		case Type_SetControllerId:
+			msg = new(ControllerID)
+		case Type_TlvTableMod:
+			msg = new(TLVTableMod)
+		case Type_TlvTableReply:
+			msg = new(TLVTableReply)
+		case Type_BundleCtrl:
+			msg = new(BundleControl)
+		case Type_BundleAdd:
+			msg = new(BundleAdd)
+		case Type_PacketIn2:
+			msg = new(PacketIn2)
+		default:
+			// Keep the data of unknown messages as is.
+			msg = new(util.BufferUnknown)
+		}
 	}
 	err = msg.UnmarshalBinary(data)
 	if err != nil {
@@ -11,6 +11,7 @@ package openflow15
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"net"
 
 	"k8s.io/klog/v2"
@@ -119,6 +120,10 @@ const (
 
 func Parse(b []byte) (message util.Message, err error) {
 	klog.V(7).InfoS("Parsing Openflow15 message", "dataLength", len(b), "data", b)
+	b, err = common.MessageBytes(b)
+	if err != nil {
+		return nil, err
+	}
 	switch b[1] {
 	case Type_Error:
 		errMsg := new(ErrorMsg)
@@ -297,6 +302,9 @@ func (p *PacketOut) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PacketOut) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full PacketOut message.")
+	}
 	err = p.Header.UnmarshalBinary(data)
 	if err != nil {
 		return
@@ -315,9 +323,13 @@ func (p *PacketOut) UnmarshalBinary(data []byte) (err error) {
 		return err
 	}
 	n += p.Match.Len()
-	a := n
-	for n < (a + p.ActionsLen) {
-		a, err := DecodeAction(data[n:])
+	end := n + p.ActionsLen
+	if int(end) > len(data) {
+		return fmt.Errorf("The PacketOut actions length %d exceeds the message.", p.ActionsLen)
+	}
+	p.Actions = nil
+	for n < end {
+		a, err := DecodeAction(data[n:end])
 		if err != nil {
 			klog.ErrorS(err, "Failed to decode PacketOut's Actions", "data", data[n:])
 			return err
@@ -410,6 +422,9 @@ func (p *PacketIn) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PacketIn) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full PacketIn message.")
+	}
 	err := p.Header.UnmarshalBinary(data)
 	if err != nil {
 		return err
@@ -432,6 +447,9 @@ func (p *PacketIn) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	n += p.Match.Len()
+	if len(data) < int(n)+2 {
+		return errors.New("The []byte is too short to unmarshal a full PacketIn message.")
+	}
 
 	copy(p.pad, data[n:])
 	n += 2
@@ -515,6 +533,9 @@ func (c *SwitchConfig) MarshalBinary() (data []byte, err error) {
 }
 
 func (c *SwitchConfig) UnmarshalBinary(data []byte) error {
+	if len(data) < 12 {
+		return errors.New("The []byte is too short to unmarshal a full SwitchConfig message.")
+	}
 	var err error
 	n := 0
 
@@ -936,6 +957,9 @@ func (s *SwitchFeatures) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *SwitchFeatures) UnmarshalBinary(data []byte) error {
+	if len(data) < 32 {
+		return errors.New("The []byte is too short to unmarshal a full SwitchFeatures message.")
+	}
 	var err error
 	n := 0
 
@@ -1015,7 +1039,13 @@ func (v *VendorHeader) UnmarshalBinary(data []byte) error {
 		return errors.New("The []byte the wrong size to unmarshal an " +
 			"VendorHeader message.")
 	}
-	v.Header.UnmarshalBinary(data)
+	if err := v.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if int(v.Header.Length) > len(data) {
+		return errors.New("The []byte the wrong size to unmarshal an " +
+			"VendorHeader message.")
+	}
 	n := int(v.Header.Len())
 	v.Vendor = binary.BigEndian.Uint32(data[n:])
 	n += 4
@@ -1023,7 +1053,7 @@ func (v *VendorHeader) UnmarshalBinary(data []byte) error {
 	n += 4
 	if n < int(v.Header.Length) {
 		var err error
-		v.VendorData, err = decodeVendorData(v.ExperimenterType, data[n:v.Header.Length])
+		v.VendorData, err = decodeVendorData(v.Vendor, v.ExperimenterType, data[n:v.Header.Length])
 		if err != nil {
 			return err
 		}
@@ -1086,6 +1116,9 @@ func (m *RoleRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *RoleRequest) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full RoleRequest message.")
+	}
 	n := 0
 
 	err = m.Header.UnmarshalBinary(data[n:])
@@ -1188,6 +1221,9 @@ func (a *Async_Config) UnmarshalBinary(data []byte) (err error) {
 	n = a.Header.Len()
 
 	for n < a.Header.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case ACPT_PACKET_IN_SLAVE:
@@ -1226,10 +1262,11 @@ func (a *Async_Config) UnmarshalBinary(data []byte) (err error) {
 		case ACPT_EXPERIMENTER_SLAVE:
 			fallthrough
 		case ACPT_EXPERIMENTER_MASTER:
-			p = new(AsyncConfigPropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(AsyncConfigPropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -1263,8 +1300,14 @@ func (h *AsyncConfigPropHeader) MarshalBinary() (data []byte, err error) {
 }
 
 func (h *AsyncConfigPropHeader) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full AsyncConfigPropHeader message.")
+	}
 	h.Type = binary.BigEndian.Uint16(data[0:])
 	h.Length = binary.BigEndian.Uint16(data[2:])
+	if h.Length < 4 || int(h.Length) > len(data) {
+		return fmt.Errorf("The property length %d is invalid for %d bytes of data.", h.Length, len(data))
+	}
 	return
 }
 
@@ -1296,7 +1339,12 @@ func (p *AsyncConfigPropReasons) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *AsyncConfigPropReasons) UnmarshalBinary(data []byte) (err error) {
-	p.Header.UnmarshalBinary(data)
+	if len(data) < int(p.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full AsyncConfigPropReasons message.")
+	}
+	if err = p.Header.UnmarshalBinary(data); err != nil {
+		return
+	}
 	n := p.Header.Len()
 
 	p.Mask = binary.BigEndian.Uint32(data[n:])
@@ -1341,13 +1389,21 @@ func (p *AsyncConfigPropExperimenter) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *AsyncConfigPropExperimenter) UnmarshalBinary(data []byte) (err error) {
-	p.Header.UnmarshalBinary(data)
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full AsyncConfigPropExperimenter message.")
+	}
+	if err = p.Header.UnmarshalBinary(data); err != nil {
+		return
+	}
 	n := p.Header.Len()
 
 	p.Experimenter = binary.BigEndian.Uint32(data[n:])
 	n += 4
 
-	p.Data = make([]byte, p.Header.Length)
+	if p.Header.Length < n {
+		return fmt.Errorf("The AsyncConfigPropExperimenter length %d is shorter than its header.", p.Header.Length)
+	}
+	p.Data = make([]byte, p.Header.Length-n)
 	copy(p.Data, data[n:])
 	n += uint16(len(p.Data))
 	return
@@ -1425,6 +1481,9 @@ func (r *RoleStatus) MarshalBinary() (data []byte, err error) {
 }
 
 func (r *RoleStatus) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full RoleStatus message.")
+	}
 	n := uint16(0)
 	err = r.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -1444,13 +1503,17 @@ func (r *RoleStatus) UnmarshalBinary(data []byte) (err error) {
 	n += 8
 
 	for n < r.Header.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case RPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -1490,8 +1553,14 @@ func (h *PropHeader) MarshalBinary() (data []byte, err error) {
 }
 
 func (h *PropHeader) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full PropHeader message.")
+	}
 	h.Type = binary.BigEndian.Uint16(data[0:])
 	h.Length = binary.BigEndian.Uint16(data[2:])
+	if h.Length < 4 || int(h.Length) > len(data) {
+		return fmt.Errorf("The property length %d is invalid for %d bytes of data.", h.Length, len(data))
+	}
 	return
 }
 
@@ -1540,7 +1609,12 @@ func (p *PropExperimenter) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PropExperimenter) UnmarshalBinary(data []byte) (err error) {
-	p.Header.UnmarshalBinary(data)
+	if len(data) < 12 {
+		return errors.New("The []byte is too short to unmarshal a full PropExperimenter message.")
+	}
+	if err = p.Header.UnmarshalBinary(data); err != nil {
+		return
+	}
 	n := p.Header.Len()
 
 	p.Experimenter = binary.BigEndian.Uint32(data[n:])
@@ -1549,7 +1623,8 @@ func (p *PropExperimenter) UnmarshalBinary(data []byte) (err error) {
 	p.ExpType = binary.BigEndian.Uint32(data[n:])
 	n += 4
 
-	for n < p.Header.Length {
+	p.Data = nil
+	for n+4 <= p.Header.Length {
 		d := binary.BigEndian.Uint32(data[n:])
 		p.Data = append(p.Data, d)
 		n += 4
@@ -1557,6 +1632,45 @@ func (p *PropExperimenter) UnmarshalBinary(data []byte) (err error) {
 	return
 }
 
+// PropUnknown is a property of a type which is not known. It keeps the bytes
+// following the property header, so that it is encoded again unchanged.
+type PropUnknown struct {
+	PropHeader
+	Data []byte
+}
+
+func (p *PropUnknown) Len() uint16 {
+	n := p.PropHeader.Len() + uint16(len(p.Data))
+	// Round it to closest multiple of 8
+	return ((n + 7) / 8) * 8
+}
+
+func (p *PropUnknown) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	p.Length = p.PropHeader.Len() + uint16(len(p.Data))
+	b, err := p.PropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	copy(data[n:], p.Data)
+	return
+}
+
+func (p *PropUnknown) UnmarshalBinary(data []byte) error {
+	if err := p.PropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	p.Data = make([]byte, int(p.Length)-int(p.PropHeader.Len()))
+	copy(p.Data, data[p.PropHeader.Len():p.Length])
+	return nil
+}
+
+// UnknownError implements util.Unknown.
+func (p *PropUnknown) UnknownError() error {
+	return fmt.Errorf("unknown property of type %d", p.Type)
+}
+
 // ofp_table_desc
 type TableDesc struct {
 	Length     uint16
@@ -1613,6 +1727,9 @@ func (t *TableDesc) MarshalBinary() (data []byte, err error) {
 }
 
 func (t *TableDesc) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full TableDesc message.")
+	}
 	var n uint16 = 0
 	t.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -1624,6 +1741,9 @@ func (t *TableDesc) UnmarshalBinary(data []byte) (err error) {
 	n += 4
 
 	for n < t.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case OFPTMPT_EVICTION:
@@ -1631,10 +1751,11 @@ func (t *TableDesc) UnmarshalBinary(data []byte) (err error) {
 		case OFPTMPT_VACANCY:
 			p = new(TableModPropVacancy)
 		case OFPTMPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -1696,6 +1817,9 @@ func (t *TableModPropEviction) MarshalBinary() (data []byte, err error) {
 }
 
 func (t *TableModPropEviction) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(t.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full TableModPropEviction message.")
+	}
 	var n uint16 = 0
 	err = t.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -1749,6 +1873,9 @@ func (t *TableModPropVacancy) MarshalBinary() (data []byte, err error) {
 }
 
 func (t *TableModPropVacancy) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(t.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full TableModPropVacancy message.")
+	}
 	var n uint16 = 0
 	err = t.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -1822,6 +1949,9 @@ func (t *TableStatus) MarshalBinary() (data []byte, err error) {
 }
 
 func (t *TableStatus) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full TableStatus message.")
+	}
 	n := uint16(0)
 	err = t.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -1898,6 +2028,9 @@ func (t *TableMod) MarshalBinary() (data []byte, err error) {
 }
 
 func (t *TableMod) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full TableMod message.")
+	}
 	var n uint16 = 0
 	err = t.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -1913,6 +2046,9 @@ func (t *TableMod) UnmarshalBinary(data []byte) (err error) {
 	n += 4
 
 	for n < uint16(len(data)) {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case OFPTMPT_EVICTION:
@@ -1920,10 +2056,11 @@ func (t *TableMod) UnmarshalBinary(data []byte) (err error) {
 		case OFPTMPT_VACANCY:
 			p = new(TableModPropVacancy)
 		case OFPTMPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -1977,6 +2114,9 @@ func (r *RequestForward) MarshalBinary() (data []byte, err error) {
 }
 
 func (r *RequestForward) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full RequestForward message.")
+	}
 	var n uint16 = 0
 	err = r.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -1989,6 +2129,10 @@ func (r *RequestForward) UnmarshalBinary(data []byte) (err error) {
 		klog.ErrorS(err, "Failed to unmarshal RequestForward's Request", "data", data[n:])
 		return
 	}
+	// The request is the rest of the message.
+	if r.Request.Length < r.Request.Len() || r.Header.Length != n+r.Request.Length {
+		return fmt.Errorf("the RequestForward request length %d does not match the message length %d", r.Request.Length, r.Header.Length)
+	}
 	n += r.Request.Len()
 	return
 }
@@ -2081,6 +2225,9 @@ func (c *BundleCtrl) MarshalBinary() (data []byte, err error) {
 }
 
 func (c *BundleCtrl) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full BundleCtrl message.")
+	}
 	var n uint16
 	err = c.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -2098,15 +2245,19 @@ func (c *BundleCtrl) UnmarshalBinary(data []byte) (err error) {
 	n += 2
 
 	for n < c.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case BPT_TIME:
 			p = new(BundlePropTime)
 		case BPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -2158,6 +2309,9 @@ func (t *BundlePropTime) MarshalBinary() (data []byte, err error) {
 }
 
 func (t *BundlePropTime) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(t.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full BundlePropTime message.")
+	}
 	var n uint16
 	err = t.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -2200,6 +2354,9 @@ func (t *OfpTime) MarshalBinary() (data []byte, err error) {
 }
 
 func (t *OfpTime) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(t.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full OfpTime message.")
+	}
 	n := 0
 	t.Seconds = binary.BigEndian.Uint64(data[n:])
 	n += 8
@@ -2280,6 +2437,9 @@ func (c *BndleAdd) MarshalBinary() (data []byte, err error) {
 }
 
 func (c *BndleAdd) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(c.Header.Len())+8 {
+		return errors.New("The []byte is too short to unmarshal a full BndleAdd message.")
+	}
 	var n uint16
 	err = c.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -2300,18 +2460,22 @@ func (c *BndleAdd) UnmarshalBinary(data []byte) (err error) {
 		klog.ErrorS(err, "Failed to parse BndleAdd's Message", "data", data[n:])
 		return
 	}
-	n += c.Message.Len()
+	n += binary.BigEndian.Uint16(data[n+2:])
 
-	for n < c.Length {
+	for int(n) < len(data) {
 		var p util.Message
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full BndleAdd property.")
+		}
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case BPT_TIME:
 			p = new(BundlePropTime)
 		case BPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -2441,6 +2605,9 @@ func (c *ControllerStatus) MarshalBinary() (data []byte, err error) {
 }
 
 func (c *ControllerStatus) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full ControllerStatus message.")
+	}
 	var n uint16
 
 	c.Length = binary.BigEndian.Uint16(data[n:])
@@ -2461,15 +2628,19 @@ func (c *ControllerStatus) UnmarshalBinary(data []byte) (err error) {
 	n += 6 //Pad
 
 	for n < uint16(len(data)) {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var p util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case CSPT_URI:
 			p = new(ControllerStatusPropUri)
 		case CSPT_EXPERIMENTER:
-			p = new(PropExperimenter)
+			if p = newExperimenterProp(data[n:]); p == nil {
+				p = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			p = new(PropUnknown)
 		}
 		err = p.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -83,6 +83,9 @@ func (p *Port) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *Port) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 40 {
+		return errors.New("The []byte is too short to unmarshal a full Port message.")
+	}
 	p.PortNo = binary.BigEndian.Uint32(data)
 	var n uint16 = 4
 	p.Length = binary.BigEndian.Uint16(data[n:])
@@ -99,6 +102,9 @@ func (p *Port) UnmarshalBinary(data []byte) (err error) {
 	p.State = binary.BigEndian.Uint32(data[n:])
 	n += 4
 	for n < p.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var prop util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case PDPT_ETHERNET:
@@ -112,10 +118,11 @@ func (p *Port) UnmarshalBinary(data []byte) (err error) {
 		case PDPT_RECIRCULATE:
 			prop = new(PortDescPropRecirculate)
 		case PDPT_EXPERIMENTER:
-			prop = new(PropExperimenter)
+			if prop = newExperimenterProp(data[n:]); prop == nil {
+				prop = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			prop = new(PropUnknown)
 		}
 		err = prop.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -182,6 +189,9 @@ func (prop *PortDescPropEthernet) MarshalBinary() (data []byte, err error) {
 }
 
 func (prop *PortDescPropEthernet) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(prop.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full PortDescPropEthernet message.")
+	}
 	var n uint16 = 0
 	err = prop.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -274,6 +284,9 @@ func (prop *PortDescPropOptical) MarshalBinary() (data []byte, err error) {
 }
 
 func (prop *PortDescPropOptical) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(prop.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full PortDescPropOptical message.")
+	}
 	var n uint16 = 0
 	err = prop.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -348,7 +361,8 @@ func (prop *PortDescPropOxm) UnmarshalBinary(data []byte) (err error) {
 	}
 	n += prop.Header.Len()
 	n += 4 // Pad
-	for n < prop.Header.Length {
+	prop.OxmIds = nil
+	for n+4 <= prop.Header.Length {
 		oxm := binary.BigEndian.Uint32(data[n:])
 		prop.OxmIds = append(prop.OxmIds, oxm)
 		n += 4
@@ -401,7 +415,8 @@ func (prop *PortDescPropRecirculate) UnmarshalBinary(data []byte) (err error) {
 	}
 	n += prop.Header.Len()
 	n += 4 // Pad
-	for n < prop.Header.Length {
+	prop.PortNos = nil
+	for n+4 <= prop.Header.Length {
 		p := binary.BigEndian.Uint32(data[n:])
 		prop.PortNos = append(prop.PortNos, p)
 		n += 4
@@ -476,6 +491,9 @@ func (p *PortMod) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PortMod) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 32 {
+		return errors.New("The []byte is too short to unmarshal a full PortMod message.")
+	}
 	err = p.Header.UnmarshalBinary(data)
 	n := p.Header.Len()
 
@@ -493,6 +511,9 @@ func (p *PortMod) UnmarshalBinary(data []byte) (err error) {
 	n += 4
 
 	for n < p.Length {
+		if len(data) < int(n)+4 {
+			return errors.New("The []byte is too short to unmarshal a full property.")
+		}
 		var prop util.Message
 		switch binary.BigEndian.Uint16(data[n:]) {
 		case PMPT_ETHERNET:
@@ -500,10 +521,11 @@ func (p *PortMod) UnmarshalBinary(data []byte) (err error) {
 		case PMPT_OPTICAL:
 			prop = new(PortModPropOptical)
 		case PMPT_EXPERIMENTER:
-			prop = new(PropExperimenter)
+			if prop = newExperimenterProp(data[n:]); prop == nil {
+				prop = new(PropExperimenter)
+			}
 		default:
-			err = errors.New("An unknown property type was received")
-			return
+			prop = new(PropUnknown)
 		}
 		err = prop.UnmarshalBinary(data[n:])
 		if err != nil {
@@ -618,6 +640,9 @@ func (prop *PortModPropEthernet) MarshalBinary() (data []byte, err error) {
 }
 
 func (prop *PortModPropEthernet) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(prop.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full PortModPropEthernet message.")
+	}
 	var n uint16
 	err = prop.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -681,6 +706,9 @@ func (prop *PortModPropOptical) MarshalBinary() (data []byte, err error) {
 }
 
 func (prop *PortModPropOptical) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < int(prop.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full PortModPropOptical message.")
+	}
 	var n uint16
 	err = prop.Header.UnmarshalBinary(data[n:])
 	if err != nil {
@@ -750,6 +778,9 @@ func (s *PortStatus) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *PortStatus) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full PortStatus message.")
+	}
 	if err := s.Header.UnmarshalBinary(data); err != nil {
 		return err
 	}
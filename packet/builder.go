@@ -0,0 +1,453 @@
+// Package packet builds the frames sent in PacketOuts. A Builder stacks the
+// layers of a frame, outermost first, and fills in the fields which depend on
+// the other layers: ethertypes, IP protocols and next headers, lengths and
+// checksums.
+package packet
+
+import (
+	"fmt"
+	"net"
+
+	"antrea.io/libOpenflow/openflow13"
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+const (
+	defaultTTL = 64
+	// IP protocols not named by the protocol package.
+	ipProtoIPv4   = 0x04
+	ipProtoNoNext = 0x3b
+)
+
+// routerAlert is the IPv4 router alert option required by IGMP.
+var routerAlert = []byte{0x94, 0x04, 0x00, 0x00}
+
+// layer is a layer of the packet, with the ethertype and IP protocol which
+// identify it in the layer carrying it, or zero if unknown.
+type layer struct {
+	msg       util.Message
+	ethertype uint16
+	ipProto   uint8
+}
+
+// Builder stacks the layers of a packet. Its methods return the Builder to be
+// chained, and the first error is returned by Build and the methods using it.
+//
+//	frame, err := packet.NewBuilder().
+//		Ethernet(srcMAC, dstMAC).
+//		VLAN(100, 0).
+//		IPv4(srcIP, dstIP).
+//		UDP(12345, 53).
+//		Layer(dnsQuery).
+//		Frame()
+type Builder struct {
+	layers []layer
+	err    error
+}
+
+func NewBuilder() *Builder {
+	return new(Builder)
+}
+
+func (b *Builder) fail(format string, args ...interface{}) *Builder {
+	if b.err == nil {
+		b.err = fmt.Errorf(format, args...)
+	}
+	return b
+}
+
+// last returns the innermost layer so far, or nil.
+func (b *Builder) last() util.Message {
+	if len(b.layers) == 0 {
+		return nil
+	}
+	return b.layers[len(b.layers)-1].msg
+}
+
+// push adds l as the payload of the innermost layer.
+func (b *Builder) push(l layer) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if parent := b.last(); parent != nil {
+		if err := setPayload(parent, l.msg); err != nil {
+			return b.fail("%v", err)
+		}
+	}
+	b.layers = append(b.layers, l)
+	return b
+}
+
+func setPayload(parent, msg util.Message) error {
+	switch p := parent.(type) {
+	case *protocol.Ethernet:
+		p.Data = msg
+	case *protocol.IPv4:
+		p.Data = msg
+	case *protocol.IPv6:
+		p.Data = msg
+	case *protocol.UDP:
+		p.Payload = msg
+	case *protocol.TCP:
+		p.Payload = msg
+	case *protocol.ICMP:
+		p.Payload = msg
+	case *protocol.MPLS:
+		p.Data = msg
+	case *protocol.GRE:
+		p.Data = msg
+	case *protocol.VXLAN:
+		p.Data = msg
+	case *protocol.Geneve:
+		p.Data = msg
+	case *protocol.STT:
+		p.Data = msg
+	case *protocol.NSH:
+		p.Data = msg
+	default:
+		return fmt.Errorf("%T cannot carry a payload", parent)
+	}
+	return nil
+}
+
+// Ethernet adds an Ethernet header, the outer one or the inner one of a
+// tunnel.
+func (b *Builder) Ethernet(src, dst net.HardwareAddr) *Builder {
+	if len(src) != 6 || len(dst) != 6 {
+		return b.fail("invalid Ethernet addresses %v and %v", src, dst)
+	}
+	eth := protocol.NewEthernet()
+	copy(eth.HWSrc, src)
+	copy(eth.HWDst, dst)
+	return b.push(layer{msg: eth, ethertype: protocol.TEB_MSG})
+}
+
+// VLAN adds a 802.1Q tag to the Ethernet header just added, inside the tags it
+// already has.
+func (b *Builder) VLAN(vid uint16, pcp uint8) *Builder {
+	eth, ok := b.last().(*protocol.Ethernet)
+	if b.err == nil && !ok {
+		return b.fail("VLAN tag %d needs an Ethernet header", vid)
+	}
+	if b.err != nil {
+		return b
+	}
+	tag := protocol.VLAN{TPID: 0x8100, PCP: pcp, VID: vid}
+	if tags := eth.VLANs(); len(tags) > 0 {
+		eth.InnerVLANs = append(eth.InnerVLANs, tag)
+	} else {
+		eth.VLANID = tag
+	}
+	return b
+}
+
+// IPv4 adds an IPv4 header with a TTL of 64.
+func (b *Builder) IPv4(src, dst net.IP) *Builder {
+	if src.To4() == nil || dst.To4() == nil {
+		return b.fail("invalid IPv4 addresses %v and %v", src, dst)
+	}
+	ip := protocol.NewIPv4()
+	ip.Version = 4
+	ip.IHL = 5
+	ip.TTL = defaultTTL
+	ip.NWSrc = append(net.IP(nil), src.To4()...)
+	ip.NWDst = append(net.IP(nil), dst.To4()...)
+	return b.push(layer{msg: ip, ethertype: protocol.IPv4_MSG, ipProto: ipProtoIPv4})
+}
+
+// IPv6 adds an IPv6 header with a hop limit of 64.
+func (b *Builder) IPv6(src, dst net.IP) *Builder {
+	if src.To16() == nil || src.To4() != nil || dst.To16() == nil || dst.To4() != nil {
+		return b.fail("invalid IPv6 addresses %v and %v", src, dst)
+	}
+	ip := &protocol.IPv6{
+		Version:  6,
+		HopLimit: defaultTTL,
+		NWSrc:    append(net.IP(nil), src.To16()...),
+		NWDst:    append(net.IP(nil), dst.To16()...),
+	}
+	return b.push(layer{msg: ip, ethertype: protocol.IPv6_MSG, ipProto: protocol.Type_IPv6})
+}
+
+func (b *Builder) lastIPv6(header string) (*protocol.IPv6, bool) {
+	if b.err != nil {
+		return nil, false
+	}
+	ip, ok := b.last().(*protocol.IPv6)
+	if !ok {
+		b.fail("IPv6 %s header needs an IPv6 header", header)
+	}
+	return ip, ok
+}
+
+// IPv6HopByHop adds a hop-by-hop options header to the IPv6 header just
+// added. The options are padded to a multiple of 8 bytes.
+func (b *Builder) IPv6HopByHop(options ...*protocol.Option) *Builder {
+	ip, ok := b.lastIPv6("hop-by-hop")
+	if !ok {
+		return b
+	}
+	l := 2
+	for _, o := range options {
+		l += int(o.Len())
+	}
+	ip.HbhHeader = &protocol.HopByHopHeader{HEL: uint8((l+7)/8 - 1), Options: options}
+	return b
+}
+
+// IPv6Routing adds a routing header to the IPv6 header just added. data is
+// the content following the segments left field, padded to a multiple of 8
+// bytes.
+func (b *Builder) IPv6Routing(routingType, segmentsLeft uint8, data []byte) *Builder {
+	ip, ok := b.lastIPv6("routing")
+	if !ok {
+		return b
+	}
+	ip.RoutingHeader = &protocol.RoutingHeader{
+		HEL:          uint8((4+len(data)+7)/8 - 1),
+		RoutingType:  routingType,
+		SegmentsLeft: segmentsLeft,
+		Data:         util.NewBuffer(append([]byte(nil), data...)),
+	}
+	return b
+}
+
+// IPv6Fragment adds a fragment header to the IPv6 header just added. offset
+// is in 8-byte units.
+func (b *Builder) IPv6Fragment(id uint32, offset uint16, more bool) *Builder {
+	ip, ok := b.lastIPv6("fragment")
+	if !ok {
+		return b
+	}
+	ip.FragmentHeader = &protocol.FragmentHeader{Identification: id, FragmentOffset: offset, MoreFragments: more}
+	return b
+}
+
+func (b *Builder) UDP(src, dst uint16) *Builder {
+	udp := protocol.NewUDP()
+	udp.PortSrc = src
+	udp.PortDst = dst
+	return b.push(layer{msg: udp, ipProto: protocol.Type_UDP})
+}
+
+// TCP adds a TCP header with the given TCP_* flags and a window of 65535.
+func (b *Builder) TCP(src, dst uint16, seq, ack uint32, flags uint16) *Builder {
+	tcp := protocol.NewTCP()
+	tcp.PortSrc = src
+	tcp.PortDst = dst
+	tcp.SeqNum = seq
+	tcp.AckNum = ack
+	tcp.WinSize = 0xffff
+	tcp.SetFlags(flags)
+	return b.push(layer{msg: tcp, ipProto: protocol.Type_TCP})
+}
+
+// ICMP adds an ICMP message, with body such as an *protocol.ICMPEcho, or nil
+// to add the body with Payload.
+func (b *Builder) ICMP(icmpType, code uint8, body util.Message) *Builder {
+	icmp := protocol.NewICMP()
+	icmp.Type = icmpType
+	icmp.Code = code
+	icmp.Payload = body
+	return b.push(layer{msg: icmp, ipProto: protocol.Type_ICMP})
+}
+
+// ICMPv6 adds an ICMPv6 message, such as *protocol.NeighborSolicitation.
+func (b *Builder) ICMPv6(msg util.Message) *Builder {
+	return b.push(layer{msg: msg, ipProto: protocol.Type_IPv6ICMP})
+}
+
+// IGMP adds an IGMP message, such as a *protocol.IGMPv3MembershipReport. As
+// IGMP requires, the TTL of the IPv4 header carrying it is set to 1 and the
+// router alert option is added.
+func (b *Builder) IGMP(msg util.Message) *Builder {
+	if ip, ok := b.last().(*protocol.IPv4); ok && b.err == nil {
+		ip.TTL = 1
+		if len(ip.Options.Bytes()) == 0 {
+			ip.Options = *util.NewBuffer(append([]byte(nil), routerAlert...))
+		}
+	}
+	return b.push(layer{msg: msg, ipProto: protocol.Type_IGMP})
+}
+
+// Layer adds any other layer, such as a *protocol.DNS over UDP or a
+// *protocol.VXLAN header. The ethertype or IP protocol identifying it is set
+// in the layer carrying it if it is one of the types of the protocol package
+// carried by Ethernet or IP.
+func (b *Builder) Layer(msg util.Message) *Builder {
+	l := layer{msg: msg}
+	switch msg.(type) {
+	case *protocol.Ethernet:
+		l.ethertype = protocol.TEB_MSG
+	case *protocol.IPv4:
+		l.ethertype, l.ipProto = protocol.IPv4_MSG, ipProtoIPv4
+	case *protocol.IPv6:
+		l.ethertype, l.ipProto = protocol.IPv6_MSG, protocol.Type_IPv6
+	case *protocol.ARP:
+		l.ethertype = protocol.ARP_MSG
+	case *protocol.MPLS:
+		l.ethertype = protocol.MPLS_MSG
+	case *protocol.LLDP:
+		l.ethertype = protocol.LLDP_MSG
+	case *protocol.NSH:
+		l.ethertype = protocol.NSH_MSG
+	case *protocol.UDP:
+		l.ipProto = protocol.Type_UDP
+	case *protocol.TCP:
+		l.ipProto = protocol.Type_TCP
+	case *protocol.ICMP:
+		l.ipProto = protocol.Type_ICMP
+	case *protocol.GRE:
+		l.ipProto = protocol.Type_GRE
+	case *protocol.IGMPv1or2, *protocol.IGMPv3Query, *protocol.IGMPv3MembershipReport:
+		l.ipProto = protocol.Type_IGMP
+	}
+	return b.push(l)
+}
+
+// Payload adds the raw data ending the packet, as the data of the UDP, TCP
+// or ICMP layer just added, or as a raw layer.
+func (b *Builder) Payload(data []byte) *Builder {
+	if b.err != nil {
+		return b
+	}
+	data = append([]byte(nil), data...)
+	switch m := b.last().(type) {
+	case *protocol.UDP:
+		m.Payload, m.Data = nil, data
+	case *protocol.TCP:
+		m.Payload, m.Data = nil, data
+	case *protocol.ICMP:
+		m.Payload, m.Data = nil, data
+	default:
+		return b.push(layer{msg: util.NewBuffer(data)})
+	}
+	return b
+}
+
+// link sets the ethertypes, IP protocols and next headers identifying each
+// layer in the layer carrying it.
+func (b *Builder) link() {
+	for i, l := range b.layers {
+		var next layer
+		if i+1 < len(b.layers) {
+			next = b.layers[i+1]
+		}
+		switch m := l.msg.(type) {
+		case *protocol.Ethernet:
+			if next.ethertype != 0 && next.ethertype != protocol.TEB_MSG {
+				m.Ethertype = next.ethertype
+			}
+		case *protocol.GRE:
+			if next.ethertype != 0 {
+				m.Protocol = next.ethertype
+			}
+		case *protocol.Geneve:
+			if next.ethertype != 0 {
+				m.Protocol = next.ethertype
+			}
+		case *protocol.IPv4:
+			if next.ipProto != 0 {
+				m.Protocol = next.ipProto
+			}
+		case *protocol.IPv6:
+			linkIPv6(m, next)
+		}
+	}
+}
+
+// linkIPv6 chains the extension headers of ip, then the upper layer.
+func linkIPv6(ip *protocol.IPv6, upper layer) {
+	proto := upper.ipProto
+	if upper.msg == nil {
+		proto = ipProtoNoNext
+	} else if proto == 0 {
+		// An unknown layer added with Layer.
+		proto = ip.NextHeader
+	}
+	if ip.FragmentHeader != nil {
+		ip.FragmentHeader.NextHeader = proto
+		proto = protocol.Type_Fragment
+	}
+	if ip.RoutingHeader != nil {
+		ip.RoutingHeader.NextHeader = proto
+		proto = protocol.Type_Routing
+	}
+	if ip.HbhHeader != nil {
+		ip.HbhHeader.NextHeader = proto
+		proto = protocol.Type_HBH
+	}
+	ip.NextHeader = proto
+}
+
+// Build completes the packet and returns its outermost layer.
+func (b *Builder) Build() (util.Message, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.layers) == 0 {
+		return nil, fmt.Errorf("empty packet")
+	}
+	b.link()
+	if _, err := protocol.Serialize(b.layers[0].msg, protocol.SerializeOptions{FixLengths: true, ComputeChecksums: true}); err != nil {
+		return nil, err
+	}
+	return b.layers[0].msg, nil
+}
+
+// Frame completes the packet, which must start with an Ethernet header, and
+// returns it.
+func (b *Builder) Frame() (*protocol.Ethernet, error) {
+	msg, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	eth, ok := msg.(*protocol.Ethernet)
+	if !ok {
+		return nil, fmt.Errorf("the packet starts with %T instead of an Ethernet header", msg)
+	}
+	return eth, nil
+}
+
+// Bytes completes the packet and returns its wire format.
+func (b *Builder) Bytes() ([]byte, error) {
+	msg, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return msg.MarshalBinary()
+}
+
+// PacketOut15 returns an OpenFlow 1.5 PacketOut sending the frame with the
+// given actions, as if received on inPort, such as openflow15.P_CONTROLLER.
+func (b *Builder) PacketOut15(inPort uint32, actions ...openflow15.Action) (*openflow15.PacketOut, error) {
+	frame, err := b.Frame()
+	if err != nil {
+		return nil, err
+	}
+	msg := openflow15.NewPacketOut()
+	msg.Match.AddField(*openflow15.NewInPortField(inPort))
+	for _, action := range actions {
+		msg.AddAction(action)
+	}
+	msg.Data = frame
+	return msg, nil
+}
+
+// PacketOut13 returns an OpenFlow 1.3 PacketOut sending the frame with the
+// given actions, as if received on inPort, such as openflow13.P_CONTROLLER.
+func (b *Builder) PacketOut13(inPort uint32, actions ...openflow13.Action) (*openflow13.PacketOut, error) {
+	frame, err := b.Frame()
+	if err != nil {
+		return nil, err
+	}
+	msg := openflow13.NewPacketOut()
+	msg.InPort = inPort
+	for _, action := range actions {
+		msg.AddAction(action)
+	}
+	msg.Data = frame
+	return msg, nil
+}
@@ -0,0 +1,222 @@
+package packet
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/openflow13"
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/protocol"
+)
+
+var (
+	srcMAC, _ = net.ParseMAC("aa:bb:cc:dd:ee:01")
+	dstMAC, _ = net.ParseMAC("aa:bb:cc:dd:ee:02")
+	srcIP     = net.ParseIP("10.0.0.1")
+	dstIP     = net.ParseIP("10.0.0.2")
+	srcIPv6   = net.ParseIP("2001:db8::1")
+	dstIPv6   = net.ParseIP("2001:db8::2")
+)
+
+// decode decodes the frame data as received, and checks its checksums.
+func decode(t *testing.T, data []byte) *protocol.Ethernet {
+	eth := new(protocol.Ethernet)
+	require.NoError(t, eth.UnmarshalBinary(data))
+	require.NoError(t, protocol.VerifyChecksums(eth))
+	return eth
+}
+
+func TestBuilderUDP(t *testing.T) {
+	data, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		VLAN(100, 3).
+		VLAN(200, 0).
+		IPv4(srcIP, dstIP).
+		UDP(12345, 9999).
+		Payload([]byte("hello")).
+		Bytes()
+	require.NoError(t, err)
+	assert.Len(t, data, 14+8+20+8+5)
+
+	eth := decode(t, data)
+	assert.Equal(t, srcMAC, eth.HWSrc)
+	assert.Equal(t, dstMAC, eth.HWDst)
+	assert.Equal(t, []protocol.VLAN{{TPID: 0x8100, PCP: 3, VID: 100}, {TPID: 0x8100, VID: 200}}, eth.VLANs())
+	assert.Equal(t, uint16(protocol.IPv4_MSG), eth.Ethertype)
+	ip := eth.Data.(*protocol.IPv4)
+	assert.Equal(t, uint8(protocol.Type_UDP), ip.Protocol)
+	assert.Equal(t, uint8(64), ip.TTL)
+	assert.Equal(t, uint16(20+8+5), ip.Length)
+	udp := ip.Data.(*protocol.UDP)
+	assert.Equal(t, uint16(8+5), udp.Length)
+	assert.Equal(t, []byte("hello"), udp.Data)
+}
+
+func TestBuilderTCP(t *testing.T) {
+	frame, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv4(srcIP, dstIP).
+		TCP(80, 40000, 100, 200, protocol.TCP_SYN|protocol.TCP_ACK).
+		Frame()
+	require.NoError(t, err)
+	data, err := frame.MarshalBinary()
+	require.NoError(t, err)
+
+	tcp := decode(t, data).Data.(*protocol.IPv4).Data.(*protocol.TCP)
+	assert.Equal(t, uint16(80), tcp.PortSrc)
+	assert.Equal(t, uint32(100), tcp.SeqNum)
+	assert.Equal(t, uint32(200), tcp.AckNum)
+	assert.True(t, tcp.SYN())
+	assert.True(t, tcp.ACK())
+}
+
+func TestBuilderICMP(t *testing.T) {
+	data, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv4(srcIP, dstIP).
+		ICMP(protocol.ICMP_Type_EchoRequest, 0, &protocol.ICMPEcho{Identifier: 1, SeqNum: 2, Data: []byte("ping")}).
+		Bytes()
+	require.NoError(t, err)
+
+	icmp := decode(t, data).Data.(*protocol.IPv4).Data.(*protocol.ICMP)
+	assert.Equal(t, uint8(protocol.ICMP_Type_EchoRequest), icmp.Type)
+	assert.Equal(t, &protocol.ICMPEcho{Identifier: 1, SeqNum: 2, Data: []byte("ping")}, icmp.Payload)
+}
+
+func TestBuilderIPv6ExtensionHeaders(t *testing.T) {
+	data, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv6(srcIPv6, dstIPv6).
+		IPv6HopByHop(&protocol.Option{Type: 1, Length: 2, Data: []byte{0, 0}}).
+		IPv6Routing(4, 0, []byte{0, 0, 0, 0}).
+		IPv6Fragment(0x1234, 0, false).
+		ICMPv6(protocol.NewICMPv6EchoRequest(7, 8)).
+		Bytes()
+	require.NoError(t, err)
+
+	eth := decode(t, data)
+	assert.Equal(t, uint16(protocol.IPv6_MSG), eth.Ethertype)
+	ip := eth.Data.(*protocol.IPv6)
+	assert.Equal(t, uint8(protocol.Type_HBH), ip.NextHeader)
+	assert.Equal(t, uint16(len(data)-14-40), ip.Length)
+	require.NotNil(t, ip.HbhHeader)
+	assert.Equal(t, uint8(protocol.Type_Routing), ip.HbhHeader.NextHeader)
+	assert.Equal(t, uint8(0), ip.HbhHeader.HEL)
+	require.NotNil(t, ip.RoutingHeader)
+	assert.Equal(t, uint8(protocol.Type_Fragment), ip.RoutingHeader.NextHeader)
+	assert.Equal(t, uint8(4), ip.RoutingHeader.RoutingType)
+	require.NotNil(t, ip.FragmentHeader)
+	assert.Equal(t, uint8(protocol.Type_IPv6ICMP), ip.FragmentHeader.NextHeader)
+	assert.Equal(t, uint32(0x1234), ip.FragmentHeader.Identification)
+	echo := ip.Data.(*protocol.ICMPv6EchoReqRpl)
+	assert.Equal(t, uint16(7), echo.Identifier)
+	assert.Equal(t, uint16(8), echo.SeqNum)
+}
+
+func TestBuilderIGMP(t *testing.T) {
+	report := &protocol.IGMPv1or2{Type: protocol.IGMPv2Report, GroupAddress: net.ParseIP("239.1.1.1").To4()}
+	data, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv4(srcIP, net.ParseIP("239.1.1.1")).
+		IGMP(report).
+		Bytes()
+	require.NoError(t, err)
+
+	ip := decode(t, data).Data.(*protocol.IPv4)
+	assert.Equal(t, uint8(protocol.Type_IGMP), ip.Protocol)
+	assert.Equal(t, uint8(1), ip.TTL)
+	assert.Equal(t, uint8(6), ip.IHL)
+	assert.Equal(t, routerAlert, ip.Options.Bytes())
+	decoded := ip.Data.(*protocol.IGMPv1or2)
+	assert.Equal(t, report.GroupAddress, decoded.GroupAddress)
+	assert.NotZero(t, decoded.Checksum)
+}
+
+func TestBuilderTunnel(t *testing.T) {
+	innerMAC, _ := net.ParseMAC("02:00:00:00:00:01")
+	data, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv4(srcIP, dstIP).
+		UDP(50000, 4789).
+		Layer(protocol.NewVXLAN(5000)).
+		Ethernet(innerMAC, innerMAC).
+		IPv6(srcIPv6, dstIPv6).
+		UDP(1000, 2000).
+		Payload([]byte{1, 2, 3}).
+		Bytes()
+	require.NoError(t, err)
+
+	outerIP := decode(t, data).Data.(*protocol.IPv4)
+	vxlan := outerIP.Data.(*protocol.UDP).Payload.(*protocol.VXLAN)
+	assert.Equal(t, uint32(5000), vxlan.VNI)
+	inner := vxlan.Data.(*protocol.Ethernet)
+	assert.Equal(t, uint16(protocol.IPv6_MSG), inner.Ethertype)
+	innerIP := inner.Data.(*protocol.IPv6)
+	assert.Equal(t, uint8(protocol.Type_UDP), innerIP.NextHeader)
+	assert.Equal(t, []byte{1, 2, 3}, innerIP.Data.(*protocol.UDP).Data)
+}
+
+func TestBuilderPacketOut(t *testing.T) {
+	b := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv4(srcIP, dstIP).
+		UDP(1, 2)
+
+	out15, err := b.PacketOut15(openflow15.P_CONTROLLER, openflow15.NewActionOutput(3))
+	require.NoError(t, err)
+	data, err := out15.MarshalBinary()
+	require.NoError(t, err)
+	decoded15 := &openflow15.PacketOut{Data: new(protocol.Ethernet)}
+	require.NoError(t, decoded15.UnmarshalBinary(data))
+	require.Len(t, decoded15.Match.Fields, 1)
+	assert.Equal(t, uint32(openflow15.P_CONTROLLER), decoded15.Match.Fields[0].Value.(*openflow15.InPortField).InPort)
+	require.Len(t, decoded15.Actions, 1)
+	assert.Equal(t, uint32(3), decoded15.Actions[0].(*openflow15.ActionOutput).Port)
+	frame, err := out15.Data.MarshalBinary()
+	require.NoError(t, err)
+	decodedFrame, err := decoded15.Data.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, frame, decodedFrame)
+
+	out13, err := b.PacketOut13(5, openflow13.NewActionOutput(openflow13.P_IN_PORT))
+	require.NoError(t, err)
+	data, err = out13.MarshalBinary()
+	require.NoError(t, err)
+	// in_port, then the actions length, then 6 bytes of padding.
+	assert.Equal(t, []byte{0, 0, 0, 5, 0, 16}, data[12:18])
+	action, err := openflow13.DecodeAction(data[24:40])
+	require.NoError(t, err)
+	assert.Equal(t, uint32(openflow13.P_IN_PORT), action.(*openflow13.ActionOutput).Port)
+	assert.Equal(t, frame, data[40:])
+}
+
+func TestBuilderErrors(t *testing.T) {
+	_, err := NewBuilder().Build()
+	assert.Error(t, err)
+
+	_, err = NewBuilder().IPv4(srcIP, dstIP).VLAN(1, 0).Build()
+	assert.Error(t, err)
+
+	_, err = NewBuilder().Ethernet(srcMAC, dstMAC).IPv4(srcIPv6, dstIP).UDP(1, 2).Build()
+	assert.Error(t, err)
+
+	_, err = NewBuilder().Ethernet(srcMAC, dstMAC).IPv6(srcIP, dstIPv6).Build()
+	assert.Error(t, err)
+
+	_, err = NewBuilder().Ethernet(srcMAC, dstMAC).IPv4(srcIP, dstIP).IPv6Fragment(1, 0, false).Build()
+	assert.Error(t, err)
+
+	_, err = NewBuilder().Ethernet(srcMAC, dstMAC).Payload([]byte{1}).UDP(1, 2).Build()
+	assert.Error(t, err)
+
+	// A frame must start with Ethernet.
+	_, err = NewBuilder().IPv4(srcIP, dstIP).UDP(1, 2).Frame()
+	assert.Error(t, err)
+	msg, err := NewBuilder().IPv4(srcIP, dstIP).UDP(1, 2).Build()
+	require.NoError(t, err)
+	assert.IsType(t, &protocol.IPv4{}, msg)
+	assert.IsType(t, &protocol.UDP{}, msg.(*protocol.IPv4).Data)
+}
@@ -0,0 +1,283 @@
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+
+	"antrea.io/libOpenflow/openflow13"
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+// IPFrag is the fragment state of an IP packet, as the NX_IP_FRAG_* bits of
+// the ip_frag match field.
+type IPFrag uint8
+
+const (
+	IPFragNone  IPFrag = 0
+	IPFragFirst IPFrag = openflow15.NX_IP_FRAG_ANY
+	IPFragLater IPFrag = openflow15.NX_IP_FRAG_ANY | openflow15.NX_IP_FRAG_LATER
+)
+
+const (
+	ipProtoSCTP = 0x84
+
+	icmpv6NeighborSolicitation  = 135
+	icmpv6NeighborAdvertisement = 136
+)
+
+// FlowKey holds the fields of a packet identifying its flow. Fields which do
+// not apply to the packet are zero: the L3 fields of a non-IP and non-ARP
+// packet, the ports of a non-TCP, UDP and SCTP packet, and all L4 fields of
+// a later IP fragment.
+type FlowKey struct {
+	// InPort is the port the packet was received on, if known.
+	InPort uint32
+
+	EthSrc net.HardwareAddr
+	EthDst net.HardwareAddr
+	// VLANs are the VLAN tags, outermost first.
+	VLANs []protocol.VLAN
+	// EthType is the ethertype after the VLAN tags.
+	EthType uint16
+
+	// IPSrc and IPDst are the IPv4 or IPv6 addresses, or the ARP protocol
+	// addresses.
+	IPSrc net.IP
+	IPDst net.IP
+	// IPProto is the IP protocol, after the IPv6 extension headers. For
+	// ARP, it is the low byte of the operation, as in Open vSwitch.
+	IPProto uint8
+	IPFrag  IPFrag
+	ARPOp   uint16
+
+	SrcPort  uint16
+	DstPort  uint16
+	ICMPType uint8
+	ICMPCode uint8
+	// TCPFlags are the TCP_* flags.
+	TCPFlags uint16
+	// NDTarget is the target address of IPv6 neighbor solicitations and
+	// advertisements.
+	NDTarget net.IP
+}
+
+// ExtractFlowKey returns the flow key of frame.
+func ExtractFlowKey(frame *protocol.Ethernet) (*FlowKey, error) {
+	if frame == nil {
+		return nil, errors.New("no frame to extract a flow key from")
+	}
+	key := &FlowKey{
+		EthSrc:  append(net.HardwareAddr(nil), frame.HWSrc...),
+		EthDst:  append(net.HardwareAddr(nil), frame.HWDst...),
+		VLANs:   frame.VLANs(),
+		EthType: frame.Ethertype,
+	}
+	switch l3 := frame.Data.(type) {
+	case *protocol.IPv4:
+		key.IPSrc = append(net.IP(nil), l3.NWSrc...)
+		key.IPDst = append(net.IP(nil), l3.NWDst...)
+		key.IPProto = l3.Protocol
+		if l3.FragmentOffset != 0 {
+			key.IPFrag = IPFragLater
+		} else if l3.Flags&protocol.IPv4_Flag_MoreFragments != 0 {
+			key.IPFrag = IPFragFirst
+		}
+		return key, key.extractL4(l3.Data)
+	case *protocol.IPv6:
+		key.IPSrc = append(net.IP(nil), l3.NWSrc...)
+		key.IPDst = append(net.IP(nil), l3.NWDst...)
+		key.IPProto = l3.NextHeader
+		if l3.HbhHeader != nil {
+			key.IPProto = l3.HbhHeader.NextHeader
+		}
+		if l3.RoutingHeader != nil {
+			key.IPProto = l3.RoutingHeader.NextHeader
+		}
+		if fh := l3.FragmentHeader; fh != nil {
+			key.IPProto = fh.NextHeader
+			key.IPFrag = IPFragFirst
+			if fh.FragmentOffset != 0 {
+				key.IPFrag = IPFragLater
+			}
+		}
+		return key, key.extractL4(l3.Data)
+	case *protocol.ARP:
+		key.IPSrc = append(net.IP(nil), l3.IPSrc...)
+		key.IPDst = append(net.IP(nil), l3.IPDst...)
+		key.ARPOp = l3.Operation
+		key.IPProto = uint8(l3.Operation)
+	}
+	return key, nil
+}
+
+// extractL4 reads the L4 fields from the wire format of the IP payload, as
+// the payload of a first fragment is not decoded.
+func (k *FlowKey) extractL4(payload util.Message) error {
+	if payload == nil || k.IPFrag == IPFragLater {
+		return nil
+	}
+	data, err := payload.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	switch k.IPProto {
+	case protocol.Type_TCP, protocol.Type_UDP, ipProtoSCTP:
+		if len(data) < 4 {
+			return nil
+		}
+		k.SrcPort = binary.BigEndian.Uint16(data[0:])
+		k.DstPort = binary.BigEndian.Uint16(data[2:])
+		if k.IPProto == protocol.Type_TCP && len(data) >= 14 {
+			k.TCPFlags = binary.BigEndian.Uint16(data[12:]) & 0x0fff
+		}
+	case protocol.Type_ICMP, protocol.Type_IPv6ICMP:
+		if len(data) < 2 {
+			return nil
+		}
+		k.ICMPType = data[0]
+		k.ICMPCode = data[1]
+		if k.IPProto == protocol.Type_IPv6ICMP && len(data) >= 24 &&
+			(k.ICMPType == icmpv6NeighborSolicitation || k.ICMPType == icmpv6NeighborAdvertisement) {
+			k.NDTarget = append(net.IP(nil), data[8:24]...)
+		}
+	}
+	return nil
+}
+
+// PacketIn15 returns the in_port of the match of an OpenFlow 1.5 PacketIn, or
+// zero if it has none, and its packet.
+func PacketIn15(msg *openflow15.PacketIn) (uint32, *protocol.Ethernet, error) {
+	frame, ok := msg.Data.(*protocol.Ethernet)
+	if !ok {
+		if msg.Data == nil {
+			return 0, nil, errors.New("the PacketIn has no packet")
+		}
+		data, err := msg.Data.MarshalBinary()
+		if err != nil {
+			return 0, nil, err
+		}
+		frame = new(protocol.Ethernet)
+		if err := frame.UnmarshalBinary(data); err != nil {
+			return 0, nil, err
+		}
+	}
+	var inPort uint32
+	for _, f := range msg.Match.Fields {
+		if p, ok := f.Value.(*openflow15.InPortField); ok && f.Class == openflow15.OXM_CLASS_OPENFLOW_BASIC {
+			inPort = p.InPort
+		}
+	}
+	return inPort, frame, nil
+}
+
+// PacketIn13 returns the in_port of the match of an OpenFlow 1.3 PacketIn, or
+// zero if it has none, and its packet.
+func PacketIn13(msg *openflow13.PacketIn) (uint32, *protocol.Ethernet) {
+	var inPort uint32
+	for _, f := range msg.Match.Fields {
+		if p, ok := f.Value.(*openflow13.InPortField); ok && f.Class == openflow13.OXM_CLASS_OPENFLOW_BASIC {
+			inPort = p.InPort
+		}
+	}
+	return inPort, &msg.Data
+}
+
+// FlowKeyFromPacketIn15 returns the flow key of the packet of an OpenFlow 1.5
+// PacketIn, with the in_port of its match.
+func FlowKeyFromPacketIn15(msg *openflow15.PacketIn) (*FlowKey, error) {
+	inPort, frame, err := PacketIn15(msg)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ExtractFlowKey(frame)
+	if err != nil {
+		return nil, err
+	}
+	key.InPort = inPort
+	return key, nil
+}
+
+// FlowKeyFromPacketIn13 returns the flow key of the packet of an OpenFlow 1.3
+// PacketIn, with the in_port of its match.
+func FlowKeyFromPacketIn13(msg *openflow13.PacketIn) (*FlowKey, error) {
+	inPort, frame := PacketIn13(msg)
+	key, err := ExtractFlowKey(frame)
+	if err != nil {
+		return nil, err
+	}
+	key.InPort = inPort
+	return key, nil
+}
+
+// Match15 returns an OpenFlow 1.5 match for exactly the packets of the flow:
+// in_port if known, the Ethernet addresses, the outermost VLAN tag or its
+// absence, the ethertype, and then the L3 and L4 fields which apply. The TCP
+// flags, which change within a connection, are left out.
+func (k *FlowKey) Match15() *openflow15.Match {
+	m := openflow15.NewMatch()
+	if k.InPort != 0 {
+		m.AddField(*openflow15.NewInPortField(k.InPort))
+	}
+	m.AddField(*openflow15.NewEthSrcField(k.EthSrc, nil))
+	m.AddField(*openflow15.NewEthDstField(k.EthDst, nil))
+	if len(k.VLANs) > 0 {
+		m.AddField(*openflow15.NewVlanIdField(k.VLANs[0].VID, nil))
+		m.AddField(*openflow15.NewVlanPcpField(k.VLANs[0].PCP))
+	} else {
+		vlan := openflow15.NewVlanIdField(0, nil)
+		vlan.Value.(*openflow15.VlanIdField).VlanId = openflow15.OFPVID_NONE
+		m.AddField(*vlan)
+	}
+	m.AddField(*openflow15.NewEthTypeField(k.EthType))
+
+	switch k.EthType {
+	case protocol.IPv4_MSG:
+		m.AddField(*openflow15.NewIpv4SrcField(k.IPSrc, nil))
+		m.AddField(*openflow15.NewIpv4DstField(k.IPDst, nil))
+	case protocol.IPv6_MSG:
+		m.AddField(*openflow15.NewIpv6SrcField(k.IPSrc, nil))
+		m.AddField(*openflow15.NewIpv6DstField(k.IPDst, nil))
+	case protocol.ARP_MSG:
+		m.AddField(*openflow15.NewArpOperField(k.ARPOp))
+		m.AddField(*openflow15.NewArpSpaField(k.IPSrc, nil))
+		m.AddField(*openflow15.NewArpTpaField(k.IPDst, nil))
+		return m
+	default:
+		return m
+	}
+	m.AddField(*openflow15.NewIpProtoField(k.IPProto))
+	fragMask := uint8(openflow15.NX_IP_FRAG_ANY | openflow15.NX_IP_FRAG_LATER)
+	m.AddField(*openflow15.NewIPFragMatchField(uint8(k.IPFrag), &fragMask))
+	if k.IPFrag == IPFragLater {
+		return m
+	}
+
+	switch k.IPProto {
+	case protocol.Type_TCP:
+		m.AddField(*openflow15.NewTcpSrcField(k.SrcPort))
+		m.AddField(*openflow15.NewTcpDstField(k.DstPort))
+	case protocol.Type_UDP:
+		m.AddField(*openflow15.NewUdpSrcField(k.SrcPort))
+		m.AddField(*openflow15.NewUdpDstField(k.DstPort))
+	case ipProtoSCTP:
+		m.AddField(*openflow15.NewSctpSrcField(k.SrcPort))
+		m.AddField(*openflow15.NewSctpDstField(k.DstPort))
+	case protocol.Type_ICMP:
+		if k.EthType == protocol.IPv4_MSG {
+			m.AddField(*openflow15.NewIcmpTypeField(k.ICMPType))
+			m.AddField(*openflow15.NewIcmpCodeField(k.ICMPCode))
+		}
+	case protocol.Type_IPv6ICMP:
+		if k.EthType == protocol.IPv6_MSG {
+			m.AddField(*openflow15.NewIcmpv6TypeField(k.ICMPType))
+			m.AddField(*openflow15.NewIcmpv6CodeField(k.ICMPCode))
+			if k.NDTarget != nil {
+				m.AddField(*openflow15.NewIpv6NDTargetField(k.NDTarget))
+			}
+		}
+	}
+	return m
+}
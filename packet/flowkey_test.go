@@ -0,0 +1,227 @@
+package packet
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/openflow13"
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/protocol"
+)
+
+// extract decodes data as received, then returns its flow key, after checking
+// that the match of the key is valid and survives a round trip.
+func extract(t *testing.T, data []byte) *FlowKey {
+	key, err := ExtractFlowKey(decode(t, data))
+	require.NoError(t, err)
+	match := key.Match15()
+	require.NoError(t, match.Validate())
+	b, err := match.MarshalBinary()
+	require.NoError(t, err)
+	decoded := openflow15.NewMatch()
+	require.NoError(t, decoded.UnmarshalBinary(b))
+	assert.Len(t, decoded.Fields, len(match.Fields))
+	return key
+}
+
+// matchFields returns the OXM or NXM field numbers of m.
+func matchFields(m *openflow15.Match) []uint8 {
+	var fields []uint8
+	for _, f := range m.Fields {
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
+
+func TestFlowKeyUDP(t *testing.T) {
+	data, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		VLAN(100, 5).
+		IPv4(srcIP, dstIP).
+		UDP(12345, 53).
+		Payload([]byte("query")).
+		Bytes()
+	require.NoError(t, err)
+
+	key := extract(t, data)
+	assert.Equal(t, &FlowKey{
+		EthSrc:  srcMAC,
+		EthDst:  dstMAC,
+		VLANs:   []protocol.VLAN{{TPID: 0x8100, PCP: 5, VID: 100}},
+		EthType: protocol.IPv4_MSG,
+		IPSrc:   srcIP.To4(),
+		IPDst:   dstIP.To4(),
+		IPProto: protocol.Type_UDP,
+		SrcPort: 12345,
+		DstPort: 53,
+	}, key)
+	assert.Equal(t, []uint8{
+		openflow15.OXM_FIELD_ETH_SRC,
+		openflow15.OXM_FIELD_ETH_DST,
+		openflow15.OXM_FIELD_VLAN_VID,
+		openflow15.OXM_FIELD_VLAN_PCP,
+		openflow15.OXM_FIELD_ETH_TYPE,
+		openflow15.OXM_FIELD_IPV4_SRC,
+		openflow15.OXM_FIELD_IPV4_DST,
+		openflow15.OXM_FIELD_IP_PROTO,
+		openflow15.NXM_NX_IP_FRAG,
+		openflow15.OXM_FIELD_UDP_SRC,
+		openflow15.OXM_FIELD_UDP_DST,
+	}, matchFields(key.Match15()))
+	vlan := key.Match15().Fields[2].Value.(*openflow15.VlanIdField)
+	assert.Equal(t, uint16(openflow15.OFPVID_PRESENT|100), vlan.VlanId)
+}
+
+func TestFlowKeyTCP(t *testing.T) {
+	data, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv6(srcIPv6, dstIPv6).
+		TCP(40000, 443, 1, 0, protocol.TCP_SYN).
+		Bytes()
+	require.NoError(t, err)
+
+	key := extract(t, data)
+	assert.Equal(t, uint16(protocol.IPv6_MSG), key.EthType)
+	assert.Equal(t, srcIPv6, key.IPSrc)
+	assert.Equal(t, uint8(protocol.Type_TCP), key.IPProto)
+	assert.Equal(t, uint16(40000), key.SrcPort)
+	assert.Equal(t, uint16(443), key.DstPort)
+	assert.Equal(t, uint16(protocol.TCP_SYN), key.TCPFlags)
+	// Untagged, and without the TCP flags.
+	m := key.Match15()
+	assert.Equal(t, uint16(openflow15.OFPVID_NONE), m.Fields[2].Value.(*openflow15.VlanIdField).VlanId)
+	assert.NotContains(t, matchFields(m), uint8(openflow15.OXM_FIELD_TCP_FLAGS))
+}
+
+func TestFlowKeyICMP(t *testing.T) {
+	data, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv4(srcIP, dstIP).
+		ICMP(protocol.ICMP_Type_DestinationUnreachable, protocol.ICMP_Code_PortUnreachable, nil).
+		Payload(make([]byte, 8)).
+		Bytes()
+	require.NoError(t, err)
+
+	key := extract(t, data)
+	assert.Equal(t, uint8(protocol.ICMP_Type_DestinationUnreachable), key.ICMPType)
+	assert.Equal(t, uint8(protocol.ICMP_Code_PortUnreachable), key.ICMPCode)
+	assert.Contains(t, matchFields(key.Match15()), uint8(openflow15.OXM_FIELD_ICMPV4_TYPE))
+}
+
+func TestFlowKeyNeighborSolicitation(t *testing.T) {
+	target := net.ParseIP("2001:db8::2")
+	data, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv6(srcIPv6, net.ParseIP("ff02::1:ff00:2")).
+		ICMPv6(protocol.NewNeighborSolicitation(target, srcMAC)).
+		Bytes()
+	require.NoError(t, err)
+
+	key := extract(t, data)
+	assert.Equal(t, uint8(protocol.Type_IPv6ICMP), key.IPProto)
+	assert.Equal(t, uint8(icmpv6NeighborSolicitation), key.ICMPType)
+	assert.Equal(t, target, key.NDTarget)
+	assert.Contains(t, matchFields(key.Match15()), uint8(openflow15.OXM_FIELD_IPV6_ND_TARGET))
+}
+
+func TestFlowKeyFragments(t *testing.T) {
+	b := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv4(srcIP, dstIP).
+		UDP(1000, 2000).
+		Payload(make([]byte, 16))
+	frame, err := b.Frame()
+	require.NoError(t, err)
+	ip := frame.Data.(*protocol.IPv4)
+
+	// The ports of the first fragment are still known.
+	ip.Flags = protocol.IPv4_Flag_MoreFragments
+	data, err := protocol.Serialize(frame, protocol.SerializeOptions{ComputeChecksums: true})
+	require.NoError(t, err)
+	key := extract(t, data)
+	assert.Equal(t, IPFragFirst, key.IPFrag)
+	assert.Equal(t, uint16(2000), key.DstPort)
+
+	ip.Flags = 0
+	ip.FragmentOffset = 3
+	data, err = protocol.Serialize(frame, protocol.SerializeOptions{ComputeChecksums: true})
+	require.NoError(t, err)
+	key = extract(t, data)
+	assert.Equal(t, IPFragLater, key.IPFrag)
+	assert.Zero(t, key.DstPort)
+	m := key.Match15()
+	assert.Equal(t, uint8(openflow15.NXM_NX_IP_FRAG), m.Fields[len(m.Fields)-1].Field)
+
+	frame, err = NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv6(srcIPv6, dstIPv6).
+		IPv6Fragment(1, 100, true).
+		Payload(make([]byte, 16)).
+		Frame()
+	require.NoError(t, err)
+	// The protocol of the later fragments is the one of the fragment header.
+	frame.Data.(*protocol.IPv6).FragmentHeader.NextHeader = protocol.Type_UDP
+	data, err = protocol.Serialize(frame, protocol.SerializeOptions{})
+	require.NoError(t, err)
+	key = extract(t, data)
+	assert.Equal(t, IPFragLater, key.IPFrag)
+	assert.Equal(t, uint8(protocol.Type_UDP), key.IPProto)
+	assert.Zero(t, key.DstPort)
+}
+
+func TestFlowKeyARP(t *testing.T) {
+	arp, err := protocol.NewARP(protocol.Type_Request)
+	require.NoError(t, err)
+	arp.HWSrc = srcMAC
+	arp.IPSrc = srcIP.To4()
+	arp.IPDst = dstIP.To4()
+	data, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		Layer(arp).
+		Bytes()
+	require.NoError(t, err)
+
+	key := extract(t, data)
+	assert.Equal(t, uint16(protocol.ARP_MSG), key.EthType)
+	assert.Equal(t, uint16(protocol.Type_Request), key.ARPOp)
+	assert.Equal(t, srcIP.To4(), key.IPSrc)
+	assert.Equal(t, dstIP.To4(), key.IPDst)
+}
+
+func TestFlowKeyFromPacketIn(t *testing.T) {
+	frame, err := NewBuilder().
+		Ethernet(srcMAC, dstMAC).
+		IPv4(srcIP, dstIP).
+		UDP(1, 2).
+		Frame()
+	require.NoError(t, err)
+
+	pktIn15 := openflow15.NewPacketIn()
+	pktIn15.Match.AddField(*openflow15.NewInPortField(7))
+	pktIn15.Data = frame
+	data, err := pktIn15.MarshalBinary()
+	require.NoError(t, err)
+	received := openflow15.NewPacketIn()
+	require.NoError(t, received.UnmarshalBinary(data))
+	key, err := FlowKeyFromPacketIn15(received)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), key.InPort)
+	assert.Equal(t, uint16(2), key.DstPort)
+	m := key.Match15()
+	assert.Equal(t, uint8(openflow15.OXM_FIELD_IN_PORT), m.Fields[0].Field)
+	assert.NoError(t, m.Validate())
+
+	pktIn13 := openflow13.NewPacketIn()
+	pktIn13.Match.AddField(*openflow13.NewInPortField(8))
+	pktIn13.Data = *frame
+	key, err = FlowKeyFromPacketIn13(pktIn13)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(8), key.InPort)
+	assert.Equal(t, uint16(2), key.DstPort)
+
+	_, err = FlowKeyFromPacketIn15(&openflow15.PacketIn{})
+	assert.Error(t, err)
+}
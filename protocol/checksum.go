@@ -0,0 +1,282 @@
+package protocol
+
+// This file computes and verifies the checksums of the packet layers. The
+// checksums of TCP, UDP and ICMPv6 cover a pseudo-header made of the addresses
+// of the IPv4 or IPv6 layer carrying them, which is passed down as the parent
+// of the layer.
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// SerializeOptions controls how Serialize completes the layers of a packet
+// before marshaling it.
+type SerializeOptions struct {
+	// FixLengths sets the length fields of the IPv4, IPv6, UDP and TCP layers
+	// from the size of the layers they carry, including the frame length of
+	// STT.
+	FixLengths bool
+	// ComputeChecksums sets the checksum fields of the IPv4, TCP, UDP, ICMP,
+	// ICMPv6 and IGMP layers, and of GRE if present.
+	ComputeChecksums bool
+}
+
+// Serialize completes the length and checksum fields of msg and of the layers
+// it carries as requested by opts, then returns its wire format. The fields
+// are updated in place, innermost layer first.
+func Serialize(msg util.Message, opts SerializeOptions) ([]byte, error) {
+	if err := prepare(msg, nil, opts); err != nil {
+		return nil, err
+	}
+	return msg.MarshalBinary()
+}
+
+// ChecksumError is returned by VerifyChecksums for a layer whose checksum is
+// wrong.
+type ChecksumError struct {
+	// Layer is the decoded layer, for instance *UDP.
+	Layer    util.Message
+	Checksum uint16
+	// Expected is the checksum computed from the content of the layer.
+	Expected uint16
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("bad %T checksum 0x%04x, expected 0x%04x", e.Layer, e.Checksum, e.Expected)
+}
+
+// VerifyChecksums checks the checksums of msg and of the layers it carries,
+// such as the Ethernet frame of a PacketIn, and returns a *ChecksumError for
+// the first wrong one. Layers without checksum and undecoded payloads are
+// ignored, as well as UDP over IPv4 with a zero checksum, meaning none.
+func VerifyChecksums(msg util.Message) error {
+	return verify(msg, nil)
+}
+
+// InternetChecksum returns the one's complement of the one's complement sum
+// of the 16-bit words of data, as defined by RFC 1071.
+func InternetChecksum(data []byte) uint16 {
+	return ^fold(sum(0, data))
+}
+
+func sum(s uint32, data []byte) uint32 {
+	for len(data) >= 2 {
+		s += uint32(binary.BigEndian.Uint16(data))
+		data = data[2:]
+	}
+	if len(data) == 1 {
+		s += uint32(data[0]) << 8
+	}
+	return s
+}
+
+func fold(s uint32) uint16 {
+	for s > 0xffff {
+		s = (s >> 16) + (s & 0xffff)
+	}
+	return uint16(s)
+}
+
+// icmpv6Layer is implemented by all ICMPv6 messages through the embedded
+// ICMPv6Header.
+type icmpv6Layer interface {
+	util.Message
+	icmpv6Header() *ICMPv6Header
+}
+
+func (i *ICMPv6Header) icmpv6Header() *ICMPv6Header {
+	return i
+}
+
+// checksumField returns the checksum field of msg and whether its checksum
+// covers a pseudo-header, or nil if msg has no checksum.
+func checksumField(msg util.Message) (field *uint16, pseudo bool) {
+	switch m := msg.(type) {
+	case *IPv4:
+		return &m.Checksum, false
+	case *TCP:
+		return &m.Checksum, true
+	case *UDP:
+		return &m.Checksum, true
+	case *ICMP:
+		return &m.Checksum, false
+	case *IGMPv1or2:
+		return &m.Checksum, false
+	case *IGMPv3Query:
+		return &m.Checksum, false
+	case *IGMPv3MembershipReport:
+		return &m.Checksum, false
+	case icmpv6Layer:
+		return &m.icmpv6Header().Checksum, true
+	case *GRE:
+		if m.ChecksumPresent {
+			return &m.Checksum, false
+		}
+	}
+	return nil, false
+}
+
+// payload returns the layer carried by msg, if any.
+func payload(msg util.Message) util.Message {
+	switch m := msg.(type) {
+	case *Ethernet:
+		return m.Data
+	case *IPv4:
+		return m.Data
+	case *IPv6:
+		return m.Data
+	case *MPLS:
+		return m.Data
+	case *UDP:
+		return m.Payload
+	case *TCP:
+		return m.Payload
+	case *VXLAN:
+		return m.Data
+	case *Geneve:
+		return m.Data
+	case *GRE:
+		return m.Data
+	case *STT:
+		return m.Data
+	case *NSH:
+		return m.Data
+	}
+	return nil
+}
+
+func prepare(msg util.Message, parent util.Message, opts SerializeOptions) error {
+	if msg == nil {
+		return nil
+	}
+	if data := payload(msg); data != nil {
+		if err := prepare(data, msg, opts); err != nil {
+			return err
+		}
+	}
+	if opts.FixLengths {
+		switch m := msg.(type) {
+		case *IPv4:
+			m.IHL = uint8(5 + (len(m.Options.Bytes())+3)/4)
+			m.Length = m.Len()
+		case *IPv6:
+			m.Length = m.Len() - 40
+		case *UDP:
+			m.Length = m.Len()
+		case *TCP:
+			m.HdrLen = uint8(m.optionsHeaderLen() / 4)
+			if stt, ok := m.Payload.(*STT); ok {
+				// The STT frame length, in a single segment.
+				m.SeqNum = uint32(stt.Len()) << 16
+			}
+		}
+	}
+	if !opts.ComputeChecksums {
+		return nil
+	}
+	field, _ := checksumField(msg)
+	if field == nil {
+		return nil
+	}
+	*field = 0
+	expected, err := computeChecksum(msg, parent)
+	if err != nil {
+		return err
+	}
+	if _, ok := msg.(*UDP); ok && expected == 0 {
+		// Zero means no checksum for UDP.
+		expected = 0xffff
+	}
+	*field = expected
+	return nil
+}
+
+func verify(msg util.Message, parent util.Message) error {
+	if msg == nil {
+		return nil
+	}
+	if field, _ := checksumField(msg); field != nil {
+		_, isUDP := msg.(*UDP)
+		_, overIPv4 := parent.(*IPv4)
+		if !(isUDP && overIPv4 && *field == 0) {
+			checksum := *field
+			*field = 0
+			expected, err := computeChecksum(msg, parent)
+			*field = checksum
+			if err != nil {
+				return err
+			}
+			if isUDP && expected == 0 {
+				expected = 0xffff
+			}
+			if checksum != expected {
+				return &ChecksumError{Layer: msg, Checksum: checksum, Expected: expected}
+			}
+		}
+	}
+	return verify(payload(msg), msg)
+}
+
+// computeChecksum returns the checksum of msg, whose checksum field is zero.
+func computeChecksum(msg util.Message, parent util.Message) (uint16, error) {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	if ip, ok := msg.(*IPv4); ok {
+		return InternetChecksum(data[:int(ip.IHL)*4]), nil
+	}
+	_, pseudo := checksumField(msg)
+	if !pseudo {
+		return InternetChecksum(data), nil
+	}
+
+	var s uint32
+	switch ip := parent.(type) {
+	case *IPv4:
+		// Ignore the Ethernet padding of short frames.
+		if n := int(ip.Length) - int(ip.IHL)*4; ip.Length != 0 && n >= 0 && n < len(data) {
+			data = data[:n]
+		}
+		s = sum(s, ip.NWSrc.To4())
+		s = sum(s, ip.NWDst.To4())
+		s += uint32(ip.Protocol)
+		s += uint32(len(data))
+	case *IPv6:
+		proto, extLen := ip.upperLayer()
+		if n := int(ip.Length) - extLen; ip.Length != 0 && n >= 0 && n < len(data) {
+			data = data[:n]
+		}
+		s = sum(s, ip.NWSrc.To16())
+		s = sum(s, ip.NWDst.To16())
+		s += uint32(len(data))
+		s += uint32(proto)
+	default:
+		return 0, fmt.Errorf("%T checksum needs the IPv4 or IPv6 layer carrying it", msg)
+	}
+	return ^fold(sum(s, data)), nil
+}
+
+// upperLayer returns the protocol of the payload of i after its extension
+// headers, and the length of the extension headers.
+func (i *IPv6) upperLayer() (proto uint8, extLen int) {
+	proto = i.NextHeader
+	for {
+		switch {
+		case proto == Type_HBH && i.HbhHeader != nil:
+			proto = i.HbhHeader.NextHeader
+			extLen += int(i.HbhHeader.Len())
+		case proto == Type_Routing && i.RoutingHeader != nil:
+			proto = i.RoutingHeader.NextHeader
+			extLen += int(i.RoutingHeader.Len())
+		case proto == Type_Fragment && i.FragmentHeader != nil:
+			proto = i.FragmentHeader.NextHeader
+			extLen += int(i.FragmentHeader.Len())
+		default:
+			return
+		}
+	}
+}
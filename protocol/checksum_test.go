@@ -0,0 +1,119 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternetChecksum(t *testing.T) {
+	// IPv4 header with a zeroed checksum field.
+	header := []byte{
+		0x45, 0x00, 0x00, 0x73, 0x00, 0x00, 0x40, 0x00, 0x40, 0x11,
+		0x00, 0x00, 0xc0, 0xa8, 0x00, 0x01, 0xc0, 0xa8, 0x00, 0xc7,
+	}
+	assert.Equal(t, uint16(0xb861), InternetChecksum(header))
+	// Odd length is padded with a zero byte.
+	assert.Equal(t, InternetChecksum([]byte{0x01, 0x02, 0x03, 0x00}), InternetChecksum([]byte{0x01, 0x02, 0x03}))
+}
+
+func newTestIPv4(proto uint8) *IPv4 {
+	ip := NewIPv4()
+	ip.Version = 4
+	ip.TTL = 64
+	ip.Protocol = proto
+	ip.NWSrc = net.ParseIP("10.0.0.1").To4()
+	ip.NWDst = net.ParseIP("10.0.0.2").To4()
+	return ip
+}
+
+func TestSerializeChecksums(t *testing.T) {
+	opts := SerializeOptions{FixLengths: true, ComputeChecksums: true}
+
+	udp := NewUDP()
+	udp.PortSrc = 68
+	udp.PortDst = 67
+	udp.Data = []byte("hello")
+	ip := newTestIPv4(Type_UDP)
+	ip.Data = udp
+	eth := NewEthernet()
+	eth.Data = ip
+
+	data, err := Serialize(eth, opts)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(33), ip.Length)
+	assert.Equal(t, uint16(13), udp.Length)
+	assert.NotZero(t, ip.Checksum)
+	assert.NotZero(t, udp.Checksum)
+	// A correct checksum sums up to zero.
+	assert.Zero(t, InternetChecksum(data[14:34]))
+
+	// Short Ethernet frames are padded, which is not part of the UDP payload.
+	data = append(data, make([]byte, 60-len(data))...)
+	decoded := new(Ethernet)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.NoError(t, VerifyChecksums(decoded))
+
+	decoded.Data.(*IPv4).Data.(*UDP).Data[0] ^= 0xff
+	err = VerifyChecksums(decoded)
+	require.IsType(t, &ChecksumError{}, err)
+	assert.IsType(t, &UDP{}, err.(*ChecksumError).Layer)
+
+	decoded.Data.(*IPv4).TTL = 1
+	err = VerifyChecksums(decoded)
+	require.IsType(t, &ChecksumError{}, err)
+	assert.IsType(t, &IPv4{}, err.(*ChecksumError).Layer)
+
+	// A zero UDP checksum over IPv4 means none.
+	udp.Checksum = 0
+	assert.NoError(t, VerifyChecksums(eth))
+}
+
+func TestSerializeChecksumsICMP(t *testing.T) {
+	opts := SerializeOptions{FixLengths: true, ComputeChecksums: true}
+
+	icmp := NewICMP()
+	icmp.Type = 8
+	icmp.Data = []byte{0x00, 0x01, 0x00, 0x02}
+	ip := newTestIPv4(Type_ICMP)
+	ip.Data = icmp
+	_, err := Serialize(ip, opts)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0xf7fc), icmp.Checksum)
+	assert.NoError(t, VerifyChecksums(ip))
+
+	igmp := NewIGMPv2Query(net.ParseIP("224.0.0.1"), 100)
+	data, err := Serialize(igmp, opts)
+	require.NoError(t, err)
+	assert.Zero(t, InternetChecksum(data))
+	assert.NoError(t, VerifyChecksums(igmp))
+
+	mld := NewMLDQuery(1000, net.IPv6zero)
+	ip6 := &IPv6{
+		Version:    6,
+		NextHeader: Type_HBH,
+		HopLimit:   1,
+		NWSrc:      net.ParseIP("fe80::1"),
+		NWDst:      net.ParseIP("ff02::1"),
+		HbhHeader: &HopByHopHeader{
+			NextHeader: Type_IPv6ICMP,
+			Options:    []*Option{{Type: 5, Length: 2, Data: []byte{0, 0}}},
+		},
+		Data: mld,
+	}
+	data, err = Serialize(ip6, opts)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(36), ip6.Length)
+	assert.NotZero(t, mld.Checksum)
+	decoded := new(IPv6)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.NoError(t, VerifyChecksums(decoded))
+	decoded.NWSrc = net.ParseIP("fe80::2")
+	assert.Error(t, VerifyChecksums(decoded))
+
+	// The pseudo-header needs the IP layer.
+	_, err = Serialize(NewUDP(), opts)
+	assert.Error(t, err)
+}
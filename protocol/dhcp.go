@@ -19,6 +19,12 @@ const (
 
 type DHCPOperation byte
 
+// BOOTP operations, the first byte of the message.
+const (
+	DHCP_OP_BOOTREQUEST DHCPOperation = 1
+	DHCP_OP_BOOTREPLY   DHCPOperation = 2
+)
+
 const (
 	DHCP_MSG_UNSPEC DHCPOperation = iota
 	DHCP_MSG_DISCOVER
@@ -49,9 +55,15 @@ type DHCP struct {
 	ServerIP     net.IP
 	GatewayIP    net.IP
 	ClientHWAddr net.HardwareAddr
-	ServerName   [64]byte
-	File         [128]byte
-	Options      []DHCPOption
+	// ClientHWPadding is the rest of the 16 bytes of the client hardware
+	// address field after ClientHWAddr, usually zeros.
+	ClientHWPadding []byte
+	ServerName      [64]byte
+	File            [128]byte
+	Options         []DHCPOption
+	// Padding is the bytes after the END option, which clients add to send
+	// messages of at least 300 bytes.
+	Padding []byte
 }
 
 const (
@@ -126,43 +138,40 @@ func (d *DHCP) Len() (n uint16) {
 	if !optend {
 		n += 1
 	}
+	n += uint16(len(d.Padding))
 	return
 }
 
-func (d *DHCP) Read(b []byte) (n int, err error) {
+func (d *DHCP) MarshalBinary() (data []byte, err error) {
 	buf := new(bytes.Buffer)
-	binary.Write(buf, binary.BigEndian, d.Operation)
-	n += 1
-	binary.Write(buf, binary.BigEndian, d.HardwareType)
-	n += 1
-	binary.Write(buf, binary.BigEndian, d.HardwareLen)
-	n += 1
-	binary.Write(buf, binary.BigEndian, d.HardwareOpts)
-	n += 1
+	if _, err = d.writeTo(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *DHCP) writeTo(buf *bytes.Buffer) (n int, err error) {
+	buf.Grow(int(d.Len()))
+	buf.WriteByte(byte(d.Operation))
+	buf.WriteByte(d.HardwareType)
+	buf.WriteByte(d.HardwareLen)
+	buf.WriteByte(d.HardwareOpts)
 	binary.Write(buf, binary.BigEndian, d.Xid)
-	n += 4
 	binary.Write(buf, binary.BigEndian, d.Secs)
-	n += 2
 	binary.Write(buf, binary.BigEndian, d.Flags)
-	n += 2
-	binary.Write(buf, binary.BigEndian, d.ClientIP)
-	n += 4
-	binary.Write(buf, binary.BigEndian, d.YourIP)
-	n += 4
-	binary.Write(buf, binary.BigEndian, d.ServerIP)
-	n += 4
-	binary.Write(buf, binary.BigEndian, d.GatewayIP)
-	n += 4
+	for _, ip := range []net.IP{d.ClientIP, d.YourIP, d.ServerIP, d.GatewayIP} {
+		addr := make([]byte, 4)
+		copy(addr, ip.To4())
+		buf.Write(addr)
+	}
 	clientHWAddr := make([]byte, 16)
-	copy(clientHWAddr[0:], d.ClientHWAddr)
-	binary.Write(buf, binary.BigEndian, clientHWAddr)
-	n += 16
-	binary.Write(buf, binary.BigEndian, d.ServerName)
-	n += 64
-	binary.Write(buf, binary.BigEndian, d.File)
-	n += 128
+	hwLen := copy(clientHWAddr[0:], d.ClientHWAddr)
+	copy(clientHWAddr[hwLen:], d.ClientHWPadding)
+	buf.Write(clientHWAddr)
+	buf.Write(d.ServerName[:])
+	buf.Write(d.File[:])
 	binary.Write(buf, binary.BigEndian, dhcpMagic)
-	n += 4
+	n = buf.Len()
 
 	optend := false
 	for _, opt := range d.Options {
@@ -182,104 +191,64 @@ func (d *DHCP) Read(b []byte) (n int, err error) {
 			return n, err
 		}
 	}
-	if n, err = buf.Read(b); n == 0 {
-		return
-	}
+	m, _ := buf.Write(d.Padding)
+	n += m
 	return n, nil
 }
 
-func (d *DHCP) Write(b []byte) (n int, err error) {
-	if len(b) < 240 {
-		return 0, errors.New("ErrTruncated")
-	}
-	buf := bytes.NewBuffer(b)
-
-	if err = binary.Read(buf, binary.BigEndian, &d.Operation); err != nil {
-		return
-	}
-	n += 1
-	if err = binary.Read(buf, binary.BigEndian, &d.HardwareType); err != nil {
-		return
-	}
-	n += 1
-	if err = binary.Read(buf, binary.BigEndian, &d.HardwareLen); err != nil {
-		return
-	}
-	n += 1
-	if err = binary.Read(buf, binary.BigEndian, &d.HardwareOpts); err != nil {
-		return
-	}
-	n += 1
-	if err = binary.Read(buf, binary.BigEndian, &d.Xid); err != nil {
-		return
-	}
-	n += 4
-	if err = binary.Read(buf, binary.BigEndian, &d.Secs); err != nil {
-		return
-	}
-	n += 2
-	if err = binary.Read(buf, binary.BigEndian, &d.Flags); err != nil {
-		return
-	}
-	n += 2
-	d.ClientIP = make([]byte, 4)
-	if err = binary.Read(buf, binary.BigEndian, &d.ClientIP); err != nil {
-		return
-	}
-	n += 4
-	d.YourIP = make([]byte, 4)
-	if err = binary.Read(buf, binary.BigEndian, &d.YourIP); err != nil {
-		return
-	}
-	n += 4
-	d.ServerIP = make([]byte, 4)
-	if err = binary.Read(buf, binary.BigEndian, &d.ServerIP); err != nil {
-		return
-	}
-	n += 4
-	d.GatewayIP = make([]byte, 4)
-	if err = binary.Read(buf, binary.BigEndian, &d.GatewayIP); err != nil {
-		return
-	}
-	n += 4
-	clientHWAddr := make([]byte, 16)
-	if err = binary.Read(buf, binary.BigEndian, &clientHWAddr); err != nil {
-		return
-	}
-	d.ClientHWAddr = net.HardwareAddr(clientHWAddr[:d.HardwareLen])
-	n += 16
-
-	if err = binary.Read(buf, binary.BigEndian, &d.ServerName); err != nil {
-		return
-	}
-	n += 64
-	if err = binary.Read(buf, binary.BigEndian, &d.File); err != nil {
-		return
-	}
-	n += 128
-
-	var magic uint32
-	if err = binary.Read(buf, binary.BigEndian, &magic); err != nil {
-		return
-	}
-	n += 4
-
-	if magic != dhcpMagic {
-		return n, errors.New("Bad DHCP header")
-	}
+func (d *DHCP) UnmarshalBinary(data []byte) error {
+	if len(data) < 240 {
+		return errors.New("The []byte is too short to unmarshal a full DHCP message.")
+	}
+	d.Operation = DHCPOperation(data[0])
+	d.HardwareType = data[1]
+	d.HardwareLen = data[2]
+	d.HardwareOpts = data[3]
+	d.Xid = binary.BigEndian.Uint32(data[4:8])
+	d.Secs = binary.BigEndian.Uint16(data[8:10])
+	d.Flags = binary.BigEndian.Uint16(data[10:12])
+	d.ClientIP = net.IP(append([]byte(nil), data[12:16]...))
+	d.YourIP = net.IP(append([]byte(nil), data[16:20]...))
+	d.ServerIP = net.IP(append([]byte(nil), data[20:24]...))
+	d.GatewayIP = net.IP(append([]byte(nil), data[24:28]...))
+	hwLen := int(d.HardwareLen)
+	if hwLen > 16 {
+		hwLen = 16
+	}
+	d.ClientHWAddr = net.HardwareAddr(append([]byte(nil), data[28:28+hwLen]...))
+	d.ClientHWPadding = append([]byte(nil), data[28+hwLen:44]...)
+	copy(d.ServerName[:], data[44:108])
+	copy(d.File[:], data[108:236])
+
+	if binary.BigEndian.Uint32(data[236:240]) != dhcpMagic {
+		return errors.New("Bad DHCP header")
+	}
+	var err error
+	var padding []byte
+	d.Options, padding, err = dhcpParseOptions(data[240:])
+	d.Padding = append([]byte(nil), padding...)
+	return err
+}
 
-	optlen := buf.Len()
-	opts := make([]byte, optlen)
-	if err = binary.Read(buf, binary.BigEndian, &opts); err != nil {
-		return
+// Read writes the DHCP message to b.
+//
+// Deprecated: use MarshalBinary.
+func (d *DHCP) Read(b []byte) (n int, err error) {
+	data, err := d.MarshalBinary()
+	if err != nil {
+		return 0, err
 	}
-	n += optlen
+	return copy(b, data), nil
+}
 
-	if d.Options, err = DHCPParseOptions(opts); err != nil {
-		return
+// Write decodes the DHCP message in b.
+//
+// Deprecated: use UnmarshalBinary.
+func (d *DHCP) Write(b []byte) (n int, err error) {
+	if err = d.UnmarshalBinary(b); err != nil {
+		return 0, err
 	}
-
-	return
+	return len(b), nil
 }
 
 // Standard options (RFC1533)
@@ -448,7 +417,12 @@ func DHCPMarshalOption(o DHCPOption) (out []byte, err error) {
 	return
 }
 
-func (self dhcpoption) Len() uint16      { return uint16(len(self.data) + 2) }
+func (self dhcpoption) Len() uint16 {
+	if self.tag == DHCP_OPT_PAD || self.tag == DHCP_OPT_END {
+		return 1
+	}
+	return uint16(len(self.data) + 2)
+}
 func (self dhcpoption) Bytes() []byte    { return self.data }
 func (self dhcpoption) OptionType() byte { return self.tag }
 
@@ -496,22 +470,30 @@ func DHCPStringOption(tag byte, s string) (opt DHCPOption, err error) {
 }
 
 func DHCPParseOptions(in []byte) (opts []DHCPOption, err error) {
+	opts, _, err = dhcpParseOptions(in)
+	return
+}
+
+// dhcpParseOptions parses the options in in, returning them with the bytes
+// after the END option.
+func dhcpParseOptions(in []byte) (opts []DHCPOption, rest []byte, err error) {
 	pos := 0
-	for pos < len(in) && err == nil {
+	for pos < len(in) {
 		var tag = in[pos]
 		pos++
 		switch tag {
 		case DHCP_OPT_PAD:
 			opts = append(opts, DHCPNewOption(tag, []byte{}))
 		case DHCP_OPT_END:
-			return
+			return opts, in[pos:], nil
 		default:
-			if len(in)-pos >= 1 {
-				_len := in[pos]
-				pos++
-				opts = append(opts, DHCPNewOption(tag, in[pos:pos+int(_len)]))
-				pos += int(_len)
+			if pos >= len(in) || pos+1+int(in[pos]) > len(in) {
+				return opts, nil, fmt.Errorf("DHCP option %d is truncated", tag)
 			}
+			_len := int(in[pos])
+			pos++
+			opts = append(opts, DHCPNewOption(tag, append([]byte(nil), in[pos:pos+_len]...)))
+			pos += _len
 		}
 	}
 	return
@@ -523,6 +505,7 @@ func NewDHCPDiscover(xid uint32, hwAddr net.HardwareAddr) (d *DHCP, err error) {
 	}
 	d.HardwareLen = uint8(len(hwAddr))
 	d.ClientHWAddr = hwAddr
+	d.Operation = DHCP_OP_BOOTREQUEST
 	d.Options = append(d.Options, DHCPNewOption(53, []byte{byte(DHCP_MSG_DISCOVER)}))
 	d.Options = append(d.Options, DHCPNewOption(DHCP_OPT_CLIENT_ID, hwAddr))
 	return
@@ -534,6 +517,7 @@ func NewDHCPOffer(xid uint32, hwAddr net.HardwareAddr) (d *DHCP, err error) {
 	}
 	d.HardwareLen = uint8(len(hwAddr))
 	d.ClientHWAddr = hwAddr
+	d.Operation = DHCP_OP_BOOTREPLY
 	d.Options = append(d.Options, DHCPNewOption(53, []byte{byte(DHCP_MSG_OFFER)}))
 	return
 }
@@ -544,6 +528,7 @@ func NewDHCPRequest(xid uint32, hwAddr net.HardwareAddr) (d *DHCP, err error) {
 	}
 	d.HardwareLen = uint8(len(hwAddr))
 	d.ClientHWAddr = hwAddr
+	d.Operation = DHCP_OP_BOOTREQUEST
 	d.Options = append(d.Options, DHCPNewOption(53, []byte{byte(DHCP_MSG_REQUEST)}))
 	return
 }
@@ -554,6 +539,7 @@ func NewDHCPAck(xid uint32, hwAddr net.HardwareAddr) (d *DHCP, err error) {
 	}
 	d.HardwareLen = uint8(len(hwAddr))
 	d.ClientHWAddr = hwAddr
+	d.Operation = DHCP_OP_BOOTREPLY
 	d.Options = append(d.Options, DHCPNewOption(53, []byte{byte(DHCP_MSG_ACK)}))
 	return
 }
@@ -564,6 +550,7 @@ func NewDHCPNak(xid uint32, hwAddr net.HardwareAddr) (d *DHCP, err error) {
 	}
 	d.HardwareLen = uint8(len(hwAddr))
 	d.ClientHWAddr = hwAddr
+	d.Operation = DHCP_OP_BOOTREPLY
 	d.Options = append(d.Options, DHCPNewOption(53, []byte{byte(DHCP_MSG_NAK)}))
 	return
 }
@@ -0,0 +1,296 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// UDP ports of DHCP, used to decode the payload of UDP.
+const (
+	UDPPort_DHCPServer = 67
+	UDPPort_DHCPClient = 68
+)
+
+const (
+	DHCP_OPT_RELAY_AGENT_INFO byte = 82
+	DHCP_OPT_CLASSLESS_ROUTE  byte = 121
+)
+
+// Relay agent information sub-options
+const (
+	DHCP_RELAY_CIRCUIT_ID byte = 1
+	DHCP_RELAY_REMOTE_ID  byte = 2
+)
+
+// Option returns the first option with the given tag, or nil.
+func (d *DHCP) Option(tag byte) DHCPOption {
+	for _, opt := range d.Options {
+		if opt.OptionType() == tag {
+			return opt
+		}
+	}
+	return nil
+}
+
+// SetOption replaces the options with the tag of opt by opt, or adds it
+// before the End option.
+func (d *DHCP) SetOption(opt DHCPOption) {
+	tag := opt.OptionType()
+	for i, o := range d.Options {
+		if o.OptionType() == tag {
+			d.Options[i] = opt
+			return
+		}
+	}
+	for i, o := range d.Options {
+		if o.OptionType() == DHCP_OPT_END {
+			d.Options = append(d.Options[:i], append([]DHCPOption{opt}, d.Options[i:]...)...)
+			return
+		}
+	}
+	d.Options = append(d.Options, opt)
+}
+
+func (d *DHCP) optionData(tag byte) []byte {
+	if opt := d.Option(tag); opt != nil {
+		return opt.Bytes()
+	}
+	return nil
+}
+
+func (d *DHCP) optionIP(tag byte) net.IP {
+	if data := d.optionData(tag); len(data) == 4 {
+		return net.IP(append([]byte(nil), data...))
+	}
+	return nil
+}
+
+func (d *DHCP) optionIPs(tag byte) []net.IP {
+	data := d.optionData(tag)
+	if len(data) == 0 || len(data)%4 != 0 {
+		return nil
+	}
+	ips := make([]net.IP, 0, len(data)/4)
+	for i := 0; i < len(data); i += 4 {
+		ips = append(ips, net.IP(append([]byte(nil), data[i:i+4]...)))
+	}
+	return ips
+}
+
+func (d *DHCP) setOptionIPs(tag byte, ips []net.IP) error {
+	opt, err := DHCPIP4sOption(tag, ips)
+	if err != nil {
+		return err
+	}
+	d.SetOption(opt)
+	return nil
+}
+
+func (d *DHCP) optionDuration(tag byte) (time.Duration, bool) {
+	if data := d.optionData(tag); len(data) == 4 {
+		return time.Duration(binary.BigEndian.Uint32(data)) * time.Second, true
+	}
+	return 0, false
+}
+
+func (d *DHCP) setOptionDuration(tag byte, t time.Duration) {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, uint32(t/time.Second))
+	d.SetOption(DHCPNewOption(tag, data))
+}
+
+// MessageType returns the DHCP message type, or DHCP_MSG_UNSPEC for BOOTP.
+func (d *DHCP) MessageType() DHCPOperation {
+	if data := d.optionData(DHCP_OPT_MESSAGE_TYPE); len(data) == 1 {
+		return DHCPOperation(data[0])
+	}
+	return DHCP_MSG_UNSPEC
+}
+
+func (d *DHCP) SetMessageType(t DHCPOperation) {
+	d.SetOption(DHCPNewOption(DHCP_OPT_MESSAGE_TYPE, []byte{byte(t)}))
+}
+
+func (d *DHCP) SubnetMask() net.IPMask {
+	if ip := d.optionIP(DHCP_OPT_SUBNET_MASK); ip != nil {
+		return net.IPMask(ip)
+	}
+	return nil
+}
+
+func (d *DHCP) SetSubnetMask(mask net.IPMask) error {
+	if len(mask) != 4 {
+		return errors.New("subnet mask is not an IPv4 mask")
+	}
+	d.SetOption(DHCPNewOption(DHCP_OPT_SUBNET_MASK, append([]byte(nil), mask...)))
+	return nil
+}
+
+// Routers returns the default gateways.
+func (d *DHCP) Routers() []net.IP {
+	return d.optionIPs(DHCP_OPT_DEFAULT_GATEWAY)
+}
+
+func (d *DHCP) SetRouters(ips ...net.IP) error {
+	return d.setOptionIPs(DHCP_OPT_DEFAULT_GATEWAY, ips)
+}
+
+func (d *DHCP) DNSServers() []net.IP {
+	return d.optionIPs(DHCP_OPT_DOMAIN_NAME_SERVERS)
+}
+
+func (d *DHCP) SetDNSServers(ips ...net.IP) error {
+	return d.setOptionIPs(DHCP_OPT_DOMAIN_NAME_SERVERS, ips)
+}
+
+func (d *DHCP) ServerID() net.IP {
+	return d.optionIP(DHCP_OPT_SERVER_ID)
+}
+
+func (d *DHCP) SetServerID(ip net.IP) error {
+	return d.setOptionIPs(DHCP_OPT_SERVER_ID, []net.IP{ip})
+}
+
+func (d *DHCP) RequestedIP() net.IP {
+	return d.optionIP(DHCP_OPT_REQUEST_IP)
+}
+
+func (d *DHCP) SetRequestedIP(ip net.IP) error {
+	return d.setOptionIPs(DHCP_OPT_REQUEST_IP, []net.IP{ip})
+}
+
+// LeaseTime returns the IP address lease time, and whether it is present.
+func (d *DHCP) LeaseTime() (time.Duration, bool) {
+	return d.optionDuration(DHCP_OPT_LEASE_TIME)
+}
+
+func (d *DHCP) SetLeaseTime(t time.Duration) {
+	d.setOptionDuration(DHCP_OPT_LEASE_TIME, t)
+}
+
+// RenewalTime returns the T1 time, and whether it is present.
+func (d *DHCP) RenewalTime() (time.Duration, bool) {
+	return d.optionDuration(DHCP_OPT_T1)
+}
+
+func (d *DHCP) SetRenewalTime(t time.Duration) {
+	d.setOptionDuration(DHCP_OPT_T1, t)
+}
+
+// RebindingTime returns the T2 time, and whether it is present.
+func (d *DHCP) RebindingTime() (time.Duration, bool) {
+	return d.optionDuration(DHCP_OPT_T2)
+}
+
+func (d *DHCP) SetRebindingTime(t time.Duration) {
+	d.setOptionDuration(DHCP_OPT_T2, t)
+}
+
+// ClientID returns the client identifier, usually the hardware type followed
+// by the hardware address, or nil.
+func (d *DHCP) ClientID() []byte {
+	return d.optionData(DHCP_OPT_CLIENT_ID)
+}
+
+func (d *DHCP) SetClientID(id []byte) {
+	d.SetOption(DHCPNewOption(DHCP_OPT_CLIENT_ID, append([]byte(nil), id...)))
+}
+
+func (d *DHCP) Hostname() string {
+	return string(d.optionData(DHCP_OPT_HOST_NAME))
+}
+
+func (d *DHCP) SetHostname(name string) {
+	d.SetOption(DHCPNewOption(DHCP_OPT_HOST_NAME, []byte(name)))
+}
+
+// DHCPRelayAgentSubOption is a sub-option of the relay agent information
+// option, such as DHCP_RELAY_CIRCUIT_ID.
+type DHCPRelayAgentSubOption struct {
+	Code byte
+	Data []byte
+}
+
+// RelayAgentInfo returns the sub-options of the relay agent information
+// option added by relays, or nil.
+func (d *DHCP) RelayAgentInfo() ([]DHCPRelayAgentSubOption, error) {
+	data := d.optionData(DHCP_OPT_RELAY_AGENT_INFO)
+	var subOpts []DHCPRelayAgentSubOption
+	for n := 0; n < len(data); {
+		if n+2 > len(data) || n+2+int(data[n+1]) > len(data) {
+			return nil, errors.New("DHCP relay agent information option is truncated")
+		}
+		l := int(data[n+1])
+		subOpts = append(subOpts, DHCPRelayAgentSubOption{Code: data[n], Data: append([]byte(nil), data[n+2:n+2+l]...)})
+		n += 2 + l
+	}
+	return subOpts, nil
+}
+
+func (d *DHCP) SetRelayAgentInfo(subOpts ...DHCPRelayAgentSubOption) error {
+	var data []byte
+	for _, o := range subOpts {
+		if len(o.Data) > 255 {
+			return fmt.Errorf("DHCP relay agent sub-option %d too long", o.Code)
+		}
+		data = append(data, o.Code, byte(len(o.Data)))
+		data = append(data, o.Data...)
+	}
+	d.SetOption(DHCPNewOption(DHCP_OPT_RELAY_AGENT_INFO, data))
+	return nil
+}
+
+// DHCPRoute is a classless static route.
+type DHCPRoute struct {
+	Destination net.IPNet
+	Router      net.IP
+}
+
+// ClasslessStaticRoutes returns the routes of the classless static route
+// option of RFC 3442, or nil.
+func (d *DHCP) ClasslessStaticRoutes() ([]DHCPRoute, error) {
+	data := d.optionData(DHCP_OPT_CLASSLESS_ROUTE)
+	var routes []DHCPRoute
+	for n := 0; n < len(data); {
+		prefixLen := int(data[n])
+		if prefixLen > 32 {
+			return nil, fmt.Errorf("invalid DHCP classless route prefix length %d", prefixLen)
+		}
+		size := (prefixLen + 7) / 8
+		if n+1+size+4 > len(data) {
+			return nil, errors.New("DHCP classless static route option is truncated")
+		}
+		dst := make(net.IP, 4)
+		copy(dst, data[n+1:n+1+size])
+		n += 1 + size
+		routes = append(routes, DHCPRoute{
+			Destination: net.IPNet{IP: dst, Mask: net.CIDRMask(prefixLen, 32)},
+			Router:      net.IP(append([]byte(nil), data[n:n+4]...)),
+		})
+		n += 4
+	}
+	return routes, nil
+}
+
+func (d *DHCP) SetClasslessStaticRoutes(routes ...DHCPRoute) error {
+	var data []byte
+	for _, r := range routes {
+		ones, bits := r.Destination.Mask.Size()
+		dst := r.Destination.IP.To4()
+		router := r.Router.To4()
+		if bits != 32 || dst == nil || router == nil {
+			return fmt.Errorf("invalid DHCP classless route to %v via %v", r.Destination, r.Router)
+		}
+		data = append(data, byte(ones))
+		data = append(data, dst[:(ones+7)/8]...)
+		data = append(data, router...)
+	}
+	if len(data) > 255 {
+		return errors.New("too many DHCP classless routes")
+	}
+	d.SetOption(DHCPNewOption(DHCP_OPT_CLASSLESS_ROUTE, data))
+	return nil
+}
@@ -0,0 +1,173 @@
+package protocol
+
+import (
+	"encoding/hex"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func TestDHCPOptions(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	ack, err := NewDHCPAck(0x1234, mac)
+	require.NoError(t, err)
+	assert.Equal(t, DHCP_OP_BOOTREPLY, ack.Operation)
+	assert.Equal(t, DHCP_MSG_ACK, ack.MessageType())
+
+	require.NoError(t, ack.SetSubnetMask(net.CIDRMask(24, 32)))
+	require.NoError(t, ack.SetRouters(net.ParseIP("10.0.0.1")))
+	require.NoError(t, ack.SetDNSServers(net.ParseIP("10.0.0.2"), net.ParseIP("10.0.0.3")))
+	require.NoError(t, ack.SetServerID(net.ParseIP("10.0.0.1")))
+	assert.Error(t, ack.SetRequestedIP(net.ParseIP("2001:db8::1")))
+	ack.SetLeaseTime(time.Hour)
+	ack.SetRenewalTime(30 * time.Minute)
+	ack.SetRebindingTime(50 * time.Minute)
+	ack.SetClientID(append([]byte{DHCP_HW_ETHERNET}, mac...))
+	ack.SetHostname("host1")
+	require.NoError(t, ack.SetRelayAgentInfo(
+		DHCPRelayAgentSubOption{Code: DHCP_RELAY_CIRCUIT_ID, Data: []byte("eth0")},
+		DHCPRelayAgentSubOption{Code: DHCP_RELAY_REMOTE_ID, Data: []byte{1, 2}}))
+	_, dst, _ := net.ParseCIDR("192.168.0.0/16")
+	_, def, _ := net.ParseCIDR("0.0.0.0/0")
+	routes := []DHCPRoute{
+		{Destination: *dst, Router: net.ParseIP("10.0.0.254").To4()},
+		{Destination: *def, Router: net.ParseIP("10.0.0.1").To4()},
+	}
+	require.NoError(t, ack.SetClasslessStaticRoutes(routes...))
+	// Replacing an option keeps a single instance.
+	ack.SetMessageType(DHCP_MSG_NAK)
+	ack.SetMessageType(DHCP_MSG_ACK)
+
+	data, err := ack.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(len(data)), ack.Len())
+	assert.Equal(t, DHCP_OPT_END, data[len(data)-1])
+	// The routes are encoded with their significant octets only.
+	assert.Contains(t, string(data), string([]byte{DHCP_OPT_CLASSLESS_ROUTE, 12, 16, 192, 168, 10, 0, 0, 254, 0, 10, 0, 0, 1}))
+
+	decoded := new(DHCP)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, DHCP_MSG_ACK, decoded.MessageType())
+	assert.Equal(t, net.CIDRMask(24, 32), decoded.SubnetMask())
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.1").To4()}, decoded.Routers())
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.2").To4(), net.ParseIP("10.0.0.3").To4()}, decoded.DNSServers())
+	assert.Equal(t, net.ParseIP("10.0.0.1").To4(), decoded.ServerID())
+	assert.Nil(t, decoded.RequestedIP())
+	lease, ok := decoded.LeaseTime()
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, lease)
+	t1, _ := decoded.RenewalTime()
+	assert.Equal(t, 30*time.Minute, t1)
+	t2, _ := decoded.RebindingTime()
+	assert.Equal(t, 50*time.Minute, t2)
+	assert.Equal(t, append([]byte{DHCP_HW_ETHERNET}, mac...), decoded.ClientID())
+	assert.Equal(t, "host1", decoded.Hostname())
+	relay, err := decoded.RelayAgentInfo()
+	require.NoError(t, err)
+	assert.Equal(t, []DHCPRelayAgentSubOption{
+		{Code: DHCP_RELAY_CIRCUIT_ID, Data: []byte("eth0")},
+		{Code: DHCP_RELAY_REMOTE_ID, Data: []byte{1, 2}},
+	}, relay)
+	decodedRoutes, err := decoded.ClasslessStaticRoutes()
+	require.NoError(t, err)
+	assert.Equal(t, routes, decodedRoutes)
+
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+}
+
+func TestDHCPMalformedOptions(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	d, err := NewDHCPRequest(1, mac)
+	require.NoError(t, err)
+	data, err := d.MarshalBinary()
+	require.NoError(t, err)
+
+	// An option whose length goes past the end of the message.
+	truncated := append(data[:len(data)-1:len(data)-1], DHCP_OPT_HOST_NAME, 10, 'a')
+	assert.Error(t, new(DHCP).UnmarshalBinary(truncated))
+	assert.Error(t, new(DHCP).UnmarshalBinary(data[:239]))
+
+	d.SetOption(DHCPNewOption(DHCP_OPT_CLASSLESS_ROUTE, []byte{33, 10, 0, 0, 0, 1}))
+	_, err = d.ClasslessStaticRoutes()
+	assert.Error(t, err)
+	d.SetOption(DHCPNewOption(DHCP_OPT_CLASSLESS_ROUTE, []byte{24, 10, 0, 0, 10}))
+	_, err = d.ClasslessStaticRoutes()
+	assert.Error(t, err)
+	d.SetOption(DHCPNewOption(DHCP_OPT_RELAY_AGENT_INFO, []byte{1, 5, 0}))
+	_, err = d.RelayAgentInfo()
+	assert.Error(t, err)
+}
+
+func TestDHCPOverUDP(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	discover, err := NewDHCPDiscover(0x5678, mac)
+	require.NoError(t, err)
+	discover.SetHostname("host1")
+
+	udp := NewUDP()
+	udp.PortSrc = UDPPort_DHCPClient
+	udp.PortDst = UDPPort_DHCPServer
+	udp.Payload = discover
+	ip := newTestIPv4(Type_UDP)
+	ip.Data = udp
+	data, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+
+	decoded := new(IPv4)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.NoError(t, VerifyChecksums(decoded))
+	d, ok := decoded.Data.(*UDP).Payload.(*DHCP)
+	require.True(t, ok)
+	assert.Equal(t, DHCP_MSG_DISCOVER, d.MessageType())
+	assert.Equal(t, uint32(0x5678), d.Xid)
+	assert.Equal(t, mac, d.ClientHWAddr)
+	assert.Equal(t, "host1", d.Hostname())
+}
+
+func TestDHCPRoundTrip(t *testing.T) {
+	// A DISCOVER from a client padding its messages to 300 bytes.
+	discover, _ := hex.DecodeString("01010600" + "29f07d4f" + "00000000" + strings.Repeat("00", 16) +
+		"00163e5a1b6c" + strings.Repeat("00", 10+64+128) + "63825363" +
+		"350101" + "3204c0a8010a" + "0c05686f737431" + "370d011c02030f06770c2c2f1a792a" + "ff")
+	require.Len(t, discover, 272)
+	discover = append(discover, make([]byte, 300-len(discover))...)
+
+	udp := NewUDP()
+	udp.PortSrc = UDPPort_DHCPClient
+	udp.PortDst = UDPPort_DHCPServer
+	udp.Payload = util.NewBuffer(discover)
+	ip := newTestIPv4(Type_UDP)
+	ip.Data = udp
+	data, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+
+	decoded := new(IPv4)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	d, ok := decoded.Data.(*UDP).Payload.(*DHCP)
+	require.True(t, ok)
+	assert.Equal(t, DHCP_MSG_DISCOVER, d.MessageType())
+	assert.Equal(t, "host1", d.Hostname())
+	assert.Len(t, d.Padding, 28)
+	assert.Equal(t, uint16(300), d.Len())
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+	assert.NoError(t, VerifyChecksums(decoded))
+
+	// The bytes of the hardware address field after the address are kept.
+	discover[40] = 0xff
+	d = new(DHCP)
+	require.NoError(t, d.UnmarshalBinary(discover))
+	assert.Equal(t, net.HardwareAddr{0x00, 0x16, 0x3e, 0x5a, 0x1b, 0x6c}, d.ClientHWAddr)
+	b, err = d.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, discover, b)
+}
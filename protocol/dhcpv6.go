@@ -0,0 +1,673 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// UDP ports of DHCPv6, used to decode the payload of UDP.
+const (
+	UDPPort_DHCPv6Client = 546
+	UDPPort_DHCPv6Server = 547
+)
+
+type DHCPv6MessageType uint8
+
+const (
+	DHCPv6_MSG_SOLICIT             DHCPv6MessageType = 1
+	DHCPv6_MSG_ADVERTISE           DHCPv6MessageType = 2
+	DHCPv6_MSG_REQUEST             DHCPv6MessageType = 3
+	DHCPv6_MSG_CONFIRM             DHCPv6MessageType = 4
+	DHCPv6_MSG_RENEW               DHCPv6MessageType = 5
+	DHCPv6_MSG_REBIND              DHCPv6MessageType = 6
+	DHCPv6_MSG_REPLY               DHCPv6MessageType = 7
+	DHCPv6_MSG_RELEASE             DHCPv6MessageType = 8
+	DHCPv6_MSG_DECLINE             DHCPv6MessageType = 9
+	DHCPv6_MSG_RECONFIGURE         DHCPv6MessageType = 10
+	DHCPv6_MSG_INFORMATION_REQUEST DHCPv6MessageType = 11
+	DHCPv6_MSG_RELAY_FORW          DHCPv6MessageType = 12
+	DHCPv6_MSG_RELAY_REPL          DHCPv6MessageType = 13
+)
+
+// DHCPv6 option codes
+const (
+	DHCPv6_OPT_CLIENTID     = 1
+	DHCPv6_OPT_SERVERID     = 2
+	DHCPv6_OPT_IA_NA        = 3
+	DHCPv6_OPT_IA_TA        = 4
+	DHCPv6_OPT_IAADDR       = 5
+	DHCPv6_OPT_ORO          = 6
+	DHCPv6_OPT_PREFERENCE   = 7
+	DHCPv6_OPT_ELAPSED_TIME = 8
+	DHCPv6_OPT_RELAY_MSG    = 9
+	DHCPv6_OPT_STATUS_CODE  = 13
+	DHCPv6_OPT_RAPID_COMMIT = 14
+	DHCPv6_OPT_INTERFACE_ID = 18
+	DHCPv6_OPT_DNS_SERVERS  = 23
+	DHCPv6_OPT_DOMAIN_LIST  = 24
+	DHCPv6_OPT_IA_PD        = 25
+	DHCPv6_OPT_IAPREFIX     = 26
+)
+
+// DHCPv6 status codes
+const (
+	DHCPv6_STATUS_SUCCESS         = 0
+	DHCPv6_STATUS_UNSPEC_FAIL     = 1
+	DHCPv6_STATUS_NO_ADDRS_AVAIL  = 2
+	DHCPv6_STATUS_NO_BINDING      = 3
+	DHCPv6_STATUS_NOT_ON_LINK     = 4
+	DHCPv6_STATUS_USE_MULTICAST   = 5
+	DHCPv6_STATUS_NO_PREFIX_AVAIL = 6
+)
+
+// DUID types
+const (
+	DHCPv6_DUID_LLT  = 1
+	DHCPv6_DUID_EN   = 2
+	DHCPv6_DUID_LL   = 3
+	DHCPv6_DUID_UUID = 4
+)
+
+// NewDHCPv6DUIDLL returns the DUID based on the link-layer address hwAddr of
+// hardware type hwType, such as DHCP_HW_ETHERNET.
+func NewDHCPv6DUIDLL(hwType uint16, hwAddr net.HardwareAddr) []byte {
+	duid := make([]byte, 4, 4+len(hwAddr))
+	binary.BigEndian.PutUint16(duid[0:2], DHCPv6_DUID_LL)
+	binary.BigEndian.PutUint16(duid[2:4], hwType)
+	return append(duid, hwAddr...)
+}
+
+// newDHCPv6ByMessageType returns the layer decoding a DHCPv6 message of the
+// given type: relay messages have their own header.
+func newDHCPv6ByMessageType(msgType uint8) util.Message {
+	switch DHCPv6MessageType(msgType) {
+	case DHCPv6_MSG_RELAY_FORW, DHCPv6_MSG_RELAY_REPL:
+		return new(DHCPv6Relay)
+	}
+	return new(DHCPv6)
+}
+
+// DHCPv6 is a message exchanged between a DHCPv6 client and server.
+type DHCPv6 struct {
+	MsgType       DHCPv6MessageType
+	TransactionID uint32 // 24-bits
+	Options       []util.Message
+}
+
+func NewDHCPv6(msgType DHCPv6MessageType, xid uint32) *DHCPv6 {
+	return &DHCPv6{MsgType: msgType, TransactionID: xid & 0xffffff}
+}
+
+func (d *DHCPv6) AddOption(opt util.Message) {
+	d.Options = append(d.Options, opt)
+}
+
+// Option returns the first option with the given code, or nil.
+func (d *DHCPv6) Option(code uint16) util.Message {
+	return findDHCPv6Option(d.Options, code)
+}
+
+func (d *DHCPv6) Len() (n uint16) {
+	return 4 + dhcpv6OptionsLen(d.Options)
+}
+
+func (d *DHCPv6) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 4, d.Len())
+	binary.BigEndian.PutUint32(data, uint32(d.MsgType)<<24|d.TransactionID&0xffffff)
+	return appendDHCPv6Options(data, d.Options)
+}
+
+func (d *DHCPv6) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full DHCPv6 message.")
+	}
+	d.MsgType = DHCPv6MessageType(data[0])
+	d.TransactionID = binary.BigEndian.Uint32(data) & 0xffffff
+	d.Options, err = decodeDHCPv6Options(data[4:])
+	return
+}
+
+// DHCPv6Relay is a Relay-Forward or Relay-Reply message, carrying the relayed
+// message in a DHCPv6RelayMessage option.
+type DHCPv6Relay struct {
+	MsgType  DHCPv6MessageType
+	HopCount uint8
+	LinkAddr net.IP
+	PeerAddr net.IP
+	Options  []util.Message
+}
+
+func NewDHCPv6Relay(msgType DHCPv6MessageType, hopCount uint8, linkAddr, peerAddr net.IP) *DHCPv6Relay {
+	return &DHCPv6Relay{MsgType: msgType, HopCount: hopCount, LinkAddr: linkAddr, PeerAddr: peerAddr}
+}
+
+func (d *DHCPv6Relay) AddOption(opt util.Message) {
+	d.Options = append(d.Options, opt)
+}
+
+// Option returns the first option with the given code, or nil.
+func (d *DHCPv6Relay) Option(code uint16) util.Message {
+	return findDHCPv6Option(d.Options, code)
+}
+
+func (d *DHCPv6Relay) Len() (n uint16) {
+	return 34 + dhcpv6OptionsLen(d.Options)
+}
+
+func (d *DHCPv6Relay) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 34, d.Len())
+	data[0] = uint8(d.MsgType)
+	data[1] = d.HopCount
+	copy(data[2:18], d.LinkAddr.To16())
+	copy(data[18:34], d.PeerAddr.To16())
+	return appendDHCPv6Options(data, d.Options)
+}
+
+func (d *DHCPv6Relay) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 34 {
+		return errors.New("The []byte is too short to unmarshal a full DHCPv6 relay message.")
+	}
+	d.MsgType = DHCPv6MessageType(data[0])
+	d.HopCount = data[1]
+	d.LinkAddr = net.IP(append([]byte(nil), data[2:18]...))
+	d.PeerAddr = net.IP(append([]byte(nil), data[18:34]...))
+	d.Options, err = decodeDHCPv6Options(data[34:])
+	return
+}
+
+// dhcpv6OptionCode returns the code of opt, which must be one of the DHCPv6
+// option types.
+func dhcpv6OptionCode(opt util.Message) uint16 {
+	switch o := opt.(type) {
+	case *DHCPv6ClientID:
+		return DHCPv6_OPT_CLIENTID
+	case *DHCPv6ServerID:
+		return DHCPv6_OPT_SERVERID
+	case *DHCPv6IANA:
+		return DHCPv6_OPT_IA_NA
+	case *DHCPv6IAAddress:
+		return DHCPv6_OPT_IAADDR
+	case *DHCPv6RelayMessage:
+		return DHCPv6_OPT_RELAY_MSG
+	case *DHCPv6StatusCode:
+		return DHCPv6_OPT_STATUS_CODE
+	case *DHCPv6DNSServers:
+		return DHCPv6_OPT_DNS_SERVERS
+	case *DHCPv6DomainList:
+		return DHCPv6_OPT_DOMAIN_LIST
+	case *DHCPv6IAPD:
+		return DHCPv6_OPT_IA_PD
+	case *DHCPv6IAPrefix:
+		return DHCPv6_OPT_IAPREFIX
+	case *DHCPv6Option:
+		return o.Code
+	}
+	return 0
+}
+
+func newDHCPv6Option(code uint16) util.Message {
+	switch code {
+	case DHCPv6_OPT_CLIENTID:
+		return new(DHCPv6ClientID)
+	case DHCPv6_OPT_SERVERID:
+		return new(DHCPv6ServerID)
+	case DHCPv6_OPT_IA_NA:
+		return new(DHCPv6IANA)
+	case DHCPv6_OPT_IAADDR:
+		return new(DHCPv6IAAddress)
+	case DHCPv6_OPT_RELAY_MSG:
+		return new(DHCPv6RelayMessage)
+	case DHCPv6_OPT_STATUS_CODE:
+		return new(DHCPv6StatusCode)
+	case DHCPv6_OPT_DNS_SERVERS:
+		return new(DHCPv6DNSServers)
+	case DHCPv6_OPT_DOMAIN_LIST:
+		return new(DHCPv6DomainList)
+	case DHCPv6_OPT_IA_PD:
+		return new(DHCPv6IAPD)
+	case DHCPv6_OPT_IAPREFIX:
+		return new(DHCPv6IAPrefix)
+	}
+	return new(DHCPv6Option)
+}
+
+func findDHCPv6Option(opts []util.Message, code uint16) util.Message {
+	for _, opt := range opts {
+		if dhcpv6OptionCode(opt) == code {
+			return opt
+		}
+	}
+	return nil
+}
+
+func dhcpv6OptionsLen(opts []util.Message) (n uint16) {
+	for _, opt := range opts {
+		n += opt.Len()
+	}
+	return
+}
+
+func appendDHCPv6Options(data []byte, opts []util.Message) ([]byte, error) {
+	for _, opt := range opts {
+		b, err := opt.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+// decodeDHCPv6Options decodes the options filling data.
+func decodeDHCPv6Options(data []byte) ([]util.Message, error) {
+	var opts []util.Message
+	for n := 0; n < len(data); {
+		code, l, err := dhcpv6OptionHeader(data[n:])
+		if err != nil {
+			return nil, err
+		}
+		opt := newDHCPv6Option(code)
+		if err := opt.UnmarshalBinary(data[n : n+4+l]); err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+		n += 4 + l
+	}
+	return opts, nil
+}
+
+// dhcpv6OptionHeader returns the code and length of the option starting data,
+// whose value must be in data.
+func dhcpv6OptionHeader(data []byte) (code uint16, length int, err error) {
+	if len(data) < 4 {
+		return 0, 0, errors.New("The []byte is too short to unmarshal a full DHCPv6 option.")
+	}
+	code = binary.BigEndian.Uint16(data[0:2])
+	length = int(binary.BigEndian.Uint16(data[2:4]))
+	if len(data) < 4+length {
+		return 0, 0, fmt.Errorf("DHCPv6 option %d is truncated", code)
+	}
+	return code, length, nil
+}
+
+// marshalDHCPv6Option returns an option with the given code holding the
+// concatenated value parts.
+func marshalDHCPv6Option(code uint16, value ...[]byte) ([]byte, error) {
+	length := 0
+	for _, v := range value {
+		length += len(v)
+	}
+	if length > 0xffff {
+		return nil, fmt.Errorf("DHCPv6 option %d too long: %d bytes", code, length)
+	}
+	data := make([]byte, 4, 4+length)
+	binary.BigEndian.PutUint16(data[0:2], code)
+	binary.BigEndian.PutUint16(data[2:4], uint16(length))
+	for _, v := range value {
+		data = append(data, v...)
+	}
+	return data, nil
+}
+
+// unmarshalDHCPv6Option returns the value of the option in data, checking
+// that it has the given code and at least min bytes.
+func unmarshalDHCPv6Option(data []byte, code uint16, min int) ([]byte, error) {
+	c, l, err := dhcpv6OptionHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if c != code {
+		return nil, fmt.Errorf("DHCPv6 option %d found instead of %d", c, code)
+	}
+	if l < min {
+		return nil, fmt.Errorf("DHCPv6 option %d too short: %d bytes", code, l)
+	}
+	return data[4 : 4+l], nil
+}
+
+// DHCPv6ClientID is the client identifier option.
+type DHCPv6ClientID struct {
+	DUID []byte
+}
+
+func (o *DHCPv6ClientID) Len() (n uint16) {
+	return uint16(4 + len(o.DUID))
+}
+
+func (o *DHCPv6ClientID) MarshalBinary() (data []byte, err error) {
+	return marshalDHCPv6Option(DHCPv6_OPT_CLIENTID, o.DUID)
+}
+
+func (o *DHCPv6ClientID) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalDHCPv6Option(data, DHCPv6_OPT_CLIENTID, 2)
+	o.DUID = append([]byte(nil), value...)
+	return err
+}
+
+// DHCPv6ServerID is the server identifier option.
+type DHCPv6ServerID struct {
+	DUID []byte
+}
+
+func (o *DHCPv6ServerID) Len() (n uint16) {
+	return uint16(4 + len(o.DUID))
+}
+
+func (o *DHCPv6ServerID) MarshalBinary() (data []byte, err error) {
+	return marshalDHCPv6Option(DHCPv6_OPT_SERVERID, o.DUID)
+}
+
+func (o *DHCPv6ServerID) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalDHCPv6Option(data, DHCPv6_OPT_SERVERID, 2)
+	o.DUID = append([]byte(nil), value...)
+	return err
+}
+
+func marshalDHCPv6IA(code uint16, iaid, t1, t2 uint32, opts []util.Message) ([]byte, error) {
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint32(header[0:4], iaid)
+	binary.BigEndian.PutUint32(header[4:8], t1)
+	binary.BigEndian.PutUint32(header[8:12], t2)
+	options, err := appendDHCPv6Options(nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	return marshalDHCPv6Option(code, header, options)
+}
+
+func unmarshalDHCPv6IA(data []byte, code uint16, iaid, t1, t2 *uint32, opts *[]util.Message) (err error) {
+	value, err := unmarshalDHCPv6Option(data, code, 12)
+	if err != nil {
+		return err
+	}
+	*iaid = binary.BigEndian.Uint32(value[0:4])
+	*t1 = binary.BigEndian.Uint32(value[4:8])
+	*t2 = binary.BigEndian.Uint32(value[8:12])
+	*opts, err = decodeDHCPv6Options(value[12:])
+	return err
+}
+
+// DHCPv6IANA is the identity association for non-temporary addresses option,
+// carrying DHCPv6IAAddress and DHCPv6StatusCode options.
+type DHCPv6IANA struct {
+	IAID    uint32
+	T1      uint32
+	T2      uint32
+	Options []util.Message
+}
+
+func (o *DHCPv6IANA) Len() (n uint16) {
+	return 16 + dhcpv6OptionsLen(o.Options)
+}
+
+func (o *DHCPv6IANA) MarshalBinary() (data []byte, err error) {
+	return marshalDHCPv6IA(DHCPv6_OPT_IA_NA, o.IAID, o.T1, o.T2, o.Options)
+}
+
+func (o *DHCPv6IANA) UnmarshalBinary(data []byte) error {
+	return unmarshalDHCPv6IA(data, DHCPv6_OPT_IA_NA, &o.IAID, &o.T1, &o.T2, &o.Options)
+}
+
+// DHCPv6IAPD is the identity association for prefix delegation option,
+// carrying DHCPv6IAPrefix and DHCPv6StatusCode options.
+type DHCPv6IAPD struct {
+	IAID    uint32
+	T1      uint32
+	T2      uint32
+	Options []util.Message
+}
+
+func (o *DHCPv6IAPD) Len() (n uint16) {
+	return 16 + dhcpv6OptionsLen(o.Options)
+}
+
+func (o *DHCPv6IAPD) MarshalBinary() (data []byte, err error) {
+	return marshalDHCPv6IA(DHCPv6_OPT_IA_PD, o.IAID, o.T1, o.T2, o.Options)
+}
+
+func (o *DHCPv6IAPD) UnmarshalBinary(data []byte) error {
+	return unmarshalDHCPv6IA(data, DHCPv6_OPT_IA_PD, &o.IAID, &o.T1, &o.T2, &o.Options)
+}
+
+// DHCPv6IAAddress is an address of an IA_NA, with lifetimes in seconds.
+type DHCPv6IAAddress struct {
+	IP                net.IP
+	PreferredLifetime uint32
+	ValidLifetime     uint32
+	Options           []util.Message
+}
+
+func (o *DHCPv6IAAddress) Len() (n uint16) {
+	return 28 + dhcpv6OptionsLen(o.Options)
+}
+
+func (o *DHCPv6IAAddress) MarshalBinary() (data []byte, err error) {
+	header := make([]byte, 24)
+	copy(header[0:16], o.IP.To16())
+	binary.BigEndian.PutUint32(header[16:20], o.PreferredLifetime)
+	binary.BigEndian.PutUint32(header[20:24], o.ValidLifetime)
+	opts, err := appendDHCPv6Options(nil, o.Options)
+	if err != nil {
+		return nil, err
+	}
+	return marshalDHCPv6Option(DHCPv6_OPT_IAADDR, header, opts)
+}
+
+func (o *DHCPv6IAAddress) UnmarshalBinary(data []byte) (err error) {
+	value, err := unmarshalDHCPv6Option(data, DHCPv6_OPT_IAADDR, 24)
+	if err != nil {
+		return err
+	}
+	o.IP = net.IP(append([]byte(nil), value[0:16]...))
+	o.PreferredLifetime = binary.BigEndian.Uint32(value[16:20])
+	o.ValidLifetime = binary.BigEndian.Uint32(value[20:24])
+	o.Options, err = decodeDHCPv6Options(value[24:])
+	return err
+}
+
+// DHCPv6IAPrefix is a prefix of an IA_PD, with lifetimes in seconds.
+type DHCPv6IAPrefix struct {
+	PreferredLifetime uint32
+	ValidLifetime     uint32
+	PrefixLength      uint8
+	Prefix            net.IP
+	Options           []util.Message
+}
+
+func (o *DHCPv6IAPrefix) Len() (n uint16) {
+	return 29 + dhcpv6OptionsLen(o.Options)
+}
+
+func (o *DHCPv6IAPrefix) MarshalBinary() (data []byte, err error) {
+	header := make([]byte, 25)
+	binary.BigEndian.PutUint32(header[0:4], o.PreferredLifetime)
+	binary.BigEndian.PutUint32(header[4:8], o.ValidLifetime)
+	header[8] = o.PrefixLength
+	copy(header[9:25], o.Prefix.To16())
+	opts, err := appendDHCPv6Options(nil, o.Options)
+	if err != nil {
+		return nil, err
+	}
+	return marshalDHCPv6Option(DHCPv6_OPT_IAPREFIX, header, opts)
+}
+
+func (o *DHCPv6IAPrefix) UnmarshalBinary(data []byte) (err error) {
+	value, err := unmarshalDHCPv6Option(data, DHCPv6_OPT_IAPREFIX, 25)
+	if err != nil {
+		return err
+	}
+	o.PreferredLifetime = binary.BigEndian.Uint32(value[0:4])
+	o.ValidLifetime = binary.BigEndian.Uint32(value[4:8])
+	o.PrefixLength = value[8]
+	o.Prefix = net.IP(append([]byte(nil), value[9:25]...))
+	o.Options, err = decodeDHCPv6Options(value[25:])
+	return err
+}
+
+// DHCPv6StatusCode is the status code option, such as DHCPv6_STATUS_SUCCESS.
+type DHCPv6StatusCode struct {
+	Code    uint16
+	Message string
+}
+
+func (o *DHCPv6StatusCode) Len() (n uint16) {
+	return uint16(6 + len(o.Message))
+}
+
+func (o *DHCPv6StatusCode) MarshalBinary() (data []byte, err error) {
+	code := make([]byte, 2)
+	binary.BigEndian.PutUint16(code, o.Code)
+	return marshalDHCPv6Option(DHCPv6_OPT_STATUS_CODE, code, []byte(o.Message))
+}
+
+func (o *DHCPv6StatusCode) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalDHCPv6Option(data, DHCPv6_OPT_STATUS_CODE, 2)
+	if err != nil {
+		return err
+	}
+	o.Code = binary.BigEndian.Uint16(value[0:2])
+	o.Message = string(value[2:])
+	return nil
+}
+
+// DHCPv6DNSServers is the recursive DNS servers option.
+type DHCPv6DNSServers struct {
+	Servers []net.IP
+}
+
+func (o *DHCPv6DNSServers) Len() (n uint16) {
+	return uint16(4 + 16*len(o.Servers))
+}
+
+func (o *DHCPv6DNSServers) MarshalBinary() (data []byte, err error) {
+	value := make([]byte, 16*len(o.Servers))
+	for i, ip := range o.Servers {
+		if ip.To16() == nil {
+			return nil, fmt.Errorf("invalid DHCPv6 DNS server %v", ip)
+		}
+		copy(value[16*i:], ip.To16())
+	}
+	return marshalDHCPv6Option(DHCPv6_OPT_DNS_SERVERS, value)
+}
+
+func (o *DHCPv6DNSServers) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalDHCPv6Option(data, DHCPv6_OPT_DNS_SERVERS, 0)
+	if err != nil {
+		return err
+	}
+	if len(value)%16 != 0 {
+		return fmt.Errorf("invalid DHCPv6 DNS servers option length %d", len(value))
+	}
+	o.Servers = nil
+	for i := 0; i < len(value); i += 16 {
+		o.Servers = append(o.Servers, net.IP(append([]byte(nil), value[i:i+16]...)))
+	}
+	return nil
+}
+
+// DHCPv6DomainList is the domain search list option. The names are encoded
+// as in DNS, without compression.
+type DHCPv6DomainList struct {
+	Domains []string
+}
+
+func (o *DHCPv6DomainList) Len() (n uint16) {
+	n = 4
+	for _, d := range o.Domains {
+		n += dnsNameLen(d)
+	}
+	return
+}
+
+func (o *DHCPv6DomainList) MarshalBinary() (data []byte, err error) {
+	var value []byte
+	for _, d := range o.Domains {
+		var name []byte
+		if name, err = marshalDNSName(d); err != nil {
+			return nil, err
+		}
+		value = append(value, name...)
+	}
+	return marshalDHCPv6Option(DHCPv6_OPT_DOMAIN_LIST, value)
+}
+
+func (o *DHCPv6DomainList) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalDHCPv6Option(data, DHCPv6_OPT_DOMAIN_LIST, 0)
+	if err != nil {
+		return err
+	}
+	o.Domains = nil
+	dec := &dnsDecoder{data: value}
+	for n := 0; n < len(value); {
+		name, next, err := dec.nameAt(value, n)
+		if err != nil {
+			return err
+		}
+		if dec.compressed {
+			return errors.New("compressed name in DHCPv6 domain list")
+		}
+		o.Domains = append(o.Domains, name)
+		n = next
+	}
+	return nil
+}
+
+// DHCPv6RelayMessage is the relay message option of relay messages, carrying
+// a *DHCPv6 or, between relays, a *DHCPv6Relay.
+type DHCPv6RelayMessage struct {
+	Message util.Message
+}
+
+func (o *DHCPv6RelayMessage) Len() (n uint16) {
+	if o.Message == nil {
+		return 4
+	}
+	return 4 + o.Message.Len()
+}
+
+func (o *DHCPv6RelayMessage) MarshalBinary() (data []byte, err error) {
+	var msg []byte
+	if o.Message != nil {
+		if msg, err = o.Message.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+	return marshalDHCPv6Option(DHCPv6_OPT_RELAY_MSG, msg)
+}
+
+func (o *DHCPv6RelayMessage) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalDHCPv6Option(data, DHCPv6_OPT_RELAY_MSG, 1)
+	if err != nil {
+		return err
+	}
+	o.Message = newDHCPv6ByMessageType(value[0])
+	return o.Message.UnmarshalBinary(value)
+}
+
+// DHCPv6Option is an option not decoded by the types above.
+type DHCPv6Option struct {
+	Code uint16
+	Data []byte
+}
+
+func (o *DHCPv6Option) Len() (n uint16) {
+	return uint16(4 + len(o.Data))
+}
+
+func (o *DHCPv6Option) MarshalBinary() (data []byte, err error) {
+	return marshalDHCPv6Option(o.Code, o.Data)
+}
+
+func (o *DHCPv6Option) UnmarshalBinary(data []byte) error {
+	code, l, err := dhcpv6OptionHeader(data)
+	if err != nil {
+		return err
+	}
+	o.Code = code
+	o.Data = append([]byte(nil), data[4:4+l]...)
+	return nil
+}
@@ -0,0 +1,139 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func newTestDHCPv6Reply() *DHCPv6 {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	serverMAC, _ := net.ParseMAC("00:00:00:00:00:01")
+	reply := NewDHCPv6(DHCPv6_MSG_REPLY, 0xabcdef)
+	reply.AddOption(&DHCPv6ClientID{DUID: NewDHCPv6DUIDLL(uint16(DHCP_HW_ETHERNET), mac)})
+	reply.AddOption(&DHCPv6ServerID{DUID: NewDHCPv6DUIDLL(uint16(DHCP_HW_ETHERNET), serverMAC)})
+	reply.AddOption(&DHCPv6IANA{IAID: 1, T1: 1800, T2: 2880, Options: []util.Message{
+		&DHCPv6IAAddress{IP: net.ParseIP("2001:db8::10"), PreferredLifetime: 3600, ValidLifetime: 7200},
+		&DHCPv6StatusCode{Code: DHCPv6_STATUS_SUCCESS, Message: "ok"},
+	}})
+	reply.AddOption(&DHCPv6IAPD{IAID: 2, T1: 1800, T2: 2880, Options: []util.Message{
+		&DHCPv6IAPrefix{PreferredLifetime: 3600, ValidLifetime: 7200, PrefixLength: 56, Prefix: net.ParseIP("2001:db8:100::")},
+	}})
+	reply.AddOption(&DHCPv6DNSServers{Servers: []net.IP{net.ParseIP("2001:db8::53"), net.ParseIP("2001:db8::54")}})
+	reply.AddOption(&DHCPv6DomainList{Domains: []string{"example.com", "corp.example.com"}})
+	reply.AddOption(&DHCPv6Option{Code: DHCPv6_OPT_RAPID_COMMIT})
+	return reply
+}
+
+func TestDHCPv6(t *testing.T) {
+	reply := newTestDHCPv6Reply()
+	data, err := reply.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(len(data)), reply.Len())
+	assert.Equal(t, []byte{byte(DHCPv6_MSG_REPLY), 0xab, 0xcd, 0xef}, data[:4])
+
+	decoded := new(DHCPv6)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, reply, decoded)
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	ia, ok := decoded.Option(DHCPv6_OPT_IA_NA).(*DHCPv6IANA)
+	require.True(t, ok)
+	assert.Equal(t, net.ParseIP("2001:db8::10"), ia.Options[0].(*DHCPv6IAAddress).IP)
+	assert.Equal(t, "ok", findDHCPv6Option(ia.Options, DHCPv6_OPT_STATUS_CODE).(*DHCPv6StatusCode).Message)
+	assert.Nil(t, decoded.Option(DHCPv6_OPT_ELAPSED_TIME))
+	assert.NotNil(t, decoded.Option(DHCPv6_OPT_RAPID_COMMIT))
+}
+
+func TestDHCPv6RelayOverUDP(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	solicit := NewDHCPv6(DHCPv6_MSG_SOLICIT, 0x123456)
+	solicit.AddOption(&DHCPv6ClientID{DUID: NewDHCPv6DUIDLL(uint16(DHCP_HW_ETHERNET), mac)})
+	solicit.AddOption(&DHCPv6Option{Code: DHCPv6_OPT_ELAPSED_TIME, Data: []byte{0, 0}})
+	solicit.AddOption(&DHCPv6IANA{IAID: 1})
+	relay := NewDHCPv6Relay(DHCPv6_MSG_RELAY_FORW, 0, net.ParseIP("2001:db8::1"), net.ParseIP("fe80::a8bb:ccff:fedd:eeff"))
+	relay.AddOption(&DHCPv6Option{Code: DHCPv6_OPT_INTERFACE_ID, Data: []byte("eth0")})
+	relay.AddOption(&DHCPv6RelayMessage{Message: solicit})
+
+	udp := NewUDP()
+	udp.PortSrc = UDPPort_DHCPv6Server
+	udp.PortDst = UDPPort_DHCPv6Server
+	udp.Payload = relay
+	ip := &IPv6{
+		Version:    6,
+		NextHeader: Type_UDP,
+		HopLimit:   64,
+		NWSrc:      net.ParseIP("2001:db8::1"),
+		NWDst:      net.ParseIP("2001:db8::547"),
+		Data:       udp,
+	}
+	data, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+
+	decoded := new(IPv6)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.NoError(t, VerifyChecksums(decoded))
+	decodedRelay, ok := decoded.Data.(*UDP).Payload.(*DHCPv6Relay)
+	require.True(t, ok)
+	assert.Equal(t, relay, decodedRelay)
+	inner, ok := decodedRelay.Option(DHCPv6_OPT_RELAY_MSG).(*DHCPv6RelayMessage).Message.(*DHCPv6)
+	require.True(t, ok)
+	assert.Equal(t, DHCPv6_MSG_SOLICIT, inner.MsgType)
+	assert.Equal(t, uint32(0x123456), inner.TransactionID)
+
+	// From the server to the client.
+	udp.PortDst = UDPPort_DHCPv6Client
+	udp.Payload = newTestDHCPv6Reply()
+	data, err = Serialize(ip, SerializeOptions{FixLengths: true})
+	require.NoError(t, err)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, udp.Payload, decoded.Data.(*UDP).Payload)
+}
+
+func TestDHCPv6Malformed(t *testing.T) {
+	data, err := newTestDHCPv6Reply().MarshalBinary()
+	require.NoError(t, err)
+	for n := 0; n < len(data); n++ {
+		// Only cuts between options decode, into the options before the cut.
+		d := new(DHCPv6)
+		if err := d.UnmarshalBinary(data[:n]); err == nil {
+			b, err := d.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, data[:n], b)
+		}
+	}
+	assert.Error(t, new(DHCPv6).UnmarshalBinary(data[:3]))
+	assert.Error(t, new(DHCPv6Relay).UnmarshalBinary(data))
+
+	compressed := []byte{0, DHCPv6_OPT_DOMAIN_LIST, 0, 5, 1, 'a', 0, 0xc0, 0}
+	assert.Error(t, new(DHCPv6DomainList).UnmarshalBinary(compressed))
+	compressed = []byte{0, DHCPv6_OPT_DOMAIN_LIST, 0, 4, 1, 'a', 0xc0, 0}
+	assert.Error(t, new(DHCPv6DomainList).UnmarshalBinary(compressed))
+	assert.Error(t, new(DHCPv6DNSServers).UnmarshalBinary([]byte{0, DHCPv6_OPT_DNS_SERVERS, 0, 4, 1, 2, 3, 4}))
+	assert.Error(t, new(DHCPv6IANA).UnmarshalBinary([]byte{0, DHCPv6_OPT_IA_NA, 0, 4, 1, 2, 3, 4}))
+	assert.Error(t, new(DHCPv6ClientID).UnmarshalBinary([]byte{0, DHCPv6_OPT_SERVERID, 0, 2, 0, 3}))
+}
+
+func TestDHCPv6DomainListRoundTrip(t *testing.T) {
+	// A name with a dot within a label, found by fuzzing.
+	data := []byte("0000\x00\x18\x00\x1f\aaaaaaaa\x03.00\x00\x040000\a0000000\x03000\x00")
+	d := new(DHCPv6)
+	require.NoError(t, d.UnmarshalBinary(data))
+	domains := d.Options[0].(*DHCPv6DomainList)
+	assert.Equal(t, []string{`aaaaaaa.\.00`, "0000.0000000.000"}, domains.Domains)
+	assert.Equal(t, uint16(len(data)), d.Len())
+	b, err := d.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	// Names that cannot be encoded fail the message.
+	domains.Domains = append(domains.Domains, "a..b")
+	_, err = d.MarshalBinary()
+	assert.Error(t, err)
+}
@@ -0,0 +1,838 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// Port_DNS is the UDP and TCP port of DNS, used to decode the payload of the
+// queries sent to it and of the responses sent from it.
+const Port_DNS = 53
+
+// DNS resource record types.
+const (
+	DNSType_A     = 1
+	DNSType_NS    = 2
+	DNSType_CNAME = 5
+	DNSType_SOA   = 6
+	DNSType_PTR   = 12
+	DNSType_MX    = 15
+	DNSType_TXT   = 16
+	DNSType_AAAA  = 28
+	DNSType_SRV   = 33
+	DNSType_OPT   = 41
+	DNSType_ANY   = 255
+)
+
+const DNSClass_IN = 1
+
+// DNS response codes.
+const (
+	DNSRCode_NoError  = 0
+	DNSRCode_FormErr  = 1
+	DNSRCode_ServFail = 2
+	DNSRCode_NXDomain = 3
+	DNSRCode_NotImp   = 4
+	DNSRCode_Refused  = 5
+)
+
+const (
+	dnsHeaderLen = 12
+	// dnsMaxNameLen is the maximum length of a name on the wire.
+	dnsMaxNameLen = 255
+)
+
+var errDNSTooShort = errors.New("The []byte is too short to unmarshal a full DNS message.")
+
+// DNSQuestion is an entry of the question section of a DNS message. Names are
+// dot separated, without the trailing dot, and the dots and backslashes within
+// a label are escaped with a backslash, as in "a\\.b.example.com".
+type DNSQuestion struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// DNSResourceRecord is an entry of the answer, authority or additional section
+// of a DNS message. Data is a DNSRecord* value for the types decoded here, and
+// a util.Buffer holding the raw RDATA otherwise.
+type DNSResourceRecord struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	Data  util.Message
+}
+
+// DNS is a DNS message of RFC 1035. A decoded message is marshaled to the
+// bytes it was decoded from as long as its fields are unchanged. Otherwise it
+// is encoded again, which may change its length: use Serialize with
+// FixLengths to update the layers carrying it.
+type DNS struct {
+	ID     uint16
+	QR     bool
+	OpCode uint8 // 4-bits
+	AA     bool
+	TC     bool
+	RD     bool
+	RA     bool
+	Z      uint8 // 1-bit
+	AD     bool
+	CD     bool
+	RCode  uint8 // 4-bits
+
+	Questions   []DNSQuestion
+	Answers     []DNSResourceRecord
+	Authorities []DNSResourceRecord
+	Additionals []DNSResourceRecord
+
+	// Compress compresses the names when marshaling. UnmarshalBinary sets
+	// it when the message has compressed names.
+	Compress bool
+
+	// received is the message decoded by UnmarshalBinary, with the bytes it
+	// was decoded from.
+	received *dnsReceived
+}
+
+type dnsReceived struct {
+	data []byte
+	msg  DNS
+}
+
+// unchanged returns whether d still has the fields it was decoded with.
+func (d *DNS) unchanged() bool {
+	if d.received == nil {
+		return false
+	}
+	current := *d
+	current.received = nil
+	return reflect.DeepEqual(&current, &d.received.msg)
+}
+
+// NewDNSQuery returns a recursive query for name.
+func NewDNSQuery(id uint16, name string, qtype uint16) *DNS {
+	d := new(DNS)
+	d.ID = id
+	d.RD = true
+	d.Questions = []DNSQuestion{{Name: name, Type: qtype, Class: DNSClass_IN}}
+	return d
+}
+
+// AddAnswer adds an answer of class IN for name, whose type is derived from
+// data.
+func (d *DNS) AddAnswer(name string, ttl uint32, data util.Message) {
+	d.Answers = append(d.Answers, DNSResourceRecord{
+		Name:  name,
+		Type:  dnsRecordType(data),
+		Class: DNSClass_IN,
+		TTL:   ttl,
+		Data:  data,
+	})
+}
+
+func dnsRecordType(data util.Message) uint16 {
+	switch data.(type) {
+	case *DNSRecordA:
+		return DNSType_A
+	case *DNSRecordAAAA:
+		return DNSType_AAAA
+	case *DNSRecordCNAME:
+		return DNSType_CNAME
+	case *DNSRecordPTR:
+		return DNSType_PTR
+	case *DNSRecordTXT:
+		return DNSType_TXT
+	case *DNSRecordSRV:
+		return DNSType_SRV
+	}
+	return 0
+}
+
+// Len returns the length of the message returned by MarshalBinary, or of the
+// part that could be encoded if it fails.
+func (d *DNS) Len() (n uint16) {
+	if d.unchanged() {
+		return uint16(len(d.received.data))
+	}
+	e := newDNSEncoder(d.Compress, true)
+	_ = d.encode(e)
+	return uint16(e.n)
+}
+
+func (d *DNS) MarshalBinary() (data []byte, err error) {
+	if d.unchanged() {
+		return append([]byte(nil), d.received.data...), nil
+	}
+	e := newDNSEncoder(d.Compress, false)
+	if err = d.encode(e); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (d *DNS) encode(e *dnsEncoder) error {
+	var header [dnsHeaderLen]byte
+	binary.BigEndian.PutUint16(header[0:2], d.ID)
+	var flags uint16
+	flags |= uint16(d.OpCode&0xf) << 11
+	flags |= uint16(d.Z&0x1) << 6
+	flags |= uint16(d.RCode & 0xf)
+	for _, f := range []struct {
+		set bool
+		bit uint16
+	}{{d.QR, 1 << 15}, {d.AA, 1 << 10}, {d.TC, 1 << 9}, {d.RD, 1 << 8}, {d.RA, 1 << 7}, {d.AD, 1 << 5}, {d.CD, 1 << 4}} {
+		if f.set {
+			flags |= f.bit
+		}
+	}
+	binary.BigEndian.PutUint16(header[2:4], flags)
+	for i, n := range []int{len(d.Questions), len(d.Answers), len(d.Authorities), len(d.Additionals)} {
+		if n > 0xffff {
+			return fmt.Errorf("too many DNS records: %d", n)
+		}
+		binary.BigEndian.PutUint16(header[4+2*i:], uint16(n))
+	}
+	e.write(header[:]...)
+
+	for _, q := range d.Questions {
+		if err := e.name(q.Name, true); err != nil {
+			return err
+		}
+		e.uint16(q.Type)
+		e.uint16(q.Class)
+	}
+	for _, section := range [][]DNSResourceRecord{d.Answers, d.Authorities, d.Additionals} {
+		for i := range section {
+			if err := e.record(&section[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if e.n > 0xffff {
+		return fmt.Errorf("DNS message too long: %d bytes", e.n)
+	}
+	return nil
+}
+
+// UnmarshalBinary decodes a DNS message, following compression pointers. It
+// returns an error for malformed messages, such as pointer loops or counts
+// larger than the message.
+func (d *DNS) UnmarshalBinary(data []byte) error {
+	d.received = nil
+	if err := d.decode(data); err != nil {
+		return err
+	}
+	received := &dnsReceived{data: append([]byte(nil), data...)}
+	if err := received.msg.decode(data); err != nil {
+		return err
+	}
+	d.received = received
+	return nil
+}
+
+func (d *DNS) decode(data []byte) error {
+	if len(data) < dnsHeaderLen {
+		return errDNSTooShort
+	}
+	d.ID = binary.BigEndian.Uint16(data[0:2])
+	flags := binary.BigEndian.Uint16(data[2:4])
+	d.QR = flags&(1<<15) != 0
+	d.OpCode = uint8(flags>>11) & 0xf
+	d.AA = flags&(1<<10) != 0
+	d.TC = flags&(1<<9) != 0
+	d.RD = flags&(1<<8) != 0
+	d.RA = flags&(1<<7) != 0
+	d.Z = uint8(flags>>6) & 0x1
+	d.AD = flags&(1<<5) != 0
+	d.CD = flags&(1<<4) != 0
+	d.RCode = uint8(flags) & 0xf
+
+	qdCount := int(binary.BigEndian.Uint16(data[4:6]))
+	// A question is at least 5 bytes and a record 11 bytes, which bounds the
+	// counts of a valid message.
+	if qdCount*5 > len(data)-dnsHeaderLen {
+		return errDNSTooShort
+	}
+	dec := &dnsDecoder{data: data, off: dnsHeaderLen}
+	d.Questions = nil
+	for i := 0; i < qdCount; i++ {
+		var q DNSQuestion
+		var err error
+		if q.Name, err = dec.name(); err != nil {
+			return err
+		}
+		if dec.off+4 > len(data) {
+			return errDNSTooShort
+		}
+		q.Type = binary.BigEndian.Uint16(data[dec.off:])
+		q.Class = binary.BigEndian.Uint16(data[dec.off+2:])
+		dec.off += 4
+		d.Questions = append(d.Questions, q)
+	}
+
+	sections := []*[]DNSResourceRecord{&d.Answers, &d.Authorities, &d.Additionals}
+	for i, section := range sections {
+		count := int(binary.BigEndian.Uint16(data[6+2*i:]))
+		if count*11 > len(data)-dec.off {
+			return errDNSTooShort
+		}
+		*section = nil
+		for j := 0; j < count; j++ {
+			r, err := dec.record()
+			if err != nil {
+				return err
+			}
+			*section = append(*section, r)
+		}
+	}
+	d.Compress = dec.compressed
+	return nil
+}
+
+// DNSOverTCP is a DNS message carried in TCP, prefixed with its length.
+type DNSOverTCP struct {
+	DNS
+}
+
+func (d *DNSOverTCP) Len() (n uint16) {
+	return 2 + d.DNS.Len()
+}
+
+func (d *DNSOverTCP) MarshalBinary() (data []byte, err error) {
+	msg, err := d.DNS.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(msg)), uint16(len(msg)))
+	return append(data, msg...), nil
+}
+
+// UnmarshalBinary decodes a single DNS message, which must fill data.
+func (d *DNSOverTCP) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errDNSTooShort
+	}
+	if n := int(binary.BigEndian.Uint16(data)); n != len(data)-2 {
+		return fmt.Errorf("DNS message length %d does not match the %d bytes of the segment", n, len(data)-2)
+	}
+	return d.DNS.UnmarshalBinary(data[2:])
+}
+
+type dnsEncoder struct {
+	buf []byte
+	// n is the length of the encoded message, the only thing computed when
+	// measuring.
+	n       int
+	measure bool
+	// names holds the offsets of the names written so far, by their wire
+	// format in lower case, for compression. It is nil without compression.
+	names map[string]int
+}
+
+func newDNSEncoder(compress, measure bool) *dnsEncoder {
+	e := &dnsEncoder{measure: measure}
+	if compress {
+		e.names = make(map[string]int)
+	}
+	if !measure {
+		e.buf = make([]byte, 0, 512)
+	}
+	return e
+}
+
+func (e *dnsEncoder) write(b ...byte) {
+	if !e.measure {
+		e.buf = append(e.buf, b...)
+	}
+	e.n += len(b)
+}
+
+func (e *dnsEncoder) uint16(v uint16) {
+	e.write(byte(v>>8), byte(v))
+}
+
+func (e *dnsEncoder) uint32(v uint32) {
+	e.write(byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// splitDNSName returns the labels of name, unescaping the dots and
+// backslashes they contain.
+func splitDNSName(name string) ([][]byte, error) {
+	var labels [][]byte
+	var label []byte
+	escaped := false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case escaped:
+			label = append(label, c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '.':
+			if len(label) == 0 {
+				// Only the root name is a dot alone.
+				if name != "." {
+					return nil, fmt.Errorf("invalid DNS name %q", name)
+				}
+				continue
+			}
+			labels = append(labels, label)
+			label = nil
+		default:
+			label = append(label, c)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("invalid DNS name %q", name)
+	}
+	if len(label) > 0 {
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// name writes name, pointing to a previous occurrence of its longest known
+// suffix if compress is set and the encoder compresses names.
+func (e *dnsEncoder) name(name string, compress bool) error {
+	labels, err := splitDNSName(name)
+	if err != nil {
+		return err
+	}
+	wireLen := 1
+	for _, l := range labels {
+		if len(l) > 63 {
+			return fmt.Errorf("invalid DNS name %q", name)
+		}
+		wireLen += 1 + len(l)
+	}
+	if wireLen > dnsMaxNameLen {
+		return fmt.Errorf("DNS name too long: %q", name)
+	}
+	for i := range labels {
+		if e.names != nil {
+			suffix := dnsSuffixKey(labels[i:])
+			if off, ok := e.names[suffix]; ok && compress {
+				e.uint16(0xc000 | uint16(off))
+				return nil
+			}
+			if _, ok := e.names[suffix]; !ok && e.n < 0x4000 {
+				e.names[suffix] = e.n
+			}
+		}
+		e.write(byte(len(labels[i])))
+		e.write(labels[i]...)
+	}
+	e.write(0)
+	return nil
+}
+
+// dnsSuffixKey returns the wire format of labels in lower case. Only ASCII
+// letters are compared without case in DNS.
+func dnsSuffixKey(labels [][]byte) string {
+	var key []byte
+	for _, l := range labels {
+		key = append(key, byte(len(l)))
+		for _, c := range l {
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			key = append(key, c)
+		}
+	}
+	return string(key)
+}
+
+func (e *dnsEncoder) record(r *DNSResourceRecord) error {
+	if err := e.name(r.Name, true); err != nil {
+		return err
+	}
+	e.uint16(r.Type)
+	e.uint16(r.Class)
+	e.uint32(r.TTL)
+	lenOff := e.n
+	e.uint16(0)
+	switch rdata := r.Data.(type) {
+	case nil:
+	case *DNSRecordCNAME:
+		if err := e.name(rdata.Name, true); err != nil {
+			return err
+		}
+	case *DNSRecordPTR:
+		if err := e.name(rdata.Name, true); err != nil {
+			return err
+		}
+	case *DNSRecordSRV:
+		// The target of SRV records is never compressed.
+		e.uint16(rdata.Priority)
+		e.uint16(rdata.Weight)
+		e.uint16(rdata.Port)
+		if err := e.name(rdata.Target, false); err != nil {
+			return err
+		}
+	default:
+		if e.measure {
+			e.n += int(rdata.Len())
+			break
+		}
+		b, err := rdata.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		e.write(b...)
+	}
+	rdLen := e.n - lenOff - 2
+	if rdLen > 0xffff {
+		return fmt.Errorf("DNS record data too long: %d bytes", rdLen)
+	}
+	if !e.measure {
+		binary.BigEndian.PutUint16(e.buf[lenOff:], uint16(rdLen))
+	}
+	return nil
+}
+
+type dnsDecoder struct {
+	data []byte
+	off  int
+	// compressed is set once a compressed name is decoded.
+	compressed bool
+}
+
+// name decodes the name at the current offset and moves past it.
+func (d *dnsDecoder) name() (string, error) {
+	name, next, err := d.nameAt(d.data, d.off)
+	if err != nil {
+		return "", err
+	}
+	d.off = next
+	return name, nil
+}
+
+// nameAt decodes the name at off in msg.
+func (d *dnsDecoder) nameAt(msg []byte, off int) (name string, next int, err error) {
+	if name, next, err = decodeDNSName(msg, off); err != nil {
+		return
+	}
+	// The labels of a valid name lead to its end or to a pointer.
+	for msg[off] != 0 && msg[off]&0xc0 == 0 {
+		off += 1 + int(msg[off])
+	}
+	if msg[off] != 0 {
+		d.compressed = true
+	}
+	return
+}
+
+// decodeDNSName decodes the name at off in msg, and returns the offset after
+// it. Compression pointers must point backwards, and the length limit of
+// names ends the loops they could still make. The dots and backslashes of the
+// labels are escaped.
+func decodeDNSName(msg []byte, off int) (name string, next int, err error) {
+	var sb strings.Builder
+	wireLen := 0
+	next = -1
+	for {
+		if off >= len(msg) {
+			return "", 0, errDNSTooShort
+		}
+		l := int(msg[off])
+		switch l & 0xc0 {
+		case 0x00:
+			if l == 0 {
+				if next < 0 {
+					next = off + 1
+				}
+				return sb.String(), next, nil
+			}
+			if off+1+l > len(msg) {
+				return "", 0, errDNSTooShort
+			}
+			wireLen += 1 + l
+			if wireLen+1 > dnsMaxNameLen {
+				return "", 0, errors.New("DNS name too long")
+			}
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			for _, c := range msg[off+1 : off+1+l] {
+				if c == '.' || c == '\\' {
+					sb.WriteByte('\\')
+				}
+				sb.WriteByte(c)
+			}
+			off += 1 + l
+		case 0xc0:
+			if off+2 > len(msg) {
+				return "", 0, errDNSTooShort
+			}
+			ptr := int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			if ptr >= off {
+				return "", 0, fmt.Errorf("invalid DNS compression pointer %d at %d", ptr, off)
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = ptr
+		default:
+			return "", 0, fmt.Errorf("invalid DNS label type 0x%02x", l&0xc0)
+		}
+	}
+}
+
+func (d *dnsDecoder) record() (r DNSResourceRecord, err error) {
+	if r.Name, err = d.name(); err != nil {
+		return
+	}
+	if d.off+10 > len(d.data) {
+		return r, errDNSTooShort
+	}
+	r.Type = binary.BigEndian.Uint16(d.data[d.off:])
+	r.Class = binary.BigEndian.Uint16(d.data[d.off+2:])
+	r.TTL = binary.BigEndian.Uint32(d.data[d.off+4:])
+	rdLen := int(binary.BigEndian.Uint16(d.data[d.off+8:]))
+	d.off += 10
+	end := d.off + rdLen
+	if end > len(d.data) {
+		return r, errDNSTooShort
+	}
+	// Names in RDATA may point anywhere before them in the message, but must
+	// end within the RDATA.
+	msg := d.data[:end]
+	switch r.Type {
+	case DNSType_CNAME, DNSType_PTR:
+		var name string
+		var next int
+		if name, next, err = d.nameAt(msg, d.off); err != nil {
+			return
+		}
+		if next != end {
+			return r, fmt.Errorf("invalid DNS record data length %d", rdLen)
+		}
+		if r.Type == DNSType_CNAME {
+			r.Data = &DNSRecordCNAME{Name: name}
+		} else {
+			r.Data = &DNSRecordPTR{Name: name}
+		}
+	case DNSType_SRV:
+		if rdLen < 7 {
+			return r, fmt.Errorf("invalid DNS SRV record length %d", rdLen)
+		}
+		srv := &DNSRecordSRV{
+			Priority: binary.BigEndian.Uint16(msg[d.off:]),
+			Weight:   binary.BigEndian.Uint16(msg[d.off+2:]),
+			Port:     binary.BigEndian.Uint16(msg[d.off+4:]),
+		}
+		var next int
+		if srv.Target, next, err = d.nameAt(msg, d.off+6); err != nil {
+			return
+		}
+		if next != end {
+			return r, fmt.Errorf("invalid DNS record data length %d", rdLen)
+		}
+		r.Data = srv
+	default:
+		switch r.Type {
+		case DNSType_A:
+			r.Data = new(DNSRecordA)
+		case DNSType_AAAA:
+			r.Data = new(DNSRecordAAAA)
+		case DNSType_TXT:
+			r.Data = new(DNSRecordTXT)
+		default:
+			r.Data = new(util.Buffer)
+		}
+		if err = r.Data.UnmarshalBinary(msg[d.off:end]); err != nil {
+			return
+		}
+	}
+	d.off = end
+	return r, nil
+}
+
+// DNSRecordA is the RDATA of an A record.
+type DNSRecordA struct {
+	IP net.IP
+}
+
+func (r *DNSRecordA) Len() (n uint16) {
+	return 4
+}
+
+func (r *DNSRecordA) MarshalBinary() (data []byte, err error) {
+	ip := r.IP.To4()
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IPv4 address %v in DNS A record", r.IP)
+	}
+	return append([]byte(nil), ip...), nil
+}
+
+func (r *DNSRecordA) UnmarshalBinary(data []byte) error {
+	if len(data) != 4 {
+		return fmt.Errorf("invalid DNS A record length %d", len(data))
+	}
+	r.IP = net.IP(append([]byte(nil), data...))
+	return nil
+}
+
+// DNSRecordAAAA is the RDATA of an AAAA record.
+type DNSRecordAAAA struct {
+	IP net.IP
+}
+
+func (r *DNSRecordAAAA) Len() (n uint16) {
+	return 16
+}
+
+func (r *DNSRecordAAAA) MarshalBinary() (data []byte, err error) {
+	if len(r.IP) != net.IPv6len {
+		return nil, fmt.Errorf("invalid IPv6 address %v in DNS AAAA record", r.IP)
+	}
+	return append([]byte(nil), r.IP...), nil
+}
+
+func (r *DNSRecordAAAA) UnmarshalBinary(data []byte) error {
+	if len(data) != 16 {
+		return fmt.Errorf("invalid DNS AAAA record length %d", len(data))
+	}
+	r.IP = net.IP(append([]byte(nil), data...))
+	return nil
+}
+
+// DNSRecordCNAME is the RDATA of a CNAME record. Alone, it is marshaled
+// without compression.
+type DNSRecordCNAME struct {
+	Name string
+}
+
+func (r *DNSRecordCNAME) Len() (n uint16) {
+	return dnsNameLen(r.Name)
+}
+
+func (r *DNSRecordCNAME) MarshalBinary() (data []byte, err error) {
+	return marshalDNSName(r.Name)
+}
+
+func (r *DNSRecordCNAME) UnmarshalBinary(data []byte) (err error) {
+	r.Name, _, err = decodeDNSName(data, 0)
+	return
+}
+
+// DNSRecordPTR is the RDATA of a PTR record. Alone, it is marshaled without
+// compression.
+type DNSRecordPTR struct {
+	Name string
+}
+
+func (r *DNSRecordPTR) Len() (n uint16) {
+	return dnsNameLen(r.Name)
+}
+
+func (r *DNSRecordPTR) MarshalBinary() (data []byte, err error) {
+	return marshalDNSName(r.Name)
+}
+
+func (r *DNSRecordPTR) UnmarshalBinary(data []byte) (err error) {
+	r.Name, _, err = decodeDNSName(data, 0)
+	return
+}
+
+// DNSRecordTXT is the RDATA of a TXT record, made of character strings of up
+// to 255 bytes.
+type DNSRecordTXT struct {
+	Texts []string
+}
+
+func (r *DNSRecordTXT) Len() (n uint16) {
+	for _, t := range r.Texts {
+		n += 1 + uint16(len(t))
+	}
+	return
+}
+
+func (r *DNSRecordTXT) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 0, r.Len())
+	for _, t := range r.Texts {
+		if len(t) > 255 {
+			return nil, fmt.Errorf("DNS TXT string too long: %d bytes", len(t))
+		}
+		data = append(data, byte(len(t)))
+		data = append(data, t...)
+	}
+	return
+}
+
+func (r *DNSRecordTXT) UnmarshalBinary(data []byte) error {
+	r.Texts = nil
+	for n := 0; n < len(data); {
+		l := int(data[n])
+		if n+1+l > len(data) {
+			return errors.New("The []byte is too short to unmarshal a full DNS TXT record.")
+		}
+		r.Texts = append(r.Texts, string(data[n+1:n+1+l]))
+		n += 1 + l
+	}
+	return nil
+}
+
+// DNSRecordSRV is the RDATA of an SRV record.
+type DNSRecordSRV struct {
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+	Target   string
+}
+
+func (r *DNSRecordSRV) Len() (n uint16) {
+	return 6 + dnsNameLen(r.Target)
+}
+
+func (r *DNSRecordSRV) MarshalBinary() (data []byte, err error) {
+	e := newDNSEncoder(false, false)
+	e.uint16(r.Priority)
+	e.uint16(r.Weight)
+	e.uint16(r.Port)
+	if err = e.name(r.Target, false); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (r *DNSRecordSRV) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 7 {
+		return fmt.Errorf("invalid DNS SRV record length %d", len(data))
+	}
+	r.Priority = binary.BigEndian.Uint16(data[0:2])
+	r.Weight = binary.BigEndian.Uint16(data[2:4])
+	r.Port = binary.BigEndian.Uint16(data[4:6])
+	// The name is decoded from the start of data, so that its pointers can
+	// only point within it.
+	var next int
+	r.Target, next, err = decodeDNSName(data[6:], 0)
+	if err == nil && next != len(data)-6 {
+		err = fmt.Errorf("invalid DNS SRV record length %d", len(data))
+	}
+	return
+}
+
+func marshalDNSName(name string) ([]byte, error) {
+	e := newDNSEncoder(false, false)
+	if err := e.name(name, false); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// dnsNameLen returns the length of name on the wire, or of the part that
+// could be encoded if it is invalid.
+func dnsNameLen(name string) uint16 {
+	e := newDNSEncoder(false, true)
+	_ = e.name(name, false)
+	return uint16(e.n)
+}
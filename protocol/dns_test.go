@@ -0,0 +1,229 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func newTestDNSResponse() *DNS {
+	dns := NewDNSQuery(0x1234, "www.example.com", DNSType_A)
+	dns.QR = true
+	dns.RA = true
+	dns.AddAnswer("www.example.com", 300, &DNSRecordCNAME{Name: "web.example.com"})
+	dns.AddAnswer("web.example.com", 300, &DNSRecordA{IP: net.ParseIP("10.0.0.10").To4()})
+	dns.AddAnswer("web.example.com", 300, &DNSRecordAAAA{IP: net.ParseIP("2001:db8::10")})
+	dns.AddAnswer("10.0.0.10.in-addr.arpa", 60, &DNSRecordPTR{Name: "web.example.com"})
+	dns.AddAnswer("example.com", 60, &DNSRecordTXT{Texts: []string{"v=spf1 -all", ""}})
+	dns.AddAnswer("_http._tcp.example.com", 60, &DNSRecordSRV{Priority: 1, Weight: 2, Port: 80, Target: "web.example.com"})
+	dns.Additionals = []DNSResourceRecord{{Type: DNSType_OPT, Class: 1232, Data: util.NewBuffer(nil)}}
+	dns.Compress = true
+	return dns
+}
+
+// decodedDNS returns the fields of a decoded DNS message, to compare them with
+// the fields of the message it was encoded from.
+func decodedDNS(msg util.Message) util.Message {
+	switch m := msg.(type) {
+	case *DNS:
+		fields := *m
+		fields.received = nil
+		return &fields
+	case *DNSOverTCP:
+		fields := *m
+		fields.received = nil
+		return &fields
+	}
+	return msg
+}
+
+func TestDNS(t *testing.T) {
+	dns := newTestDNSResponse()
+	data, err := dns.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(len(data)), dns.Len())
+	// The answer name points to the question name.
+	assert.Equal(t, []byte{0xc0, 0x0c}, data[33:35])
+
+	decoded := new(DNS)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, dns, decodedDNS(decoded))
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	// Without compression, by hand.
+	query := []byte{
+		0xab, 0xcd, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x03, 'f', 'o', 'o', 0x03, 'c', 'o', 'm', 0x00, 0x00, 0x1c, 0x00, 0x01,
+	}
+	require.NoError(t, decoded.UnmarshalBinary(query))
+	assert.Equal(t, NewDNSQuery(0xabcd, "foo.com", DNSType_AAAA), decodedDNS(decoded))
+}
+
+func TestDNSDispatch(t *testing.T) {
+	udp := NewUDP()
+	udp.PortSrc = Port_DNS
+	udp.PortDst = 34567
+	udp.Payload = newTestDNSResponse()
+
+	tcp := NewTCP()
+	tcp.PortSrc = 34567
+	tcp.PortDst = Port_DNS
+	tcp.Payload = &DNSOverTCP{DNS: *NewDNSQuery(1, "example.com", DNSType_A)}
+
+	for _, l4 := range []util.Message{udp, tcp} {
+		ip := newTestIPv4(Type_UDP)
+		if _, ok := l4.(*TCP); ok {
+			ip.Protocol = Type_TCP
+		}
+		ip.Data = l4
+		data, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+		require.NoError(t, err)
+
+		decoded := new(IPv4)
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		assert.NoError(t, VerifyChecksums(decoded))
+		// Data holds the received payload.
+		switch l4 := decoded.Data.(type) {
+		case *UDP:
+			assert.Equal(t, udp.Payload, decodedDNS(l4.Payload))
+			payload, err := udp.Payload.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, payload, l4.Data)
+		case *TCP:
+			assert.Equal(t, tcp.Payload, decodedDNS(l4.Payload))
+			payload, err := tcp.Payload.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, payload, l4.Data)
+		}
+	}
+
+	// A partial message over TCP is left undecoded.
+	b, err := tcp.MarshalBinary()
+	require.NoError(t, err)
+	decoded := new(TCP)
+	require.NoError(t, decoded.UnmarshalBinary(b[:len(b)-1]))
+	assert.Nil(t, decoded.Payload)
+}
+
+func TestDNSRoundTrip(t *testing.T) {
+	uncompressed := newTestDNSResponse()
+	uncompressed.Compress = false
+	// The answer is not compressed but its CNAME target is, which is not
+	// what the encoder would do.
+	partial := []byte{
+		0x12, 0x34, 0x81, 0x80, 0, 1, 0, 1, 0, 0, 0, 0,
+		1, 'a', 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, 0, 5, 0, 1,
+		1, 'a', 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, 0, 5, 0, 1, 0, 0, 0, 60, 0, 4,
+		1, 'b', 0xc0, 14,
+	}
+	// A label holding a dot.
+	dotted := []byte{
+		0, 1, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0,
+		3, 'a', '.', 'b', 3, 'c', 'o', 'm', 0, 0, 1, 0, 1,
+	}
+
+	for name, tc := range map[string]struct {
+		msg      util.Message
+		compress bool
+	}{
+		"uncompressed":         {msg: uncompressed},
+		"compressed":           {msg: newTestDNSResponse(), compress: true},
+		"partially compressed": {msg: util.NewBuffer(partial), compress: true},
+		"dotted label":         {msg: util.NewBuffer(dotted)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			udp := NewUDP()
+			udp.PortSrc = Port_DNS
+			udp.PortDst = 34567
+			udp.Payload = tc.msg
+			ip := newTestIPv4(Type_UDP)
+			ip.Data = udp
+			data, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+			require.NoError(t, err)
+
+			decoded := new(IPv4)
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			dns, ok := decoded.Data.(*UDP).Payload.(*DNS)
+			require.True(t, ok)
+			assert.Equal(t, tc.compress, dns.Compress)
+			b, err := decoded.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, data, b)
+			assert.NoError(t, VerifyChecksums(decoded))
+
+			// A changed message is encoded again, with the same names.
+			dns.ID++
+			b, err = dns.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, int(dns.Len()), len(b))
+			changed := new(DNS)
+			require.NoError(t, changed.UnmarshalBinary(b))
+			assert.Equal(t, decodedDNS(dns), decodedDNS(changed))
+		})
+	}
+
+	dns := new(DNS)
+	require.NoError(t, dns.UnmarshalBinary(dotted))
+	assert.Equal(t, `a\.b.com`, dns.Questions[0].Name)
+	dns.ID = 0
+	b, err := dns.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, dotted[2:], b[2:])
+}
+
+func TestDNSInvalid(t *testing.T) {
+	data, err := newTestDNSResponse().MarshalBinary()
+	require.NoError(t, err)
+	for i := 0; i < len(data); i++ {
+		assert.Error(t, new(DNS).UnmarshalBinary(data[:i]), "%d bytes", i)
+	}
+
+	header := []byte{0, 1, 0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0}
+	for name, question := range map[string][]byte{
+		"pointer to itself":  {0xc0, 0x0c, 0, 1, 0, 1},
+		"forward pointer":    {0xc0, 0x0e, 0, 1, 0, 1},
+		"pointer loop":       {0x01, 'a', 0xc0, 0x0c, 0, 1, 0, 1},
+		"extended label":     {0x41, 'a', 0, 0, 1, 0, 1},
+		"label beyond":       {0x3f, 'a', 0, 0, 1, 0, 1},
+		"name beyond 255":    append(append([]byte{}, repeatLabel(5, 63)...), 0, 0, 1, 0, 1),
+		"questions beyond 5": {},
+	} {
+		assert.Error(t, new(DNS).UnmarshalBinary(append(append([]byte{}, header...), question...)), name)
+	}
+
+	// Records whose RDATA does not match their type.
+	for _, rdata := range [][]byte{
+		{0, 1, 0, 1, 0, 0, 0, 0, 0, 3, 1, 2, 3},
+		{0, 5, 0, 1, 0, 0, 0, 0, 0, 2, 0, 0},
+		{0, 33, 0, 1, 0, 0, 0, 0, 0, 6, 0, 0, 0, 0, 0, 0},
+		{0, 16, 0, 1, 0, 0, 0, 0, 0, 2, 5, 'a'},
+	} {
+		msg := append([]byte{0, 1, 0x81, 0x80, 0, 0, 0, 1, 0, 0, 0, 0, 0}, rdata...)
+		assert.Error(t, new(DNS).UnmarshalBinary(msg), "%v", rdata)
+	}
+
+	for _, name := range []string{"a..b", ".a", "a\\"} {
+		_, err = NewDNSQuery(1, name, DNSType_A).MarshalBinary()
+		assert.Error(t, err, name)
+	}
+	_, err = NewDNSQuery(1, string(repeatLabel(5, 63)[1:]), DNSType_A).MarshalBinary()
+	assert.Error(t, err)
+}
+
+// repeatLabel returns n labels of size bytes on the wire.
+func repeatLabel(n, size int) []byte {
+	var data []byte
+	for i := 0; i < n; i++ {
+		data = append(data, byte(size))
+		for j := 0; j < size; j++ {
+			data = append(data, 'a')
+		}
+	}
+	return data
+}
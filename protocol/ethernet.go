@@ -16,8 +16,15 @@ const (
 	WOL_MSG  = 0x0842
 	RARP_MSG = 0x8035
 	VLAN_MSG = 0x8100
+	QINQ_MSG = 0x88A8 // 802.1ad service tag.
+
+	VLAN_9100_MSG = 0x9100 // Pre-standard outer tag.
 
 	IPv6_MSG     = 0x86DD
+	MPLS_MSG     = 0x8847
+	MPLS_MC_MSG  = 0x8848
+	TEB_MSG      = 0x6558 // Transparent Ethernet bridging, in tunnels.
+	NSH_MSG      = 0x894F
 	STP_MSG      = 0x4242
 	STP_BPDU_MSG = 0xAAAA
 )
@@ -26,9 +33,13 @@ type Ethernet struct {
 	Delimiter uint8
 	HWDst     net.HardwareAddr
 	HWSrc     net.HardwareAddr
-	VLANID    VLAN
-	Ethertype uint16
-	Data      util.Message
+	// VLANID is the outermost VLAN tag, present if its VID is not 0 or if
+	// there are inner tags.
+	VLANID VLAN
+	// InnerVLANs are the stacked tags after VLANID, outermost first.
+	InnerVLANs []VLAN
+	Ethertype  uint16
+	Data       util.Message
 }
 
 func NewEthernet() *Ethernet {
@@ -44,9 +55,7 @@ func NewEthernet() *Ethernet {
 func (e *Ethernet) Len() (n uint16) {
 	n = 0
 	n += 12
-	if e.VLANID.VID != 0 {
-		n += 4
-	}
+	n += 4 * uint16(len(e.VLANs()))
 	n += 2
 	if e.Data != nil {
 		n += e.Data.Len()
@@ -63,8 +72,8 @@ func (e *Ethernet) MarshalBinary() (data []byte, err error) {
 	copy(data[n:], e.HWSrc)
 	n += len(e.HWSrc)
 
-	if e.VLANID.VID != 0 {
-		if bytes, err = e.VLANID.MarshalBinary(); err != nil {
+	for _, v := range e.VLANs() {
+		if bytes, err = v.MarshalBinary(); err != nil {
 			return
 		}
 		copy(data[n:], bytes)
@@ -83,6 +92,50 @@ func (e *Ethernet) MarshalBinary() (data []byte, err error) {
 	return
 }
 
+// VLANs returns the VLAN tags of the frame, outermost first.
+func (e *Ethernet) VLANs() []VLAN {
+	if e.VLANID.VID == 0 && len(e.InnerVLANs) == 0 {
+		return nil
+	}
+	return append([]VLAN{e.VLANID}, e.InnerVLANs...)
+}
+
+// PushVLAN adds an outermost VLAN tag, like the push_vlan action.
+func (e *Ethernet) PushVLAN(v VLAN) {
+	if tags := e.VLANs(); len(tags) > 0 {
+		e.InnerVLANs = tags
+	}
+	e.VLANID = v
+}
+
+// PopVLAN removes the outermost VLAN tag, like the pop_vlan action, and
+// returns it.
+func (e *Ethernet) PopVLAN() (VLAN, bool) {
+	tags := e.VLANs()
+	if len(tags) == 0 {
+		return VLAN{}, false
+	}
+	if len(tags) == 1 {
+		e.VLANID = VLAN{}
+	} else {
+		e.VLANID = tags[1]
+	}
+	e.InnerVLANs = nil
+	if len(tags) > 2 {
+		e.InnerVLANs = tags[2:]
+	}
+	return tags[0], true
+}
+
+// IsVLANTPID reports whether ethertype is the TPID of a VLAN tag.
+func IsVLANTPID(ethertype uint16) bool {
+	switch ethertype {
+	case VLAN_MSG, QINQ_MSG, VLAN_9100_MSG:
+		return true
+	}
+	return false
+}
+
 func (e *Ethernet) UnmarshalBinary(data []byte) error {
 	if len(data) < 14 {
 		return errors.New("The []byte is too short to unmarshal a full Ethernet message.")
@@ -95,33 +148,53 @@ func (e *Ethernet) UnmarshalBinary(data []byte) error {
 	copy(e.HWSrc, data[n:n+6])
 	n += 6
 
+	e.VLANID = *new(VLAN)
+	e.InnerVLANs = nil
 	e.Ethertype = binary.BigEndian.Uint16(data[n:])
-	if e.Ethertype == VLAN_MSG {
-		e.VLANID = *new(VLAN)
-		err := e.VLANID.UnmarshalBinary(data[n:])
+	for tags := 0; IsVLANTPID(e.Ethertype); tags++ {
+		v := new(VLAN)
+		err := v.UnmarshalBinary(data[n:])
 		if err != nil {
 			return err
 		}
-		n += int(e.VLANID.Len())
+		if tags == 0 {
+			e.VLANID = *v
+		} else {
+			e.InnerVLANs = append(e.InnerVLANs, *v)
+		}
+		n += int(v.Len())
 
+		if len(data) < n+2 {
+			return errors.New("The []byte is too short to unmarshal a full Ethernet message.")
+		}
 		e.Ethertype = binary.BigEndian.Uint16(data[n:])
-	} else {
-		e.VLANID = *new(VLAN)
-		e.VLANID.VID = 0
 	}
 	n += 2
 
-	switch e.Ethertype {
+	e.Data = newByEthertype(e.Ethertype)
+	return e.Data.UnmarshalBinary(data[n:])
+}
+
+// newByEthertype returns the layer to decode a payload of the given type,
+// which is also used by the tunnel headers.
+func newByEthertype(ethertype uint16) util.Message {
+	switch ethertype {
 	case IPv4_MSG:
-		e.Data = new(IPv4)
+		return new(IPv4)
 	case IPv6_MSG:
-		e.Data = new(IPv6)
+		return new(IPv6)
 	case ARP_MSG:
-		e.Data = new(ARP)
-	default:
-		e.Data = new(util.Buffer)
+		return new(ARP)
+	case LLDP_MSG:
+		return new(LLDP)
+	case MPLS_MSG, MPLS_MC_MSG:
+		return NewMPLS()
+	case TEB_MSG:
+		return new(Ethernet)
+	case NSH_MSG:
+		return new(NSH)
 	}
-	return e.Data.UnmarshalBinary(data[n:])
+	return new(util.Buffer)
 }
 
 const (
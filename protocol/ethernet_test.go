@@ -0,0 +1,68 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEthernetVLANStack(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		tags []VLAN
+	}{
+		{"untagged", nil},
+		{"802.1Q", []VLAN{{TPID: VLAN_MSG, PCP: 5, VID: 100}}},
+		{"802.1ad", []VLAN{{TPID: QINQ_MSG, VID: 10}, {TPID: VLAN_MSG, DEI: 1, VID: 20}}},
+		{"0x9100", []VLAN{{TPID: VLAN_9100_MSG, VID: 10}, {TPID: VLAN_MSG, VID: 20}}},
+		{"three tags", []VLAN{{TPID: QINQ_MSG, VID: 1}, {TPID: VLAN_MSG, VID: 2}, {TPID: VLAN_MSG, VID: 3}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			eth := NewEthernet()
+			eth.HWDst, _ = net.ParseMAC("aa:bb:cc:dd:ee:ff")
+			eth.HWSrc, _ = net.ParseMAC("11:22:33:44:55:66")
+			eth.Ethertype = IPv4_MSG
+			ip := newTestIPv4(Type_UDP)
+			ip.Data = newLargeUDP(4)
+			eth.Data = ip
+			for i := len(tc.tags) - 1; i >= 0; i-- {
+				eth.PushVLAN(tc.tags[i])
+			}
+			assert.Equal(t, tc.tags, eth.VLANs())
+			data, err := Serialize(eth, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+			require.NoError(t, err)
+			assert.Len(t, data, 14+4*len(tc.tags)+int(eth.Data.Len()))
+
+			decoded := new(Ethernet)
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			assert.Equal(t, tc.tags, decoded.VLANs())
+			assert.Equal(t, uint16(IPv4_MSG), decoded.Ethertype)
+			require.IsType(t, new(IPv4), decoded.Data)
+			b, err := decoded.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, data, b)
+
+			for i := range tc.tags {
+				v, ok := decoded.PopVLAN()
+				require.True(t, ok)
+				assert.Equal(t, tc.tags[i], v)
+			}
+			_, ok := decoded.PopVLAN()
+			assert.False(t, ok)
+			assert.Equal(t, uint16(0), decoded.VLANID.VID)
+		})
+	}
+}
+
+func TestEthernetVLANTruncated(t *testing.T) {
+	eth := NewEthernet()
+	eth.PushVLAN(VLAN{TPID: QINQ_MSG, VID: 10})
+	eth.PushVLAN(VLAN{TPID: QINQ_MSG, VID: 20})
+	data, err := eth.MarshalBinary()
+	require.NoError(t, err)
+	// Cut in the middle of the inner tag.
+	assert.Error(t, new(Ethernet).UnmarshalBinary(data[:17]))
+	assert.Error(t, new(Ethernet).UnmarshalBinary(data[:18]))
+}
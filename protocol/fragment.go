@@ -0,0 +1,471 @@
+package protocol
+
+// This file splits IPv4 and IPv6 packets into fragments and reassembles them.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"antrea.io/libOpenflow/util"
+)
+
+var (
+	// ErrDontFragment is returned by FragmentIPv4 for a packet larger than
+	// the MTU with the Don't Fragment flag.
+	ErrDontFragment = errors.New("packet larger than the MTU with the Don't Fragment flag")
+	// ErrFragmentOverlap is returned by the Reassembler for a fragment
+	// overlapping another fragment of the same packet with different data.
+	// The packet is dropped, see RFC 5722.
+	ErrFragmentOverlap = errors.New("overlapping fragments")
+	// ErrFragmentTooLarge is returned by the Reassembler for a fragment
+	// beyond the maximum packet size, or larger than its memory limit.
+	ErrFragmentTooLarge = errors.New("fragment too large")
+)
+
+const maxIPPayload = 65535
+
+// FragmentIPv4 splits ip into fragments whose length is at most mtu. It returns
+// ip itself if it fits. The payload of ip is copied as is, so its checksum must
+// be computed first, for instance with Serialize. The fragments have their
+// Length and Checksum set, and carry the options of ip whose copied flag is
+// set, all of them for the first fragment.
+func FragmentIPv4(ip *IPv4, mtu int) ([]*IPv4, error) {
+	if int(ip.Len()) <= mtu {
+		return []*IPv4{ip}, nil
+	}
+	if ip.Flags&IPv4_Flag_DontFragment != 0 {
+		return nil, ErrDontFragment
+	}
+	payload, err := marshalPayload(ip.Data)
+	if err != nil {
+		return nil, err
+	}
+	options := ip.Options.Bytes()
+	copied := copiedIPv4Options(options)
+
+	var fragments []*IPv4
+	for offset := 0; offset < len(payload); {
+		opts := copied
+		if offset == 0 {
+			opts = options
+		}
+		ihl := 5 + (len(opts)+3)/4
+		size := (mtu - ihl*4) &^ 7
+		if size <= 0 {
+			return nil, fmt.Errorf("MTU %d too small to fragment IPv4 packet", mtu)
+		}
+		more := offset+size < len(payload)
+		if !more {
+			size = len(payload) - offset
+		}
+
+		f := *ip
+		f.IHL = uint8(ihl)
+		f.Options = *util.NewBuffer(append([]byte(nil), opts...))
+		f.Data = util.NewBuffer(payload[offset : offset+size])
+		f.FragmentOffset = ip.FragmentOffset + uint16(offset/8)
+		if more {
+			f.Flags |= IPv4_Flag_MoreFragments
+		}
+		f.Length = f.Len()
+		if err := prepare(&f, nil, SerializeOptions{ComputeChecksums: true}); err != nil {
+			return nil, err
+		}
+		fragments = append(fragments, &f)
+		offset += size
+	}
+	return fragments, nil
+}
+
+// copiedIPv4Options returns the options whose copied flag is set, padded to a
+// multiple of 4 bytes.
+func copiedIPv4Options(options []byte) []byte {
+	var copied []byte
+	for n := 0; n < len(options); {
+		switch options[n] {
+		case 0: // End of options list.
+			n = len(options)
+			continue
+		case 1: // No operation.
+			n += 1
+			continue
+		}
+		if n+1 >= len(options) || options[n+1] < 2 || n+int(options[n+1]) > len(options) {
+			break
+		}
+		length := int(options[n+1])
+		if options[n]&0x80 != 0 {
+			copied = append(copied, options[n:n+length]...)
+		}
+		n += length
+	}
+	for len(copied)%4 != 0 {
+		copied = append(copied, 0)
+	}
+	return copied
+}
+
+// FragmentIPv6 splits ip into fragments whose length is at most mtu, with a
+// Fragment header with the given identification. It returns ip itself if it
+// fits. The payload of ip is copied as is, so its checksum must be computed
+// first, for instance with Serialize. The Hop-by-Hop and Routing headers are
+// repeated in every fragment and the fragments have their Length set.
+func FragmentIPv6(ip *IPv6, mtu int, id uint32) ([]*IPv6, error) {
+	if int(ip.Len()) <= mtu {
+		return []*IPv6{ip}, nil
+	}
+	if ip.FragmentHeader != nil {
+		return nil, errors.New("IPv6 packet is already a fragment")
+	}
+	payload, err := marshalPayload(ip.Data)
+	if err != nil {
+		return nil, err
+	}
+	proto, extLen := ip.upperLayer()
+	size := (mtu - 40 - extLen - 8) &^ 7
+	if size <= 0 {
+		return nil, fmt.Errorf("MTU %d too small to fragment IPv6 packet", mtu)
+	}
+
+	var fragments []*IPv6
+	for offset := 0; offset < len(payload); offset += size {
+		end := offset + size
+		if end > len(payload) {
+			end = len(payload)
+		}
+		f := *ip
+		// The Fragment header follows the Hop-by-Hop and Routing headers.
+		switch {
+		case f.RoutingHeader != nil:
+			rh := *f.RoutingHeader
+			rh.NextHeader = Type_Fragment
+			f.RoutingHeader = &rh
+		case f.HbhHeader != nil:
+			hbh := *f.HbhHeader
+			hbh.NextHeader = Type_Fragment
+			f.HbhHeader = &hbh
+		default:
+			f.NextHeader = Type_Fragment
+		}
+		f.FragmentHeader = &FragmentHeader{
+			NextHeader:     proto,
+			FragmentOffset: uint16(offset / 8),
+			MoreFragments:  end < len(payload),
+			Identification: id,
+		}
+		f.Data = util.NewBuffer(payload[offset:end])
+		f.Length = f.Len() - 40
+		fragments = append(fragments, &f)
+	}
+	return fragments, nil
+}
+
+func marshalPayload(data util.Message) ([]byte, error) {
+	if data == nil {
+		return nil, nil
+	}
+	return data.MarshalBinary()
+}
+
+type fragmentKey struct {
+	ipv6     bool
+	src, dst [16]byte
+	proto    uint8
+	id       uint32
+}
+
+type fragment struct {
+	offset int
+	data   []byte
+}
+
+// fragmentList holds the fragments received for a packet.
+type fragmentList struct {
+	created   time.Time
+	fragments []fragment // Sorted by offset.
+	size      int        // Bytes of the fragments.
+	total     int        // Payload length, known once the last fragment is received.
+	// header is the *IPv4 or *IPv6 fragment at offset 0.
+	header util.Message
+}
+
+// insert adds a fragment, unless it duplicates another one.
+func (l *fragmentList) insert(f fragment) error {
+	i := sort.Search(len(l.fragments), func(i int) bool { return l.fragments[i].offset >= f.offset })
+	if i < len(l.fragments) && l.fragments[i].offset == f.offset && bytes.Equal(l.fragments[i].data, f.data) {
+		return nil
+	}
+	if i > 0 {
+		prev := l.fragments[i-1]
+		if prev.offset+len(prev.data) > f.offset {
+			return ErrFragmentOverlap
+		}
+	}
+	if i < len(l.fragments) && f.offset+len(f.data) > l.fragments[i].offset {
+		return ErrFragmentOverlap
+	}
+	l.fragments = append(l.fragments, fragment{})
+	copy(l.fragments[i+1:], l.fragments[i:])
+	l.fragments[i] = f
+	l.size += len(f.data)
+	return nil
+}
+
+// payload returns the reassembled payload, or nil if fragments are missing.
+func (l *fragmentList) payload() []byte {
+	if l.total < 0 || l.size != l.total || l.header == nil {
+		return nil
+	}
+	// Fragments do not overlap, so they cover the whole payload.
+	payload := make([]byte, 0, l.total)
+	for _, f := range l.fragments {
+		payload = append(payload, f.data...)
+	}
+	return payload
+}
+
+// Reassembler reassembles IPv4 and IPv6 fragments, for instance from PacketIn
+// messages. Fragments are grouped by source, destination, protocol and
+// identification. It is safe for concurrent use.
+type Reassembler struct {
+	// Timeout is the time after its first fragment that an incomplete
+	// packet is dropped.
+	Timeout time.Duration
+	// MaxBytes is the total size of the fragments kept. The oldest
+	// incomplete packets are dropped to stay below it.
+	MaxBytes int
+
+	mutex   sync.Mutex
+	packets map[fragmentKey]*fragmentList
+	bytes   int
+	now     func() time.Time
+}
+
+// NewReassembler returns a Reassembler with the given timeout and memory
+// limit.
+func NewReassembler(timeout time.Duration, maxBytes int) *Reassembler {
+	return &Reassembler{
+		Timeout:  timeout,
+		MaxBytes: maxBytes,
+		packets:  make(map[fragmentKey]*fragmentList),
+	}
+}
+
+// AddIPv4 adds a fragment. When ip completes a packet, it returns the
+// reassembled packet, a new IPv4 with its payload decoded which shares no
+// memory with the fragments. It returns nil while fragments are missing. A
+// packet which is not a fragment is returned as is, not copied, so the caller
+// can compare the result with ip to tell the two apart.
+func (r *Reassembler) AddIPv4(ip *IPv4) (*IPv4, error) {
+	if ip.Flags&IPv4_Flag_MoreFragments == 0 && ip.FragmentOffset == 0 {
+		return ip, nil
+	}
+	data, err := marshalPayload(ip.Data)
+	if err != nil {
+		return nil, err
+	}
+	key := fragmentKey{proto: ip.Protocol, id: uint32(ip.Id)}
+	copy(key.src[:], ip.NWSrc.To16())
+	copy(key.dst[:], ip.NWDst.To16())
+
+	payload, header, err := r.add(key, int(ip.FragmentOffset)*8, data, ip.Flags&IPv4_Flag_MoreFragments != 0, ip)
+	if payload == nil || err != nil {
+		return nil, err
+	}
+
+	packet := *header.(*IPv4)
+	packet.Flags &^= IPv4_Flag_MoreFragments
+	packet.Data = util.NewBuffer(payload)
+	packet.Length = packet.Len()
+	if err := prepare(&packet, nil, SerializeOptions{ComputeChecksums: true}); err != nil {
+		return nil, err
+	}
+	b, err := packet.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	result := new(IPv4)
+	if err := result.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AddIPv6 adds a fragment. When ip completes a packet, it returns the
+// reassembled packet, a new IPv6 with its payload decoded and without Fragment
+// header, which shares no memory with the fragments. It returns nil while
+// fragments are missing. A packet which is not a fragment is returned as is,
+// not copied, as in AddIPv4.
+func (r *Reassembler) AddIPv6(ip *IPv6) (*IPv6, error) {
+	fh := ip.FragmentHeader
+	if fh == nil || (!fh.MoreFragments && fh.FragmentOffset == 0) {
+		return ip, nil
+	}
+	data, err := marshalPayload(ip.Data)
+	if err != nil {
+		return nil, err
+	}
+	key := fragmentKey{ipv6: true, id: fh.Identification}
+	copy(key.src[:], ip.NWSrc.To16())
+	copy(key.dst[:], ip.NWDst.To16())
+
+	payload, header, err := r.add(key, int(fh.FragmentOffset)*8, data, fh.MoreFragments, ip)
+	if payload == nil || err != nil {
+		return nil, err
+	}
+
+	packet := *header.(*IPv6)
+	proto := packet.FragmentHeader.NextHeader
+	switch {
+	case packet.RoutingHeader != nil:
+		rh := *packet.RoutingHeader
+		rh.NextHeader = proto
+		packet.RoutingHeader = &rh
+	case packet.HbhHeader != nil:
+		hbh := *packet.HbhHeader
+		hbh.NextHeader = proto
+		packet.HbhHeader = &hbh
+	default:
+		packet.NextHeader = proto
+	}
+	packet.FragmentHeader = nil
+	packet.Data = util.NewBuffer(payload)
+	packet.Length = packet.Len() - 40
+	b, err := packet.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	result := new(IPv6)
+	if err := result.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Expire drops the incomplete packets older than Timeout and returns how many
+// were dropped. It is also called when adding fragments.
+func (r *Reassembler) Expire() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.expire()
+}
+
+// Pending returns the number of incomplete packets.
+func (r *Reassembler) Pending() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.packets)
+}
+
+func (r *Reassembler) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+func (r *Reassembler) expire() int {
+	dropped := 0
+	deadline := r.clock().Add(-r.Timeout)
+	for key, l := range r.packets {
+		if l.created.Before(deadline) {
+			r.drop(key)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// add adds a fragment to the packet with key. ip is the fragment, kept if it
+// is the first one. Once the packet is complete, add removes it and returns its
+// payload and first fragment.
+func (r *Reassembler) add(key fragmentKey, offset int, data []byte, more bool, ip util.Message) ([]byte, util.Message, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.expire()
+
+	if offset+len(data) > maxIPPayload || len(data) > r.MaxBytes {
+		r.drop(key)
+		return nil, nil, ErrFragmentTooLarge
+	}
+	// All fragments but the last one carry a multiple of 8 bytes.
+	if more && len(data)%8 != 0 {
+		r.drop(key)
+		return nil, nil, fmt.Errorf("fragment length %d is not a multiple of 8", len(data))
+	}
+	for r.bytes+len(data) > r.MaxBytes {
+		if !r.dropOldest() {
+			break
+		}
+	}
+
+	l, ok := r.packets[key]
+	if !ok {
+		l = &fragmentList{created: r.clock(), total: -1}
+		if r.packets == nil {
+			r.packets = make(map[fragmentKey]*fragmentList)
+		}
+		r.packets[key] = l
+	}
+	end := offset + len(data)
+	if !more {
+		if (l.total >= 0 && l.total != end) || (len(l.fragments) > 0 && lastEnd(l) > end) {
+			r.drop(key)
+			return nil, nil, ErrFragmentOverlap
+		}
+		l.total = end
+	} else if l.total >= 0 && end > l.total {
+		r.drop(key)
+		return nil, nil, ErrFragmentOverlap
+	}
+	size := l.size
+	if err := l.insert(fragment{offset: offset, data: append([]byte(nil), data...)}); err != nil {
+		r.drop(key)
+		return nil, nil, err
+	}
+	r.bytes += l.size - size
+	if offset == 0 {
+		l.header = ip
+	}
+
+	payload := l.payload()
+	if payload == nil {
+		return nil, nil, nil
+	}
+	r.drop(key)
+	return payload, l.header, nil
+}
+
+func lastEnd(l *fragmentList) int {
+	last := l.fragments[len(l.fragments)-1]
+	return last.offset + len(last.data)
+}
+
+// dropOldest drops the oldest incomplete packet. It returns false if there is
+// none.
+func (r *Reassembler) dropOldest() bool {
+	var oldest *fragmentList
+	var oldestKey fragmentKey
+	for key, l := range r.packets {
+		if oldest == nil || l.created.Before(oldest.created) {
+			oldest, oldestKey = l, key
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	r.drop(oldestKey)
+	return true
+}
+
+// drop removes the packet with key and frees its memory.
+func (r *Reassembler) drop(key fragmentKey) {
+	if l, ok := r.packets[key]; ok {
+		r.bytes -= l.size
+		delete(r.packets, key)
+	}
+}
@@ -0,0 +1,189 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func newLargeUDP(size int) *UDP {
+	udp := NewUDP()
+	udp.PortSrc = 53
+	udp.PortDst = 34567
+	udp.Data = make([]byte, size)
+	for i := range udp.Data {
+		udp.Data[i] = byte(i)
+	}
+	return udp
+}
+
+// redecode returns ip marshaled and decoded again, as received in a PacketIn.
+func redecode[T interface {
+	util.Message
+	*IPv4 | *IPv6
+}](t *testing.T, ip T, decoded T) T {
+	b, err := ip.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, decoded.UnmarshalBinary(b))
+	return decoded
+}
+
+func TestFragmentIPv4(t *testing.T) {
+	ip := newTestIPv4(Type_UDP)
+	ip.Id = 0x1234
+	// Security is copied in all fragments, record route is not.
+	ip.Options = *util.NewBuffer([]byte{0x82, 0x04, 0x00, 0x01, 0x07, 0x03, 0x04, 0x00})
+	ip.Data = newLargeUDP(3000)
+	data, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+
+	fragments, err := FragmentIPv4(ip, 1500)
+	require.NoError(t, err)
+	require.Len(t, fragments, 3)
+	assert.Equal(t, uint16(1500), fragments[0].Len())
+	assert.Equal(t, uint8(7), fragments[0].IHL)
+	assert.Equal(t, []byte{0x82, 0x04, 0x00, 0x01}, fragments[1].Options.Bytes())
+	assert.Equal(t, uint8(6), fragments[1].IHL)
+	assert.Equal(t, uint16(0), fragments[0].FragmentOffset)
+	assert.Equal(t, uint16(1472/8), fragments[1].FragmentOffset)
+	assert.NotZero(t, fragments[0].Flags&IPv4_Flag_MoreFragments)
+	assert.Zero(t, fragments[2].Flags&IPv4_Flag_MoreFragments)
+
+	r := NewReassembler(time.Minute, 1<<20)
+	// Out of order, with a duplicate.
+	for _, i := range []int{2, 0, 2} {
+		f := redecode(t, fragments[i], new(IPv4))
+		assert.IsType(t, new(util.Buffer), f.Data)
+		assert.NoError(t, VerifyChecksums(f))
+		packet, err := r.AddIPv4(f)
+		require.NoError(t, err)
+		assert.Nil(t, packet)
+	}
+	assert.Equal(t, 1, r.Pending())
+	packet, err := r.AddIPv4(redecode(t, fragments[1], new(IPv4)))
+	require.NoError(t, err)
+	require.NotNil(t, packet)
+	assert.Equal(t, 0, r.Pending())
+	require.IsType(t, new(UDP), packet.Data)
+	assert.NoError(t, VerifyChecksums(packet))
+	b, err := packet.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	// Not a fragment.
+	small := newTestIPv4(Type_UDP)
+	small.Data = newLargeUDP(10)
+	packet, err = r.AddIPv4(small)
+	require.NoError(t, err)
+	assert.Same(t, small, packet)
+
+	ip.Flags = IPv4_Flag_DontFragment
+	_, err = FragmentIPv4(ip, 1500)
+	assert.Equal(t, ErrDontFragment, err)
+}
+
+func TestFragmentIPv6(t *testing.T) {
+	ip := &IPv6{
+		Version:    6,
+		NextHeader: Type_HBH,
+		HopLimit:   64,
+		NWSrc:      net.ParseIP("2001:db8::1"),
+		NWDst:      net.ParseIP("2001:db8::2"),
+		HbhHeader: &HopByHopHeader{
+			NextHeader: Type_UDP,
+			Options:    []*Option{{Type: 1, Length: 4, Data: make([]byte, 4)}},
+		},
+		Data: newLargeUDP(3000),
+	}
+	data, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+
+	fragments, err := FragmentIPv6(ip, 1280, 42)
+	require.NoError(t, err)
+	require.Len(t, fragments, 3)
+	for _, f := range fragments {
+		assert.LessOrEqual(t, f.Len(), uint16(1280))
+		assert.Equal(t, uint8(Type_Fragment), f.HbhHeader.NextHeader)
+		assert.Equal(t, uint8(Type_UDP), f.FragmentHeader.NextHeader)
+	}
+	assert.Equal(t, uint8(Type_UDP), ip.HbhHeader.NextHeader)
+
+	r := NewReassembler(time.Minute, 1<<20)
+	var packet *IPv6
+	for _, f := range fragments {
+		packet, err = r.AddIPv6(redecode(t, f, new(IPv6)))
+		require.NoError(t, err)
+	}
+	require.NotNil(t, packet)
+	assert.Nil(t, packet.FragmentHeader)
+	require.IsType(t, new(UDP), packet.Data)
+	assert.NoError(t, VerifyChecksums(packet))
+	b, err := packet.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+}
+
+func TestReassemblerLimits(t *testing.T) {
+	ip := newTestIPv4(Type_UDP)
+	ip.Data = newLargeUDP(3000)
+	_, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+	fragments, err := FragmentIPv4(ip, 1500)
+	require.NoError(t, err)
+
+	// Overlapping fragments drop the packet.
+	r := NewReassembler(time.Minute, 1<<20)
+	_, err = r.AddIPv4(fragments[0])
+	require.NoError(t, err)
+	overlap := *fragments[1]
+	overlap.FragmentOffset -= 1
+	_, err = r.AddIPv4(&overlap)
+	assert.Equal(t, ErrFragmentOverlap, err)
+	assert.Equal(t, 0, r.Pending())
+
+	// Incomplete packets expire.
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	_, err = r.AddIPv4(fragments[0])
+	require.NoError(t, err)
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 0, r.Expire())
+	now = now.Add(time.Minute)
+	assert.Equal(t, 1, r.Expire())
+	assert.Equal(t, 0, r.Pending())
+
+	// The oldest packets are dropped to stay below the memory limit.
+	r = NewReassembler(time.Minute, 2000)
+	r.now = func() time.Time { return now }
+	_, err = r.AddIPv4(fragments[0])
+	require.NoError(t, err)
+	other := *fragments[1]
+	other.Id++
+	now = now.Add(time.Second)
+	_, err = r.AddIPv4(&other)
+	require.NoError(t, err)
+	assert.Equal(t, 1, r.Pending())
+	_, err = r.AddIPv4(fragments[1])
+	require.NoError(t, err)
+	_, err = r.AddIPv4(fragments[2])
+	require.NoError(t, err)
+	packet, err := r.AddIPv4(fragments[0])
+	require.NoError(t, err)
+	assert.Nil(t, packet, "fragments were dropped")
+
+	r = NewReassembler(time.Minute, 1000)
+	_, err = r.AddIPv4(fragments[0])
+	assert.Equal(t, ErrFragmentTooLarge, err)
+
+	// Adding a fragment stops dropping packets once there are none left.
+	r = NewReassembler(time.Minute, 2000)
+	r.bytes = 2000
+	_, err = r.AddIPv4(fragments[0])
+	require.NoError(t, err)
+	assert.Equal(t, 1, r.Pending())
+}
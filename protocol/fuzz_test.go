@@ -0,0 +1,386 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// fuzzLayer is a layer to fuzz the decoder of, with valid messages to start
+// from.
+type fuzzLayer struct {
+	// newMsg returns the message to decode data into, as chosen by the
+	// layer carrying it.
+	newMsg func(data []byte) util.Message
+	seeds  func() []util.Message
+}
+
+func newIPv6Packet(proto uint8, payload util.Message) *IPv6 {
+	return &IPv6{
+		Version:    6,
+		NextHeader: proto,
+		HopLimit:   64,
+		NWSrc:      net.ParseIP("fe80::1"),
+		NWDst:      net.ParseIP("ff02::1"),
+		Data:       payload,
+	}
+}
+
+func fuzzICMPv6Seeds() []util.Message {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	group := net.ParseIP("ff02::1:3")
+	return []util.Message{
+		NewICMPv6EchoRequest(1, 2),
+		NewNeighborSolicitation(net.ParseIP("fe80::2"), mac),
+		NewNeighborAdvertisement(net.ParseIP("fe80::2"), mac, true, true, false),
+		NewRouterSolicitation(mac),
+		NewRouterAdvertisement(64, 1800, mac),
+		NewRedirect(net.ParseIP("fe80::3"), net.ParseIP("2001:db8::1")),
+		NewMLDReport(group),
+		NewMLDv2Query(100, group, 125, []net.IP{net.ParseIP("2001:db8::1")}),
+		NewMLDv2Report([]MLDv2Record{*NewMLDv2Record(IGMPToIn, group, []net.IP{net.ParseIP("2001:db8::1")})}),
+	}
+}
+
+func fuzzIGMPSeeds() []util.Message {
+	group := net.ParseIP("239.1.1.1").To4()
+	return []util.Message{
+		NewIGMPv2Report(group),
+		NewIGMPv3Query(group, 100, 125, []net.IP{net.ParseIP("10.0.0.1").To4()}),
+		NewIGMPv3Report([]IGMPv3GroupRecord{{Type: IGMPIsEx, MulticastAddress: group}}),
+	}
+}
+
+func fuzzIPv4Seeds() []util.Message {
+	var seeds []util.Message
+	for _, payload := range []util.Message{
+		newLargeUDP(16),
+		newTestTCP(),
+		&ICMP{Type: ICMP_Type_EchoRequest, Payload: &ICMPEcho{Identifier: 1, SeqNum: 1}},
+		NewGRE(IPv4_MSG),
+	} {
+		var proto uint8
+		switch payload.(type) {
+		case *UDP:
+			proto = Type_UDP
+		case *TCP:
+			proto = Type_TCP
+		case *ICMP:
+			proto = Type_ICMP
+		case *GRE:
+			proto = Type_GRE
+		}
+		ip := newTestIPv4(proto)
+		ip.Data = payload
+		seeds = append(seeds, ip)
+	}
+	for _, igmp := range fuzzIGMPSeeds() {
+		ip := newTestIPv4(Type_IGMP)
+		ip.Data = igmp
+		seeds = append(seeds, ip)
+	}
+	return seeds
+}
+
+func fuzzIPv6Seeds() []util.Message {
+	var seeds []util.Message
+	for _, icmp := range fuzzICMPv6Seeds() {
+		seeds = append(seeds, newIPv6Packet(Type_IPv6ICMP, icmp))
+	}
+	dhcp := NewUDP()
+	dhcp.PortSrc = UDPPort_DHCPv6Server
+	dhcp.PortDst = UDPPort_DHCPv6Client
+	dhcp.Payload = newTestDHCPv6Reply()
+	ip := newIPv6Packet(Type_HBH, dhcp)
+	ip.HbhHeader = &HopByHopHeader{NextHeader: Type_Routing, Options: []*Option{{Type: 1, Length: 4, Data: make([]byte, 4)}}}
+	ip.RoutingHeader = &RoutingHeader{NextHeader: Type_Fragment, HEL: 0, Data: util.NewBuffer(make([]byte, 4))}
+	ip.FragmentHeader = &FragmentHeader{NextHeader: Type_UDP}
+	seeds = append(seeds, ip, newIPv6Packet(Type_TCP, newTestTCP()))
+	return seeds
+}
+
+func fuzzEthernetSeeds() []util.Message {
+	eth := func(ethertype uint16, payload util.Message) *Ethernet {
+		frame := newTestInnerFrame()
+		frame.Ethertype = ethertype
+		frame.Data = payload
+		return frame
+	}
+	tagged := newTestInnerFrame()
+	tagged.VLANID = VLAN{TPID: 0x88a8, VID: 100}
+	tagged.InnerVLANs = []VLAN{{TPID: 0x8100, VID: 200}}
+	arp, _ := NewARP(Type_Request)
+	mpls := NewMPLS()
+	mpls.Labels = []MPLSLabel{{Label: 100, TTL: 64}, {Label: 200, BoS: true, TTL: 64}}
+	mpls.Data = newTestIPv4(Type_UDP)
+	mpls.Data.(*IPv4).Data = newLargeUDP(4)
+	nsh := NewNSH(100, 255)
+	nsh.Data = newTestInnerFrame()
+	seeds := []util.Message{
+		newTestInnerFrame(),
+		tagged,
+		eth(ARP_MSG, arp),
+		eth(MPLS_MSG, mpls),
+		eth(NSH_MSG, nsh),
+		eth(LLDP_MSG, NewLLDP(4, []byte{1, 2, 3, 4, 5, 6}, 5, []byte("eth0"), 120)),
+	}
+	for _, ip := range fuzzIPv6Seeds() {
+		seeds = append(seeds, eth(IPv6_MSG, ip))
+	}
+	for _, payload := range fuzzTunnelSeeds() {
+		udp := NewUDP()
+		udp.PortSrc = 50000
+		switch payload.(type) {
+		case *VXLAN:
+			udp.PortDst = UDPPort_VXLAN
+		case *Geneve:
+			udp.PortDst = UDPPort_Geneve
+		default:
+			continue
+		}
+		udp.Payload = payload
+		seeds = append(seeds, newTestOuterFrame(Type_UDP, udp))
+	}
+	return seeds
+}
+
+func fuzzTunnelSeeds() []util.Message {
+	vxlan := NewVXLAN(5000)
+	vxlan.SetGBP(100, VXLAN_GBP_PolicyApplied)
+	vxlan.Data = newTestInnerFrame()
+	geneve := NewGeneve(5000)
+	geneve.Options = []*GeneveOption{{Class: 0x0104, Type: 0x80, Data: []byte{0, 0, 0, 1}}}
+	geneve.Data = newTestInnerFrame()
+	gre := NewGRE(TEB_MSG)
+	gre.ChecksumPresent = true
+	gre.KeyPresent = true
+	gre.SeqPresent = true
+	gre.Data = newTestInnerFrame()
+	stt := NewSTT(1)
+	stt.Data = newTestInnerFrame()
+	return []util.Message{vxlan, geneve, gre, stt}
+}
+
+func fuzzDHCPSeeds() []util.Message {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	discover, _ := NewDHCPDiscover(1, mac)
+	ack, _ := NewDHCPAck(1, mac)
+	ack.SetSubnetMask(net.CIDRMask(24, 32))
+	ack.SetRouters(net.ParseIP("10.0.0.1"))
+	ack.SetRelayAgentInfo(DHCPRelayAgentSubOption{Code: DHCP_RELAY_CIRCUIT_ID, Data: []byte("eth0")})
+	_, dst, _ := net.ParseCIDR("192.168.0.0/16")
+	ack.SetClasslessStaticRoutes(DHCPRoute{Destination: *dst, Router: net.ParseIP("10.0.0.1")})
+	return []util.Message{discover, ack}
+}
+
+var fuzzLayers = map[string]fuzzLayer{
+	"Ethernet": {
+		newMsg: func([]byte) util.Message { return new(Ethernet) },
+		seeds:  fuzzEthernetSeeds,
+	},
+	"ARP": {
+		newMsg: func([]byte) util.Message { return new(ARP) },
+		seeds: func() []util.Message {
+			arp, _ := NewARP(Type_Reply)
+			return []util.Message{arp}
+		},
+	},
+	"MPLS": {
+		newMsg: func([]byte) util.Message { return new(MPLS) },
+		seeds: func() []util.Message {
+			return []util.Message{fuzzEthernetSeeds()[3].(*Ethernet).Data}
+		},
+	},
+	"NSH": {
+		newMsg: func([]byte) util.Message { return new(NSH) },
+		seeds: func() []util.Message {
+			nsh := NewNSH(100, 255)
+			nsh.Data = newTestInnerFrame()
+			return []util.Message{nsh}
+		},
+	},
+	"LLDP": {
+		newMsg: func([]byte) util.Message { return new(LLDP) },
+		seeds: func() []util.Message {
+			return []util.Message{fuzzEthernetSeeds()[5].(*Ethernet).Data}
+		},
+	},
+	"IPv4": {
+		newMsg: func([]byte) util.Message { return new(IPv4) },
+		seeds:  fuzzIPv4Seeds,
+	},
+	"IPv6": {
+		newMsg: func([]byte) util.Message { return new(IPv6) },
+		seeds:  fuzzIPv6Seeds,
+	},
+	"ICMP": {
+		newMsg: func([]byte) util.Message { return NewICMP() },
+		seeds: func() []util.Message {
+			original := newTestIPv4(Type_UDP)
+			original.Data = newLargeUDP(16)
+			unreachable, _ := NewICMPDestinationUnreachable(net.ParseIP("10.0.0.254"), ICMP_Code_PortUnreachable, 0, original)
+			return []util.Message{fuzzIPv4Seeds()[2].(*IPv4).Data, unreachable.Data}
+		},
+	},
+	"ICMPv6": {
+		newMsg: func(data []byte) util.Message {
+			if len(data) == 0 {
+				return new(ICMPv6Header)
+			}
+			return NewICMPv6ByHeaderType(data[0])
+		},
+		seeds: fuzzICMPv6Seeds,
+	},
+	"IGMP": {
+		newMsg: func(data []byte) util.Message { return NewIGMPByHeader(data) },
+		seeds:  fuzzIGMPSeeds,
+	},
+	"TCP": {
+		newMsg: func([]byte) util.Message { return NewTCP() },
+		seeds: func() []util.Message {
+			tcp := newTestTCP()
+			tcp.Options = []util.Message{
+				&TCPOptionMSS{MSS: 1460},
+				&TCPOptionSACKPermitted{},
+				&TCPOptionTimestamps{Value: 1, EchoReply: 2},
+				&TCPOptionNOP{},
+				&TCPOptionWindowScale{Shift: 7},
+			}
+			return []util.Message{tcp}
+		},
+	},
+	"UDP": {
+		newMsg: func([]byte) util.Message { return NewUDP() },
+		seeds: func() []util.Message {
+			dns := NewUDP()
+			dns.PortSrc = 53
+			dns.PortDst = 5353
+			dns.Payload = newTestDNSResponse()
+			dhcp := NewUDP()
+			dhcp.PortSrc = UDPPort_DHCPServer
+			dhcp.PortDst = UDPPort_DHCPClient
+			dhcp.Payload = fuzzDHCPSeeds()[1]
+			return []util.Message{newLargeUDP(8), dns, dhcp}
+		},
+	},
+	"VXLAN": {
+		newMsg: func([]byte) util.Message { return new(VXLAN) },
+		seeds:  func() []util.Message { return fuzzTunnelSeeds()[0:1] },
+	},
+	"Geneve": {
+		newMsg: func([]byte) util.Message { return new(Geneve) },
+		seeds:  func() []util.Message { return fuzzTunnelSeeds()[1:2] },
+	},
+	"GRE": {
+		newMsg: func([]byte) util.Message { return new(GRE) },
+		seeds:  func() []util.Message { return fuzzTunnelSeeds()[2:3] },
+	},
+	"STT": {
+		newMsg: func([]byte) util.Message { return new(STT) },
+		seeds:  func() []util.Message { return fuzzTunnelSeeds()[3:4] },
+	},
+	"DHCP": {
+		newMsg: func([]byte) util.Message { return new(DHCP) },
+		seeds:  fuzzDHCPSeeds,
+	},
+	"DHCPv6": {
+		newMsg: func(data []byte) util.Message {
+			if len(data) == 0 {
+				return new(DHCPv6)
+			}
+			return newDHCPv6ByMessageType(data[0])
+		},
+		seeds: func() []util.Message {
+			relay := NewDHCPv6Relay(DHCPv6_MSG_RELAY_FORW, 0, net.ParseIP("2001:db8::1"), net.ParseIP("fe80::1"))
+			relay.AddOption(&DHCPv6RelayMessage{Message: newTestDHCPv6Reply()})
+			return []util.Message{newTestDHCPv6Reply(), relay}
+		},
+	},
+	"DNS": {
+		newMsg: func([]byte) util.Message { return new(DNS) },
+		seeds: func() []util.Message {
+			return []util.Message{NewDNSQuery(1, "example.com", DNSType_A), newTestDNSResponse()}
+		},
+	},
+	"DNSOverTCP": {
+		newMsg: func([]byte) util.Message { return new(DNSOverTCP) },
+		seeds: func() []util.Message {
+			return []util.Message{&DNSOverTCP{DNS: *newTestDNSResponse()}}
+		},
+	},
+}
+
+// fuzzSeedData returns the wire format of the seeds of the layer.
+func fuzzSeedData(t testing.TB, layer fuzzLayer) [][]byte {
+	var seeds [][]byte
+	for _, msg := range layer.seeds() {
+		data, err := Serialize(msg, SerializeOptions{FixLengths: true})
+		require.NoError(t, err)
+		seeds = append(seeds, data)
+	}
+	return seeds
+}
+
+// fuzzDecode decodes data, then encodes it again if it is valid. Neither
+// may panic.
+func fuzzDecode(layer fuzzLayer, data []byte) {
+	msg := layer.newMsg(data)
+	if err := msg.UnmarshalBinary(data); err != nil {
+		return
+	}
+	msg.Len()
+	_, _ = msg.MarshalBinary()
+}
+
+func fuzzDecoder(f *testing.F, name string) {
+	layer := fuzzLayers[name]
+	for _, data := range fuzzSeedData(f, layer) {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzDecode(layer, data)
+	})
+}
+
+func FuzzEthernet(f *testing.F)   { fuzzDecoder(f, "Ethernet") }
+func FuzzARP(f *testing.F)        { fuzzDecoder(f, "ARP") }
+func FuzzMPLS(f *testing.F)       { fuzzDecoder(f, "MPLS") }
+func FuzzNSH(f *testing.F)        { fuzzDecoder(f, "NSH") }
+func FuzzLLDP(f *testing.F)       { fuzzDecoder(f, "LLDP") }
+func FuzzIPv4(f *testing.F)       { fuzzDecoder(f, "IPv4") }
+func FuzzIPv6(f *testing.F)       { fuzzDecoder(f, "IPv6") }
+func FuzzICMP(f *testing.F)       { fuzzDecoder(f, "ICMP") }
+func FuzzICMPv6(f *testing.F)     { fuzzDecoder(f, "ICMPv6") }
+func FuzzIGMP(f *testing.F)       { fuzzDecoder(f, "IGMP") }
+func FuzzTCP(f *testing.F)        { fuzzDecoder(f, "TCP") }
+func FuzzUDP(f *testing.F)        { fuzzDecoder(f, "UDP") }
+func FuzzVXLAN(f *testing.F)      { fuzzDecoder(f, "VXLAN") }
+func FuzzGeneve(f *testing.F)     { fuzzDecoder(f, "Geneve") }
+func FuzzGRE(f *testing.F)        { fuzzDecoder(f, "GRE") }
+func FuzzSTT(f *testing.F)        { fuzzDecoder(f, "STT") }
+func FuzzDHCP(f *testing.F)       { fuzzDecoder(f, "DHCP") }
+func FuzzDHCPv6(f *testing.F)     { fuzzDecoder(f, "DHCPv6") }
+func FuzzDNS(f *testing.F)        { fuzzDecoder(f, "DNS") }
+func FuzzDNSOverTCP(f *testing.F) { fuzzDecoder(f, "DNSOverTCP") }
+
+// TestTruncatedMessages decodes every prefix of the seeds of the fuzz
+// targets, as well as the seeds with each byte set to 0xff.
+func TestTruncatedMessages(t *testing.T) {
+	for name, layer := range fuzzLayers {
+		t.Run(name, func(t *testing.T) {
+			for _, data := range fuzzSeedData(t, layer) {
+				for n := 0; n <= len(data); n++ {
+					fuzzDecode(layer, data[:n])
+				}
+				for i := range data {
+					corrupted := append([]byte(nil), data...)
+					corrupted[i] = 0xff
+					fuzzDecode(layer, corrupted)
+				}
+			}
+		})
+	}
+}
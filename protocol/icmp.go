@@ -3,6 +3,43 @@ package protocol
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"net"
+
+	"antrea.io/libOpenflow/util"
+)
+
+const (
+	ICMP_Type_EchoReply              = 0
+	ICMP_Type_DestinationUnreachable = 3
+	ICMP_Type_SourceQuench           = 4
+	ICMP_Type_Redirect               = 5
+	ICMP_Type_EchoRequest            = 8
+	ICMP_Type_TimeExceeded           = 11
+	ICMP_Type_ParameterProblem       = 12
+
+	// Code for ICMP Destination Unreachable
+	ICMP_Code_NetUnreachable      = 0
+	ICMP_Code_HostUnreachable     = 1
+	ICMP_Code_ProtocolUnreachable = 2
+	ICMP_Code_PortUnreachable     = 3
+	ICMP_Code_FragmentationNeeded = 4
+	ICMP_Code_SourceRouteFailed   = 5
+	ICMP_Code_AdminProhibited     = 13
+
+	// Code for ICMP Redirect
+	ICMP_Code_RedirectNet     = 0
+	ICMP_Code_RedirectHost    = 1
+	ICMP_Code_RedirectTOSNet  = 2
+	ICMP_Code_RedirectTOSHost = 3
+
+	// Code for ICMP Time Exceeded
+	ICMP_Code_TTLExceeded        = 0
+	ICMP_Code_ReassemblyExceeded = 1
+
+	// icmpQuotePayloadLen is the number of payload bytes of the original
+	// datagram quoted in the errors built here.
+	icmpQuotePayloadLen = 8
 )
 
 type ICMP struct {
@@ -10,6 +47,11 @@ type ICMP struct {
 	Code     uint8
 	Checksum uint16
 	Data     []byte
+	// Payload is Data decoded according to Type, such as an *ICMPEcho, or nil
+	// for other types. When set, it is the only source of the message body:
+	// it is marshaled instead of Data, which keeps the received bytes, so
+	// clear it to send a modified Data.
+	Payload util.Message
 }
 
 func NewICMP() *ICMP {
@@ -19,6 +61,9 @@ func NewICMP() *ICMP {
 }
 
 func (i *ICMP) Len() (n uint16) {
+	if i.Payload != nil {
+		return 4 + i.Payload.Len()
+	}
 	return uint16(4 + len(i.Data))
 }
 
@@ -27,6 +72,17 @@ func (i *ICMP) MarshalBinary() (data []byte, err error) {
 	data[0] = i.Type
 	data[1] = i.Code
 	binary.BigEndian.PutUint16(data[2:4], i.Checksum)
+	if i.Payload != nil {
+		var bytes []byte
+		if bytes, err = i.Payload.MarshalBinary(); err != nil {
+			return nil, err
+		}
+		if len(bytes) != len(data)-4 {
+			return nil, fmt.Errorf("ICMP payload of %d bytes, expecting %d", len(bytes), len(data)-4)
+		}
+		copy(data[4:], bytes)
+		return
+	}
 	copy(data[4:], i.Data)
 	return
 }
@@ -41,5 +97,323 @@ func (i *ICMP) UnmarshalBinary(data []byte) error {
 
 	i.Data = make([]byte, len(data)-4)
 	copy(i.Data, data[4:])
+
+	i.Payload = newICMPByType(i.Type)
+	if i.Payload != nil && i.Payload.UnmarshalBinary(i.Data) != nil {
+		i.Payload = nil
+	}
+	return nil
+}
+
+// newICMPByType returns the layer to decode the body of an ICMP message of the
+// given type, or nil if it is not known.
+func newICMPByType(icmpType uint8) util.Message {
+	switch icmpType {
+	case ICMP_Type_EchoReply, ICMP_Type_EchoRequest:
+		return new(ICMPEcho)
+	case ICMP_Type_DestinationUnreachable:
+		return new(ICMPDestinationUnreachable)
+	case ICMP_Type_Redirect:
+		return new(ICMPRedirect)
+	case ICMP_Type_TimeExceeded:
+		return new(ICMPTimeExceeded)
+	case ICMP_Type_ParameterProblem:
+		return new(ICMPParameterProblem)
+	}
+	return nil
+}
+
+// isICMPError returns whether ICMP messages of the given type report an error
+// about another datagram.
+func isICMPError(icmpType uint8) bool {
+	switch icmpType {
+	case ICMP_Type_DestinationUnreachable, ICMP_Type_SourceQuench, ICMP_Type_Redirect,
+		ICMP_Type_TimeExceeded, ICMP_Type_ParameterProblem:
+		return true
+	}
+	return false
+}
+
+// ICMPEcho is the body of an echo request or reply.
+type ICMPEcho struct {
+	Identifier uint16
+	SeqNum     uint16
+	Data       []byte
+}
+
+func (e *ICMPEcho) Len() (n uint16) {
+	return uint16(4 + len(e.Data))
+}
+
+func (e *ICMPEcho) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, e.Len())
+	binary.BigEndian.PutUint16(data[0:2], e.Identifier)
+	binary.BigEndian.PutUint16(data[2:4], e.SeqNum)
+	copy(data[4:], e.Data)
+	return
+}
+
+func (e *ICMPEcho) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full ICMP echo message.")
+	}
+	e.Identifier = binary.BigEndian.Uint16(data[0:2])
+	e.SeqNum = binary.BigEndian.Uint16(data[2:4])
+	e.Data = append([]byte(nil), data[4:]...)
 	return nil
 }
+
+// ICMPDestinationUnreachable is the body of a destination unreachable error.
+// NextHopMTU is set for ICMP_Code_FragmentationNeeded.
+type ICMPDestinationUnreachable struct {
+	Unused     uint16
+	NextHopMTU uint16
+	// Original is the start of the datagram which could not be delivered.
+	Original *IPv4
+}
+
+func (u *ICMPDestinationUnreachable) Len() (n uint16) {
+	return 4 + icmpQuoteLen(u.Original)
+}
+
+func (u *ICMPDestinationUnreachable) MarshalBinary() (data []byte, err error) {
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint16(header[0:2], u.Unused)
+	binary.BigEndian.PutUint16(header[2:4], u.NextHopMTU)
+	return appendICMPQuote(header, u.Original)
+}
+
+func (u *ICMPDestinationUnreachable) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full ICMP destination unreachable message.")
+	}
+	u.Unused = binary.BigEndian.Uint16(data[0:2])
+	u.NextHopMTU = binary.BigEndian.Uint16(data[2:4])
+	u.Original, err = unmarshalICMPQuote(data[4:])
+	return
+}
+
+// ICMPTimeExceeded is the body of a time exceeded error.
+type ICMPTimeExceeded struct {
+	Unused uint32
+	// Original is the start of the datagram which was discarded.
+	Original *IPv4
+}
+
+func (t *ICMPTimeExceeded) Len() (n uint16) {
+	return 4 + icmpQuoteLen(t.Original)
+}
+
+func (t *ICMPTimeExceeded) MarshalBinary() (data []byte, err error) {
+	return appendICMPQuote(binary.BigEndian.AppendUint32(nil, t.Unused), t.Original)
+}
+
+func (t *ICMPTimeExceeded) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full ICMP time exceeded message.")
+	}
+	t.Unused = binary.BigEndian.Uint32(data[0:4])
+	t.Original, err = unmarshalICMPQuote(data[4:])
+	return
+}
+
+// ICMPRedirect is the body of a redirect message.
+type ICMPRedirect struct {
+	// Gateway is the router to send the datagrams to instead.
+	Gateway net.IP
+	// Original is the start of the datagram which was redirected.
+	Original *IPv4
+}
+
+func (r *ICMPRedirect) Len() (n uint16) {
+	return 4 + icmpQuoteLen(r.Original)
+}
+
+func (r *ICMPRedirect) MarshalBinary() (data []byte, err error) {
+	gateway := make([]byte, 4)
+	copy(gateway, r.Gateway.To4())
+	return appendICMPQuote(gateway, r.Original)
+}
+
+func (r *ICMPRedirect) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full ICMP redirect message.")
+	}
+	r.Gateway = net.IP(append([]byte(nil), data[0:4]...))
+	r.Original, err = unmarshalICMPQuote(data[4:])
+	return
+}
+
+// ICMPParameterProblem is the body of a parameter problem error.
+type ICMPParameterProblem struct {
+	// Pointer is the offset of the faulty byte in Original.
+	Pointer uint8
+	Unused  uint32 // 24-bits
+	// Original is the start of the datagram which was discarded.
+	Original *IPv4
+}
+
+func (p *ICMPParameterProblem) Len() (n uint16) {
+	return 4 + icmpQuoteLen(p.Original)
+}
+
+func (p *ICMPParameterProblem) MarshalBinary() (data []byte, err error) {
+	return appendICMPQuote(binary.BigEndian.AppendUint32(nil, uint32(p.Pointer)<<24|p.Unused&0xffffff), p.Original)
+}
+
+func (p *ICMPParameterProblem) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full ICMP parameter problem message.")
+	}
+	word := binary.BigEndian.Uint32(data[0:4])
+	p.Pointer = uint8(word >> 24)
+	p.Unused = word & 0xffffff
+	p.Original, err = unmarshalICMPQuote(data[4:])
+	return
+}
+
+func icmpQuoteLen(original *IPv4) uint16 {
+	if original == nil {
+		return 0
+	}
+	return original.Len()
+}
+
+func appendICMPQuote(header []byte, original *IPv4) ([]byte, error) {
+	if original == nil {
+		return header, nil
+	}
+	quote, err := original.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(header, quote...), nil
+}
+
+// unmarshalICMPQuote decodes the original datagram quoted by an ICMP error:
+// its IPv4 header, and the start of its payload, kept in a *util.Buffer since
+// it is truncated.
+func unmarshalICMPQuote(data []byte) (*IPv4, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	original := new(IPv4)
+	n, err := original.unmarshalHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	original.Data = util.NewBuffer(append([]byte(nil), data[n:]...))
+	return original, nil
+}
+
+// newICMPQuote returns the start of original to quote in an ICMP error about
+// it, or an error if RFC 1122 forbids sending one: for ICMP errors, fragments
+// other than the first and datagrams not sent by a single host to a single
+// host.
+func newICMPQuote(original *IPv4) (*IPv4, error) {
+	data, err := original.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	hdrLen := int(original.IHL) * 4
+	if original.Protocol == Type_ICMP && len(data) > hdrLen && isICMPError(data[hdrLen]) {
+		return nil, errors.New("no ICMP error is sent about an ICMP error")
+	}
+	if original.FragmentOffset != 0 {
+		return nil, errors.New("no ICMP error is sent about a fragment other than the first")
+	}
+	for _, ip := range []net.IP{original.NWSrc, original.NWDst} {
+		if ip.To4() == nil || ip.IsUnspecified() || ip.IsMulticast() || ip.Equal(net.IPv4bcast) {
+			return nil, fmt.Errorf("no ICMP error is sent about a datagram between %v and %v", original.NWSrc, original.NWDst)
+		}
+	}
+	if len(data) > hdrLen+icmpQuotePayloadLen {
+		data = data[:hdrLen+icmpQuotePayloadLen]
+	}
+	return unmarshalICMPQuote(data)
+}
+
+// newICMPError returns the IPv4 datagram sent from src carrying an ICMP error
+// about original.
+func newICMPError(src net.IP, icmpType, code uint8, original *IPv4, body func(quote *IPv4) util.Message) (*IPv4, error) {
+	quote, err := newICMPQuote(original)
+	if err != nil {
+		return nil, err
+	}
+	icmp := NewICMP()
+	icmp.Type = icmpType
+	icmp.Code = code
+	icmp.Payload = body(quote)
+	return newICMPDatagram(src, original.NWSrc, icmp), nil
+}
+
+func newICMPDatagram(src, dst net.IP, icmp *ICMP) *IPv4 {
+	ip := NewIPv4()
+	ip.Version = 4
+	ip.IHL = 5
+	ip.TTL = 64
+	ip.Protocol = Type_ICMP
+	ip.NWSrc = append(net.IP(nil), src.To4()...)
+	ip.NWDst = append(net.IP(nil), dst.To4()...)
+	ip.Data = icmp
+	return ip
+}
+
+// The constructors below return datagrams whose lengths and checksums are
+// still to be set, for instance by Serialize with FixLengths and
+// ComputeChecksums.
+
+// NewICMPEchoReply returns the reply to the echo request carried by request.
+func NewICMPEchoReply(request *IPv4) (*IPv4, error) {
+	icmp, ok := request.Data.(*ICMP)
+	if !ok || icmp.Type != ICMP_Type_EchoRequest {
+		return nil, errors.New("not an ICMP echo request")
+	}
+	echo, ok := icmp.Payload.(*ICMPEcho)
+	if !ok {
+		echo = new(ICMPEcho)
+		if err := echo.UnmarshalBinary(icmp.Data); err != nil {
+			return nil, err
+		}
+	}
+	reply := NewICMP()
+	reply.Type = ICMP_Type_EchoReply
+	reply.Payload = &ICMPEcho{Identifier: echo.Identifier, SeqNum: echo.SeqNum, Data: append([]byte(nil), echo.Data...)}
+	return newICMPDatagram(request.NWDst, request.NWSrc, reply), nil
+}
+
+// NewICMPDestinationUnreachable returns the destination unreachable error sent
+// from src about original. nextHopMTU is the MTU of the link for
+// ICMP_Code_FragmentationNeeded, and zero for other codes.
+func NewICMPDestinationUnreachable(src net.IP, code uint8, nextHopMTU uint16, original *IPv4) (*IPv4, error) {
+	return newICMPError(src, ICMP_Type_DestinationUnreachable, code, original, func(quote *IPv4) util.Message {
+		return &ICMPDestinationUnreachable{NextHopMTU: nextHopMTU, Original: quote}
+	})
+}
+
+// NewICMPTimeExceeded returns the time exceeded error sent from src about
+// original, such as ICMP_Code_TTLExceeded when its TTL expires.
+func NewICMPTimeExceeded(src net.IP, code uint8, original *IPv4) (*IPv4, error) {
+	return newICMPError(src, ICMP_Type_TimeExceeded, code, original, func(quote *IPv4) util.Message {
+		return &ICMPTimeExceeded{Original: quote}
+	})
+}
+
+// NewICMPRedirect returns the redirect sent from src telling the sender of
+// original to use gateway instead.
+func NewICMPRedirect(src net.IP, code uint8, gateway net.IP, original *IPv4) (*IPv4, error) {
+	if gateway.To4() == nil {
+		return nil, fmt.Errorf("invalid ICMP redirect gateway %v", gateway)
+	}
+	return newICMPError(src, ICMP_Type_Redirect, code, original, func(quote *IPv4) util.Message {
+		return &ICMPRedirect{Gateway: append(net.IP(nil), gateway.To4()...), Original: quote}
+	})
+}
+
+// NewICMPParameterProblem returns the parameter problem error sent from src
+// about the byte at offset pointer in the header of original.
+func NewICMPParameterProblem(src net.IP, pointer uint8, original *IPv4) (*IPv4, error) {
+	return newICMPError(src, ICMP_Type_ParameterProblem, 0, original, func(quote *IPv4) util.Message {
+		return &ICMPParameterProblem{Pointer: pointer, Original: quote}
+	})
+}
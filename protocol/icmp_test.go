@@ -0,0 +1,182 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// newTestOffendingUDP returns a UDP datagram from 10.0.0.1 to 10.0.0.2 as
+// received, with its lengths and checksums set.
+func newTestOffendingUDP(t *testing.T) (*IPv4, []byte) {
+	ip := newTestIPv4(Type_UDP)
+	ip.Id = 0x4242
+	ip.Data = newLargeUDP(32)
+	data, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+	return ip, data
+}
+
+func TestICMPEcho(t *testing.T) {
+	icmp := NewICMP()
+	icmp.Type = ICMP_Type_EchoRequest
+	icmp.Payload = &ICMPEcho{Identifier: 0x1234, SeqNum: 7, Data: []byte("ping")}
+	request := newTestIPv4(Type_ICMP)
+	request.Data = icmp
+	data, err := Serialize(request, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+
+	decoded := new(IPv4)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	decodedICMP := decoded.Data.(*ICMP)
+	assert.Equal(t, []byte{0x12, 0x34, 0x00, 0x07, 'p', 'i', 'n', 'g'}, decodedICMP.Data)
+	assert.Equal(t, icmp.Payload, decodedICMP.Payload)
+
+	reply, err := NewICMPEchoReply(decoded)
+	require.NoError(t, err)
+	_, err = Serialize(reply, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+	assert.NoError(t, VerifyChecksums(reply))
+	assert.Equal(t, net.ParseIP("10.0.0.2").To4(), reply.NWSrc)
+	assert.Equal(t, net.ParseIP("10.0.0.1").To4(), reply.NWDst)
+	assert.Equal(t, uint8(ICMP_Type_EchoReply), reply.Data.(*ICMP).Type)
+	assert.Equal(t, icmp.Payload, reply.Data.(*ICMP).Payload)
+
+	_, err = NewICMPEchoReply(reply)
+	assert.Error(t, err)
+
+	// Payload takes precedence over Data, until cleared.
+	decodedICMP.Data = []byte{0x12, 0x34, 0x00, 0x08, 'p', 'o', 'n', 'g'}
+	b, err := decodedICMP.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data[len(data)-12:], b)
+	decodedICMP.Payload = nil
+	b, err = decodedICMP.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, decodedICMP.Data, b[4:])
+}
+
+func TestICMPErrors(t *testing.T) {
+	router := net.ParseIP("10.0.0.254")
+	gateway := net.ParseIP("10.0.0.253")
+	for _, tc := range []struct {
+		name  string
+		build func(original *IPv4) (*IPv4, error)
+		body  util.Message
+	}{
+		{
+			name: "fragmentation needed",
+			build: func(original *IPv4) (*IPv4, error) {
+				return NewICMPDestinationUnreachable(router, ICMP_Code_FragmentationNeeded, 1400, original)
+			},
+			body: &ICMPDestinationUnreachable{NextHopMTU: 1400},
+		},
+		{
+			name: "time exceeded",
+			build: func(original *IPv4) (*IPv4, error) {
+				return NewICMPTimeExceeded(router, ICMP_Code_TTLExceeded, original)
+			},
+			body: &ICMPTimeExceeded{},
+		},
+		{
+			name: "redirect",
+			build: func(original *IPv4) (*IPv4, error) {
+				return NewICMPRedirect(router, ICMP_Code_RedirectHost, gateway, original)
+			},
+			body: &ICMPRedirect{Gateway: gateway.To4()},
+		},
+		{
+			name: "parameter problem",
+			build: func(original *IPv4) (*IPv4, error) {
+				return NewICMPParameterProblem(router, 8, original)
+			},
+			body: &ICMPParameterProblem{Pointer: 8},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			original, originalData := newTestOffendingUDP(t)
+			reply, err := tc.build(original)
+			require.NoError(t, err)
+			data, err := Serialize(reply, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+			require.NoError(t, err)
+			assert.Len(t, data, 20+8+20+8)
+
+			decoded := new(IPv4)
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			require.NoError(t, VerifyChecksums(decoded))
+			assert.Equal(t, router.To4(), decoded.NWSrc)
+			assert.Equal(t, original.NWSrc, decoded.NWDst)
+			icmp := decoded.Data.(*ICMP)
+			require.IsType(t, tc.body, icmp.Payload)
+			// The quote is the original header and first 8 payload bytes,
+			// unchanged.
+			assert.Equal(t, originalData[:28], icmp.Data[4:])
+
+			var quote *IPv4
+			switch body := icmp.Payload.(type) {
+			case *ICMPDestinationUnreachable:
+				assert.Equal(t, uint8(ICMP_Type_DestinationUnreachable), icmp.Type)
+				assert.Equal(t, uint8(ICMP_Code_FragmentationNeeded), icmp.Code)
+				assert.Equal(t, uint16(1400), body.NextHopMTU)
+				quote = body.Original
+			case *ICMPTimeExceeded:
+				assert.Equal(t, uint8(ICMP_Type_TimeExceeded), icmp.Type)
+				quote = body.Original
+			case *ICMPRedirect:
+				assert.Equal(t, uint8(ICMP_Type_Redirect), icmp.Type)
+				assert.Equal(t, gateway.To4(), body.Gateway)
+				quote = body.Original
+			case *ICMPParameterProblem:
+				assert.Equal(t, uint8(ICMP_Type_ParameterProblem), icmp.Type)
+				assert.Equal(t, uint8(8), body.Pointer)
+				quote = body.Original
+			}
+			require.NotNil(t, quote)
+			assert.Equal(t, uint16(0x4242), quote.Id)
+			assert.Equal(t, original.Length, quote.Length)
+			assert.Equal(t, originalData[20:28], quote.Data.(*util.Buffer).Bytes())
+
+			// No error about the error.
+			_, err = tc.build(decoded)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestICMPErrorNotSent(t *testing.T) {
+	router := net.ParseIP("10.0.0.254")
+	original, _ := newTestOffendingUDP(t)
+	original.NWDst = net.ParseIP("224.0.0.251").To4()
+	_, err := NewICMPTimeExceeded(router, ICMP_Code_TTLExceeded, original)
+	assert.Error(t, err)
+
+	original, _ = newTestOffendingUDP(t)
+	original.FragmentOffset = 100
+	_, err = NewICMPTimeExceeded(router, ICMP_Code_TTLExceeded, original)
+	assert.Error(t, err)
+
+	// An error about an echo request is fine.
+	icmp := NewICMP()
+	icmp.Type = ICMP_Type_EchoRequest
+	icmp.Payload = &ICMPEcho{Identifier: 1, SeqNum: 1}
+	original = newTestIPv4(Type_ICMP)
+	original.Data = icmp
+	_, err = NewICMPDestinationUnreachable(router, ICMP_Code_HostUnreachable, 0, original)
+	assert.NoError(t, err)
+}
+
+func TestICMPMalformedQuote(t *testing.T) {
+	// A quote shorter than an IPv4 header leaves the body raw.
+	icmp := new(ICMP)
+	require.NoError(t, icmp.UnmarshalBinary([]byte{ICMP_Type_TimeExceeded, 0, 0, 0, 0, 0, 0, 0, 0x45, 0, 0}))
+	assert.Nil(t, icmp.Payload)
+	assert.Len(t, icmp.Data, 7)
+
+	// Without a quote.
+	require.NoError(t, icmp.UnmarshalBinary([]byte{ICMP_Type_DestinationUnreachable, 1, 0, 0, 0, 0, 0, 0}))
+	assert.Equal(t, &ICMPDestinationUnreachable{}, icmp.Payload)
+}
@@ -106,11 +106,13 @@ func (i *ICMPv6EchoReqRpl) MarshalBinary() (data []byte, err error) {
 	n += 2
 	binary.BigEndian.PutUint16(data[n:], i.SeqNum)
 	n += 2
-	dataBytes, err := i.Data.MarshalBinary()
-	if err != nil {
-		return nil, err
+	if i.Data != nil {
+		dataBytes, err := i.Data.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		copy(data[n:], dataBytes)
 	}
-	copy(data[n:], dataBytes)
 	return data, nil
 }
 
@@ -363,7 +365,11 @@ func (q *MLDQuery) UnmarshalBinary(data []byte) error {
 	copy(q.MulticastAddress, data[n:n+16])
 	n += 16
 	q.Version2 = len(data) > 28
+	q.SourceAddresses = nil
 	if q.Version2 {
+		if len(data) < 32 {
+			return errors.New("The []byte is too short to unmarshal a full MLDv2 Query message.")
+		}
 		q.SuppressRouterProcessing = data[n]&0x8 != 0
 		q.RobustnessValue = data[n] & 0x7
 		n += 1
@@ -371,7 +377,7 @@ func (q *MLDQuery) UnmarshalBinary(data []byte) error {
 		n += 1
 		q.NumberOfSources = binary.BigEndian.Uint16(data[n:])
 		n += 2
-		if len(data) < int(q.Len()) {
+		if len(data) < 28+16*int(q.NumberOfSources) {
 			return fmt.Errorf("The []byte is too short to unmarshal a full MLDv2 Query message.")
 		}
 		for j := 0; j < int(q.NumberOfSources); j++ {
@@ -479,8 +485,8 @@ func (r *MLDv2Report) MarshalBinary() (data []byte, err error) {
 }
 
 func (r *MLDv2Report) UnmarshalBinary(data []byte) error {
-	if len(data) < 4 {
-		return errors.New("The []byte is too short to unmarshal a full ICMPv6Header message.")
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full MLDv2Report message.")
 	}
 	err := r.ICMPv6Header.UnmarshalBinary(data)
 	if err != nil {
@@ -491,6 +497,7 @@ func (r *MLDv2Report) UnmarshalBinary(data []byte) error {
 	n += 2
 	r.NumberOfRecords = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	r.GroupRecords = nil
 	for i := uint16(0); i < r.NumberOfRecords; i++ {
 		record := new(MLDv2Record)
 		if err := record.UnmarshalBinary(data[n:]); err != nil {
@@ -595,7 +602,7 @@ func (r *MLDv2Record) MarshalBinary() (data []byte, err error) {
 }
 
 func (r *MLDv2Record) UnmarshalBinary(data []byte) error {
-	if len(data) < 8 {
+	if len(data) < 20 {
 		return errors.New("The []byte is too short to unmarshal a full MLDv2Record message.")
 	}
 	n := 0
@@ -608,9 +615,11 @@ func (r *MLDv2Record) UnmarshalBinary(data []byte) error {
 	r.MulticastAddress = make([]byte, 16)
 	copy(r.MulticastAddress, data[n:n+16])
 	n += 16
-	if len(data) < int(r.Len()) {
+	if len(data) < 20+4*int(r.AuxDataLen)+16*int(r.NumberOfSources) {
 		return fmt.Errorf("The []byte is too short to unmarshal a full MLDv2Record message.")
 	}
+	r.SourceAddresses = nil
+	r.AuxData = nil
 	for i := uint16(0); i < r.NumberOfSources; i++ {
 		r.SourceAddresses = append(r.SourceAddresses, data[n:n+16])
 		n += 16
@@ -646,6 +655,16 @@ func NewICMPv6ByHeaderType(packetType uint8) util.Message {
 		return new(MLD)
 	case ICMPv6_Type_MLDv2_Report:
 		return new(MLDv2Report)
+	case ICMPv6_Type_RouterSolicitation:
+		return new(RouterSolicitation)
+	case ICMPv6_Type_RouterAdvertisement:
+		return new(RouterAdvertisement)
+	case ICMPv6_Type_NeighborSolicitation:
+		return new(NeighborSolicitation)
+	case ICMPv6_Type_NeighborAdvertisement:
+		return new(NeighborAdvertisement)
+	case ICMPv6_Type_Redirect:
+		return new(Redirect)
 	}
 	return new(util.Buffer)
 }
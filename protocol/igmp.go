@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net"
+
+	"antrea.io/libOpenflow/util"
 )
 
 const (
@@ -201,9 +203,10 @@ func (p *IGMPv3Query) UnmarshalBinary(data []byte) error {
 	n += 1
 	p.NumberOfSources = binary.BigEndian.Uint16(data[n:])
 	n += 2
-	if len(data) < int(p.Len()) {
+	if len(data) < 12+4*int(p.NumberOfSources) {
 		return fmt.Errorf("The []byte is too short to unmarshal a full IGMPv3Query message.")
 	}
+	p.SourceAddresses = nil
 	for j := 0; j < int(p.NumberOfSources); j++ {
 		p.SourceAddresses = append(p.SourceAddresses, data[n:n+4])
 		n += 4
@@ -300,9 +303,11 @@ func (p *IGMPv3GroupRecord) UnmarshalBinary(data []byte) error {
 	p.MulticastAddress = make([]byte, 4)
 	copy(p.MulticastAddress, data[n:n+4])
 	n += 4
-	if len(data) < int(p.Len()) {
+	if len(data) < 8+4*int(p.AuxDataLen)+4*int(p.NumberOfSources) {
 		return fmt.Errorf("The []byte is too short to unmarshal a full IGMPv3GroupRecord message.")
 	}
+	p.SourceAddresses = nil
+	p.AuxData = nil
 	for i := uint16(0); i < p.NumberOfSources; i++ {
 		p.SourceAddresses = append(p.SourceAddresses, data[n:n+4])
 		n += 4
@@ -410,6 +415,7 @@ func (p *IGMPv3MembershipReport) UnmarshalBinary(data []byte) error {
 	n += 2
 	p.NumberOfGroups = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	p.GroupRecords = nil
 	for i := uint16(0); i < p.NumberOfGroups; i++ {
 		gr := new(IGMPv3GroupRecord)
 		if err := gr.UnmarshalBinary(data[n:]); err != nil {
@@ -432,3 +438,24 @@ func NewIGMPv3Report(groups []IGMPv3GroupRecord) *IGMPv3MembershipReport {
 		GroupRecords:   groups,
 	}
 }
+
+// NewIGMPByHeader returns the message type for the IGMP message data, as
+// described in RFC 3376 section 7.1: queries of 8 bytes are IGMPv1 or IGMPv2
+// queries, longer ones IGMPv3 queries.
+func NewIGMPByHeader(data []byte) util.Message {
+	if len(data) == 0 {
+		return new(util.Buffer)
+	}
+	switch data[0] {
+	case IGMPQuery:
+		if len(data) >= 12 {
+			return new(IGMPv3Query)
+		}
+		return new(IGMPv1or2)
+	case IGMPv1Report, IGMPv2Report, IGMPv2LeaveGroup:
+		return new(IGMPv1or2)
+	case IGMPv3Report:
+		return new(IGMPv3MembershipReport)
+	}
+	return new(util.Buffer)
+}
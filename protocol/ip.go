@@ -3,6 +3,7 @@ package protocol
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"net"
 
 	"antrea.io/libOpenflow/util"
@@ -13,10 +14,17 @@ const (
 	Type_IGMP     = 0x02
 	Type_TCP      = 0x06
 	Type_UDP      = 0x11
+	Type_GRE      = 0x2f
 	Type_IPv6     = 0x29
 	Type_IPv6ICMP = 0x3a
 )
 
+// IPv4 flags.
+const (
+	IPv4_Flag_MoreFragments = 0x1
+	IPv4_Flag_DontFragment  = 0x2
+)
+
 type IPv4 struct {
 	Version        uint8 //4-bits
 	IHL            uint8 //4-bits
@@ -101,8 +109,43 @@ func (i *IPv4) MarshalBinary() (data []byte, err error) {
 }
 
 func (i *IPv4) UnmarshalBinary(data []byte) error {
+	n, err := i.unmarshalHeader(data)
+	if err != nil {
+		return err
+	}
+
+	// Leave out the Ethernet padding of short frames, which would otherwise
+	// be taken for the end of the payload.
+	end := len(data)
+	if int(i.Length) >= n && int(i.Length) < end {
+		end = int(i.Length)
+	}
+
+	switch {
+	case i.Flags&IPv4_Flag_MoreFragments != 0 || i.FragmentOffset != 0:
+		// The payload of a fragment is only decoded once reassembled.
+		i.Data = new(util.Buffer)
+	case i.Protocol == Type_ICMP:
+		i.Data = NewICMP()
+	case i.Protocol == Type_UDP:
+		i.Data = NewUDP()
+	case i.Protocol == Type_TCP:
+		i.Data = NewTCP()
+	case i.Protocol == Type_IGMP:
+		i.Data = NewIGMPByHeader(data[n:end])
+	case i.Protocol == Type_GRE:
+		i.Data = new(GRE)
+	default:
+		i.Data = new(util.Buffer)
+	}
+	return i.Data.UnmarshalBinary(data[n:end])
+}
+
+// unmarshalHeader decodes the header and options in data, and returns their
+// length.
+func (i *IPv4) unmarshalHeader(data []byte) (int, error) {
 	if len(data) < 20 {
-		return errors.New("The []byte is too short to unmarshal a full IPv4 message.")
+		return 0, errors.New("The []byte is too short to unmarshal a full IPv4 message.")
 	}
 	n := 0
 
@@ -142,19 +185,11 @@ func (i *IPv4) UnmarshalBinary(data []byte) error {
 	copy(i.NWDst, data[n:n+4])
 	n += 4
 
-	err := i.Options.UnmarshalBinary(data[n:int(i.IHL*4)])
-	if err != nil {
-		return err
+	if i.IHL < 5 || len(data) < int(i.IHL)*4 {
+		return 0, fmt.Errorf("invalid IPv4 header length %d", int(i.IHL)*4)
 	}
-	n += int(i.IHL*4) - n
-
-	switch i.Protocol {
-	case Type_ICMP:
-		i.Data = NewICMP()
-	case Type_UDP:
-		i.Data = NewUDP()
-	default:
-		i.Data = new(util.Buffer)
+	if err := i.Options.UnmarshalBinary(data[n:int(i.IHL*4)]); err != nil {
+		return 0, err
 	}
-	return i.Data.UnmarshalBinary(data[n:])
+	return int(i.IHL * 4), nil
 }
@@ -0,0 +1,140 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func newTestTCP() *TCP {
+	tcp := NewTCP()
+	tcp.PortSrc = 34567
+	tcp.PortDst = 80
+	tcp.SeqNum = 1000
+	tcp.Code = 0x02
+	tcp.WinSize = 65535
+	tcp.Data = []byte("GET /")
+	return tcp
+}
+
+func TestIPv4Dispatch(t *testing.T) {
+	group := net.ParseIP("239.1.1.1")
+	udp := NewUDP()
+	udp.PortSrc = 53
+	udp.PortDst = 5353
+	udp.Data = []byte{0x01, 0x02}
+	icmp := NewICMP()
+	icmp.Type = 8
+	icmp.Data = []byte{0x00, 0x01, 0x00, 0x01}
+
+	for _, tc := range []struct {
+		name     string
+		protocol uint8
+		data     util.Message
+	}{
+		{"ICMP", Type_ICMP, icmp},
+		{"UDP", Type_UDP, udp},
+		{"TCP", Type_TCP, newTestTCP()},
+		{"IGMPv1 query", Type_IGMP, NewIGMPv1Query(net.IPv4zero)},
+		{"IGMPv1 report", Type_IGMP, NewIGMPv1Report(group)},
+		{"IGMPv2 query", Type_IGMP, NewIGMPv2Query(group, 100)},
+		{"IGMPv2 report", Type_IGMP, NewIGMPv2Report(group)},
+		{"IGMPv2 leave", Type_IGMP, NewIGMPv2Leave(group)},
+		{"IGMPv3 query", Type_IGMP, NewIGMPv3Query(group, 100, 125, []net.IP{net.ParseIP("10.0.0.1")})},
+		{"IGMPv3 general query", Type_IGMP, NewIGMPv3Query(net.IPv4zero, 100, 125, nil)},
+		{"IGMPv3 report", Type_IGMP, NewIGMPv3Report([]IGMPv3GroupRecord{NewGroupRecord(IGMPIsEx, group, nil)})},
+		{"unknown IGMP", Type_IGMP, util.NewBuffer([]byte{0x30, 0x00, 0x00, 0x00})},
+		{"unknown protocol", 0x84, util.NewBuffer([]byte{0x01, 0x02, 0x03, 0x04})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ip := newTestIPv4(tc.protocol)
+			ip.Data = tc.data
+			eth := NewEthernet()
+			eth.Data = ip
+			data, err := Serialize(eth, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+			require.NoError(t, err)
+			// Pad to the minimum Ethernet frame size.
+			if len(data) < 60 {
+				data = append(data, make([]byte, 60-len(data))...)
+			}
+
+			decoded := new(Ethernet)
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			decodedIP, ok := decoded.Data.(*IPv4)
+			require.True(t, ok)
+			require.IsType(t, tc.data, decodedIP.Data)
+			b, err := decodedIP.Data.MarshalBinary()
+			require.NoError(t, err)
+			expected, err := tc.data.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, expected, b)
+			assert.NoError(t, VerifyChecksums(decoded))
+		})
+	}
+}
+
+func TestIPv4InvalidHeaderLength(t *testing.T) {
+	ip := newTestIPv4(Type_UDP)
+	ip.Data = NewUDP()
+	data, err := Serialize(ip, SerializeOptions{FixLengths: true})
+	require.NoError(t, err)
+	// Shorter than the fixed header, and longer than the packet.
+	for _, ihl := range []uint8{4, 15} {
+		data[0] = 0x40 | ihl
+		assert.Error(t, new(IPv4).UnmarshalBinary(data), ihl)
+	}
+}
+
+func TestIPv6Dispatch(t *testing.T) {
+	udp := NewUDP()
+	udp.PortSrc = 546
+	udp.PortDst = 547
+	udp.Data = []byte{0x01, 0x02}
+	echo := NewICMPv6EchoRequest(1, 2)
+	echo.Data = util.NewBuffer([]byte{0xaa, 0xbb})
+
+	for _, tc := range []struct {
+		name       string
+		nextHeader uint8
+		data       util.Message
+	}{
+		{"ICMPv6", Type_IPv6ICMP, echo},
+		{"MLD", Type_IPv6ICMP, NewMLDReport(net.ParseIP("ff02::2"))},
+		{"UDP", Type_UDP, udp},
+		{"TCP", Type_TCP, newTestTCP()},
+		{"unknown", 0x84, util.NewBuffer([]byte{0x01, 0x02, 0x03, 0x04})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, fragment := range []bool{false, true} {
+				ip := &IPv6{
+					Version:    6,
+					NextHeader: tc.nextHeader,
+					HopLimit:   64,
+					NWSrc:      net.ParseIP("2001:db8::1"),
+					NWDst:      net.ParseIP("2001:db8::2"),
+					Data:       tc.data,
+				}
+				if fragment {
+					ip.NextHeader = Type_Fragment
+					ip.FragmentHeader = &FragmentHeader{NextHeader: tc.nextHeader, Identification: 1}
+				}
+				data, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+				require.NoError(t, err)
+
+				decoded := new(IPv6)
+				require.NoError(t, decoded.UnmarshalBinary(data))
+				require.IsType(t, tc.data, decoded.Data)
+				b, err := decoded.Data.MarshalBinary()
+				require.NoError(t, err)
+				expected, err := tc.data.MarshalBinary()
+				require.NoError(t, err)
+				assert.Equal(t, expected, b)
+				assert.NoError(t, VerifyChecksums(decoded))
+			}
+		})
+	}
+}
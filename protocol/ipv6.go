@@ -67,27 +67,29 @@ func (i *IPv6) MarshalBinary() (data []byte, err error) {
 	copy(data[n:], i.NWDst)
 	n += 16
 
+	// Each extension header is written once, following the chain of next
+	// headers until one is missing, such as after a fragment.
 	checkExtHeader := true
 	nxtHeader := i.NextHeader
+	var written [3]bool
 	for checkExtHeader {
 		var err error
 		var hBytes []byte
-		switch nxtHeader {
-		case Type_HBH:
-			checkExtHeader = true
+		switch {
+		case nxtHeader == Type_HBH && i.HbhHeader != nil && !written[0]:
+			written[0] = true
 			nxtHeader = i.HbhHeader.NextHeader
 			hBytes, err = i.HbhHeader.MarshalBinary()
-		case Type_Routing:
-			checkExtHeader = true
+		case nxtHeader == Type_Routing && i.RoutingHeader != nil && !written[1]:
+			written[1] = true
 			nxtHeader = i.RoutingHeader.NextHeader
 			hBytes, err = i.RoutingHeader.MarshalBinary()
-		case Type_Fragment:
-			checkExtHeader = true
+		case nxtHeader == Type_Fragment && i.FragmentHeader != nil && !written[2]:
+			written[2] = true
 			nxtHeader = i.FragmentHeader.NextHeader
 			hBytes, err = i.FragmentHeader.MarshalBinary()
 		default:
 			checkExtHeader = false
-			break
 		}
 		if err != nil {
 			return nil, err
@@ -135,6 +137,9 @@ func (i *IPv6) UnmarshalBinary(data []byte) error {
 	copy(i.NWDst, data[n:n+16])
 	n += 16
 
+	i.HbhHeader = nil
+	i.RoutingHeader = nil
+	i.FragmentHeader = nil
 	checkExtHeader := true
 	nxtHeader := i.NextHeader
 checkXHeader:
@@ -167,13 +172,27 @@ checkXHeader:
 			}
 			nxtHeader = i.FragmentHeader.NextHeader
 			n += int(i.FragmentHeader.Len())
+			if i.FragmentHeader.MoreFragments || i.FragmentHeader.FragmentOffset != 0 {
+				// The payload of a fragment is only decoded once reassembled.
+				i.Data = new(util.Buffer)
+				break checkXHeader
+			}
 		case Type_IPv6ICMP:
+			if len(data) <= n {
+				return errors.New("The []byte is too short to unmarshal a full ICMPv6 message.")
+			}
 			packetType := data[n]
 			i.Data = NewICMPv6ByHeaderType(packetType)
 			break checkXHeader
 		case Type_UDP:
 			i.Data = NewUDP()
 			break checkXHeader
+		case Type_TCP:
+			i.Data = NewTCP()
+			break checkXHeader
+		case Type_GRE:
+			i.Data = new(GRE)
+			break checkXHeader
 		default:
 			i.Data = new(util.Buffer)
 			break checkXHeader
@@ -204,6 +223,9 @@ func (o *Option) MarshalBinary() (data []byte, err error) {
 }
 
 func (o *Option) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errors.New("The []byte is too short to unmarshal a full Option message.")
+	}
 	n := 0
 	o.Type = data[n]
 	n += 1
@@ -224,7 +246,7 @@ type HopByHopHeader struct {
 }
 
 func (h *HopByHopHeader) Len() uint16 {
-	return 8 * uint16(h.HEL+1)
+	return 8 * (uint16(h.HEL) + 1)
 }
 
 func (h *HopByHopHeader) MarshalBinary() (data []byte, err error) {
@@ -246,17 +268,18 @@ func (h *HopByHopHeader) MarshalBinary() (data []byte, err error) {
 }
 
 func (h *HopByHopHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || len(data) < 8*(int(data[1])+1) {
+		return errors.New("The []byte is too short to unmarshal a full HopByHopHeader message.")
+	}
 	n := 0
 	h.NextHeader = data[n]
 	n += 1
 	h.HEL = data[n]
-	if len(data) < 8*int(h.HEL+1) {
-		return errors.New("The []byte is too short to unmarshal a full HopByHopHeader message.")
-	}
 	n += 1
+	h.Options = nil
 	for n < int(h.Len()) {
 		o := new(Option)
-		err := o.UnmarshalBinary(data[n:])
+		err := o.UnmarshalBinary(data[n:h.Len()])
 		if err != nil {
 			return err
 		}
@@ -279,7 +302,7 @@ type RoutingHeader struct {
 }
 
 func (h *RoutingHeader) Len() uint16 {
-	return 8 * uint16(h.HEL+1)
+	return 8 * (uint16(h.HEL) + 1)
 }
 
 func (h *RoutingHeader) MarshalBinary() (data []byte, err error) {
@@ -298,13 +321,13 @@ func (h *RoutingHeader) MarshalBinary() (data []byte, err error) {
 }
 
 func (h *RoutingHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || len(data) < 8*(int(data[1])+1) {
+		return errors.New("The []byte is too short to unmarshal a full RoutingHeader message.")
+	}
 	n := 0
 	h.NextHeader = data[n]
 	n += 1
 	h.HEL = data[n]
-	if len(data) < 8*int(h.HEL+1) {
-		return errors.New("The []byte is too short to unmarshal a full RoutingHeader message.")
-	}
 	n += 1
 	h.RoutingType = data[n]
 	n += 1
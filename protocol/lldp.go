@@ -1,52 +1,241 @@
 package protocol
 
 import (
-	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// LLDPMulticastMAC is the nearest bridge address LLDP frames are sent to.
+var LLDPMulticastMAC = net.HardwareAddr{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}
+
+// LLDP TLV types
+const (
+	LLDP_TLV_END           = 0
+	LLDP_TLV_CHASSIS_ID    = 1
+	LLDP_TLV_PORT_ID       = 2
+	LLDP_TLV_TTL           = 3
+	LLDP_TLV_PORT_DESC     = 4
+	LLDP_TLV_SYSTEM_NAME   = 5
+	LLDP_TLV_SYSTEM_DESC   = 6
+	LLDP_TLV_SYSTEM_CAP    = 7
+	LLDP_TLV_MGMT_ADDR     = 8
+	LLDP_TLV_ORG_SPECIFIC  = 127
+	lldpTLVMaxLength       = 0x1ff
+	lldpOrgSpecificMinSize = 4
+)
+
+// System capabilities
+const (
+	LLDP_CAP_OTHER     = 1 << 0
+	LLDP_CAP_REPEATER  = 1 << 1
+	LLDP_CAP_BRIDGE    = 1 << 2
+	LLDP_CAP_WLAN_AP   = 1 << 3
+	LLDP_CAP_ROUTER    = 1 << 4
+	LLDP_CAP_TELEPHONE = 1 << 5
+	LLDP_CAP_DOCSIS    = 1 << 6
+	LLDP_CAP_STATION   = 1 << 7
+)
+
+// Organizationally unique identifiers of the IEEE 802.1 and 802.3 TLVs, and
+// the subtypes decoded here.
+var (
+	LLDP_OUI_8021 = [3]byte{0x00, 0x80, 0xc2}
+	LLDP_OUI_8023 = [3]byte{0x00, 0x12, 0x0f}
 )
 
+const (
+	LLDP_8021_PORT_VLAN_ID = 1
+	LLDP_8021_VLAN_NAME    = 3
+	LLDP_8023_MAC_PHY      = 1
+	LLDP_8023_MAX_FRAME    = 4
+)
+
+// LLDP is an LLDP data unit: the mandatory chassis ID, port ID and TTL TLVs,
+// followed by the optional ones. The End TLV is added when marshaling.
 type LLDP struct {
 	Chassis ChassisTLV
 	Port    PortTLV
 	TTL     TTLTLV
+	// Optional are LLDPPortDescription, LLDPSystemName, LLDPSystemDescription,
+	// LLDPSystemCapabilities, LLDPManagementAddress, the organizationally
+	// specific LLDP8021*, LLDP8023* and LLDPOrgSpecific, or LLDPTLV values.
+	Optional []util.Message
+}
+
+func NewLLDP(chassisSubtype uint8, chassisID []byte, portSubtype uint8, portID []byte, ttl uint16) *LLDP {
+	d := new(LLDP)
+	d.Chassis = ChassisTLV{Type: LLDP_TLV_CHASSIS_ID, Subtype: chassisSubtype, Data: chassisID}
+	d.Port = PortTLV{Type: LLDP_TLV_PORT_ID, Subtype: portSubtype, Data: portID}
+	d.TTL = TTLTLV{Type: LLDP_TLV_TTL, Seconds: ttl}
+	return d
+}
+
+// AddTLV adds an optional TLV.
+func (d *LLDP) AddTLV(tlv util.Message) {
+	d.Optional = append(d.Optional, tlv)
 }
 
 func (d *LLDP) Len() (n uint16) {
-	return 15
+	n = d.Chassis.Len() + d.Port.Len() + d.TTL.Len()
+	for _, t := range d.Optional {
+		n += t.Len()
+	}
+	return n + 2
 }
 
-func (d *LLDP) Read(b []byte) (n int, err error) {
-	m, o, p := 0, 0, 0
-	if m, err = d.Chassis.Read(b); m == 0 {
-		return
+func (d *LLDP) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 0, d.Len())
+	tlvs := append([]util.Message{&d.Chassis, &d.Port, &d.TTL}, d.Optional...)
+	for _, t := range tlvs {
+		var b []byte
+		if b, err = t.MarshalBinary(); err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
 	}
-	n += m
-	if o, err = d.Port.Read(b); o == 0 {
-		return
+	return append(data, 0, 0), nil
+}
+
+func (d *LLDP) UnmarshalBinary(data []byte) error {
+	n := 0
+	for i, t := range []util.Message{&d.Chassis, &d.Port, &d.TTL} {
+		typ, l, err := lldpTLVHeader(data[n:])
+		if err != nil {
+			return err
+		}
+		if int(typ) != i+1 {
+			return fmt.Errorf("LLDP TLV %d found instead of mandatory TLV %d", typ, i+1)
+		}
+		if err := t.UnmarshalBinary(data[n : n+2+l]); err != nil {
+			return err
+		}
+		n += 2 + l
+	}
+
+	d.Optional = nil
+	// Stop at the End TLV, or at the end of the data if it is missing.
+	for n < len(data) {
+		typ, l, err := lldpTLVHeader(data[n:])
+		if err != nil {
+			return err
+		}
+		if typ == LLDP_TLV_END {
+			break
+		}
+		tlv := newLLDPTLV(typ, data[n+2:n+2+l])
+		if err := tlv.UnmarshalBinary(data[n : n+2+l]); err != nil {
+			return err
+		}
+		d.Optional = append(d.Optional, tlv)
+		n += 2 + l
 	}
-	n += o
-	if p, err = d.Chassis.Read(b); p == 0 {
-		return
+	return nil
+}
+
+// Read writes the LLDP data unit to b.
+//
+// Deprecated: use MarshalBinary.
+func (d *LLDP) Read(b []byte) (n int, err error) {
+	data, err := d.MarshalBinary()
+	if err != nil {
+		return 0, err
 	}
-	n += p
-	return
+	return copy(b, data), nil
 }
 
+// Write decodes the LLDP data unit in b.
+//
+// Deprecated: use UnmarshalBinary.
 func (d *LLDP) Write(b []byte) (n int, err error) {
-	m, o, p := 0, 0, 0
-	if m, err = d.Chassis.Write(b); m == 0 {
-		return
+	if err = d.UnmarshalBinary(b); err != nil {
+		return 0, err
+	}
+	return int(d.Len()), nil
+}
+
+func newLLDPTLV(typ uint8, value []byte) util.Message {
+	switch typ {
+	case LLDP_TLV_PORT_DESC:
+		return new(LLDPPortDescription)
+	case LLDP_TLV_SYSTEM_NAME:
+		return new(LLDPSystemName)
+	case LLDP_TLV_SYSTEM_DESC:
+		return new(LLDPSystemDescription)
+	case LLDP_TLV_SYSTEM_CAP:
+		return new(LLDPSystemCapabilities)
+	case LLDP_TLV_MGMT_ADDR:
+		return new(LLDPManagementAddress)
+	case LLDP_TLV_ORG_SPECIFIC:
+		if len(value) < lldpOrgSpecificMinSize {
+			break
+		}
+		oui := [3]byte{value[0], value[1], value[2]}
+		switch {
+		case oui == LLDP_OUI_8021 && value[3] == LLDP_8021_PORT_VLAN_ID && len(value) == 6:
+			return new(LLDP8021PortVLANID)
+		case oui == LLDP_OUI_8021 && value[3] == LLDP_8021_VLAN_NAME && len(value) >= 7 && int(value[6]) == len(value)-7:
+			return new(LLDP8021VLANName)
+		case oui == LLDP_OUI_8023 && value[3] == LLDP_8023_MAC_PHY && len(value) == 9:
+			return new(LLDP8023MACPHY)
+		case oui == LLDP_OUI_8023 && value[3] == LLDP_8023_MAX_FRAME && len(value) == 6:
+			return new(LLDP8023MaxFrameSize)
+		}
+		return new(LLDPOrgSpecific)
 	}
-	n += m
-	if o, err = d.Port.Write(b[n:]); o == 0 {
-		return
+	return new(LLDPTLV)
+}
+
+// lldpTLVHeader returns the type and length of the TLV starting data, whose
+// value must be in data.
+func lldpTLVHeader(data []byte) (typ uint8, length int, err error) {
+	if len(data) < 2 {
+		return 0, 0, errors.New("The []byte is too short to unmarshal a full LLDP TLV.")
 	}
-	n += o
-	if p, err = d.Chassis.Write(b[n:]); p == 0 {
-		return
+	typeAndLen := binary.BigEndian.Uint16(data)
+	typ = uint8(typeAndLen >> 9)
+	length = int(typeAndLen & lldpTLVMaxLength)
+	if len(data) < 2+length {
+		return 0, 0, errors.New("The []byte is too short to unmarshal a full LLDP TLV.")
 	}
-	n += p
-	return
+	return typ, length, nil
+}
+
+// marshalLLDPTLV returns a TLV of the given type holding the concatenated
+// value parts.
+func marshalLLDPTLV(typ uint8, value ...[]byte) ([]byte, error) {
+	length := 0
+	for _, v := range value {
+		length += len(v)
+	}
+	if length > lldpTLVMaxLength {
+		return nil, fmt.Errorf("LLDP TLV %d too long: %d bytes", typ, length)
+	}
+	data := make([]byte, 2, 2+length)
+	binary.BigEndian.PutUint16(data, uint16(typ)<<9|uint16(length))
+	for _, v := range value {
+		data = append(data, v...)
+	}
+	return data, nil
+}
+
+// unmarshalLLDPTLV returns the value of the TLV in data, checking that it has
+// the given type and at least min bytes.
+func unmarshalLLDPTLV(data []byte, typ uint8, min int) ([]byte, error) {
+	t, l, err := lldpTLVHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if t != typ {
+		return nil, fmt.Errorf("LLDP TLV %d found instead of %d", t, typ)
+	}
+	if l < min {
+		return nil, fmt.Errorf("LLDP TLV %d too short: %d bytes", typ, l)
+	}
+	return data[2 : 2+l], nil
 }
 
 // Chassis ID subtypes
@@ -61,6 +250,7 @@ const (
 	CH_LOCAL_ASSGN
 )
 
+// ChassisTLV is the chassis ID TLV. Type and Length are set when marshaling.
 type ChassisTLV struct {
 	Type    uint8
 	Length  uint16
@@ -68,36 +258,40 @@ type ChassisTLV struct {
 	Data    []uint8
 }
 
+func (t *ChassisTLV) Len() (n uint16) {
+	return uint16(3 + len(t.Data))
+}
+
+func (t *ChassisTLV) MarshalBinary() (data []byte, err error) {
+	t.Type = LLDP_TLV_CHASSIS_ID
+	t.Length = uint16(1 + len(t.Data))
+	return marshalLLDPTLV(t.Type, []byte{t.Subtype}, t.Data)
+}
+
+func (t *ChassisTLV) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalLLDPTLV(data, LLDP_TLV_CHASSIS_ID, 2)
+	if err != nil {
+		return err
+	}
+	t.Type = LLDP_TLV_CHASSIS_ID
+	t.Length = uint16(len(value))
+	t.Subtype = value[0]
+	t.Data = append([]uint8(nil), value[1:]...)
+	return nil
+}
+
+// Read writes the TLV to b.
+//
+// Deprecated: use MarshalBinary.
 func (t *ChassisTLV) Read(b []byte) (n int, err error) {
-	buf := new(bytes.Buffer)
-	var tni uint16 = 0
-	typeAndLen := (tni | uint16(t.Type)<<9) + (tni | uint16(t.Length))
-	binary.Write(buf, binary.BigEndian, typeAndLen)
-	binary.Write(buf, binary.BigEndian, t.Subtype)
-	binary.Write(buf, binary.BigEndian, t.Data)
-	n, err = buf.Read(b)
-	return
+	return readLLDPTLV(t, b)
 }
 
+// Write decodes the TLV in b.
+//
+// Deprecated: use UnmarshalBinary.
 func (t *ChassisTLV) Write(b []byte) (n int, err error) {
-	buf := bytes.NewBuffer(b)
-	var typeAndLen uint16 = 0
-	if err = binary.Read(buf, binary.BigEndian, &typeAndLen); err != nil {
-		return
-	}
-	n += 2
-	t.Type = uint8(typeAndLen >> 9)
-	t.Length = uint16(uint16(0x01ff) & typeAndLen)
-	if err = binary.Read(buf, binary.BigEndian, &t.Subtype); err != nil {
-		return
-	}
-	n += 1
-	t.Data = make([]uint8, t.Length)
-	if err = binary.Read(buf, binary.BigEndian, &t.Data); err != nil {
-		return
-	}
-	n += int(t.Length)
-	return
+	return writeLLDPTLV(t, b)
 }
 
 // Port ID subtypes
@@ -112,6 +306,7 @@ const (
 	PT_LOCAL_ASSGN
 )
 
+// PortTLV is the port ID TLV. Type and Length are set when marshaling.
 type PortTLV struct {
 	Type    uint8  //7bits
 	Length  uint16 //9bits
@@ -119,66 +314,403 @@ type PortTLV struct {
 	Data    []uint8
 }
 
+func (t *PortTLV) Len() (n uint16) {
+	return uint16(3 + len(t.Data))
+}
+
+func (t *PortTLV) MarshalBinary() (data []byte, err error) {
+	t.Type = LLDP_TLV_PORT_ID
+	t.Length = uint16(1 + len(t.Data))
+	return marshalLLDPTLV(t.Type, []byte{t.Subtype}, t.Data)
+}
+
+func (t *PortTLV) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalLLDPTLV(data, LLDP_TLV_PORT_ID, 2)
+	if err != nil {
+		return err
+	}
+	t.Type = LLDP_TLV_PORT_ID
+	t.Length = uint16(len(value))
+	t.Subtype = value[0]
+	t.Data = append([]uint8(nil), value[1:]...)
+	return nil
+}
+
+// Read writes the TLV to b.
+//
+// Deprecated: use MarshalBinary.
 func (t *PortTLV) Read(b []byte) (n int, err error) {
-	buf := new(bytes.Buffer)
-	var tni uint16 = 0
-	typeAndLen := (tni | uint16(t.Type)<<9) + (tni | uint16(t.Length))
-	binary.Write(buf, binary.BigEndian, typeAndLen)
-	binary.Write(buf, binary.BigEndian, t.Subtype)
-	binary.Write(buf, binary.BigEndian, t.Data)
-	n, err = buf.Read(b)
-	return
+	return readLLDPTLV(t, b)
 }
 
+// Write decodes the TLV in b.
+//
+// Deprecated: use UnmarshalBinary.
 func (t *PortTLV) Write(b []byte) (n int, err error) {
-	buf := bytes.NewBuffer(b)
-	var typeAndLen uint16 = 0
-	if err = binary.Read(buf, binary.BigEndian, &typeAndLen); err != nil {
-		return
-	}
-	n += 2
-	t.Type = uint8(typeAndLen >> 9)
-	t.Length = uint16(uint16(0x01ff) & typeAndLen)
-	if err = binary.Read(buf, binary.BigEndian, &t.Subtype); err != nil {
-		return
-	}
-	n += 1
-	t.Data = make([]uint8, t.Length)
-	if err = binary.Read(buf, binary.BigEndian, &t.Data); err != nil {
-		return
-	}
-	n += int(t.Length)
-	return
+	return writeLLDPTLV(t, b)
 }
 
+// TTLTLV is the time to live TLV. Type and Length are set when marshaling.
 type TTLTLV struct {
 	Type    uint8  //7 bits
 	Length  uint16 //9 bits
 	Seconds uint16
 }
 
+func (t *TTLTLV) Len() (n uint16) {
+	return 4
+}
+
+func (t *TTLTLV) MarshalBinary() (data []byte, err error) {
+	t.Type = LLDP_TLV_TTL
+	t.Length = 2
+	return marshalLLDPTLV(t.Type, binary.BigEndian.AppendUint16(nil, t.Seconds))
+}
+
+func (t *TTLTLV) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalLLDPTLV(data, LLDP_TLV_TTL, 2)
+	if err != nil {
+		return err
+	}
+	t.Type = LLDP_TLV_TTL
+	t.Length = uint16(len(value))
+	t.Seconds = binary.BigEndian.Uint16(value)
+	return nil
+}
+
+// Read writes the TLV to b.
+//
+// Deprecated: use MarshalBinary.
 func (t *TTLTLV) Read(b []byte) (n int, err error) {
-	buf := new(bytes.Buffer)
-	var tni uint16 = 0
-	typeAndLen := (tni | uint16(t.Type)<<9) + (tni | uint16(t.Length))
-	binary.Write(buf, binary.BigEndian, typeAndLen)
-	binary.Write(buf, binary.BigEndian, t.Seconds)
-	n, err = buf.Read(b)
-	return
+	return readLLDPTLV(t, b)
 }
 
+// Write decodes the TLV in b.
+//
+// Deprecated: use UnmarshalBinary.
 func (t *TTLTLV) Write(b []byte) (n int, err error) {
-	buf := bytes.NewBuffer(b)
-	var typeAndLen uint16 = 0
-	if err = binary.Read(buf, binary.BigEndian, &typeAndLen); err != nil {
-		return
-	}
-	n += 2
-	t.Type = uint8(typeAndLen >> 9)
-	t.Length = uint16(uint16(0x01ff) & typeAndLen)
-	if err = binary.Read(buf, binary.BigEndian, &t.Seconds); err != nil {
-		return
-	}
-	n += 2
-	return
+	return writeLLDPTLV(t, b)
+}
+
+func readLLDPTLV(t util.Message, b []byte) (int, error) {
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	return copy(b, data), nil
+}
+
+func writeLLDPTLV(t util.Message, b []byte) (int, error) {
+	if err := t.UnmarshalBinary(b); err != nil {
+		return 0, err
+	}
+	return int(t.Len()), nil
+}
+
+// LLDPPortDescription is the port description TLV.
+type LLDPPortDescription struct {
+	Description string
+}
+
+func (t *LLDPPortDescription) Len() (n uint16) {
+	return uint16(2 + len(t.Description))
+}
+
+func (t *LLDPPortDescription) MarshalBinary() (data []byte, err error) {
+	return marshalLLDPTLV(LLDP_TLV_PORT_DESC, []byte(t.Description))
+}
+
+func (t *LLDPPortDescription) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalLLDPTLV(data, LLDP_TLV_PORT_DESC, 0)
+	t.Description = string(value)
+	return err
+}
+
+// LLDPSystemName is the system name TLV.
+type LLDPSystemName struct {
+	Name string
+}
+
+func (t *LLDPSystemName) Len() (n uint16) {
+	return uint16(2 + len(t.Name))
+}
+
+func (t *LLDPSystemName) MarshalBinary() (data []byte, err error) {
+	return marshalLLDPTLV(LLDP_TLV_SYSTEM_NAME, []byte(t.Name))
+}
+
+func (t *LLDPSystemName) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalLLDPTLV(data, LLDP_TLV_SYSTEM_NAME, 0)
+	t.Name = string(value)
+	return err
+}
+
+// LLDPSystemDescription is the system description TLV.
+type LLDPSystemDescription struct {
+	Description string
+}
+
+func (t *LLDPSystemDescription) Len() (n uint16) {
+	return uint16(2 + len(t.Description))
+}
+
+func (t *LLDPSystemDescription) MarshalBinary() (data []byte, err error) {
+	return marshalLLDPTLV(LLDP_TLV_SYSTEM_DESC, []byte(t.Description))
+}
+
+func (t *LLDPSystemDescription) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalLLDPTLV(data, LLDP_TLV_SYSTEM_DESC, 0)
+	t.Description = string(value)
+	return err
+}
+
+// LLDPSystemCapabilities is the system capabilities TLV, made of LLDP_CAP_*
+// bits.
+type LLDPSystemCapabilities struct {
+	Capabilities uint16
+	Enabled      uint16
+}
+
+func (t *LLDPSystemCapabilities) Len() (n uint16) {
+	return 6
+}
+
+func (t *LLDPSystemCapabilities) MarshalBinary() (data []byte, err error) {
+	value := make([]byte, 4)
+	binary.BigEndian.PutUint16(value[0:2], t.Capabilities)
+	binary.BigEndian.PutUint16(value[2:4], t.Enabled)
+	return marshalLLDPTLV(LLDP_TLV_SYSTEM_CAP, value)
+}
+
+func (t *LLDPSystemCapabilities) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalLLDPTLV(data, LLDP_TLV_SYSTEM_CAP, 4)
+	if err != nil {
+		return err
+	}
+	t.Capabilities = binary.BigEndian.Uint16(value[0:2])
+	t.Enabled = binary.BigEndian.Uint16(value[2:4])
+	return nil
+}
+
+// LLDPManagementAddress is the management address TLV. AddrSubtype is an
+// IANA address family number, 1 for IPv4 and 2 for IPv6.
+type LLDPManagementAddress struct {
+	AddrSubtype uint8
+	Addr        []byte
+	IfSubtype   uint8
+	IfNumber    uint32
+	OID         []byte
+}
+
+func (t *LLDPManagementAddress) Len() (n uint16) {
+	return uint16(2 + 1 + 1 + len(t.Addr) + 5 + 1 + len(t.OID))
+}
+
+func (t *LLDPManagementAddress) MarshalBinary() (data []byte, err error) {
+	if len(t.Addr) < 1 || len(t.Addr) > 31 || len(t.OID) > 128 {
+		return nil, fmt.Errorf("invalid LLDP management address of %d bytes with OID of %d bytes", len(t.Addr), len(t.OID))
+	}
+	iface := make([]byte, 5)
+	iface[0] = t.IfSubtype
+	binary.BigEndian.PutUint32(iface[1:], t.IfNumber)
+	return marshalLLDPTLV(LLDP_TLV_MGMT_ADDR,
+		[]byte{uint8(1 + len(t.Addr)), t.AddrSubtype}, t.Addr,
+		iface, []byte{uint8(len(t.OID))}, t.OID)
+}
+
+func (t *LLDPManagementAddress) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalLLDPTLV(data, LLDP_TLV_MGMT_ADDR, 9)
+	if err != nil {
+		return err
+	}
+	addrLen := int(value[0])
+	if addrLen < 2 || len(value) < 1+addrLen+6 {
+		return errors.New("The []byte is too short to unmarshal a full LLDP management address.")
+	}
+	t.AddrSubtype = value[1]
+	t.Addr = append([]byte(nil), value[2:1+addrLen]...)
+	n := 1 + addrLen
+	t.IfSubtype = value[n]
+	t.IfNumber = binary.BigEndian.Uint32(value[n+1:])
+	n += 5
+	oidLen := int(value[n])
+	if len(value) != n+1+oidLen {
+		return errors.New("The []byte is too short to unmarshal a full LLDP management address.")
+	}
+	t.OID = append([]byte(nil), value[n+1:]...)
+	return nil
+}
+
+// LLDPOrgSpecific is an organizationally specific TLV which is not decoded
+// further.
+type LLDPOrgSpecific struct {
+	OUI     [3]byte
+	Subtype uint8
+	Info    []byte
+}
+
+func (t *LLDPOrgSpecific) Len() (n uint16) {
+	return uint16(6 + len(t.Info))
+}
+
+func (t *LLDPOrgSpecific) MarshalBinary() (data []byte, err error) {
+	return marshalLLDPTLV(LLDP_TLV_ORG_SPECIFIC, t.OUI[:], []byte{t.Subtype}, t.Info)
+}
+
+func (t *LLDPOrgSpecific) UnmarshalBinary(data []byte) error {
+	value, err := unmarshalLLDPTLV(data, LLDP_TLV_ORG_SPECIFIC, lldpOrgSpecificMinSize)
+	if err != nil {
+		return err
+	}
+	copy(t.OUI[:], value[0:3])
+	t.Subtype = value[3]
+	t.Info = append([]byte(nil), value[4:]...)
+	return nil
+}
+
+// unmarshalLLDPOrgSpecific returns the information of the organizationally
+// specific TLV in data, which must have the given OUI, subtype and length.
+func unmarshalLLDPOrgSpecific(data []byte, oui [3]byte, subtype uint8, length int) ([]byte, error) {
+	t := new(LLDPOrgSpecific)
+	if err := t.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	if t.OUI != oui || t.Subtype != subtype {
+		return nil, fmt.Errorf("unexpected LLDP organizationally specific TLV %x subtype %d", t.OUI, t.Subtype)
+	}
+	if len(t.Info) < length {
+		return nil, fmt.Errorf("LLDP organizationally specific TLV %x subtype %d too short", t.OUI, t.Subtype)
+	}
+	return t.Info, nil
+}
+
+// LLDP8021PortVLANID is the IEEE 802.1 port VLAN ID TLV.
+type LLDP8021PortVLANID struct {
+	VID uint16
+}
+
+func (t *LLDP8021PortVLANID) Len() (n uint16) {
+	return 8
+}
+
+func (t *LLDP8021PortVLANID) MarshalBinary() (data []byte, err error) {
+	return marshalLLDPTLV(LLDP_TLV_ORG_SPECIFIC, LLDP_OUI_8021[:], []byte{LLDP_8021_PORT_VLAN_ID},
+		binary.BigEndian.AppendUint16(nil, t.VID))
+}
+
+func (t *LLDP8021PortVLANID) UnmarshalBinary(data []byte) error {
+	info, err := unmarshalLLDPOrgSpecific(data, LLDP_OUI_8021, LLDP_8021_PORT_VLAN_ID, 2)
+	if err != nil {
+		return err
+	}
+	t.VID = binary.BigEndian.Uint16(info)
+	return nil
+}
+
+// LLDP8021VLANName is the IEEE 802.1 VLAN name TLV.
+type LLDP8021VLANName struct {
+	VID  uint16
+	Name string
+}
+
+func (t *LLDP8021VLANName) Len() (n uint16) {
+	return uint16(9 + len(t.Name))
+}
+
+func (t *LLDP8021VLANName) MarshalBinary() (data []byte, err error) {
+	if len(t.Name) > 32 {
+		return nil, fmt.Errorf("LLDP VLAN name too long: %d bytes", len(t.Name))
+	}
+	info := binary.BigEndian.AppendUint16(nil, t.VID)
+	info = append(info, uint8(len(t.Name)))
+	return marshalLLDPTLV(LLDP_TLV_ORG_SPECIFIC, LLDP_OUI_8021[:], []byte{LLDP_8021_VLAN_NAME}, info, []byte(t.Name))
+}
+
+func (t *LLDP8021VLANName) UnmarshalBinary(data []byte) error {
+	info, err := unmarshalLLDPOrgSpecific(data, LLDP_OUI_8021, LLDP_8021_VLAN_NAME, 3)
+	if err != nil {
+		return err
+	}
+	if int(info[2]) != len(info)-3 {
+		return errors.New("The []byte is too short to unmarshal a full LLDP VLAN name.")
+	}
+	t.VID = binary.BigEndian.Uint16(info)
+	t.Name = string(info[3:])
+	return nil
+}
+
+// LLDP8023MACPHY is the IEEE 802.3 MAC/PHY configuration/status TLV.
+type LLDP8023MACPHY struct {
+	AutoNegotiation uint8 // Support and status bits.
+	PMDCapability   uint16
+	MAUType         uint16
+}
+
+func (t *LLDP8023MACPHY) Len() (n uint16) {
+	return 11
+}
+
+func (t *LLDP8023MACPHY) MarshalBinary() (data []byte, err error) {
+	info := []byte{t.AutoNegotiation}
+	info = binary.BigEndian.AppendUint16(info, t.PMDCapability)
+	info = binary.BigEndian.AppendUint16(info, t.MAUType)
+	return marshalLLDPTLV(LLDP_TLV_ORG_SPECIFIC, LLDP_OUI_8023[:], []byte{LLDP_8023_MAC_PHY}, info)
+}
+
+func (t *LLDP8023MACPHY) UnmarshalBinary(data []byte) error {
+	info, err := unmarshalLLDPOrgSpecific(data, LLDP_OUI_8023, LLDP_8023_MAC_PHY, 5)
+	if err != nil {
+		return err
+	}
+	t.AutoNegotiation = info[0]
+	t.PMDCapability = binary.BigEndian.Uint16(info[1:3])
+	t.MAUType = binary.BigEndian.Uint16(info[3:5])
+	return nil
+}
+
+// LLDP8023MaxFrameSize is the IEEE 802.3 maximum frame size TLV.
+type LLDP8023MaxFrameSize struct {
+	Size uint16
+}
+
+func (t *LLDP8023MaxFrameSize) Len() (n uint16) {
+	return 8
+}
+
+func (t *LLDP8023MaxFrameSize) MarshalBinary() (data []byte, err error) {
+	return marshalLLDPTLV(LLDP_TLV_ORG_SPECIFIC, LLDP_OUI_8023[:], []byte{LLDP_8023_MAX_FRAME},
+		binary.BigEndian.AppendUint16(nil, t.Size))
+}
+
+func (t *LLDP8023MaxFrameSize) UnmarshalBinary(data []byte) error {
+	info, err := unmarshalLLDPOrgSpecific(data, LLDP_OUI_8023, LLDP_8023_MAX_FRAME, 2)
+	if err != nil {
+		return err
+	}
+	t.Size = binary.BigEndian.Uint16(info)
+	return nil
+}
+
+// LLDPTLV is a TLV of another type, kept raw.
+type LLDPTLV struct {
+	Type uint8
+	Data []byte
+}
+
+func (t *LLDPTLV) Len() (n uint16) {
+	return uint16(2 + len(t.Data))
+}
+
+func (t *LLDPTLV) MarshalBinary() (data []byte, err error) {
+	return marshalLLDPTLV(t.Type, t.Data)
+}
+
+func (t *LLDPTLV) UnmarshalBinary(data []byte) error {
+	typ, l, err := lldpTLVHeader(data)
+	if err != nil {
+		return err
+	}
+	t.Type = typ
+	t.Data = append([]byte(nil), data[2:2+l]...)
+	return nil
 }
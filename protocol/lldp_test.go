@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLLDP(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	lldp := NewLLDP(CH_MAC_ADDR, mac, PT_IFACE_NAME, []byte("eth0"), 120)
+	lldp.AddTLV(&LLDPPortDescription{Description: "uplink"})
+	lldp.AddTLV(&LLDPSystemName{Name: "switch1"})
+	lldp.AddTLV(&LLDPSystemDescription{Description: "Open vSwitch"})
+	lldp.AddTLV(&LLDPSystemCapabilities{Capabilities: LLDP_CAP_BRIDGE | LLDP_CAP_ROUTER, Enabled: LLDP_CAP_BRIDGE})
+	lldp.AddTLV(&LLDPManagementAddress{AddrSubtype: 1, Addr: []byte{10, 0, 0, 1}, IfSubtype: 2, IfNumber: 3})
+	lldp.AddTLV(&LLDP8021PortVLANID{VID: 100})
+	lldp.AddTLV(&LLDP8021VLANName{VID: 100, Name: "blue"})
+	lldp.AddTLV(&LLDP8023MACPHY{AutoNegotiation: 0x03, PMDCapability: 0x6c00, MAUType: 16})
+	lldp.AddTLV(&LLDP8023MaxFrameSize{Size: 9216})
+	lldp.AddTLV(&LLDPOrgSpecific{OUI: [3]byte{0x00, 0x26, 0xe1}, Subtype: 1, Info: []byte{0, 0, 0, 1}})
+	lldp.AddTLV(&LLDPTLV{Type: 9, Data: []byte{1, 2}})
+
+	eth := NewEthernet()
+	eth.HWDst = LLDPMulticastMAC
+	eth.HWSrc = mac
+	eth.Ethertype = LLDP_MSG
+	eth.Data = lldp
+	data, err := eth.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, int(eth.Len()), len(data))
+	// Chassis ID TLV of 7 bytes.
+	assert.Equal(t, []byte{0x02, 0x07, CH_MAC_ADDR}, data[14:17])
+	assert.Equal(t, []byte{0, 0}, data[len(data)-2:])
+
+	decoded := new(Ethernet)
+	require.NoError(t, decoded.UnmarshalBinary(append(data, make([]byte, 20)...)))
+	require.IsType(t, new(LLDP), decoded.Data)
+	assert.Equal(t, lldp, decoded.Data)
+
+	// Without End TLV.
+	require.NoError(t, decoded.UnmarshalBinary(data[:len(data)-2]))
+	assert.Equal(t, lldp, decoded.Data)
+}
+
+func TestLLDPInvalid(t *testing.T) {
+	lldp := NewLLDP(CH_LOCAL_ASSGN, []byte("dpid"), PT_LOCAL_ASSGN, []byte("1"), 120)
+	data, err := lldp.MarshalBinary()
+	require.NoError(t, err)
+
+	// TTL before port ID.
+	bad := append([]byte(nil), data...)
+	bad[7] = LLDP_TLV_TTL << 1
+	assert.Error(t, new(LLDP).UnmarshalBinary(bad))
+	for i := 0; i < len(data)-2; i++ {
+		assert.Error(t, new(LLDP).UnmarshalBinary(data[:i]), "%d bytes", i)
+	}
+
+	// A known organizationally specific subtype with an unexpected size is
+	// kept generic.
+	lldp.AddTLV(&LLDPOrgSpecific{OUI: LLDP_OUI_8023, Subtype: LLDP_8023_MAX_FRAME, Info: []byte{1}})
+	data, err = lldp.MarshalBinary()
+	require.NoError(t, err)
+	decoded := new(LLDP)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, lldp.Optional, decoded.Optional)
+
+	lldp.AddTLV(&LLDPSystemName{Name: string(make([]byte, 512))})
+	_, err = lldp.MarshalBinary()
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"antrea.io/libOpenflow/util"
+)
+
+const (
+	MPLS_LABEL_MASK = 0xfffff000
+	MPLS_TC_MASK    = 0x00000e00
+	MPLS_BOS_MASK   = 0x00000100
+	MPLS_TTL_MASK   = 0x000000ff
+)
+
+// MPLSLabel is an entry of an MPLS label stack.
+type MPLSLabel struct {
+	Label uint32
+	TC    uint8
+	BoS   bool
+	TTL   uint8
+}
+
+func (l *MPLSLabel) Len() (n uint16) {
+	return 4
+}
+
+func (l *MPLSLabel) MarshalBinary() (data []byte, err error) {
+	if l.Label > MPLS_LABEL_MASK>>12 {
+		return nil, errors.New("MPLS label is larger than 20 bits.")
+	}
+	data = make([]byte, l.Len())
+	entry := l.Label<<12 | uint32(l.TC&0x7)<<9 | uint32(l.TTL)
+	if l.BoS {
+		entry |= MPLS_BOS_MASK
+	}
+	binary.BigEndian.PutUint32(data, entry)
+	return
+}
+
+func (l *MPLSLabel) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full MPLS label.")
+	}
+	entry := binary.BigEndian.Uint32(data)
+	l.Label = (entry & MPLS_LABEL_MASK) >> 12
+	l.TC = uint8((entry & MPLS_TC_MASK) >> 9)
+	l.BoS = entry&MPLS_BOS_MASK != 0
+	l.TTL = uint8(entry & MPLS_TTL_MASK)
+	return nil
+}
+
+// MPLS is an MPLS label stack, outermost label first, followed by its
+// payload. The BoS bit of the labels is marshaled as given.
+type MPLS struct {
+	Labels []MPLSLabel
+	Data   util.Message
+}
+
+func NewMPLS() *MPLS {
+	m := new(MPLS)
+	m.Labels = make([]MPLSLabel, 0)
+	return m
+}
+
+// PushLabel adds an outermost label. The BoS bit is set if it is the only
+// label of the stack.
+func (m *MPLS) PushLabel(label uint32, tc, ttl uint8) {
+	l := MPLSLabel{Label: label, TC: tc, TTL: ttl, BoS: len(m.Labels) == 0}
+	m.Labels = append([]MPLSLabel{l}, m.Labels...)
+}
+
+func (m *MPLS) Len() (n uint16) {
+	n = 4 * uint16(len(m.Labels))
+	if m.Data != nil {
+		n += m.Data.Len()
+	}
+	return
+}
+
+func (m *MPLS) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(m.Len()))
+	n := 0
+	for i := range m.Labels {
+		var bytes []byte
+		if bytes, err = m.Labels[i].MarshalBinary(); err != nil {
+			return
+		}
+		copy(data[n:], bytes)
+		n += len(bytes)
+	}
+	if m.Data != nil {
+		var bytes []byte
+		if bytes, err = m.Data.MarshalBinary(); err != nil {
+			return
+		}
+		copy(data[n:], bytes)
+	}
+	return
+}
+
+// UnmarshalBinary decodes labels up to the bottom of stack. As MPLS does not
+// identify its payload, it is decoded as IPv4 or IPv6 according to its first
+// nibble, and kept as a util.Buffer otherwise.
+func (m *MPLS) UnmarshalBinary(data []byte) error {
+	m.Labels = make([]MPLSLabel, 0)
+	n := 0
+	for {
+		var l MPLSLabel
+		if err := l.UnmarshalBinary(data[n:]); err != nil {
+			return errors.New("The []byte is too short to unmarshal a full MPLS message.")
+		}
+		m.Labels = append(m.Labels, l)
+		n += int(l.Len())
+		if l.BoS {
+			break
+		}
+	}
+
+	m.Data = new(util.Buffer)
+	if len(data) > n {
+		switch data[n] >> 4 {
+		case 4:
+			m.Data = new(IPv4)
+		case 6:
+			m.Data = new(IPv6)
+		}
+	}
+	if err := m.Data.UnmarshalBinary(data[n:]); err != nil {
+		// Not IP after all, such as an Ethernet pseudowire.
+		m.Data = new(util.Buffer)
+		return m.Data.UnmarshalBinary(data[n:])
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func TestMPLS(t *testing.T) {
+	ip := newTestIPv4(Type_UDP)
+	ip.Data = newLargeUDP(4)
+	mpls := NewMPLS()
+	mpls.Data = ip
+	mpls.PushLabel(100, 0, 64)
+	mpls.PushLabel(0xfffff, 5, 255)
+	assert.Equal(t, []MPLSLabel{{Label: 0xfffff, TC: 5, TTL: 255}, {Label: 100, BoS: true, TTL: 64}}, mpls.Labels)
+
+	eth := NewEthernet()
+	eth.Ethertype = MPLS_MSG
+	eth.Data = mpls
+	data, err := Serialize(eth, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0xff, 0xfa, 0xff, 0x00, 0x06, 0x41, 0x40}, data[14:22])
+
+	decoded := new(Ethernet)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.IsType(t, new(MPLS), decoded.Data)
+	assert.Equal(t, mpls.Labels, decoded.Data.(*MPLS).Labels)
+	require.IsType(t, new(IPv4), decoded.Data.(*MPLS).Data)
+	assert.NoError(t, VerifyChecksums(decoded))
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+}
+
+func TestMPLSPayload(t *testing.T) {
+	mpls := NewMPLS()
+	mpls.PushLabel(16, 0, 64)
+	mpls.Data = util.NewBuffer([]byte{0x00, 0x01, 0x02, 0x03})
+	data, err := mpls.MarshalBinary()
+	require.NoError(t, err)
+	decoded := NewMPLS()
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, mpls.Data, decoded.Data)
+
+	// A payload starting like IPv4 which is not one is kept raw.
+	mpls.Data = util.NewBuffer([]byte{0x45, 0x01, 0x02, 0x03})
+	data, err = mpls.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, mpls.Data, decoded.Data)
+
+	// The stack ends before the bottom of stack.
+	mpls.Labels[0].BoS = false
+	data, err = mpls.MarshalBinary()
+	require.NoError(t, err)
+	assert.Error(t, decoded.UnmarshalBinary(data[:4]))
+
+	mpls.Labels[0].Label = 1 << 20
+	_, err = mpls.MarshalBinary()
+	assert.Error(t, err)
+}
@@ -0,0 +1,691 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// Neighbor Discovery for IPv6, see RFC 4861.
+
+const (
+	ICMPv6_Type_RouterSolicitation    = 133
+	ICMPv6_Type_RouterAdvertisement   = 134
+	ICMPv6_Type_NeighborSolicitation  = 135
+	ICMPv6_Type_NeighborAdvertisement = 136
+	ICMPv6_Type_Redirect              = 137
+)
+
+// Neighbor Discovery option types.
+const (
+	NDOptionType_SourceLinkLayerAddress = 1
+	NDOptionType_TargetLinkLayerAddress = 2
+	NDOptionType_PrefixInformation      = 3
+	NDOptionType_RedirectedHeader       = 4
+	NDOptionType_MTU                    = 5
+	NDOptionType_RDNSS                  = 25 // RFC 8106
+)
+
+func marshalNDOptions(data []byte, options []util.Message) error {
+	n := 0
+	for _, o := range options {
+		b, err := o.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		copy(data[n:], b)
+		n += len(b)
+	}
+	return nil
+}
+
+func ndOptionsLen(options []util.Message) (n uint16) {
+	for _, o := range options {
+		n += o.Len()
+	}
+	return
+}
+
+func decodeNDOptions(data []byte) ([]util.Message, error) {
+	var options []util.Message
+	n := 0
+	for n < len(data) {
+		if n+2 > len(data) {
+			return nil, errors.New("The []byte is too short to unmarshal a full ND option.")
+		}
+		length := int(data[n+1]) * 8
+		if length == 0 || n+length > len(data) {
+			return nil, fmt.Errorf("invalid ND option %d length %d", data[n], length)
+		}
+		var option util.Message
+		switch data[n] {
+		case NDOptionType_SourceLinkLayerAddress, NDOptionType_TargetLinkLayerAddress:
+			option = new(NDOptionLinkLayerAddress)
+		case NDOptionType_PrefixInformation:
+			option = new(NDOptionPrefixInformation)
+		case NDOptionType_RedirectedHeader:
+			option = new(NDOptionRedirectedHeader)
+		case NDOptionType_MTU:
+			option = new(NDOptionMTU)
+		case NDOptionType_RDNSS:
+			option = new(NDOptionRDNSS)
+		default:
+			option = new(NDOptionRaw)
+		}
+		if err := option.UnmarshalBinary(data[n : n+length]); err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+		n += length
+	}
+	return options, nil
+}
+
+// NDOptionLinkLayerAddress is the source or target link-layer address option.
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     Type      |    Length     |    Link-Layer Address ...
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// The option does not tell the length of the address, so Addr holds all the
+// bytes after the option length: the 6 bytes of an Ethernet address, or the 20
+// bytes of an IP over InfiniBand address followed by 2 bytes of padding.
+type NDOptionLinkLayerAddress struct {
+	Type uint8 // NDOptionType_SourceLinkLayerAddress or NDOptionType_TargetLinkLayerAddress.
+	Addr net.HardwareAddr
+}
+
+func (o *NDOptionLinkLayerAddress) Len() uint16 {
+	return uint16((2 + len(o.Addr) + 7) / 8 * 8)
+}
+
+func (o *NDOptionLinkLayerAddress) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(o.Len()))
+	data[0] = o.Type
+	data[1] = uint8(len(data) / 8)
+	copy(data[2:], o.Addr)
+	return
+}
+
+func (o *NDOptionLinkLayerAddress) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full NDOptionLinkLayerAddress message.")
+	}
+	o.Type = data[0]
+	o.Addr = make(net.HardwareAddr, len(data)-2)
+	copy(o.Addr, data[2:])
+	return nil
+}
+
+// NDOptionPrefixInformation is the prefix information option of Router
+// Advertisements.
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     Type      |    Length     | Prefix Length |L|A| Reserved1 |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                         Valid Lifetime                        |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                       Preferred Lifetime                      |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                           Reserved2                           |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                                                               |
+//	+                            Prefix                             +
+//	|                                                               |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+type NDOptionPrefixInformation struct {
+	PrefixLength      uint8
+	OnLink            bool
+	Autonomous        bool
+	ValidLifetime     uint32
+	PreferredLifetime uint32
+	Prefix            net.IP
+}
+
+func (o *NDOptionPrefixInformation) Len() uint16 {
+	return 32
+}
+
+func (o *NDOptionPrefixInformation) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(o.Len()))
+	data[0] = NDOptionType_PrefixInformation
+	data[1] = 4
+	data[2] = o.PrefixLength
+	if o.OnLink {
+		data[3] |= 0x80
+	}
+	if o.Autonomous {
+		data[3] |= 0x40
+	}
+	binary.BigEndian.PutUint32(data[4:], o.ValidLifetime)
+	binary.BigEndian.PutUint32(data[8:], o.PreferredLifetime)
+	copy(data[16:], o.Prefix.To16())
+	return
+}
+
+func (o *NDOptionPrefixInformation) UnmarshalBinary(data []byte) error {
+	if len(data) < int(o.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full NDOptionPrefixInformation message.")
+	}
+	o.PrefixLength = data[2]
+	o.OnLink = data[3]&0x80 != 0
+	o.Autonomous = data[3]&0x40 != 0
+	o.ValidLifetime = binary.BigEndian.Uint32(data[4:])
+	o.PreferredLifetime = binary.BigEndian.Uint32(data[8:])
+	o.Prefix = make(net.IP, 16)
+	copy(o.Prefix, data[16:32])
+	return nil
+}
+
+// NDOptionRedirectedHeader is the redirected header option of Redirect
+// messages, holding the start of the redirected packet.
+type NDOptionRedirectedHeader struct {
+	Data []byte
+}
+
+func (o *NDOptionRedirectedHeader) Len() uint16 {
+	return uint16((8 + len(o.Data) + 7) / 8 * 8)
+}
+
+func (o *NDOptionRedirectedHeader) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(o.Len()))
+	data[0] = NDOptionType_RedirectedHeader
+	data[1] = uint8(len(data) / 8)
+	copy(data[8:], o.Data)
+	return
+}
+
+func (o *NDOptionRedirectedHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full NDOptionRedirectedHeader message.")
+	}
+	o.Data = make([]byte, len(data)-8)
+	copy(o.Data, data[8:])
+	return nil
+}
+
+// NDOptionMTU is the MTU option of Router Advertisements.
+type NDOptionMTU struct {
+	MTU uint32
+}
+
+func (o *NDOptionMTU) Len() uint16 {
+	return 8
+}
+
+func (o *NDOptionMTU) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(o.Len()))
+	data[0] = NDOptionType_MTU
+	data[1] = 1
+	binary.BigEndian.PutUint32(data[4:], o.MTU)
+	return
+}
+
+func (o *NDOptionMTU) UnmarshalBinary(data []byte) error {
+	if len(data) < int(o.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full NDOptionMTU message.")
+	}
+	o.MTU = binary.BigEndian.Uint32(data[4:])
+	return nil
+}
+
+// NDOptionRDNSS is the recursive DNS server option of RFC 8106.
+type NDOptionRDNSS struct {
+	Lifetime uint32
+	Servers  []net.IP
+}
+
+func (o *NDOptionRDNSS) Len() uint16 {
+	return uint16(8 + 16*len(o.Servers))
+}
+
+func (o *NDOptionRDNSS) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(o.Len()))
+	data[0] = NDOptionType_RDNSS
+	data[1] = uint8(len(data) / 8)
+	binary.BigEndian.PutUint32(data[4:], o.Lifetime)
+	n := 8
+	for _, s := range o.Servers {
+		copy(data[n:], s.To16())
+		n += 16
+	}
+	return
+}
+
+func (o *NDOptionRDNSS) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full NDOptionRDNSS message.")
+	}
+	o.Lifetime = binary.BigEndian.Uint32(data[4:])
+	o.Servers = nil
+	for n := 8; n+16 <= len(data); n += 16 {
+		server := make(net.IP, 16)
+		copy(server, data[n:n+16])
+		o.Servers = append(o.Servers, server)
+	}
+	return nil
+}
+
+// NDOptionRaw is an option of another type.
+type NDOptionRaw struct {
+	Type uint8
+	// Data follows the option length. It is padded with zeros to 8 bytes when
+	// marshaled, and holds the padding when unmarshaled.
+	Data []byte
+}
+
+func (o *NDOptionRaw) Len() uint16 {
+	return uint16((2 + len(o.Data) + 7) / 8 * 8)
+}
+
+func (o *NDOptionRaw) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(o.Len()))
+	data[0] = o.Type
+	data[1] = uint8(len(data) / 8)
+	copy(data[2:], o.Data)
+	return
+}
+
+func (o *NDOptionRaw) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full NDOptionRaw message.")
+	}
+	o.Type = data[0]
+	o.Data = make([]byte, len(data)-2)
+	copy(o.Data, data[2:])
+	return nil
+}
+
+// RouterSolicitation:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     Type      |     Code      |          Checksum             |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                            Reserved                           |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|   Options ...
+//	+-+-+-+-+-+-+-+-+-+-+-+-
+type RouterSolicitation struct {
+	ICMPv6Header
+	Reserved uint32
+	Options  []util.Message
+}
+
+func (r *RouterSolicitation) Len() uint16 {
+	return 8 + ndOptionsLen(r.Options)
+}
+
+func (r *RouterSolicitation) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(r.Len()))
+	b, err := r.ICMPv6Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data, b)
+	binary.BigEndian.PutUint32(data[4:], r.Reserved)
+	if err = marshalNDOptions(data[8:], r.Options); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (r *RouterSolicitation) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full RouterSolicitation message.")
+	}
+	if err := r.ICMPv6Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	r.Reserved = binary.BigEndian.Uint32(data[4:])
+	var err error
+	r.Options, err = decodeNDOptions(data[8:])
+	return err
+}
+
+func NewRouterSolicitation(srcMAC net.HardwareAddr) *RouterSolicitation {
+	r := &RouterSolicitation{
+		ICMPv6Header: ICMPv6Header{Type: ICMPv6_Type_RouterSolicitation},
+	}
+	if srcMAC != nil {
+		r.Options = append(r.Options, &NDOptionLinkLayerAddress{Type: NDOptionType_SourceLinkLayerAddress, Addr: srcMAC})
+	}
+	return r
+}
+
+// RouterAdvertisement:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     Type      |     Code      |          Checksum             |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	| Cur Hop Limit |M|O|  Reserved |       Router Lifetime         |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                         Reachable Time                        |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                          Retrans Timer                        |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|   Options ...
+//	+-+-+-+-+-+-+-+-+-+-+-+-
+type RouterAdvertisement struct {
+	ICMPv6Header
+	CurHopLimit          uint8
+	ManagedAddressConfig bool
+	OtherConfig          bool
+	RouterLifetime       uint16
+	ReachableTime        uint32
+	RetransTimer         uint32
+	Options              []util.Message
+}
+
+func (r *RouterAdvertisement) Len() uint16 {
+	return 16 + ndOptionsLen(r.Options)
+}
+
+func (r *RouterAdvertisement) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(r.Len()))
+	b, err := r.ICMPv6Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data, b)
+	n := 4
+	data[n] = r.CurHopLimit
+	n += 1
+	if r.ManagedAddressConfig {
+		data[n] |= 0x80
+	}
+	if r.OtherConfig {
+		data[n] |= 0x40
+	}
+	n += 1
+	binary.BigEndian.PutUint16(data[n:], r.RouterLifetime)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], r.ReachableTime)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], r.RetransTimer)
+	n += 4
+	if err = marshalNDOptions(data[n:], r.Options); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (r *RouterAdvertisement) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("The []byte is too short to unmarshal a full RouterAdvertisement message.")
+	}
+	if err := r.ICMPv6Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	n := 4
+	r.CurHopLimit = data[n]
+	n += 1
+	r.ManagedAddressConfig = data[n]&0x80 != 0
+	r.OtherConfig = data[n]&0x40 != 0
+	n += 1
+	r.RouterLifetime = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	r.ReachableTime = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	r.RetransTimer = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	var err error
+	r.Options, err = decodeNDOptions(data[n:])
+	return err
+}
+
+func NewRouterAdvertisement(curHopLimit uint8, routerLifetime uint16, srcMAC net.HardwareAddr) *RouterAdvertisement {
+	r := &RouterAdvertisement{
+		ICMPv6Header:   ICMPv6Header{Type: ICMPv6_Type_RouterAdvertisement},
+		CurHopLimit:    curHopLimit,
+		RouterLifetime: routerLifetime,
+	}
+	if srcMAC != nil {
+		r.Options = append(r.Options, &NDOptionLinkLayerAddress{Type: NDOptionType_SourceLinkLayerAddress, Addr: srcMAC})
+	}
+	return r
+}
+
+// AddOption appends an ND option to the Router Advertisement.
+func (r *RouterAdvertisement) AddOption(option util.Message) {
+	r.Options = append(r.Options, option)
+}
+
+// NeighborSolicitation:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     Type      |     Code      |          Checksum             |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                           Reserved                            |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                                                               |
+//	+                                                               +
+//	|                                                               |
+//	+                       Target Address                          +
+//	|                                                               |
+//	+                                                               +
+//	|                                                               |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|   Options ...
+//	+-+-+-+-+-+-+-+-+-+-+-+-
+type NeighborSolicitation struct {
+	ICMPv6Header
+	Reserved      uint32
+	TargetAddress net.IP
+	Options       []util.Message
+}
+
+func (s *NeighborSolicitation) Len() uint16 {
+	return 24 + ndOptionsLen(s.Options)
+}
+
+func (s *NeighborSolicitation) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(s.Len()))
+	b, err := s.ICMPv6Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data, b)
+	binary.BigEndian.PutUint32(data[4:], s.Reserved)
+	copy(data[8:24], s.TargetAddress.To16())
+	if err = marshalNDOptions(data[24:], s.Options); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *NeighborSolicitation) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full NeighborSolicitation message.")
+	}
+	if err := s.ICMPv6Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	s.Reserved = binary.BigEndian.Uint32(data[4:])
+	s.TargetAddress = make(net.IP, 16)
+	copy(s.TargetAddress, data[8:24])
+	var err error
+	s.Options, err = decodeNDOptions(data[24:])
+	return err
+}
+
+func NewNeighborSolicitation(target net.IP, srcMAC net.HardwareAddr) *NeighborSolicitation {
+	s := &NeighborSolicitation{
+		ICMPv6Header:  ICMPv6Header{Type: ICMPv6_Type_NeighborSolicitation},
+		TargetAddress: target,
+	}
+	if srcMAC != nil {
+		s.Options = append(s.Options, &NDOptionLinkLayerAddress{Type: NDOptionType_SourceLinkLayerAddress, Addr: srcMAC})
+	}
+	return s
+}
+
+// NeighborAdvertisement:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     Type      |     Code      |          Checksum             |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|R|S|O|                     Reserved                            |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                                                               |
+//	+                                                               +
+//	|                                                               |
+//	+                       Target Address                          +
+//	|                                                               |
+//	+                                                               +
+//	|                                                               |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|   Options ...
+//	+-+-+-+-+-+-+-+-+-+-+-+-
+type NeighborAdvertisement struct {
+	ICMPv6Header
+	Router        bool
+	Solicited     bool
+	Override      bool
+	TargetAddress net.IP
+	Options       []util.Message
+}
+
+func (a *NeighborAdvertisement) Len() uint16 {
+	return 24 + ndOptionsLen(a.Options)
+}
+
+func (a *NeighborAdvertisement) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(a.Len()))
+	b, err := a.ICMPv6Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data, b)
+	if a.Router {
+		data[4] |= 0x80
+	}
+	if a.Solicited {
+		data[4] |= 0x40
+	}
+	if a.Override {
+		data[4] |= 0x20
+	}
+	copy(data[8:24], a.TargetAddress.To16())
+	if err = marshalNDOptions(data[24:], a.Options); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (a *NeighborAdvertisement) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("The []byte is too short to unmarshal a full NeighborAdvertisement message.")
+	}
+	if err := a.ICMPv6Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	a.Router = data[4]&0x80 != 0
+	a.Solicited = data[4]&0x40 != 0
+	a.Override = data[4]&0x20 != 0
+	a.TargetAddress = make(net.IP, 16)
+	copy(a.TargetAddress, data[8:24])
+	var err error
+	a.Options, err = decodeNDOptions(data[24:])
+	return err
+}
+
+func NewNeighborAdvertisement(target net.IP, targetMAC net.HardwareAddr, router, solicited, override bool) *NeighborAdvertisement {
+	a := &NeighborAdvertisement{
+		ICMPv6Header:  ICMPv6Header{Type: ICMPv6_Type_NeighborAdvertisement},
+		Router:        router,
+		Solicited:     solicited,
+		Override:      override,
+		TargetAddress: target,
+	}
+	if targetMAC != nil {
+		a.Options = append(a.Options, &NDOptionLinkLayerAddress{Type: NDOptionType_TargetLinkLayerAddress, Addr: targetMAC})
+	}
+	return a
+}
+
+// Redirect:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     Type      |     Code      |          Checksum             |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                           Reserved                            |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                                                               |
+//	+                       Target Address                          +
+//	|                                                               |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                                                               |
+//	+                     Destination Address                       +
+//	|                                                               |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|   Options ...
+//	+-+-+-+-+-+-+-+-+-+-+-+-
+type Redirect struct {
+	ICMPv6Header
+	Reserved           uint32
+	TargetAddress      net.IP
+	DestinationAddress net.IP
+	Options            []util.Message
+}
+
+func (r *Redirect) Len() uint16 {
+	return 40 + ndOptionsLen(r.Options)
+}
+
+func (r *Redirect) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(r.Len()))
+	b, err := r.ICMPv6Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data, b)
+	binary.BigEndian.PutUint32(data[4:], r.Reserved)
+	copy(data[8:24], r.TargetAddress.To16())
+	copy(data[24:40], r.DestinationAddress.To16())
+	if err = marshalNDOptions(data[40:], r.Options); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (r *Redirect) UnmarshalBinary(data []byte) error {
+	if len(data) < 40 {
+		return errors.New("The []byte is too short to unmarshal a full Redirect message.")
+	}
+	if err := r.ICMPv6Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	r.Reserved = binary.BigEndian.Uint32(data[4:])
+	r.TargetAddress = make(net.IP, 16)
+	copy(r.TargetAddress, data[8:24])
+	r.DestinationAddress = make(net.IP, 16)
+	copy(r.DestinationAddress, data[24:40])
+	var err error
+	r.Options, err = decodeNDOptions(data[40:])
+	return err
+}
+
+func NewRedirect(target, destination net.IP) *Redirect {
+	return &Redirect{
+		ICMPv6Header:       ICMPv6Header{Type: ICMPv6_Type_Redirect},
+		TargetAddress:      target,
+		DestinationAddress: destination,
+	}
+}
@@ -0,0 +1,119 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func TestNeighborDiscovery(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	target := net.ParseIP("fe80::2")
+
+	ra := NewRouterAdvertisement(64, 1800, mac)
+	ra.ManagedAddressConfig = true
+	ra.ReachableTime = 30000
+	ra.AddOption(&NDOptionMTU{MTU: 1450})
+	ra.AddOption(&NDOptionPrefixInformation{
+		PrefixLength:      64,
+		OnLink:            true,
+		Autonomous:        true,
+		ValidLifetime:     86400,
+		PreferredLifetime: 14400,
+		Prefix:            net.ParseIP("2001:db8::"),
+	})
+	ra.AddOption(&NDOptionRDNSS{Lifetime: 600, Servers: []net.IP{net.ParseIP("2001:db8::53")}})
+	ra.AddOption(&NDOptionRaw{Type: 31, Data: []byte{0, 0, 0, 0, 0, 0x3c, 0x07, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 0}})
+
+	redirect := NewRedirect(target, net.ParseIP("2001:db8::1"))
+	redirect.Options = append(redirect.Options,
+		&NDOptionLinkLayerAddress{Type: NDOptionType_TargetLinkLayerAddress, Addr: mac},
+		&NDOptionRedirectedHeader{Data: make([]byte, 48)})
+
+	for _, tc := range []struct {
+		name string
+		msg  util.Message
+		len  uint16
+	}{
+		{"router solicitation", NewRouterSolicitation(mac), 16},
+		{"router solicitation without address", NewRouterSolicitation(nil), 8},
+		{"router advertisement", ra, 16 + 8 + 8 + 32 + 24 + 24},
+		{"neighbor solicitation", NewNeighborSolicitation(target, mac), 32},
+		{"neighbor advertisement", NewNeighborAdvertisement(target, mac, true, true, false), 32},
+		{"redirect", redirect, 40 + 8 + 56},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ip := &IPv6{
+				Version:    6,
+				NextHeader: Type_IPv6ICMP,
+				HopLimit:   255,
+				NWSrc:      net.ParseIP("fe80::1"),
+				NWDst:      net.ParseIP("ff02::1"),
+				Data:       tc.msg,
+			}
+			assert.Equal(t, tc.len, tc.msg.Len())
+			data, err := Serialize(ip, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+			require.NoError(t, err)
+
+			decoded := new(IPv6)
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			require.IsType(t, tc.msg, decoded.Data)
+			assert.NoError(t, VerifyChecksums(decoded))
+			b, err := decoded.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, data, b)
+		})
+	}
+}
+
+func TestNeighborAdvertisementFields(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	na := NewNeighborAdvertisement(net.ParseIP("fe80::2"), mac, false, true, true)
+	data, err := na.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0x60), data[4])
+
+	decoded := new(NeighborAdvertisement)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.False(t, decoded.Router)
+	assert.True(t, decoded.Solicited)
+	assert.True(t, decoded.Override)
+	assert.True(t, decoded.TargetAddress.Equal(net.ParseIP("fe80::2")))
+	require.Len(t, decoded.Options, 1)
+	assert.Equal(t, &NDOptionLinkLayerAddress{Type: NDOptionType_TargetLinkLayerAddress, Addr: mac}, decoded.Options[0])
+
+	// Longer addresses, such as IP over InfiniBand, are kept whole.
+	ipoib := make(net.HardwareAddr, 22)
+	for i := 0; i < 20; i++ {
+		ipoib[i] = byte(i + 1)
+	}
+	na = NewNeighborAdvertisement(net.ParseIP("fe80::2"), ipoib, false, true, true)
+	data, err = na.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(3), data[25])
+	decoded = new(NeighborAdvertisement)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, &NDOptionLinkLayerAddress{Type: NDOptionType_TargetLinkLayerAddress, Addr: ipoib}, decoded.Options[0])
+
+	// A zero option length is invalid.
+	data[25] = 0
+	assert.Error(t, new(NeighborAdvertisement).UnmarshalBinary(data))
+}
+
+func TestNDOptionRawPadding(t *testing.T) {
+	raw := &NDOptionRaw{Type: 31, Data: []byte{1, 2, 3}}
+	data, err := raw.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{31, 1, 1, 2, 3, 0, 0, 0}, data)
+
+	decoded := new(NDOptionRaw)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, []byte{1, 2, 3, 0, 0, 0}, decoded.Data)
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+}
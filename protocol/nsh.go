@@ -0,0 +1,218 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// NSH metadata types.
+const (
+	NSH_MDType1 = 0x1
+	NSH_MDType2 = 0x2
+)
+
+// NSH next protocols.
+const (
+	NSH_NextProto_IPv4     = 0x1
+	NSH_NextProto_IPv6     = 0x2
+	NSH_NextProto_Ethernet = 0x3
+	NSH_NextProto_NSH      = 0x4
+	NSH_NextProto_MPLS     = 0x5
+)
+
+// nshEthertypes maps the NSH next protocols to the ethertype decoding them.
+var nshEthertypes = map[uint8]uint16{
+	NSH_NextProto_IPv4:     IPv4_MSG,
+	NSH_NextProto_IPv6:     IPv6_MSG,
+	NSH_NextProto_Ethernet: TEB_MSG,
+	NSH_NextProto_NSH:      NSH_MSG,
+	NSH_NextProto_MPLS:     MPLS_MSG,
+}
+
+// NSHTLV is a context header of NSH MD type 2.
+type NSHTLV struct {
+	Class uint16
+	// Type includes the critical bit in its most significant bit.
+	Type     uint8
+	Reserved uint8 // 1-bit
+	// Data is up to 127 bytes, padded to a multiple of 4 bytes when marshaled.
+	Data []byte
+}
+
+func (t *NSHTLV) Len() (n uint16) {
+	return uint16(4 + (len(t.Data)+3)/4*4)
+}
+
+func (t *NSHTLV) MarshalBinary() (data []byte, err error) {
+	if len(t.Data) > 127 {
+		return nil, fmt.Errorf("NSH TLV too long: %d bytes", len(t.Data))
+	}
+	data = make([]byte, t.Len())
+	binary.BigEndian.PutUint16(data[:2], t.Class)
+	data[2] = t.Type
+	data[3] = t.Reserved<<7 | uint8(len(t.Data))
+	copy(data[4:], t.Data)
+	return
+}
+
+func (t *NSHTLV) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full NSH TLV.")
+	}
+	t.Class = binary.BigEndian.Uint16(data[:2])
+	t.Type = data[2]
+	t.Reserved = data[3] >> 7
+	length := int(data[3] & 0x7f)
+	if len(data) < 4+length {
+		return errors.New("The []byte is too short to unmarshal a full NSH TLV.")
+	}
+	t.Data = make([]byte, length)
+	copy(t.Data, data[4:4+length])
+	return nil
+}
+
+// NSH is the Network Service Header of RFC 8300. The metadata is Context for
+// MD type 1, TLVs for MD type 2 and Metadata for other types. The length is
+// set from the metadata when marshaling.
+type NSH struct {
+	Version   uint8 // 2-bits
+	OAM       bool
+	Reserved0 uint8 // 1-bit
+	TTL       uint8 // 6-bits
+	Length    uint8 // 6-bits, in 4-byte words.
+	Reserved1 uint8 // 4-bits
+	MDType    uint8 // 4-bits
+	// NextProtocol is the NSH_NextProto_* type of Data.
+	NextProtocol uint8
+	SPI          uint32 // 24-bits
+	SI           uint8
+	Context      [4]uint32
+	TLVs         []*NSHTLV
+	Metadata     []byte
+	Data         util.Message
+}
+
+func NewNSH(spi uint32, si uint8) *NSH {
+	n := new(NSH)
+	n.TTL = 63
+	n.MDType = NSH_MDType1
+	n.SPI = spi
+	n.SI = si
+	return n
+}
+
+func (n *NSH) headerLen() int {
+	switch n.MDType {
+	case NSH_MDType1:
+		return 24
+	case NSH_MDType2:
+		l := 8
+		for _, t := range n.TLVs {
+			l += int(t.Len())
+		}
+		return l
+	}
+	return 8 + (len(n.Metadata)+3)/4*4
+}
+
+func (n *NSH) Len() uint16 {
+	return uint16(n.headerLen()) + innerLen(n.Data)
+}
+
+func (n *NSH) MarshalBinary() (data []byte, err error) {
+	hdrLen := n.headerLen()
+	if hdrLen > 0x3f*4 {
+		return nil, fmt.Errorf("NSH metadata too long: %d bytes", hdrLen-8)
+	}
+	n.Length = uint8(hdrLen / 4)
+
+	header := make([]byte, 8, n.Len())
+	word := uint16(n.Version&0x3)<<14 | uint16(n.Reserved0&0x1)<<12 | uint16(n.TTL&0x3f)<<6 | uint16(n.Length)
+	if n.OAM {
+		word |= 1 << 13
+	}
+	binary.BigEndian.PutUint16(header[0:2], word)
+	header[2] = n.Reserved1<<4 | n.MDType&0xf
+	header[3] = n.NextProtocol
+	binary.BigEndian.PutUint32(header[4:8], n.SPI<<8|uint32(n.SI))
+
+	switch n.MDType {
+	case NSH_MDType1:
+		for _, c := range n.Context {
+			header = binary.BigEndian.AppendUint32(header, c)
+		}
+	case NSH_MDType2:
+		for _, t := range n.TLVs {
+			var bytes []byte
+			if bytes, err = t.MarshalBinary(); err != nil {
+				return nil, err
+			}
+			header = append(header, bytes...)
+		}
+	default:
+		header = append(header, n.Metadata...)
+		header = header[:hdrLen]
+	}
+	return marshalInner(header, n.Data)
+}
+
+func (n *NSH) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full NSH message.")
+	}
+	word := binary.BigEndian.Uint16(data[0:2])
+	n.Version = uint8(word >> 14)
+	n.OAM = word&(1<<13) != 0
+	n.Reserved0 = uint8(word>>12) & 0x1
+	n.TTL = uint8(word>>6) & 0x3f
+	n.Length = uint8(word) & 0x3f
+	n.Reserved1 = data[2] >> 4
+	n.MDType = data[2] & 0xf
+	n.NextProtocol = data[3]
+	spi := binary.BigEndian.Uint32(data[4:8])
+	n.SPI = spi >> 8
+	n.SI = uint8(spi)
+
+	hdrLen := int(n.Length) * 4
+	if hdrLen < 8 || len(data) < hdrLen {
+		return fmt.Errorf("invalid NSH length %d", hdrLen)
+	}
+	n.Context = [4]uint32{}
+	n.TLVs = nil
+	n.Metadata = nil
+	switch n.MDType {
+	case NSH_MDType1:
+		if hdrLen != 24 {
+			return fmt.Errorf("invalid NSH MD type 1 length %d", hdrLen)
+		}
+		for i := range n.Context {
+			n.Context[i] = binary.BigEndian.Uint32(data[8+4*i:])
+		}
+	case NSH_MDType2:
+		for i := 8; i < hdrLen; {
+			t := new(NSHTLV)
+			if err := t.UnmarshalBinary(data[i:hdrLen]); err != nil {
+				return err
+			}
+			if i+int(t.Len()) > hdrLen {
+				return errors.New("The []byte is too short to unmarshal a full NSH TLV.")
+			}
+			n.TLVs = append(n.TLVs, t)
+			i += int(t.Len())
+		}
+	default:
+		n.Metadata = make([]byte, hdrLen-8)
+		copy(n.Metadata, data[8:hdrLen])
+	}
+
+	if ethertype, ok := nshEthertypes[n.NextProtocol]; ok {
+		n.Data = decodeInner(ethertype, data[hdrLen:])
+	} else {
+		n.Data = new(util.Buffer)
+		n.Data.UnmarshalBinary(data[hdrLen:])
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func TestNSH(t *testing.T) {
+	md1 := NewNSH(0x123456, 255)
+	md1.NextProtocol = NSH_NextProto_Ethernet
+	md1.Context = [4]uint32{1, 2, 3, 4}
+	md1.Data = newTestInnerFrame()
+
+	md2 := NewNSH(10, 254)
+	md2.OAM = true
+	md2.MDType = NSH_MDType2
+	md2.NextProtocol = NSH_NextProto_IPv4
+	md2.TLVs = []*NSHTLV{
+		{Class: 0x0101, Type: 0x80, Data: []byte{1, 2, 3, 4, 5}},
+		{Class: 0xffff, Type: 0x01},
+	}
+	md2.Data = newTestInnerFrame().Data
+
+	other := NewNSH(1, 1)
+	other.MDType = 0xf
+	other.NextProtocol = 0xfe
+	other.Metadata = []byte{1, 2, 3, 4}
+	other.Data = util.NewBuffer([]byte{0xde, 0xad})
+
+	for _, tc := range []struct {
+		name  string
+		nsh   *NSH
+		len   uint8
+		inner util.Message
+	}{
+		{"MD type 1", md1, 6, new(Ethernet)},
+		{"MD type 2", md2, 2 + 3 + 1, new(IPv4)},
+		{"experimental MD type", other, 3, new(util.Buffer)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			eth := NewEthernet()
+			eth.Ethertype = NSH_MSG
+			eth.Data = tc.nsh
+			data, err := Serialize(eth, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+			require.NoError(t, err)
+			assert.Equal(t, tc.len, tc.nsh.Length)
+
+			decoded := new(Ethernet)
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			require.IsType(t, new(NSH), decoded.Data)
+			nsh := decoded.Data.(*NSH)
+			assert.Equal(t, tc.nsh.SPI, nsh.SPI)
+			assert.Equal(t, tc.nsh.SI, nsh.SI)
+			assert.Equal(t, tc.nsh.OAM, nsh.OAM)
+			assert.Equal(t, uint8(63), nsh.TTL)
+			assert.Equal(t, tc.nsh.Context, nsh.Context)
+			assert.Equal(t, tc.nsh.Metadata, nsh.Metadata)
+			assert.IsType(t, tc.inner, nsh.Data)
+			assert.NoError(t, VerifyChecksums(decoded))
+			b, err := decoded.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, data, b)
+		})
+	}
+
+	data, err := md1.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x0f, 0xc6, 0x01, 0x03, 0x12, 0x34, 0x56, 0xff}, data[:8])
+}
+
+func TestNSHInvalid(t *testing.T) {
+	nsh := NewNSH(1, 1)
+	data, err := nsh.MarshalBinary()
+	require.NoError(t, err)
+	assert.Error(t, new(NSH).UnmarshalBinary(data[:20]))
+
+	// MD type 1 is always 6 words long.
+	data[1] = 0xc5
+	assert.Error(t, new(NSH).UnmarshalBinary(data))
+
+	// A TLV beyond the header length.
+	nsh.MDType = NSH_MDType2
+	nsh.TLVs = []*NSHTLV{{Class: 1, Type: 1, Data: make([]byte, 8)}}
+	data, err = nsh.MarshalBinary()
+	require.NoError(t, err)
+	data[11] = 9
+	assert.Error(t, new(NSH).UnmarshalBinary(data))
+
+	nsh.TLVs[0].Data = make([]byte, 128)
+	_, err = nsh.MarshalBinary()
+	assert.Error(t, err)
+}
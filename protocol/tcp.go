@@ -3,6 +3,34 @@ package protocol
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// TCP flags, as returned by TCP.Flags. NS is stored in the lowest bit of
+// Reserved, the others in Code.
+const (
+	TCP_FIN = 1 << 0
+	TCP_SYN = 1 << 1
+	TCP_RST = 1 << 2
+	TCP_PSH = 1 << 3
+	TCP_ACK = 1 << 4
+	TCP_URG = 1 << 5
+	TCP_ECE = 1 << 6
+	TCP_CWR = 1 << 7
+	TCP_NS  = 1 << 8
+)
+
+// TCP option kinds.
+const (
+	TCPOptionKindEnd           = 0
+	TCPOptionKindNOP           = 1
+	TCPOptionKindMSS           = 2
+	TCPOptionKindWindowScale   = 3
+	TCPOptionKindSACKPermitted = 4
+	TCPOptionKindSACK          = 5
+	TCPOptionKindTimestamps    = 8
 )
 
 type TCP struct {
@@ -11,51 +39,111 @@ type TCP struct {
 	SeqNum  uint32
 	AckNum  uint32
 
-	HdrLen uint8
-	Code   uint8
+	// HdrLen is the header length in 4-byte words, 4-bits. The header is
+	// marshaled with the options padded to HdrLen words, or to the length of
+	// the options if they do not fit. Serialize with FixLengths sets it from
+	// the options.
+	HdrLen   uint8
+	Reserved uint8 // 4-bits, the lowest one is the NS flag.
+	Code     uint8
 
 	WinSize  uint16
 	Checksum uint16
 	UrgFlag  uint16
 
+	// Options are TCPOption* values. They are padded to a multiple of 4 bytes
+	// with end of option list bytes.
+	Options []util.Message
+
 	Data []byte
+	// Payload is Data decoded according to the ports, such as the STT frame
+	// header or a DNS message, or nil. When set, it is the only source of the
+	// segment data: it is marshaled instead of Data, which keeps the received
+	// bytes, so clear it to send a modified Data.
+	Payload util.Message
 }
 
 func NewTCP() *TCP {
 	u := new(TCP)
+	u.HdrLen = 5
 	u.Data = make([]byte, 0)
 	return u
 }
 
+// optionsHeaderLen returns the length of the TCP header with its options
+// padded to a multiple of 4 bytes.
+func (t *TCP) optionsHeaderLen() int {
+	n := 0
+	for _, o := range t.Options {
+		n += int(o.Len())
+	}
+	return 20 + (n+3)/4*4
+}
+
+// headerLen returns the length of the marshaled TCP header, with its options
+// padded to HdrLen words.
+func (t *TCP) headerLen() int {
+	if n := int(t.HdrLen) * 4; n > t.optionsHeaderLen() {
+		return n
+	}
+	return t.optionsHeaderLen()
+}
+
 func (t *TCP) Len() (n uint16) {
-	if t.Data != nil {
-		return uint16(20 + len(t.Data))
+	if t.Payload != nil {
+		return uint16(t.headerLen()) + t.Payload.Len()
 	}
-	return uint16(20)
+	return uint16(t.headerLen() + len(t.Data))
 }
 
 func (t *TCP) MarshalBinary() (data []byte, err error) {
+	hdrLen := t.headerLen()
+	if hdrLen > 60 {
+		return nil, fmt.Errorf("TCP header too long: %d bytes", hdrLen)
+	}
+
 	data = make([]byte, int(t.Len()))
 	binary.BigEndian.PutUint16(data[:2], t.PortSrc)
 	binary.BigEndian.PutUint16(data[2:4], t.PortDst)
 	binary.BigEndian.PutUint32(data[4:8], t.SeqNum)
 	binary.BigEndian.PutUint32(data[8:12], t.AckNum)
 
-	data[12] = (t.HdrLen << 4) & 0xf0
-	data[13] = t.Code & 0x3f
+	data[12] = uint8(hdrLen/4)<<4 | t.Reserved&0x0f
+	data[13] = t.Code
 
 	binary.BigEndian.PutUint16(data[14:16], t.WinSize)
 	binary.BigEndian.PutUint16(data[16:18], t.Checksum)
 	binary.BigEndian.PutUint16(data[18:20], t.UrgFlag)
 
-	copy(data[20:], t.Data)
+	n := 20
+	for _, o := range t.Options {
+		b, err := o.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		copy(data[n:], b)
+		n += len(b)
+	}
+
+	if t.Payload != nil {
+		b, err := t.Payload.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != len(data)-hdrLen {
+			return nil, fmt.Errorf("TCP payload of %d bytes, expecting %d", len(b), len(data)-hdrLen)
+		}
+		copy(data[hdrLen:], b)
+		return data, nil
+	}
+	copy(data[hdrLen:], t.Data)
 
 	return
 }
 
 func (t *TCP) UnmarshalBinary(data []byte) error {
 	if len(data) < 20 {
-		return errors.New("The []byte is too short to unmarshal a full ARP message.")
+		return errors.New("The []byte is too short to unmarshal a full TCP message.")
 	}
 	t.PortSrc = binary.BigEndian.Uint16(data[:2])
 	t.PortDst = binary.BigEndian.Uint16(data[2:4])
@@ -63,17 +151,308 @@ func (t *TCP) UnmarshalBinary(data []byte) error {
 	t.AckNum = binary.BigEndian.Uint32(data[8:12])
 
 	t.HdrLen = (data[12] >> 4) & 0xf
-	t.Code = data[13] & 0x3f
+	t.Reserved = data[12] & 0xf
+	t.Code = data[13]
 
 	t.WinSize = binary.BigEndian.Uint16(data[14:16])
 	t.Checksum = binary.BigEndian.Uint16(data[16:18])
 	t.UrgFlag = binary.BigEndian.Uint16(data[18:20])
 
-	if len(data) > 20 {
-		t.Data = make([]byte, (len(data) - 20))
-		copy(t.Data, data[20:])
+	hdrLen := int(t.HdrLen) * 4
+	if hdrLen < 20 || hdrLen > len(data) {
+		return fmt.Errorf("invalid TCP header length %d", hdrLen)
 	}
+	options, err := decodeTCPOptions(data[20:hdrLen])
+	if err != nil {
+		return err
+	}
+	t.Options = options
+
+	t.Data = make([]byte, len(data)-hdrLen)
+	copy(t.Data, data[hdrLen:])
 
+	t.Payload = nil
+	switch {
+	case t.PortDst == TCPPort_STT:
+		// STT uses the sequence number as the frame length and fragment
+		// offset, only a whole frame in a single segment can be decoded.
+		if t.SeqNum&0xffff == 0 && int(t.SeqNum>>16) == len(t.Data) {
+			t.Payload = new(STT)
+		}
+	case t.PortSrc == Port_DNS || t.PortDst == Port_DNS:
+		// Only a segment holding a whole message can be decoded.
+		if len(t.Data) > 0 {
+			t.Payload = new(DNSOverTCP)
+		}
+	}
+	if t.Payload != nil && t.Payload.UnmarshalBinary(t.Data) != nil {
+		t.Payload = nil
+	}
 	return nil
+}
 
+// Flags returns the TCP_* flags of t.
+func (t *TCP) Flags() uint16 {
+	return uint16(t.Reserved&0x1)<<8 | uint16(t.Code)
+}
+
+// SetFlags sets the TCP_* flags of t.
+func (t *TCP) SetFlags(flags uint16) {
+	t.Code = uint8(flags)
+	t.Reserved = t.Reserved&^0x1 | uint8(flags>>8)&0x1
+}
+
+func (t *TCP) FIN() bool { return t.Flags()&TCP_FIN != 0 }
+func (t *TCP) SYN() bool { return t.Flags()&TCP_SYN != 0 }
+func (t *TCP) RST() bool { return t.Flags()&TCP_RST != 0 }
+func (t *TCP) PSH() bool { return t.Flags()&TCP_PSH != 0 }
+func (t *TCP) ACK() bool { return t.Flags()&TCP_ACK != 0 }
+func (t *TCP) URG() bool { return t.Flags()&TCP_URG != 0 }
+func (t *TCP) ECE() bool { return t.Flags()&TCP_ECE != 0 }
+func (t *TCP) CWR() bool { return t.Flags()&TCP_CWR != 0 }
+func (t *TCP) NS() bool  { return t.Flags()&TCP_NS != 0 }
+
+// MSS returns the value of the maximum segment size option, if any.
+func (t *TCP) MSS() (uint16, bool) {
+	for _, o := range t.Options {
+		if mss, ok := o.(*TCPOptionMSS); ok {
+			return mss.MSS, true
+		}
+	}
+	return 0, false
+}
+
+// WindowScale returns the shift count of the window scale option, if any.
+func (t *TCP) WindowScale() (uint8, bool) {
+	for _, o := range t.Options {
+		if ws, ok := o.(*TCPOptionWindowScale); ok {
+			return ws.Shift, true
+		}
+	}
+	return 0, false
+}
+
+// AddOption appends an option to t.
+func (t *TCP) AddOption(option util.Message) {
+	t.Options = append(t.Options, option)
+}
+
+func decodeTCPOptions(data []byte) ([]util.Message, error) {
+	var options []util.Message
+	n := 0
+	for n < len(data) {
+		kind := data[n]
+		if kind == TCPOptionKindEnd {
+			// The rest is padding.
+			break
+		}
+		if kind == TCPOptionKindNOP {
+			options = append(options, new(TCPOptionNOP))
+			n += 1
+			continue
+		}
+		if n+2 > len(data) || int(data[n+1]) < 2 || n+int(data[n+1]) > len(data) {
+			return nil, fmt.Errorf("invalid TCP option %d", kind)
+		}
+		length := int(data[n+1])
+		var option util.Message
+		switch {
+		case kind == TCPOptionKindMSS && length == 4:
+			option = new(TCPOptionMSS)
+		case kind == TCPOptionKindWindowScale && length == 3:
+			option = new(TCPOptionWindowScale)
+		case kind == TCPOptionKindSACKPermitted && length == 2:
+			option = new(TCPOptionSACKPermitted)
+		case kind == TCPOptionKindSACK && (length-2)%8 == 0:
+			option = new(TCPOptionSACK)
+		case kind == TCPOptionKindTimestamps && length == 10:
+			option = new(TCPOptionTimestamps)
+		default:
+			option = new(TCPOptionRaw)
+		}
+		if err := option.UnmarshalBinary(data[n : n+length]); err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+		n += length
+	}
+	return options, nil
+}
+
+// TCPOptionNOP is a no-operation option, used to align the next option.
+type TCPOptionNOP struct{}
+
+func (o *TCPOptionNOP) Len() uint16 {
+	return 1
+}
+
+func (o *TCPOptionNOP) MarshalBinary() (data []byte, err error) {
+	return []byte{TCPOptionKindNOP}, nil
+}
+
+func (o *TCPOptionNOP) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return errors.New("The []byte is too short to unmarshal a full TCPOptionNOP message.")
+	}
+	return nil
+}
+
+// TCPOptionMSS is the maximum segment size option.
+type TCPOptionMSS struct {
+	MSS uint16
+}
+
+func (o *TCPOptionMSS) Len() uint16 {
+	return 4
+}
+
+func (o *TCPOptionMSS) MarshalBinary() (data []byte, err error) {
+	data = []byte{TCPOptionKindMSS, 4, 0, 0}
+	binary.BigEndian.PutUint16(data[2:], o.MSS)
+	return
+}
+
+func (o *TCPOptionMSS) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full TCPOptionMSS message.")
+	}
+	o.MSS = binary.BigEndian.Uint16(data[2:])
+	return nil
+}
+
+// TCPOptionWindowScale is the window scale option of RFC 7323.
+type TCPOptionWindowScale struct {
+	Shift uint8
+}
+
+func (o *TCPOptionWindowScale) Len() uint16 {
+	return 3
+}
+
+func (o *TCPOptionWindowScale) MarshalBinary() (data []byte, err error) {
+	return []byte{TCPOptionKindWindowScale, 3, o.Shift}, nil
+}
+
+func (o *TCPOptionWindowScale) UnmarshalBinary(data []byte) error {
+	if len(data) < 3 {
+		return errors.New("The []byte is too short to unmarshal a full TCPOptionWindowScale message.")
+	}
+	o.Shift = data[2]
+	return nil
+}
+
+// TCPOptionSACKPermitted is the SACK-permitted option of RFC 2018.
+type TCPOptionSACKPermitted struct{}
+
+func (o *TCPOptionSACKPermitted) Len() uint16 {
+	return 2
+}
+
+func (o *TCPOptionSACKPermitted) MarshalBinary() (data []byte, err error) {
+	return []byte{TCPOptionKindSACKPermitted, 2}, nil
+}
+
+func (o *TCPOptionSACKPermitted) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errors.New("The []byte is too short to unmarshal a full TCPOptionSACKPermitted message.")
+	}
+	return nil
+}
+
+// TCPSACKBlock is a block of data received by the sender of a SACK option.
+type TCPSACKBlock struct {
+	LeftEdge  uint32
+	RightEdge uint32
+}
+
+// TCPOptionSACK is the selective acknowledgment option of RFC 2018.
+type TCPOptionSACK struct {
+	Blocks []TCPSACKBlock
+}
+
+func (o *TCPOptionSACK) Len() uint16 {
+	return uint16(2 + 8*len(o.Blocks))
+}
+
+func (o *TCPOptionSACK) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(o.Len()))
+	data[0] = TCPOptionKindSACK
+	data[1] = uint8(o.Len())
+	n := 2
+	for _, b := range o.Blocks {
+		binary.BigEndian.PutUint32(data[n:], b.LeftEdge)
+		binary.BigEndian.PutUint32(data[n+4:], b.RightEdge)
+		n += 8
+	}
+	return
+}
+
+func (o *TCPOptionSACK) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || len(data) < int(data[1]) {
+		return errors.New("The []byte is too short to unmarshal a full TCPOptionSACK message.")
+	}
+	o.Blocks = nil
+	for n := 2; n+8 <= int(data[1]); n += 8 {
+		o.Blocks = append(o.Blocks, TCPSACKBlock{
+			LeftEdge:  binary.BigEndian.Uint32(data[n:]),
+			RightEdge: binary.BigEndian.Uint32(data[n+4:]),
+		})
+	}
+	return nil
+}
+
+// TCPOptionTimestamps is the timestamps option of RFC 7323.
+type TCPOptionTimestamps struct {
+	Value     uint32
+	EchoReply uint32
+}
+
+func (o *TCPOptionTimestamps) Len() uint16 {
+	return 10
+}
+
+func (o *TCPOptionTimestamps) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(o.Len()))
+	data[0] = TCPOptionKindTimestamps
+	data[1] = 10
+	binary.BigEndian.PutUint32(data[2:], o.Value)
+	binary.BigEndian.PutUint32(data[6:], o.EchoReply)
+	return
+}
+
+func (o *TCPOptionTimestamps) UnmarshalBinary(data []byte) error {
+	if len(data) < 10 {
+		return errors.New("The []byte is too short to unmarshal a full TCPOptionTimestamps message.")
+	}
+	o.Value = binary.BigEndian.Uint32(data[2:])
+	o.EchoReply = binary.BigEndian.Uint32(data[6:])
+	return nil
+}
+
+// TCPOptionRaw is an option of another kind, or with an unexpected length.
+type TCPOptionRaw struct {
+	Kind uint8
+	Data []byte
+}
+
+func (o *TCPOptionRaw) Len() uint16 {
+	return uint16(2 + len(o.Data))
+}
+
+func (o *TCPOptionRaw) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(o.Len()))
+	data[0] = o.Kind
+	data[1] = uint8(o.Len())
+	copy(data[2:], o.Data)
+	return
+}
+
+func (o *TCPOptionRaw) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || int(data[1]) < 2 || len(data) < int(data[1]) {
+		return errors.New("The []byte is too short to unmarshal a full TCPOptionRaw message.")
+	}
+	o.Kind = data[0]
+	o.Data = make([]byte, int(data[1])-2)
+	copy(o.Data, data[2:])
+	return nil
 }
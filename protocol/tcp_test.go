@@ -0,0 +1,136 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func TestTCPOptions(t *testing.T) {
+	syn := NewTCP()
+	syn.PortSrc = 34567
+	syn.PortDst = 443
+	syn.SetFlags(TCP_SYN | TCP_ECE | TCP_CWR | TCP_NS)
+	syn.AddOption(&TCPOptionMSS{MSS: 1460})
+	syn.AddOption(new(TCPOptionSACKPermitted))
+	syn.AddOption(&TCPOptionTimestamps{Value: 100, EchoReply: 0})
+	syn.AddOption(new(TCPOptionNOP))
+	syn.AddOption(&TCPOptionWindowScale{Shift: 7})
+	syn.AddOption(&TCPOptionSACK{Blocks: []TCPSACKBlock{{LeftEdge: 1, RightEdge: 2}}})
+	syn.AddOption(&TCPOptionRaw{Kind: 30, Data: []byte{0x01}})
+	syn.Data = []byte("data")
+
+	// 4 + 2 + 10 + 1 + 3 + 10 + 3 bytes of options, padded to 36.
+	assert.Equal(t, uint16(20+36+4), syn.Len())
+	data, err := syn.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0xe1), data[12])
+	assert.Equal(t, uint8(0xc2), data[13])
+
+	decoded := new(TCP)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, syn.Options, decoded.Options)
+	assert.Equal(t, []byte("data"), decoded.Data)
+	assert.True(t, decoded.SYN())
+	assert.True(t, decoded.ECE())
+	assert.True(t, decoded.CWR())
+	assert.True(t, decoded.NS())
+	assert.False(t, decoded.ACK())
+	assert.False(t, decoded.FIN())
+	assert.False(t, decoded.RST())
+	assert.False(t, decoded.PSH())
+	assert.False(t, decoded.URG())
+	mss, ok := decoded.MSS()
+	assert.True(t, ok)
+	assert.Equal(t, uint16(1460), mss)
+	ws, ok := decoded.WindowScale()
+	assert.True(t, ok)
+	assert.Equal(t, uint8(7), ws)
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	rst := NewTCP()
+	rst.SetFlags(TCP_RST | TCP_ACK)
+	data, err = rst.MarshalBinary()
+	require.NoError(t, err)
+	assert.Len(t, data, 20)
+	decoded = new(TCP)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, uint16(TCP_RST|TCP_ACK), decoded.Flags())
+	assert.Empty(t, decoded.Options)
+	_, ok = decoded.MSS()
+	assert.False(t, ok)
+}
+
+func TestTCPHeaderLength(t *testing.T) {
+	// A header padded beyond its options is kept.
+	tcp := NewTCP()
+	tcp.HdrLen = 7
+	tcp.AddOption(&TCPOptionMSS{MSS: 1460})
+	tcp.Data = []byte("data")
+	assert.Equal(t, uint16(28+4), tcp.Len())
+	data, err := tcp.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0x70), data[12])
+	assert.Equal(t, make([]byte, 4), data[24:28])
+	decoded := new(TCP)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, uint8(7), decoded.HdrLen)
+	assert.Equal(t, tcp.Options, decoded.Options)
+	b, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	// Options which do not fit in HdrLen extend the header, without
+	// changing HdrLen.
+	tcp.HdrLen = 5
+	data, err = tcp.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0x60), data[12])
+	assert.Equal(t, uint8(5), tcp.HdrLen)
+
+	// Serialize sets HdrLen from the options.
+	tcp.HdrLen = 7
+	_, err = Serialize(tcp, SerializeOptions{FixLengths: true})
+	require.NoError(t, err)
+	assert.Equal(t, uint8(6), tcp.HdrLen)
+
+	tcp.HdrLen = 16
+	_, err = tcp.MarshalBinary()
+	assert.Error(t, err)
+}
+
+func TestTCPOptionsInvalid(t *testing.T) {
+	tcp := NewTCP()
+	tcp.AddOption(&TCPOptionMSS{MSS: 1460})
+	data, err := tcp.MarshalBinary()
+	require.NoError(t, err)
+
+	// Option length beyond the header.
+	bad := append([]byte(nil), data...)
+	bad[21] = 8
+	assert.Error(t, new(TCP).UnmarshalBinary(bad))
+
+	// Header length beyond the segment.
+	bad = append([]byte(nil), data...)
+	bad[12] = 0xf0
+	assert.Error(t, new(TCP).UnmarshalBinary(bad))
+
+	// Options must fit in 40 bytes.
+	tcp.AddOption(&TCPOptionRaw{Kind: 30, Data: make([]byte, 40)})
+	_, err = tcp.MarshalBinary()
+	assert.Error(t, err)
+
+	// An option with an unexpected length is kept raw.
+	tcp = NewTCP()
+	tcp.AddOption(&TCPOptionRaw{Kind: TCPOptionKindMSS, Data: []byte{0x05}})
+	data, err = tcp.MarshalBinary()
+	require.NoError(t, err)
+	decoded := new(TCP)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, []util.Message{&TCPOptionRaw{Kind: TCPOptionKindMSS, Data: []byte{0x05}}}, decoded.Options)
+}
@@ -0,0 +1,422 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"antrea.io/libOpenflow/util"
+)
+
+// Well-known ports of the tunnel protocols, used to decode the payload of UDP
+// and TCP.
+const (
+	UDPPort_VXLAN  = 4789
+	UDPPort_Geneve = 6081
+	TCPPort_STT    = 7471
+)
+
+// decodeInner decodes the payload of a tunnel header, keeping it raw if it
+// turns out not to be a valid ethertype payload.
+func decodeInner(ethertype uint16, data []byte) util.Message {
+	inner := newByEthertype(ethertype)
+	if inner.UnmarshalBinary(data) != nil {
+		inner = new(util.Buffer)
+		inner.UnmarshalBinary(data)
+	}
+	return inner
+}
+
+func marshalInner(header []byte, inner util.Message) (data []byte, err error) {
+	if inner == nil {
+		return header, nil
+	}
+	bytes, err := inner.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(header, bytes...), nil
+}
+
+func innerLen(inner util.Message) uint16 {
+	if inner == nil {
+		return 0
+	}
+	return inner.Len()
+}
+
+// VXLAN flags, the G flag announcing the group based policy extension.
+const (
+	VXLAN_Flag_VNI = 0x08
+	VXLAN_Flag_GBP = 0x80
+)
+
+// VXLAN group based policy flags, matched by NXM_NX_TUN_GBP_FLAGS.
+const (
+	VXLAN_GBP_DontLearn     = 0x40
+	VXLAN_GBP_PolicyApplied = 0x08
+)
+
+// VXLAN is the header of RFC 7348, with the group based policy extension
+// which uses the otherwise reserved fields.
+type VXLAN struct {
+	Flags uint8
+	// GBPFlags and GBPID are the group based policy flags and ID, matched by
+	// NXM_NX_TUN_GBP_FLAGS and NXM_NX_TUN_GBP_ID. They are reserved fields
+	// unless Flags has VXLAN_Flag_GBP.
+	GBPFlags uint8
+	GBPID    uint16
+	VNI      uint32 // 24-bits
+	Reserved uint8
+	Data     util.Message
+}
+
+func NewVXLAN(vni uint32) *VXLAN {
+	v := new(VXLAN)
+	v.Flags = VXLAN_Flag_VNI
+	v.VNI = vni
+	return v
+}
+
+// SetGBP sets the group based policy of the header.
+func (v *VXLAN) SetGBP(id uint16, flags uint8) {
+	v.Flags |= VXLAN_Flag_GBP
+	v.GBPID = id
+	v.GBPFlags = flags
+}
+
+func (v *VXLAN) Len() (n uint16) {
+	return 8 + innerLen(v.Data)
+}
+
+func (v *VXLAN) MarshalBinary() (data []byte, err error) {
+	header := make([]byte, 8, v.Len())
+	header[0] = v.Flags
+	header[1] = v.GBPFlags
+	binary.BigEndian.PutUint16(header[2:4], v.GBPID)
+	binary.BigEndian.PutUint32(header[4:8], v.VNI<<8|uint32(v.Reserved))
+	return marshalInner(header, v.Data)
+}
+
+func (v *VXLAN) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full VXLAN message.")
+	}
+	v.Flags = data[0]
+	v.GBPFlags = data[1]
+	v.GBPID = binary.BigEndian.Uint16(data[2:4])
+	v.VNI = binary.BigEndian.Uint32(data[4:8]) >> 8
+	v.Reserved = data[7]
+	v.Data = decodeInner(TEB_MSG, data[8:])
+	return nil
+}
+
+// GeneveOption is an option TLV of a Geneve header. Class, Type and the length
+// of Data are the OptClass, OptType and OptLength of the TLVTableMap mapping
+// the option to a tun_metadata field.
+type GeneveOption struct {
+	Class uint16
+	Type  uint8
+	Flags uint8 // 3-bits
+	// Data is a multiple of 4 bytes, up to 124.
+	Data []byte
+}
+
+func (o *GeneveOption) Len() (n uint16) {
+	return uint16(4 + len(o.Data))
+}
+
+func (o *GeneveOption) MarshalBinary() (data []byte, err error) {
+	if len(o.Data)%4 != 0 || len(o.Data) > 124 {
+		return nil, fmt.Errorf("invalid Geneve option length %d", len(o.Data))
+	}
+	data = make([]byte, o.Len())
+	binary.BigEndian.PutUint16(data[:2], o.Class)
+	data[2] = o.Type
+	data[3] = o.Flags<<5 | uint8(len(o.Data)/4)
+	copy(data[4:], o.Data)
+	return
+}
+
+func (o *GeneveOption) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full Geneve option.")
+	}
+	o.Class = binary.BigEndian.Uint16(data[:2])
+	o.Type = data[2]
+	o.Flags = data[3] >> 5
+	n := 4 + int(data[3]&0x1f)*4
+	if len(data) < n {
+		return errors.New("The []byte is too short to unmarshal a full Geneve option.")
+	}
+	o.Data = make([]byte, n-4)
+	copy(o.Data, data[4:n])
+	return nil
+}
+
+// Geneve is the header of RFC 8926. The length of the options is set from
+// Options when marshaling.
+type Geneve struct {
+	Version   uint8 // 2-bits
+	OAM       bool
+	Critical  bool
+	Reserved0 uint8 // 6-bits
+	// Protocol is the ethertype of Data, usually TEB_MSG.
+	Protocol  uint16
+	VNI       uint32 // 24-bits
+	Reserved1 uint8
+	Options   []*GeneveOption
+	Data      util.Message
+}
+
+func NewGeneve(vni uint32) *Geneve {
+	g := new(Geneve)
+	g.Protocol = TEB_MSG
+	g.VNI = vni
+	return g
+}
+
+// Option returns the first option with the given class and type, such as the
+// ones of a TLVTableMap, or nil.
+func (g *Geneve) Option(class uint16, optType uint8) *GeneveOption {
+	for _, o := range g.Options {
+		if o.Class == class && o.Type == optType {
+			return o
+		}
+	}
+	return nil
+}
+
+func (g *Geneve) optionsLen() int {
+	n := 0
+	for _, o := range g.Options {
+		n += int(o.Len())
+	}
+	return n
+}
+
+func (g *Geneve) Len() (n uint16) {
+	return uint16(8+g.optionsLen()) + innerLen(g.Data)
+}
+
+func (g *Geneve) MarshalBinary() (data []byte, err error) {
+	optLen := g.optionsLen()
+	if optLen > 252 {
+		return nil, fmt.Errorf("Geneve options too long: %d bytes", optLen)
+	}
+	header := make([]byte, 8, g.Len())
+	header[0] = g.Version<<6 | uint8(optLen/4)
+	if g.OAM {
+		header[1] |= 0x80
+	}
+	if g.Critical {
+		header[1] |= 0x40
+	}
+	header[1] |= g.Reserved0 & 0x3f
+	binary.BigEndian.PutUint16(header[2:4], g.Protocol)
+	binary.BigEndian.PutUint32(header[4:8], g.VNI<<8|uint32(g.Reserved1))
+	for _, o := range g.Options {
+		var bytes []byte
+		if bytes, err = o.MarshalBinary(); err != nil {
+			return nil, err
+		}
+		header = append(header, bytes...)
+	}
+	return marshalInner(header, g.Data)
+}
+
+func (g *Geneve) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full Geneve message.")
+	}
+	g.Version = data[0] >> 6
+	g.OAM = data[1]&0x80 != 0
+	g.Critical = data[1]&0x40 != 0
+	g.Reserved0 = data[1] & 0x3f
+	g.Protocol = binary.BigEndian.Uint16(data[2:4])
+	g.VNI = binary.BigEndian.Uint32(data[4:8]) >> 8
+	g.Reserved1 = data[7]
+	end := 8 + int(data[0]&0x3f)*4
+	if len(data) < end {
+		return errors.New("The []byte is too short to unmarshal a full Geneve message.")
+	}
+	g.Options = nil
+	for n := 8; n < end; {
+		o := new(GeneveOption)
+		if err := o.UnmarshalBinary(data[n:end]); err != nil {
+			return err
+		}
+		g.Options = append(g.Options, o)
+		n += int(o.Len())
+	}
+	g.Data = decodeInner(g.Protocol, data[end:])
+	return nil
+}
+
+// GRE is the header of RFC 2784 with the key and sequence number of RFC 2890.
+// The optional fields are present according to their flag.
+type GRE struct {
+	ChecksumPresent bool
+	KeyPresent      bool
+	SeqPresent      bool
+	// Reserved0 holds the other bits before Version, with the flags above
+	// cleared.
+	Reserved0 uint16 // 12-bits
+	Version   uint8  // 3-bits
+	// Protocol is the ethertype of Data.
+	Protocol  uint16
+	Checksum  uint16
+	Reserved1 uint16
+	Key       uint32
+	Seq       uint32
+	Data      util.Message
+}
+
+func NewGRE(protocol uint16) *GRE {
+	g := new(GRE)
+	g.Protocol = protocol
+	return g
+}
+
+func (g *GRE) headerLen() int {
+	n := 4
+	if g.ChecksumPresent {
+		n += 4
+	}
+	if g.KeyPresent {
+		n += 4
+	}
+	if g.SeqPresent {
+		n += 4
+	}
+	return n
+}
+
+func (g *GRE) Len() (n uint16) {
+	return uint16(g.headerLen()) + innerLen(g.Data)
+}
+
+func (g *GRE) MarshalBinary() (data []byte, err error) {
+	header := make([]byte, g.headerLen(), g.Len())
+	flags := (g.Reserved0&0xfff)<<3 | uint16(g.Version&0x7)
+	flags &^= 0xb000
+	if g.ChecksumPresent {
+		flags |= 0x8000
+	}
+	if g.KeyPresent {
+		flags |= 0x2000
+	}
+	if g.SeqPresent {
+		flags |= 0x1000
+	}
+	binary.BigEndian.PutUint16(header[0:2], flags)
+	binary.BigEndian.PutUint16(header[2:4], g.Protocol)
+	n := 4
+	if g.ChecksumPresent {
+		binary.BigEndian.PutUint16(header[n:], g.Checksum)
+		binary.BigEndian.PutUint16(header[n+2:], g.Reserved1)
+		n += 4
+	}
+	if g.KeyPresent {
+		binary.BigEndian.PutUint32(header[n:], g.Key)
+		n += 4
+	}
+	if g.SeqPresent {
+		binary.BigEndian.PutUint32(header[n:], g.Seq)
+	}
+	return marshalInner(header, g.Data)
+}
+
+func (g *GRE) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full GRE message.")
+	}
+	flags := binary.BigEndian.Uint16(data[0:2])
+	g.ChecksumPresent = flags&0x8000 != 0
+	g.KeyPresent = flags&0x2000 != 0
+	g.SeqPresent = flags&0x1000 != 0
+	g.Reserved0 = (flags &^ 0xb000) >> 3
+	g.Version = uint8(flags & 0x7)
+	g.Protocol = binary.BigEndian.Uint16(data[2:4])
+	if len(data) < g.headerLen() {
+		return errors.New("The []byte is too short to unmarshal a full GRE message.")
+	}
+	n := 4
+	g.Checksum, g.Reserved1, g.Key, g.Seq = 0, 0, 0, 0
+	if g.ChecksumPresent {
+		g.Checksum = binary.BigEndian.Uint16(data[n:])
+		g.Reserved1 = binary.BigEndian.Uint16(data[n+2:])
+		n += 4
+	}
+	if g.KeyPresent {
+		g.Key = binary.BigEndian.Uint32(data[n:])
+		n += 4
+	}
+	if g.SeqPresent {
+		g.Seq = binary.BigEndian.Uint32(data[n:])
+		n += 4
+	}
+	g.Data = decodeInner(g.Protocol, data[n:])
+	return nil
+}
+
+// STT frame header flags.
+const (
+	STT_Flag_ChecksumVerified = 0x01
+	STT_Flag_ChecksumPartial  = 0x02
+	STT_Flag_IPv4             = 0x04
+	STT_Flag_TCP              = 0x08
+)
+
+// STT is the frame header of the Stateless Transport Tunneling protocol,
+// carried in TCP to port TCPPort_STT and followed by an Ethernet frame.
+type STT struct {
+	Version   uint8
+	Flags     uint8
+	L4Offset  uint8
+	Reserved  uint8
+	MSS       uint16
+	VLANTCI   uint16 // PCP, V flag and VID of the inner frame.
+	ContextID uint64
+	Padding   uint16
+	Data      util.Message
+}
+
+func NewSTT(contextID uint64) *STT {
+	s := new(STT)
+	s.ContextID = contextID
+	return s
+}
+
+func (s *STT) Len() (n uint16) {
+	return 18 + innerLen(s.Data)
+}
+
+func (s *STT) MarshalBinary() (data []byte, err error) {
+	header := make([]byte, 18, s.Len())
+	header[0] = s.Version
+	header[1] = s.Flags
+	header[2] = s.L4Offset
+	header[3] = s.Reserved
+	binary.BigEndian.PutUint16(header[4:6], s.MSS)
+	binary.BigEndian.PutUint16(header[6:8], s.VLANTCI)
+	binary.BigEndian.PutUint64(header[8:16], s.ContextID)
+	binary.BigEndian.PutUint16(header[16:18], s.Padding)
+	return marshalInner(header, s.Data)
+}
+
+func (s *STT) UnmarshalBinary(data []byte) error {
+	if len(data) < 18 {
+		return errors.New("The []byte is too short to unmarshal a full STT message.")
+	}
+	s.Version = data[0]
+	s.Flags = data[1]
+	s.L4Offset = data[2]
+	s.Reserved = data[3]
+	s.MSS = binary.BigEndian.Uint16(data[4:6])
+	s.VLANTCI = binary.BigEndian.Uint16(data[6:8])
+	s.ContextID = binary.BigEndian.Uint64(data[8:16])
+	s.Padding = binary.BigEndian.Uint16(data[16:18])
+	s.Data = decodeInner(TEB_MSG, data[18:])
+	return nil
+}
@@ -0,0 +1,206 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/util"
+)
+
+func newTestInnerFrame() *Ethernet {
+	ip := newTestIPv4(Type_UDP)
+	ip.Data = newLargeUDP(4)
+	eth := NewEthernet()
+	eth.HWDst, _ = net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	eth.HWSrc, _ = net.ParseMAC("11:22:33:44:55:66")
+	eth.Data = ip
+	return eth
+}
+
+// newTestOuterFrame returns an Ethernet frame carrying payload in IPv4.
+func newTestOuterFrame(proto uint8, payload util.Message) *Ethernet {
+	ip := newTestIPv4(proto)
+	ip.Data = payload
+	eth := NewEthernet()
+	eth.Data = ip
+	return eth
+}
+
+func TestTunnels(t *testing.T) {
+	vxlan := NewVXLAN(5000)
+	vxlan.SetGBP(100, VXLAN_GBP_PolicyApplied)
+	vxlan.Data = newTestInnerFrame()
+	vxlanUDP := NewUDP()
+	vxlanUDP.PortSrc = 50000
+	vxlanUDP.PortDst = UDPPort_VXLAN
+	vxlanUDP.Payload = vxlan
+
+	geneve := NewGeneve(5000)
+	geneve.Critical = true
+	geneve.Options = []*GeneveOption{
+		{Class: 0x0104, Type: 0x80, Data: []byte{0, 0, 0, 1}},
+		{Class: 0xffff, Type: 0x01, Flags: 0x1, Data: make([]byte, 8)},
+	}
+	geneve.Data = newTestInnerFrame()
+	geneveUDP := NewUDP()
+	geneveUDP.PortSrc = 50000
+	geneveUDP.PortDst = UDPPort_Geneve
+	geneveUDP.Payload = geneve
+
+	gre := NewGRE(TEB_MSG)
+	gre.ChecksumPresent = true
+	gre.KeyPresent = true
+	gre.SeqPresent = true
+	gre.Key = 0x1234
+	gre.Seq = 7
+	gre.Data = newTestInnerFrame()
+
+	greIP := NewGRE(IPv4_MSG)
+	greIP.Data = newTestInnerFrame().Data
+
+	stt := NewSTT(0xabcdef)
+	stt.Flags = STT_Flag_ChecksumVerified
+	stt.Data = newTestInnerFrame()
+	sttTCP := NewTCP()
+	sttTCP.PortSrc = 50000
+	sttTCP.PortDst = TCPPort_STT
+	sttTCP.Payload = stt
+
+	for _, tc := range []struct {
+		name  string
+		frame *Ethernet
+		inner func(ip *IPv4) util.Message
+	}{
+		{"VXLAN", newTestOuterFrame(Type_UDP, vxlanUDP), func(ip *IPv4) util.Message {
+			return ip.Data.(*UDP).Payload.(*VXLAN).Data
+		}},
+		{"Geneve", newTestOuterFrame(Type_UDP, geneveUDP), func(ip *IPv4) util.Message {
+			return ip.Data.(*UDP).Payload.(*Geneve).Data
+		}},
+		{"GRE", newTestOuterFrame(Type_GRE, gre), func(ip *IPv4) util.Message {
+			return ip.Data.(*GRE).Data
+		}},
+		{"GRE IPv4", newTestOuterFrame(Type_GRE, greIP), func(ip *IPv4) util.Message {
+			return ip.Data.(*GRE).Data
+		}},
+		{"STT", newTestOuterFrame(Type_TCP, sttTCP), func(ip *IPv4) util.Message {
+			return ip.Data.(*TCP).Payload.(*STT).Data
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Serialize(tc.frame, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+			require.NoError(t, err)
+
+			decoded := new(Ethernet)
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			require.IsType(t, new(IPv4), decoded.Data)
+			inner := tc.inner(decoded.Data.(*IPv4))
+			require.IsType(t, tc.inner(tc.frame.Data.(*IPv4)), inner)
+			expected, err := tc.inner(tc.frame.Data.(*IPv4)).MarshalBinary()
+			require.NoError(t, err)
+			b, err := inner.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, expected, b)
+			assert.NoError(t, VerifyChecksums(decoded))
+			b, err = decoded.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, data, b)
+		})
+	}
+
+	assert.Equal(t, uint32(stt.Len())<<16, sttTCP.SeqNum)
+	assert.Equal(t, geneve.Options[0], geneve.Option(0x0104, 0x80))
+	assert.Nil(t, geneve.Option(0x0104, 0x81))
+
+	frame := newTestOuterFrame(Type_GRE, gre)
+	_, err := Serialize(frame, SerializeOptions{FixLengths: true, ComputeChecksums: true})
+	require.NoError(t, err)
+	gre.Checksum++
+	err = VerifyChecksums(frame)
+	require.IsType(t, new(ChecksumError), err)
+	assert.Same(t, gre, err.(*ChecksumError).Layer)
+}
+
+func TestTunnelPayload(t *testing.T) {
+	vxlan := NewVXLAN(5000)
+	vxlan.Data = newTestInnerFrame()
+	udp := NewUDP()
+	udp.PortDst = UDPPort_VXLAN
+	udp.Payload = vxlan
+	data, err := Serialize(udp, SerializeOptions{FixLengths: true})
+	require.NoError(t, err)
+
+	// The modified Payload is marshaled, Data keeps the received bytes.
+	decodedUDP := new(UDP)
+	require.NoError(t, decodedUDP.UnmarshalBinary(data))
+	decodedUDP.Payload.(*VXLAN).VNI = 5001
+	b, err := decodedUDP.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data[8:], decodedUDP.Data)
+	payload, err := decodedUDP.Payload.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, payload, b[8:])
+	assert.NotEqual(t, data, b)
+
+	stt := NewSTT(0xabcdef)
+	stt.Data = newTestInnerFrame()
+	tcp := NewTCP()
+	tcp.PortDst = TCPPort_STT
+	tcp.Payload = stt
+	data, err = Serialize(tcp, SerializeOptions{FixLengths: true})
+	require.NoError(t, err)
+
+	decodedTCP := new(TCP)
+	require.NoError(t, decodedTCP.UnmarshalBinary(data))
+	decodedTCP.Payload.(*STT).ContextID = 0xabcdf0
+	b, err = decodedTCP.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data[20:], decodedTCP.Data)
+	payload, err = decodedTCP.Payload.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, payload, b[20:])
+	assert.NotEqual(t, data, b)
+}
+
+func TestTunnelsInvalid(t *testing.T) {
+	// Not a VXLAN header, only Data is kept.
+	udp := NewUDP()
+	udp.PortDst = UDPPort_VXLAN
+	udp.Data = []byte{0x08, 0, 0}
+	udp.Length = udp.Len()
+	data, err := udp.MarshalBinary()
+	require.NoError(t, err)
+	decoded := NewUDP()
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Nil(t, decoded.Payload)
+	assert.Equal(t, udp.Data, decoded.Data)
+
+	// An inner frame which does not decode is kept raw.
+	vxlan := NewVXLAN(1)
+	vxlan.Data = util.NewBuffer([]byte{1, 2, 3})
+	data, err = vxlan.MarshalBinary()
+	require.NoError(t, err)
+	decodedVXLAN := new(VXLAN)
+	require.NoError(t, decodedVXLAN.UnmarshalBinary(data))
+	assert.Equal(t, vxlan, decodedVXLAN)
+
+	// Options longer than the header.
+	geneve := NewGeneve(1)
+	geneve.Options = []*GeneveOption{{Class: 1, Type: 1, Data: make([]byte, 4)}}
+	data, err = geneve.MarshalBinary()
+	require.NoError(t, err)
+	data[11] = 2
+	assert.Error(t, new(Geneve).UnmarshalBinary(data))
+	geneve.Options[0].Data = make([]byte, 3)
+	_, err = geneve.MarshalBinary()
+	assert.Error(t, err)
+
+	gre := NewGRE(IPv4_MSG)
+	gre.KeyPresent = true
+	data, err = gre.MarshalBinary()
+	require.NoError(t, err)
+	assert.Error(t, new(GRE).UnmarshalBinary(data[:6]))
+}
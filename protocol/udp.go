@@ -3,6 +3,9 @@ package protocol
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
+
+	"antrea.io/libOpenflow/util"
 )
 
 type UDP struct {
@@ -11,6 +14,11 @@ type UDP struct {
 	Length   uint16
 	Checksum uint16
 	Data     []byte
+	// Payload is Data decoded according to the ports, such as a VXLAN header
+	// or a DNS message, or nil for other ports. When set, it is the only
+	// source of the datagram data: it is marshaled instead of Data, which
+	// keeps the received bytes, so clear it to send a modified Data.
+	Payload util.Message
 }
 
 func NewUDP() *UDP {
@@ -20,6 +28,9 @@ func NewUDP() *UDP {
 }
 
 func (u *UDP) Len() (n uint16) {
+	if u.Payload != nil {
+		return 8 + u.Payload.Len()
+	}
 	if u.Data != nil {
 		return uint16(8 + len(u.Data))
 	}
@@ -32,19 +43,62 @@ func (u *UDP) MarshalBinary() (data []byte, err error) {
 	binary.BigEndian.PutUint16(data[2:4], u.PortDst)
 	binary.BigEndian.PutUint16(data[4:6], u.Length)
 	binary.BigEndian.PutUint16(data[6:8], u.Checksum)
+	if u.Payload != nil {
+		var bytes []byte
+		if bytes, err = u.Payload.MarshalBinary(); err != nil {
+			return nil, err
+		}
+		if len(bytes) != len(data)-8 {
+			return nil, fmt.Errorf("UDP payload of %d bytes, expecting %d", len(bytes), len(data)-8)
+		}
+		copy(data[8:], bytes)
+		return
+	}
 	copy(data[8:], u.Data)
 	return
 }
 
 func (u *UDP) UnmarshalBinary(data []byte) error {
 	if len(data) < 8 {
-		return errors.New("The []byte is too short to unmarshal a full ARP message.")
+		return errors.New("The []byte is too short to unmarshal a full UDP message.")
 	}
 	u.PortSrc = binary.BigEndian.Uint16(data[:2])
 	u.PortDst = binary.BigEndian.Uint16(data[2:4])
 	u.Length = binary.BigEndian.Uint16(data[4:6])
 	u.Checksum = binary.BigEndian.Uint16(data[6:8])
-	u.Data = append(u.Data, data[8:]...)
+	// Leave out the padding after the datagram, but keep the data of a
+	// datagram truncated by the switch, or with an invalid length.
+	end := len(data)
+	if int(u.Length) >= 8 && int(u.Length) < end {
+		end = int(u.Length)
+	}
+	u.Data = append([]byte(nil), data[8:end]...)
+
+	u.Payload = newByUDPPorts(u.PortSrc, u.PortDst, u.Data)
+	if u.Payload != nil && u.Payload.UnmarshalBinary(u.Data) != nil {
+		// Some other protocol using the port, Data is enough.
+		u.Payload = nil
+	}
+	return nil
+}
 
+// newByUDPPorts returns the layer to decode the payload of UDP between the
+// given ports, or nil if they are not known.
+func newByUDPPorts(src, dst uint16, data []byte) util.Message {
+	switch dst {
+	case UDPPort_VXLAN:
+		return new(VXLAN)
+	case UDPPort_Geneve:
+		return new(Geneve)
+	}
+	if src == Port_DNS || dst == Port_DNS {
+		return new(DNS)
+	}
+	if (src == UDPPort_DHCPServer || src == UDPPort_DHCPClient) && (dst == UDPPort_DHCPServer || dst == UDPPort_DHCPClient) {
+		return new(DHCP)
+	}
+	if (src == UDPPort_DHCPv6Server || src == UDPPort_DHCPv6Client) && (dst == UDPPort_DHCPv6Server || dst == UDPPort_DHCPv6Client) && len(data) > 0 {
+		return newDHCPv6ByMessageType(data[0])
+	}
 	return nil
 }
@@ -0,0 +1,254 @@
+// Package reconciler converges the flows, groups and meters of an OpenFlow 1.5
+// switch to a desired State. It reads the current tables with multipart
+// requests, computes the minimal set of changes and applies them, inside a
+// bundle when the switch supports one.
+package reconciler
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/transaction"
+	"antrea.io/libOpenflow/util"
+)
+
+// Switch sends messages to a switch and collects its replies.
+type Switch interface {
+	// RoundTrip sends msgs followed by a barrier request, and returns all
+	// messages the switch sent with the Xid of one of msgs before it answered
+	// the barrier. That includes multipart replies, bundle control replies and
+	// error messages.
+	RoundTrip(msgs ...util.Message) ([]util.Message, error)
+}
+
+// SwitchError is an ofp_error_msg returned by the switch. Apply returns it
+// whether the changes are applied inside a bundle or not.
+type SwitchError struct {
+	// Xid is the Xid of the error message. It is zero for the errors on
+	// bundles, whose Xids are the ones of the bundle messages.
+	Xid  uint32
+	Type uint16
+	Code uint16
+	// Message is the change rejected by the switch, nil if the error is not
+	// about a single change, like a failed bundle commit.
+	Message util.Message
+	// Err is the *transaction.Error of the changes applied inside a bundle.
+	Err error
+}
+
+func (e *SwitchError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("switch returned error for xid %d: type %d, code %d", e.Xid, e.Type, e.Code)
+}
+
+func (e *SwitchError) Unwrap() error {
+	return e.Err
+}
+
+// bundleUnsupported reports whether err means that the switch does not
+// implement OpenFlow 1.5 bundles.
+func bundleUnsupported(err error) bool {
+	var e *transaction.Error
+	if !errors.As(err, &e) || e.Message != nil {
+		return false
+	}
+	return (e.Type == openflow15.ET_BAD_REQUEST && e.Code == openflow15.BRC_BAD_TYPE) ||
+		(e.Type == openflow15.ET_BUNDLE_FAILED && e.Code == openflow15.BFC_BAD_TYPE)
+}
+
+// Reconciler owns the flows, groups and meters of a switch.
+type Reconciler struct {
+	sw Switch
+
+	// Cookie and CookieMask restrict the flows the Reconciler reads from the
+	// switch, and so the flows it is allowed to delete. A zero CookieMask
+	// selects all flows.
+	Cookie     uint64
+	CookieMask uint64
+
+	// UseBundle applies changes inside a bundle, so that the switch commits
+	// all of them or none. Switches without bundle support fall back to plain
+	// messages.
+	UseBundle bool
+	// BundleOptions configures the bundle. With the default Atomic flag,
+	// changes over its MaxMessages or MaxBytes fail with
+	// transaction.ErrTooLarge rather than being split.
+	BundleOptions transaction.Options
+}
+
+func NewReconciler(sw Switch) *Reconciler {
+	r := new(Reconciler)
+	r.sw = sw
+	r.UseBundle = true
+	r.BundleOptions.Flags = transaction.Atomic | transaction.Ordered
+	return r
+}
+
+// ReadState reads the flows, groups and meters installed on the switch.
+func (r *Reconciler) ReadState() (*State, error) {
+	flowReq := openflow15.NewMpRequest(openflow15.MultipartType_FlowDesc)
+	flowStats := openflow15.NewFlowStatsRequest()
+	flowStats.TableId = openflow15.OFPTT_ALL
+	flowStats.Cookie = r.Cookie
+	flowStats.CookieMask = r.CookieMask
+	flowReq.Body = append(flowReq.Body, flowStats)
+
+	groupReq := openflow15.NewMpRequest(openflow15.MultipartType_GroupDesc)
+	groupReq.Body = append(groupReq.Body, openflow15.NewGroupMultipartRequest(openflow15.OFPG_ALL))
+
+	meterReq := openflow15.NewMpRequest(openflow15.MultipartType_MeterDesc)
+	meterReq.Body = append(meterReq.Body, openflow15.NewMeterMultipartRequest(openflow15.M_ALL))
+
+	replies, err := r.sw.RoundTrip(flowReq, groupReq, meterReq)
+	if err != nil {
+		return nil, err
+	}
+	if err := firstError(replies, nil); err != nil {
+		return nil, err
+	}
+
+	s := new(State)
+	for _, msg := range replies {
+		reply, ok := msg.(*openflow15.MultipartReply)
+		if !ok {
+			continue
+		}
+		for _, body := range reply.Body {
+			switch b := body.(type) {
+			case *openflow15.FlowDesc:
+				s.Flows = append(s.Flows, flowFromDesc(b))
+			case *openflow15.GroupDesc:
+				s.Groups = append(s.Groups, groupFromDesc(b))
+			case *openflow15.MeterDesc:
+				s.Meters = append(s.Meters, meterFromDesc(b))
+			}
+		}
+	}
+	return s, nil
+}
+
+// Diff returns the changes needed to converge the switch to desired, without
+// applying them. A non-empty Diff reports drift between desired and the
+// switch.
+func (r *Reconciler) Diff(desired *State) (*Diff, error) {
+	current, err := r.ReadState()
+	if err != nil {
+		return nil, err
+	}
+	return ComputeDiff(desired, current)
+}
+
+// Reconcile converges the switch to desired and returns the changes that were
+// applied.
+func (r *Reconciler) Reconcile(desired *State) (*Diff, error) {
+	d, err := r.Diff(desired)
+	if err != nil {
+		return nil, err
+	}
+	if d.Empty() {
+		return d, nil
+	}
+	if err := r.Apply(d); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+// Apply sends the messages of d to the switch.
+func (r *Reconciler) Apply(d *Diff) error {
+	msgs := d.Messages()
+	if r.UseBundle {
+		err := r.applyBundle(msgs)
+		if !bundleUnsupported(err) {
+			return err
+		}
+		klog.InfoS("Switch does not support bundles, applying changes without bundle")
+	}
+	replies, err := r.sw.RoundTrip(msgs...)
+	if err != nil {
+		return err
+	}
+	return firstError(replies, msgs)
+}
+
+func (r *Reconciler) applyBundle(msgs []util.Message) error {
+	tx, err := transaction.Begin15(r.sw, r.BundleOptions)
+	if err != nil {
+		return bundleError(err)
+	}
+	if err := tx.Add(msgs...); err != nil {
+		if discardErr := tx.Discard(); discardErr != nil {
+			klog.ErrorS(discardErr, "Failed to discard bundle")
+		}
+		return bundleError(err)
+	}
+	return bundleError(tx.Commit())
+}
+
+// bundleError returns the errors of the switch on a bundle as a *SwitchError.
+func bundleError(err error) error {
+	var e *transaction.Error
+	if !errors.As(err, &e) {
+		return err
+	}
+	return &SwitchError{Type: e.Type, Code: e.Code, Message: e.Message, Err: err}
+}
+
+// firstError returns the first error message of replies as a *SwitchError,
+// with the message of msgs it is about.
+func firstError(replies []util.Message, msgs []util.Message) error {
+	for _, reply := range replies {
+		e, ok := reply.(*openflow15.ErrorMsg)
+		if !ok {
+			continue
+		}
+		switchErr := &SwitchError{Xid: e.Header.Xid, Type: e.Type, Code: e.Code}
+		for _, msg := range msgs {
+			if data, err := msg.MarshalBinary(); err == nil && len(data) >= 8 && binary.BigEndian.Uint32(data[4:]) == e.Header.Xid {
+				switchErr.Message = msg
+				break
+			}
+		}
+		return switchErr
+	}
+	return nil
+}
+
+func flowFromDesc(d *openflow15.FlowDesc) *openflow15.FlowMod {
+	f := openflow15.NewFlowMod()
+	f.Cookie = d.Cookie
+	f.TableId = d.TableId
+	f.IdleTimeout = d.IdleTimeout
+	f.HardTimeout = d.HardTimeout
+	f.Priority = d.Priority
+	f.Flags = d.Flags
+	f.Importance = d.Importance
+	f.Match = d.Match
+	f.Instructions = append(f.Instructions, d.Instructions...)
+	return f
+}
+
+func groupFromDesc(d *openflow15.GroupDesc) *openflow15.GroupMod {
+	g := openflow15.NewGroupMod()
+	g.Type = d.Type
+	g.GroupId = d.GroupId
+	for _, b := range d.Buckets {
+		g.AddBucket(b)
+	}
+	g.Properties = append(g.Properties, d.Properties...)
+	return g
+}
+
+func meterFromDesc(d *openflow15.MeterDesc) *openflow15.MeterMod {
+	m := openflow15.NewMeterMod()
+	m.Flags = d.Flags
+	m.MeterId = d.MeterId
+	m.MeterBands = append(m.MeterBands, d.Bands...)
+	return m
+}
@@ -0,0 +1,358 @@
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/transaction"
+	"antrea.io/libOpenflow/util"
+)
+
+// fakeSwitch keeps its tables in memory and exchanges messages in wire format.
+type fakeSwitch struct {
+	noBundle bool
+
+	flows   []*openflow15.FlowMod
+	groups  map[uint32]*openflow15.GroupMod
+	meters  map[uint32]*openflow15.MeterMod
+	bundles map[uint32][]util.Message
+
+	// received counts the FlowMods, GroupMods and MeterMods applied.
+	received int
+}
+
+func newFakeSwitch() *fakeSwitch {
+	return &fakeSwitch{
+		groups:  make(map[uint32]*openflow15.GroupMod),
+		meters:  make(map[uint32]*openflow15.MeterMod),
+		bundles: make(map[uint32][]util.Message),
+	}
+}
+
+func roundTrip(msg util.Message) (util.Message, error) {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return openflow15.Parse(data)
+}
+
+func (s *fakeSwitch) RoundTrip(msgs ...util.Message) ([]util.Message, error) {
+	var replies []util.Message
+	for _, msg := range msgs {
+		parsed, err := roundTrip(msg)
+		if err != nil {
+			return nil, err
+		}
+		reply := s.handle(parsed)
+		if reply == nil {
+			continue
+		}
+		if reply, err = roundTrip(reply); err != nil {
+			return nil, err
+		}
+		replies = append(replies, reply)
+	}
+	return replies, nil
+}
+
+func errorReply(xid uint32, errType, code uint16) util.Message {
+	e := openflow15.NewErrorMsg()
+	e.Header.Xid = xid
+	e.Type = errType
+	e.Code = code
+	return e
+}
+
+func (s *fakeSwitch) handle(msg util.Message) util.Message {
+	switch m := msg.(type) {
+	case *openflow15.MultipartRequest:
+		return s.multipart(m)
+	case *openflow15.BundleCtrl:
+		if s.noBundle {
+			return errorReply(m.Header.Xid, openflow15.ET_BAD_REQUEST, openflow15.BRC_BAD_TYPE)
+		}
+		reply := openflow15.NewBundleCtrl(m.BundleId, m.Type+1, m.Flags)
+		reply.Header.Xid = m.Header.Xid
+		switch m.Type {
+		case openflow15.BCT_OPEN_REQUEST:
+			s.bundles[m.BundleId] = nil
+		case openflow15.BCT_COMMIT_REQUEST:
+			for _, inner := range s.bundles[m.BundleId] {
+				s.apply(inner)
+			}
+			delete(s.bundles, m.BundleId)
+		case openflow15.BCT_DISCARD_REQUEST:
+			delete(s.bundles, m.BundleId)
+		}
+		return reply
+	case *openflow15.BndleAdd:
+		if _, ok := s.bundles[m.BundleId]; !ok {
+			return errorReply(m.Header.Xid, openflow15.ET_BUNDLE_FAILED, openflow15.BFC_BAD_ID)
+		}
+		s.bundles[m.BundleId] = append(s.bundles[m.BundleId], m.Message)
+		return nil
+	default:
+		s.apply(msg)
+		return nil
+	}
+}
+
+func (s *fakeSwitch) apply(msg util.Message) {
+	s.received++
+	switch m := msg.(type) {
+	case *openflow15.FlowMod:
+		key, _ := keyOfFlow(m)
+		for i, f := range s.flows {
+			if k, _ := keyOfFlow(f); k == key {
+				switch m.Command {
+				case openflow15.FC_ADD:
+					s.flows[i] = m
+				case openflow15.FC_MODIFY_STRICT:
+					f.Instructions = m.Instructions
+				case openflow15.FC_DELETE_STRICT:
+					s.flows = append(s.flows[:i], s.flows[i+1:]...)
+				}
+				return
+			}
+		}
+		if m.Command == openflow15.FC_ADD {
+			s.flows = append(s.flows, m)
+		}
+	case *openflow15.GroupMod:
+		if m.Command == openflow15.OFPGC_DELETE {
+			delete(s.groups, m.GroupId)
+		} else {
+			s.groups[m.GroupId] = m
+		}
+	case *openflow15.MeterMod:
+		if m.Command == openflow15.MC_DELETE {
+			delete(s.meters, m.MeterId)
+		} else {
+			s.meters[m.MeterId] = m
+		}
+	}
+}
+
+func (s *fakeSwitch) multipart(req *openflow15.MultipartRequest) util.Message {
+	reply := openflow15.NewMpReply(req.Type)
+	reply.Header.Xid = req.Header.Xid
+	switch req.Type {
+	case openflow15.MultipartType_FlowDesc:
+		for _, f := range s.flows {
+			d := openflow15.NewFlowDesc()
+			d.TableId = f.TableId
+			d.Priority = f.Priority
+			d.IdleTimeout = f.IdleTimeout
+			d.HardTimeout = f.HardTimeout
+			d.Flags = f.Flags
+			d.Importance = f.Importance
+			d.Cookie = f.Cookie
+			d.Match = f.Match
+			d.Instructions = f.Instructions
+			reply.Body = append(reply.Body, d)
+		}
+	case openflow15.MultipartType_GroupDesc:
+		for _, g := range s.groups {
+			d := openflow15.NewGroupDesc()
+			d.Type = g.Type
+			d.GroupId = g.GroupId
+			for _, b := range g.Buckets {
+				d.AddBucket(b)
+			}
+			reply.Body = append(reply.Body, d)
+		}
+	case openflow15.MultipartType_MeterDesc:
+		for _, m := range s.meters {
+			d := openflow15.NewMeterDesc(m.MeterId)
+			d.Flags = m.Flags
+			d.Bands = m.MeterBands
+			reply.Body = append(reply.Body, d)
+		}
+	}
+	return reply
+}
+
+func newFlow(tableID uint8, inPort, outPort uint32) *openflow15.FlowMod {
+	f := openflow15.NewFlowMod()
+	f.TableId = tableID
+	f.Cookie = 0x1234
+	f.Match.AddField(*openflow15.NewInPortField(inPort))
+	instr := openflow15.NewInstrApplyActions()
+	instr.AddAction(openflow15.NewActionOutput(outPort), false)
+	f.AddInstruction(instr)
+	return f
+}
+
+func newGroup(id uint32, ports ...uint32) *openflow15.GroupMod {
+	g := openflow15.NewGroupMod()
+	g.GroupId = id
+	for i, port := range ports {
+		bkt := openflow15.NewBucket(uint32(i))
+		bkt.AddAction(openflow15.NewActionOutput(port))
+		g.AddBucket(*bkt)
+	}
+	return g
+}
+
+func newMeter(id uint32, rate uint32) *openflow15.MeterMod {
+	m := openflow15.NewMeterMod()
+	m.MeterId = id
+	m.Flags = openflow15.MF_KBPS
+	band := openflow15.NewMeterBandDrop()
+	band.Rate = rate
+	m.AddMeterBand(band)
+	return m
+}
+
+func TestComputeDiff(t *testing.T) {
+	current := &State{
+		Flows: []*openflow15.FlowMod{
+			newFlow(0, 1, 2),
+			newFlow(0, 2, 1),
+			newFlow(1, 1, 3),
+		},
+		Groups: []*openflow15.GroupMod{newGroup(1, 1, 2), newGroup(2, 1)},
+		Meters: []*openflow15.MeterMod{newMeter(1, 100)},
+	}
+	modifiedAttrs := newFlow(0, 2, 1)
+	modifiedAttrs.IdleTimeout = 10
+	desired := &State{
+		Flows: []*openflow15.FlowMod{
+			newFlow(0, 1, 2),
+			modifiedAttrs,
+			newFlow(1, 1, 4),
+			newFlow(1, 2, 4),
+		},
+		Groups: []*openflow15.GroupMod{newGroup(1, 1, 2, 3), newGroup(3, 1)},
+		Meters: []*openflow15.MeterMod{newMeter(1, 100), newMeter(2, 200)},
+	}
+
+	d, err := ComputeDiff(desired, current)
+	require.NoError(t, err)
+	require.Len(t, d.FlowAdds, 1)
+	assert.Equal(t, uint8(openflow15.FC_ADD), d.FlowAdds[0].Command)
+	require.Len(t, d.FlowModifies, 2)
+	assert.Equal(t, uint8(openflow15.FC_ADD), d.FlowModifies[0].Command)
+	assert.Equal(t, uint16(10), d.FlowModifies[0].IdleTimeout)
+	assert.Equal(t, uint8(openflow15.FC_MODIFY_STRICT), d.FlowModifies[1].Command)
+	assert.Empty(t, d.FlowDeletes)
+	require.Len(t, d.GroupAdds, 1)
+	assert.Equal(t, uint32(3), d.GroupAdds[0].GroupId)
+	require.Len(t, d.GroupModifies, 1)
+	assert.Equal(t, uint32(1), d.GroupModifies[0].GroupId)
+	require.Len(t, d.GroupDeletes, 1)
+	assert.Equal(t, uint32(2), d.GroupDeletes[0].GroupId)
+	require.Len(t, d.MeterAdds, 1)
+	assert.Empty(t, d.MeterModifies)
+	assert.Empty(t, d.MeterDeletes)
+	assert.Equal(t, 7, len(d.Messages()))
+
+	// The FlowMods of the desired state are left untouched.
+	assert.Equal(t, uint8(openflow15.FC_ADD), desired.Flows[2].Command)
+}
+
+func TestComputeDiffMatchOrder(t *testing.T) {
+	a := newFlow(0, 1, 2)
+	a.Match.AddField(*openflow15.NewEthTypeField(0x0800))
+	b := openflow15.NewFlowMod()
+	b.Cookie = a.Cookie
+	b.Match.AddField(*openflow15.NewEthTypeField(0x0800))
+	b.Match.AddField(*openflow15.NewInPortField(1))
+	b.Instructions = a.Instructions
+
+	d, err := ComputeDiff(&State{Flows: []*openflow15.FlowMod{a}}, &State{Flows: []*openflow15.FlowMod{b}})
+	require.NoError(t, err)
+	assert.True(t, d.Empty())
+}
+
+func TestReconcile(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		noBundle bool
+	}{
+		{name: "bundle"},
+		{name: "no bundle", noBundle: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sw := newFakeSwitch()
+			sw.noBundle = tc.noBundle
+			sw.apply(newFlow(0, 3, 4))
+			sw.apply(newGroup(5, 1))
+			sw.received = 0
+
+			desired := &State{
+				Flows:  []*openflow15.FlowMod{newFlow(0, 1, 2), newFlow(1, 2, 1)},
+				Groups: []*openflow15.GroupMod{newGroup(1, 1, 2)},
+				Meters: []*openflow15.MeterMod{newMeter(1, 100)},
+			}
+			r := NewReconciler(sw)
+			d, err := r.Reconcile(desired)
+			require.NoError(t, err)
+			assert.Equal(t, 6, d.Len())
+			assert.Equal(t, 6, sw.received)
+			assert.Empty(t, sw.bundles)
+
+			d, err = r.Diff(desired)
+			require.NoError(t, err)
+			assert.True(t, d.Empty(), "switch did not converge")
+
+			// Drift on the switch is reported and repaired with a single message.
+			sw.flows[0].Instructions = newFlow(0, 1, 9).Instructions
+			sw.received = 0
+			d, err = r.Reconcile(desired)
+			require.NoError(t, err)
+			require.Len(t, d.FlowModifies, 1)
+			assert.Equal(t, 1, sw.received)
+		})
+	}
+}
+
+func TestReconcileError(t *testing.T) {
+	sw := newFakeSwitch()
+	r := NewReconciler(sw)
+	// The switch rejects the first message added to the bundle.
+	r.sw = switchFunc(func(msgs ...util.Message) ([]util.Message, error) {
+		for _, msg := range msgs {
+			if add, ok := msg.(*openflow15.BndleAdd); ok {
+				return []util.Message{errorReply(add.Header.Xid, openflow15.ET_FLOW_MOD_FAILED, openflow15.FMFC_TABLE_FULL)}, nil
+			}
+		}
+		return sw.RoundTrip(msgs...)
+	})
+
+	_, err := r.Reconcile(&State{Flows: []*openflow15.FlowMod{newFlow(0, 1, 2)}})
+	require.Error(t, err)
+	switchErr, ok := err.(*SwitchError)
+	require.True(t, ok)
+	assert.Equal(t, uint16(openflow15.ET_FLOW_MOD_FAILED), switchErr.Type)
+	assert.IsType(t, &openflow15.FlowMod{}, switchErr.Message)
+	var txErr *transaction.Error
+	assert.ErrorAs(t, err, &txErr)
+	assert.Empty(t, sw.flows)
+	assert.Empty(t, sw.bundles, "bundle was not discarded")
+
+	// The same error is returned without bundle.
+	r.UseBundle = false
+	r.sw = switchFunc(func(msgs ...util.Message) ([]util.Message, error) {
+		if flow, ok := msgs[len(msgs)-1].(*openflow15.FlowMod); ok {
+			return []util.Message{errorReply(flow.Header.Xid, openflow15.ET_FLOW_MOD_FAILED, openflow15.FMFC_TABLE_FULL)}, nil
+		}
+		return sw.RoundTrip(msgs...)
+	})
+	_, err = r.Reconcile(&State{Flows: []*openflow15.FlowMod{newFlow(0, 1, 2)}})
+	switchErr, ok = err.(*SwitchError)
+	require.True(t, ok)
+	assert.Equal(t, uint16(openflow15.ET_FLOW_MOD_FAILED), switchErr.Type)
+	assert.IsType(t, &openflow15.FlowMod{}, switchErr.Message)
+	assert.Empty(t, sw.flows)
+}
+
+type switchFunc func(msgs ...util.Message) ([]util.Message, error)
+
+func (f switchFunc) RoundTrip(msgs ...util.Message) ([]util.Message, error) {
+	return f(msgs...)
+}
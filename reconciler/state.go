@@ -0,0 +1,395 @@
+package reconciler
+
+// This file computes the difference between two sets of flows, groups and meters.
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/util"
+)
+
+// State is a set of flows, groups and meters, either the state desired by the
+// caller or the state read back from the switch. Flows are expressed as
+// FC_ADD FlowMods, groups as OFPGC_ADD GroupMods and meters as MC_ADD MeterMods.
+type State struct {
+	Flows  []*openflow15.FlowMod
+	Groups []*openflow15.GroupMod
+	Meters []*openflow15.MeterMod
+}
+
+// Diff is the minimal set of changes that converges the switch to the desired
+// State. A non-empty Diff read from the switch is the drift report.
+type Diff struct {
+	FlowAdds     []*openflow15.FlowMod
+	FlowModifies []*openflow15.FlowMod
+	FlowDeletes  []*openflow15.FlowMod
+
+	GroupAdds     []*openflow15.GroupMod
+	GroupModifies []*openflow15.GroupMod
+	GroupDeletes  []*openflow15.GroupMod
+
+	MeterAdds     []*openflow15.MeterMod
+	MeterModifies []*openflow15.MeterMod
+	MeterDeletes  []*openflow15.MeterMod
+}
+
+// Empty reports whether the switch already matches the desired state.
+func (d *Diff) Empty() bool {
+	return d.Len() == 0
+}
+
+// Len returns the number of messages needed to apply the Diff.
+func (d *Diff) Len() int {
+	return len(d.FlowAdds) + len(d.FlowModifies) + len(d.FlowDeletes) +
+		len(d.GroupAdds) + len(d.GroupModifies) + len(d.GroupDeletes) +
+		len(d.MeterAdds) + len(d.MeterModifies) + len(d.MeterDeletes)
+}
+
+// Messages returns the Diff as a list of messages in an order that is safe to
+// apply one by one: groups and meters are created before the flows that may
+// refer to them, and removed only after those flows are gone.
+func (d *Diff) Messages() []util.Message {
+	msgs := make([]util.Message, 0, d.Len())
+	for _, m := range d.MeterAdds {
+		msgs = append(msgs, m)
+	}
+	for _, m := range d.MeterModifies {
+		msgs = append(msgs, m)
+	}
+	for _, g := range d.GroupAdds {
+		msgs = append(msgs, g)
+	}
+	for _, g := range d.GroupModifies {
+		msgs = append(msgs, g)
+	}
+	for _, f := range d.FlowDeletes {
+		msgs = append(msgs, f)
+	}
+	for _, f := range d.FlowModifies {
+		msgs = append(msgs, f)
+	}
+	for _, f := range d.FlowAdds {
+		msgs = append(msgs, f)
+	}
+	for _, g := range d.GroupDeletes {
+		msgs = append(msgs, g)
+	}
+	for _, m := range d.MeterDeletes {
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+// ComputeDiff compares the desired State with the current State of the switch.
+// Flows are identified by table, priority and match, groups by group ID and
+// meters by meter ID. The FlowMods, GroupMods and MeterMods in the returned
+// Diff are new messages; the ones in desired and current are not modified.
+func ComputeDiff(desired, current *State) (*Diff, error) {
+	d := new(Diff)
+	if err := d.diffFlows(desired.Flows, current.Flows); err != nil {
+		return nil, err
+	}
+	if err := d.diffGroups(desired.Groups, current.Groups); err != nil {
+		return nil, err
+	}
+	if err := d.diffMeters(desired.Meters, current.Meters); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+type flowKey struct {
+	tableID  uint8
+	priority uint16
+	match    string
+}
+
+func (k flowKey) String() string {
+	return fmt.Sprintf("table=%d,priority=%d,match=%s", k.tableID, k.priority, hex.EncodeToString([]byte(k.match)))
+}
+
+// matchKey returns the match fields in a canonical form, so that the same
+// match sent with its fields in a different order yields the same key.
+func matchKey(m *openflow15.Match) (string, error) {
+	fields := make([][]byte, 0, len(m.Fields))
+	for i := range m.Fields {
+		b, err := m.Fields[i].MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, b)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return bytes.Compare(fields[i], fields[j]) < 0
+	})
+	return string(bytes.Join(fields, nil)), nil
+}
+
+func keyOfFlow(f *openflow15.FlowMod) (flowKey, error) {
+	match, err := matchKey(&f.Match)
+	if err != nil {
+		return flowKey{}, err
+	}
+	return flowKey{tableID: f.TableId, priority: f.Priority, match: match}, nil
+}
+
+func marshalAll[T util.Message](msgs []T) ([]byte, error) {
+	var data []byte
+	for _, m := range msgs {
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+func indexFlows(flows []*openflow15.FlowMod) (map[flowKey]*openflow15.FlowMod, []flowKey, error) {
+	index := make(map[flowKey]*openflow15.FlowMod, len(flows))
+	keys := make([]flowKey, 0, len(flows))
+	for _, f := range flows {
+		k, err := keyOfFlow(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := index[k]; ok {
+			return nil, nil, fmt.Errorf("duplicate flow %s", k)
+		}
+		index[k] = f
+		keys = append(keys, k)
+	}
+	return index, keys, nil
+}
+
+func (d *Diff) diffFlows(desired, current []*openflow15.FlowMod) error {
+	want, wantKeys, err := indexFlows(desired)
+	if err != nil {
+		return fmt.Errorf("invalid desired state: %w", err)
+	}
+	have, haveKeys, err := indexFlows(current)
+	if err != nil {
+		return fmt.Errorf("invalid switch state: %w", err)
+	}
+
+	for _, k := range wantKeys {
+		w := want[k]
+		h, ok := have[k]
+		if !ok {
+			d.FlowAdds = append(d.FlowAdds, copyFlow(w, openflow15.FC_ADD))
+			continue
+		}
+		sameInstrs, err := sameInstructions(w, h)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !sameFlowAttributes(w, h):
+			// MODIFY does not update cookie, timeouts, flags or importance.
+			// ADD of an identical match replaces the existing entry.
+			d.FlowModifies = append(d.FlowModifies, copyFlow(w, openflow15.FC_ADD))
+		case !sameInstrs:
+			// MODIFY_STRICT keeps the counters of the existing entry.
+			d.FlowModifies = append(d.FlowModifies, copyFlow(w, openflow15.FC_MODIFY_STRICT))
+		}
+	}
+	for _, k := range haveKeys {
+		if _, ok := want[k]; !ok {
+			d.FlowDeletes = append(d.FlowDeletes, deleteFlow(have[k]))
+		}
+	}
+	return nil
+}
+
+func sameFlowAttributes(a, b *openflow15.FlowMod) bool {
+	return a.Cookie == b.Cookie &&
+		a.IdleTimeout == b.IdleTimeout &&
+		a.HardTimeout == b.HardTimeout &&
+		a.Flags == b.Flags &&
+		a.Importance == b.Importance
+}
+
+func sameInstructions(a, b *openflow15.FlowMod) (bool, error) {
+	ab, err := marshalAll(a.Instructions)
+	if err != nil {
+		return false, err
+	}
+	bb, err := marshalAll(b.Instructions)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ab, bb), nil
+}
+
+func copyFlow(f *openflow15.FlowMod, command uint8) *openflow15.FlowMod {
+	n := openflow15.NewFlowMod()
+	n.Cookie = f.Cookie
+	n.TableId = f.TableId
+	n.Command = command
+	n.IdleTimeout = f.IdleTimeout
+	n.HardTimeout = f.HardTimeout
+	n.Priority = f.Priority
+	n.Flags = f.Flags
+	n.Importance = f.Importance
+	n.Match.Fields = append(n.Match.Fields, f.Match.Fields...)
+	n.Match.Length = f.Match.Length
+	n.Instructions = append(n.Instructions, f.Instructions...)
+	return n
+}
+
+func deleteFlow(f *openflow15.FlowMod) *openflow15.FlowMod {
+	n := openflow15.NewFlowMod()
+	n.TableId = f.TableId
+	n.Command = openflow15.FC_DELETE_STRICT
+	n.Priority = f.Priority
+	n.Cookie = f.Cookie
+	n.CookieMask = 0xffffffffffffffff
+	n.Match.Fields = append(n.Match.Fields, f.Match.Fields...)
+	n.Match.Length = f.Match.Length
+	return n
+}
+
+// groupContent returns the wire form of everything but the group ID. Buckets
+// are marshaled field by field because Bucket.MarshalBinary accumulates
+// ActionArrayLen.
+func groupContent(g *openflow15.GroupMod) ([]byte, error) {
+	data := []byte{g.Type}
+	for i := range g.Buckets {
+		b := &g.Buckets[i]
+		data = append(data, byte(b.BucketId>>24), byte(b.BucketId>>16), byte(b.BucketId>>8), byte(b.BucketId))
+		actions, err := marshalAll(b.Actions)
+		if err != nil {
+			return nil, err
+		}
+		props, err := marshalAll(b.Properties)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, byte(len(actions)>>8), byte(len(actions)))
+		data = append(data, actions...)
+		data = append(data, props...)
+	}
+	props, err := marshalAll(g.Properties)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, props...), nil
+}
+
+func (d *Diff) diffGroups(desired, current []*openflow15.GroupMod) error {
+	have := make(map[uint32]*openflow15.GroupMod, len(current))
+	for _, g := range current {
+		have[g.GroupId] = g
+	}
+	want := make(map[uint32]bool, len(desired))
+	for _, w := range desired {
+		if want[w.GroupId] {
+			return fmt.Errorf("invalid desired state: duplicate group %d", w.GroupId)
+		}
+		want[w.GroupId] = true
+		h, ok := have[w.GroupId]
+		if !ok {
+			d.GroupAdds = append(d.GroupAdds, copyGroup(w, openflow15.OFPGC_ADD))
+			continue
+		}
+		wb, err := groupContent(w)
+		if err != nil {
+			return err
+		}
+		hb, err := groupContent(h)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(wb, hb) {
+			d.GroupModifies = append(d.GroupModifies, copyGroup(w, openflow15.OFPGC_MODIFY))
+		}
+	}
+	for _, h := range current {
+		if !want[h.GroupId] {
+			g := openflow15.NewGroupMod()
+			g.Command = openflow15.OFPGC_DELETE
+			g.GroupId = h.GroupId
+			d.GroupDeletes = append(d.GroupDeletes, g)
+		}
+	}
+	return nil
+}
+
+func copyGroup(g *openflow15.GroupMod, command uint16) *openflow15.GroupMod {
+	n := openflow15.NewGroupMod()
+	n.Command = command
+	n.Type = g.Type
+	n.GroupId = g.GroupId
+	for _, b := range g.Buckets {
+		bkt := openflow15.NewBucket(b.BucketId)
+		for _, a := range b.Actions {
+			bkt.AddAction(a)
+		}
+		for _, p := range b.Properties {
+			bkt.AddProperty(p)
+		}
+		n.AddBucket(*bkt)
+	}
+	n.Properties = append(n.Properties, g.Properties...)
+	return n
+}
+
+func meterContent(m *openflow15.MeterMod) ([]byte, error) {
+	bands, err := marshalAll(m.MeterBands)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(m.Flags >> 8), byte(m.Flags)}, bands...), nil
+}
+
+func (d *Diff) diffMeters(desired, current []*openflow15.MeterMod) error {
+	have := make(map[uint32]*openflow15.MeterMod, len(current))
+	for _, m := range current {
+		have[m.MeterId] = m
+	}
+	want := make(map[uint32]bool, len(desired))
+	for _, w := range desired {
+		if want[w.MeterId] {
+			return fmt.Errorf("invalid desired state: duplicate meter %d", w.MeterId)
+		}
+		want[w.MeterId] = true
+		h, ok := have[w.MeterId]
+		if !ok {
+			d.MeterAdds = append(d.MeterAdds, copyMeter(w, openflow15.MC_ADD))
+			continue
+		}
+		wb, err := meterContent(w)
+		if err != nil {
+			return err
+		}
+		hb, err := meterContent(h)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(wb, hb) {
+			d.MeterModifies = append(d.MeterModifies, copyMeter(w, openflow15.MC_MODIFY))
+		}
+	}
+	for _, h := range current {
+		if !want[h.MeterId] {
+			m := openflow15.NewMeterMod()
+			m.Command = openflow15.MC_DELETE
+			m.MeterId = h.MeterId
+			d.MeterDeletes = append(d.MeterDeletes, m)
+		}
+	}
+	return nil
+}
+
+func copyMeter(m *openflow15.MeterMod, command uint16) *openflow15.MeterMod {
+	n := openflow15.NewMeterMod()
+	n.Command = command
+	n.Flags = m.Flags
+	n.MeterId = m.MeterId
+	n.MeterBands = append(n.MeterBands, m.MeterBands...)
+	return n
+}
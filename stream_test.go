@@ -162,6 +162,21 @@ func TestStreamInbound(t *testing.T) {
 	}
 }
 
+func TestStreamInboundInvalidLength(t *testing.T) {
+	for _, length := range []byte{0, 4, 7} {
+		c := newFakeConn(1, func() []byte {
+			return []byte{4, 0, 0, length, 0, 0, 0, 1}
+		})
+		stream := util.NewMessageStream(c, parserIntf{})
+		select {
+		case err := <-stream.Error:
+			assert.ErrorContains(t, err, "invalid OpenFlow message length")
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no error reported for message length %d", length)
+		}
+	}
+}
+
 func TestObj(t *testing.T) {
 	b, err := os.ReadFile("msg.txt") // just pass the file name
 	if err != nil {
@@ -0,0 +1,78 @@
+package topology
+
+import (
+	"antrea.io/libOpenflow/openflow13"
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+// dialect builds the PacketOuts and reads the PacketIns of an OpenFlow
+// version.
+type dialect interface {
+	packetOut(port uint32, frame *protocol.Ethernet) util.Message
+	// packetIn returns the input port and the frame of a PacketIn.
+	packetIn(msg util.Message) (uint32, []byte, bool)
+}
+
+type of15 struct{}
+
+func (of15) packetOut(port uint32, frame *protocol.Ethernet) util.Message {
+	msg := openflow15.NewPacketOut()
+	msg.Match.AddField(*openflow15.NewInPortField(openflow15.P_CONTROLLER))
+	msg.AddAction(openflow15.NewActionOutput(port))
+	msg.Data = frame
+	return msg
+}
+
+func (of15) packetIn(msg util.Message) (uint32, []byte, bool) {
+	m, ok := msg.(*openflow15.PacketIn)
+	if !ok || m.Data == nil {
+		return 0, nil, false
+	}
+	var inPort *openflow15.InPortField
+	for _, f := range m.Match.Fields {
+		if f.Class == openflow15.OXM_CLASS_OPENFLOW_BASIC && f.Field == openflow15.OXM_FIELD_IN_PORT {
+			inPort, _ = f.Value.(*openflow15.InPortField)
+		}
+	}
+	if inPort == nil {
+		return 0, nil, false
+	}
+	frame, err := m.Data.MarshalBinary()
+	if err != nil {
+		return 0, nil, false
+	}
+	return inPort.InPort, frame, true
+}
+
+type of13 struct{}
+
+func (of13) packetOut(port uint32, frame *protocol.Ethernet) util.Message {
+	msg := openflow13.NewPacketOut()
+	msg.InPort = openflow13.P_CONTROLLER
+	msg.AddAction(openflow13.NewActionOutput(port))
+	msg.Data = frame
+	return msg
+}
+
+func (of13) packetIn(msg util.Message) (uint32, []byte, bool) {
+	m, ok := msg.(*openflow13.PacketIn)
+	if !ok {
+		return 0, nil, false
+	}
+	var inPort *openflow13.InPortField
+	for _, f := range m.Match.Fields {
+		if f.Class == openflow13.OXM_CLASS_OPENFLOW_BASIC && f.Field == openflow13.OXM_FIELD_IN_PORT {
+			inPort, _ = f.Value.(*openflow13.InPortField)
+		}
+	}
+	if inPort == nil {
+		return 0, nil, false
+	}
+	frame, err := m.Data.MarshalBinary()
+	if err != nil {
+		return 0, nil, false
+	}
+	return inPort.InPort, frame, true
+}
@@ -0,0 +1,228 @@
+// Package topology discovers the links between OpenFlow switches with LLDP. It
+// sends an LLDP frame identifying the datapath and port out of every port of
+// the switches, and builds the link graph from the frames the switches send
+// back in PacketIns.
+package topology
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+const chassisPrefix = "dpid:"
+
+// Conn sends messages to a switch, for instance through the Outbound channel
+// of a util.MessageStream.
+type Conn interface {
+	Send(msg util.Message) error
+}
+
+// Port is a port of a datapath.
+type Port struct {
+	DatapathID uint64
+	PortNo     uint32
+}
+
+func (p Port) String() string {
+	return fmt.Sprintf("%016x/%d", p.DatapathID, p.PortNo)
+}
+
+// Link is a unidirectional link, seen by Dst receiving a probe sent from Src.
+type Link struct {
+	Src Port
+	Dst Port
+}
+
+// LocalPort is a port to probe, as described by the port description of the
+// switch.
+type LocalPort struct {
+	PortNo uint32
+	HWAddr net.HardwareAddr
+}
+
+// Discovery probes switches and tracks the links discovered. It is safe for
+// concurrent use.
+type Discovery struct {
+	// TTL is the time after which a link which was not seen again expires. It
+	// is also announced in the probes.
+	TTL time.Duration
+
+	d     dialect
+	mutex sync.Mutex
+	links map[Link]time.Time
+	now   func() time.Time
+}
+
+// NewDiscovery15 returns a Discovery for OpenFlow 1.5 switches.
+func NewDiscovery15(ttl time.Duration) *Discovery {
+	return newDiscovery(of15{}, ttl)
+}
+
+// NewDiscovery13 returns a Discovery for OpenFlow 1.3 switches.
+func NewDiscovery13(ttl time.Duration) *Discovery {
+	return newDiscovery(of13{}, ttl)
+}
+
+func newDiscovery(d dialect, ttl time.Duration) *Discovery {
+	return &Discovery{TTL: ttl, d: d, links: make(map[Link]time.Time), now: time.Now}
+}
+
+// Frame returns the LLDP frame sent out of port of the datapath dpid.
+func (d *Discovery) Frame(dpid uint64, port LocalPort) *protocol.Ethernet {
+	ttl := d.TTL.Seconds()
+	if ttl > 0xffff {
+		ttl = 0xffff
+	}
+	portID := make([]byte, 4)
+	binary.BigEndian.PutUint32(portID, port.PortNo)
+	lldp := protocol.NewLLDP(protocol.CH_LOCAL_ASSGN, []byte(chassisPrefix+fmt.Sprintf("%016x", dpid)),
+		protocol.PT_PORT_COMPONENT, portID, uint16(ttl))
+
+	eth := protocol.NewEthernet()
+	eth.HWDst = protocol.LLDPMulticastMAC
+	if len(port.HWAddr) == 6 {
+		eth.HWSrc = port.HWAddr
+	}
+	eth.Ethertype = protocol.LLDP_MSG
+	eth.Data = lldp
+	return eth
+}
+
+// Probe sends a PacketOut with an LLDP frame out of each port of the datapath
+// dpid. The switch must send the LLDP frames it receives to the controller,
+// whose PacketIns are passed to HandlePacketIn.
+func (d *Discovery) Probe(conn Conn, dpid uint64, ports []LocalPort) error {
+	for _, port := range ports {
+		if err := conn.Send(d.d.packetOut(port.PortNo, d.Frame(dpid, port))); err != nil {
+			return fmt.Errorf("failed to probe port %d of %016x: %w", port.PortNo, dpid, err)
+		}
+	}
+	return nil
+}
+
+// HandlePacketIn records the link on which a PacketIn received by the
+// datapath dpid carried one of the probes, and returns it. It returns false
+// for other PacketIns.
+func (d *Discovery) HandlePacketIn(dpid uint64, msg util.Message) (Link, bool) {
+	inPort, frame, ok := d.d.packetIn(msg)
+	if !ok {
+		return Link{}, false
+	}
+	src, ok := parseProbe(frame)
+	if !ok {
+		return Link{}, false
+	}
+	link := Link{Src: src, Dst: Port{DatapathID: dpid, PortNo: inPort}}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.links[link] = d.now()
+	return link, true
+}
+
+// parseProbe returns the port which sent the LLDP frame, if it is a probe.
+func parseProbe(frame []byte) (Port, bool) {
+	eth := new(protocol.Ethernet)
+	if err := eth.UnmarshalBinary(frame); err != nil {
+		return Port{}, false
+	}
+	lldp, ok := eth.Data.(*protocol.LLDP)
+	if !ok || lldp.Chassis.Subtype != protocol.CH_LOCAL_ASSGN || lldp.Port.Subtype != protocol.PT_PORT_COMPONENT || len(lldp.Port.Data) != 4 {
+		return Port{}, false
+	}
+	chassis := string(lldp.Chassis.Data)
+	if !strings.HasPrefix(chassis, chassisPrefix) {
+		return Port{}, false
+	}
+	dpid, err := strconv.ParseUint(strings.TrimPrefix(chassis, chassisPrefix), 16, 64)
+	if err != nil {
+		return Port{}, false
+	}
+	return Port{DatapathID: dpid, PortNo: binary.BigEndian.Uint32(lldp.Port.Data)}, true
+}
+
+// Expire removes the links which were not seen within TTL, and returns how
+// many were removed.
+func (d *Discovery) Expire() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	n := 0
+	for link, seen := range d.links {
+		if d.now().Sub(seen) > d.TTL {
+			delete(d.links, link)
+			n++
+		}
+	}
+	return n
+}
+
+// RemoveDatapath removes the links from and to the datapath dpid, such as
+// when it disconnects.
+func (d *Discovery) RemoveDatapath(dpid uint64) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for link := range d.links {
+		if link.Src.DatapathID == dpid || link.Dst.DatapathID == dpid {
+			delete(d.links, link)
+		}
+	}
+}
+
+// Links returns the links discovered, sorted by source and destination port.
+func (d *Discovery) Links() []Link {
+	d.mutex.Lock()
+	links := make([]Link, 0, len(d.links))
+	for link := range d.links {
+		links = append(links, link)
+	}
+	d.mutex.Unlock()
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Src != links[j].Src {
+			return portLess(links[i].Src, links[j].Src)
+		}
+		return portLess(links[i].Dst, links[j].Dst)
+	})
+	return links
+}
+
+func portLess(a, b Port) bool {
+	if a.DatapathID != b.DatapathID {
+		return a.DatapathID < b.DatapathID
+	}
+	return a.PortNo < b.PortNo
+}
+
+// Graph is the adjacency list of the datapaths: the links leaving each
+// datapath.
+type Graph map[uint64][]Link
+
+// Graph returns the graph of the links discovered.
+func (d *Discovery) Graph() Graph {
+	g := make(Graph)
+	for _, link := range d.Links() {
+		g[link.Src.DatapathID] = append(g[link.Src.DatapathID], link)
+	}
+	return g
+}
+
+// Neighbors returns the datapaths linked from dpid, sorted.
+func (g Graph) Neighbors(dpid uint64) []uint64 {
+	var neighbors []uint64
+	seen := make(map[uint64]bool)
+	for _, link := range g[dpid] {
+		if !seen[link.Dst.DatapathID] {
+			seen[link.Dst.DatapathID] = true
+			neighbors = append(neighbors, link.Dst.DatapathID)
+		}
+	}
+	sort.Slice(neighbors, func(i, j int) bool { return neighbors[i] < neighbors[j] })
+	return neighbors
+}
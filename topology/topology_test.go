@@ -0,0 +1,154 @@
+package topology
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/openflow13"
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/protocol"
+	"antrea.io/libOpenflow/util"
+)
+
+// fakeNetwork delivers the PacketOuts sent by a datapath to the peer of the
+// output port as PacketIns.
+type fakeNetwork struct {
+	t        *testing.T
+	version  uint8
+	wires    map[Port]Port
+	received []received
+}
+
+type received struct {
+	dpid uint64
+	msg  util.Message
+}
+
+type fakeConn struct {
+	net  *fakeNetwork
+	dpid uint64
+}
+
+func (c *fakeConn) Send(msg util.Message) error {
+	data, err := msg.MarshalBinary()
+	require.NoError(c.net.t, err)
+	var port uint32
+	var frame []byte
+	switch m := msg.(type) {
+	case *openflow15.PacketOut:
+		port = m.Actions[0].(*openflow15.ActionOutput).Port
+		frame, err = m.Data.MarshalBinary()
+	case *openflow13.PacketOut:
+		port = m.Actions[0].(*openflow13.ActionOutput).Port
+		frame, err = m.Data.MarshalBinary()
+	}
+	require.NoError(c.net.t, err)
+	require.NotEmpty(c.net.t, data)
+
+	peer, ok := c.net.wires[Port{DatapathID: c.dpid, PortNo: port}]
+	if !ok {
+		return nil
+	}
+	var pktIn util.Message
+	if c.net.version == openflow15.VERSION {
+		p := openflow15.NewPacketIn()
+		p.Match.AddField(*openflow15.NewInPortField(peer.PortNo))
+		p.Data = util.NewBuffer(frame)
+		pktIn = p
+	} else {
+		p := openflow13.NewPacketIn()
+		p.Match.AddField(*openflow13.NewInPortField(peer.PortNo))
+		require.NoError(c.net.t, p.Data.UnmarshalBinary(frame))
+		pktIn = p
+	}
+	data, err = pktIn.MarshalBinary()
+	require.NoError(c.net.t, err)
+	if c.net.version == openflow15.VERSION {
+		pktIn, err = openflow15.Parse(data)
+	} else {
+		pktIn, err = openflow13.Parse(data)
+	}
+	require.NoError(c.net.t, err)
+	c.net.received = append(c.net.received, received{dpid: peer.DatapathID, msg: pktIn})
+	return nil
+}
+
+func TestDiscovery(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		version uint8
+		d       *Discovery
+	}{
+		{"OpenFlow 1.5", openflow15.VERSION, NewDiscovery15(time.Minute)},
+		{"OpenFlow 1.3", openflow13.VERSION, NewDiscovery13(time.Minute)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1:1 <-> 2:1, 2:2 <-> 3:5, and 1:2 going nowhere.
+			network := &fakeNetwork{t: t, version: tc.version, wires: map[Port]Port{
+				{1, 1}: {2, 1}, {2, 1}: {1, 1},
+				{2, 2}: {3, 5}, {3, 5}: {2, 2},
+			}}
+			mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+			ports := map[uint64][]LocalPort{
+				1: {{PortNo: 1, HWAddr: mac}, {PortNo: 2}},
+				2: {{PortNo: 1}, {PortNo: 2}},
+				3: {{PortNo: 5}},
+			}
+			for dpid := uint64(1); dpid <= 3; dpid++ {
+				require.NoError(t, tc.d.Probe(&fakeConn{net: network, dpid: dpid}, dpid, ports[dpid]))
+			}
+			require.Len(t, network.received, 4)
+			for _, r := range network.received {
+				_, ok := tc.d.HandlePacketIn(r.dpid, r.msg)
+				assert.True(t, ok)
+			}
+
+			assert.Equal(t, []Link{
+				{Src: Port{1, 1}, Dst: Port{2, 1}},
+				{Src: Port{2, 1}, Dst: Port{1, 1}},
+				{Src: Port{2, 2}, Dst: Port{3, 5}},
+				{Src: Port{3, 5}, Dst: Port{2, 2}},
+			}, tc.d.Links())
+			graph := tc.d.Graph()
+			assert.Equal(t, []uint64{1, 3}, graph.Neighbors(2))
+			assert.Empty(t, graph.Neighbors(4))
+
+			tc.d.RemoveDatapath(3)
+			assert.Len(t, tc.d.Links(), 2)
+		})
+	}
+}
+
+func TestDiscoveryIgnoredAndExpired(t *testing.T) {
+	d := NewDiscovery15(time.Minute)
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	// Not a probe.
+	pktIn := openflow15.NewPacketIn()
+	pktIn.Match.AddField(*openflow15.NewInPortField(1))
+	eth := protocol.NewEthernet()
+	eth.Ethertype = protocol.LLDP_MSG
+	eth.Data = protocol.NewLLDP(protocol.CH_MAC_ADDR, make([]byte, 6), protocol.PT_IFACE_NAME, []byte("eth0"), 120)
+	pktIn.Data = eth
+	_, ok := d.HandlePacketIn(1, pktIn)
+	assert.False(t, ok)
+	_, ok = d.HandlePacketIn(1, openflow15.NewPacketOut())
+	assert.False(t, ok)
+
+	pktIn.Data = d.Frame(2, LocalPort{PortNo: 3})
+	link, ok := d.HandlePacketIn(1, pktIn)
+	require.True(t, ok)
+	assert.Equal(t, Link{Src: Port{2, 3}, Dst: Port{1, 1}}, link)
+	assert.Equal(t, uint16(60), pktIn.Data.(*protocol.Ethernet).Data.(*protocol.LLDP).TTL.Seconds)
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 0, d.Expire())
+	now = now.Add(time.Minute)
+	assert.Equal(t, 1, d.Expire())
+	assert.Empty(t, d.Links())
+}
@@ -0,0 +1,87 @@
+package transaction
+
+import (
+	"time"
+
+	"antrea.io/libOpenflow/openflow13"
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/util"
+)
+
+// of15 uses the bundle messages of OpenFlow 1.5.
+type of15 struct{}
+
+func (of15) control(id uint32, ctrlType uint16, flags uint16, commitTime time.Time) (util.Message, uint32) {
+	msg := openflow15.NewBundleCtrl(id, ctrlType, flags)
+	if !commitTime.IsZero() {
+		prop := new(openflow15.BundlePropTime)
+		prop.Header.Type = openflow15.BPT_TIME
+		prop.SchedTime.Seconds = uint64(commitTime.Unix())
+		prop.SchedTime.NanoSeconds = uint32(commitTime.Nanosecond())
+		msg.Properties = append(msg.Properties, prop)
+	}
+	return msg, msg.Header.Xid
+}
+
+func (of15) add(id uint32, flags uint16, msg util.Message) (util.Message, uint32) {
+	add := openflow15.NewBndleAdd(id, flags)
+	add.Message = &xidMessage{Message: msg, xid: add.Header.Xid}
+	return add, add.Header.Xid
+}
+
+func (of15) controlReply(msg util.Message) (uint32, uint16, bool) {
+	if m, ok := msg.(*openflow15.BundleCtrl); ok {
+		return m.Header.Xid, m.Type, true
+	}
+	return 0, 0, false
+}
+
+func (of15) switchError(msg util.Message) (uint32, uint16, uint16, bool) {
+	switch m := msg.(type) {
+	case *openflow15.ErrorMsg:
+		return m.Header.Xid, m.Type, m.Code, true
+	case *openflow15.VendorError:
+		return m.Header.Xid, m.Type, m.Code, true
+	}
+	return 0, 0, 0, false
+}
+
+// of13 uses the ONF bundle extension of OpenFlow 1.3.
+type of13 struct{}
+
+func (of13) control(id uint32, ctrlType uint16, flags uint16, _ time.Time) (util.Message, uint32) {
+	msg := openflow13.NewBundleControl(&openflow13.BundleControl{
+		BundleID: id,
+		Type:     ctrlType,
+		Flags:    flags,
+	})
+	return msg, msg.Header.Xid
+}
+
+func (of13) add(id uint32, flags uint16, msg util.Message) (util.Message, uint32) {
+	add := openflow13.NewBundleAdd(&openflow13.BundleAdd{
+		BundleID: id,
+		Flags:    flags,
+	})
+	add.VendorData.(*openflow13.BundleAdd).Message = &xidMessage{Message: msg, xid: add.Header.Xid}
+	return add, add.Header.Xid
+}
+
+func (of13) controlReply(msg util.Message) (uint32, uint16, bool) {
+	if m, ok := msg.(*openflow13.VendorHeader); ok {
+		if ctrl, ok := m.VendorData.(*openflow13.BundleControl); ok {
+			return m.Header.Xid, ctrl.Type, true
+		}
+	}
+	return 0, 0, false
+}
+
+func (of13) switchError(msg util.Message) (uint32, uint16, uint16, bool) {
+	switch m := msg.(type) {
+	case *openflow13.ErrorMsg:
+		return m.Header.Xid, m.Type, m.Code, true
+	case *openflow13.VendorError:
+		return m.Header.Xid, m.Type, m.Code, true
+	}
+	return 0, 0, 0, false
+}
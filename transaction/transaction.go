@@ -0,0 +1,317 @@
+// Package transaction applies a set of OpenFlow messages to a switch as a
+// transaction, using OpenFlow 1.5 bundles or the ONF bundle extension of
+// OpenFlow 1.3.
+//
+// A Transaction opens a bundle, adds messages to it and commits or discards
+// it. Change sets larger than the limits of the switch are spread across
+// several bundles that are committed one after the other. The Transaction is
+// then not atomic, so an Atomic Transaction fails with ErrTooLarge instead of
+// being split.
+package transaction
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/util"
+)
+
+// Conn sends messages to a switch and collects its replies.
+type Conn interface {
+	// RoundTrip sends msgs followed by a barrier request, and returns all
+	// messages the switch sent with the Xid of one of msgs before it answered
+	// the barrier.
+	RoundTrip(msgs ...util.Message) ([]util.Message, error)
+}
+
+// Bundle flags. The values are the same in OpenFlow 1.3 and 1.5.
+const (
+	Atomic  = openflow15.BF_ATOMIC  /* Execute atomically. */
+	Ordered = openflow15.BF_ORDERED /* Execute in specified order. */
+)
+
+// Bundle control types. The values are the same in OpenFlow 1.3 and 1.5.
+const (
+	ctrlOpen    = openflow15.BCT_OPEN_REQUEST
+	ctrlCommit  = openflow15.BCT_COMMIT_REQUEST
+	ctrlDiscard = openflow15.BCT_DISCARD_REQUEST
+)
+
+var (
+	ErrDone             = errors.New("transaction is already committed or discarded")
+	ErrSchedUnsupported = errors.New("scheduled commit requires OpenFlow 1.5")
+	ErrTooLarge         = errors.New("atomic transaction exceeds the limits of a single bundle")
+)
+
+// Options configures a Transaction.
+type Options struct {
+	// Flags is a combination of Atomic and Ordered.
+	Flags uint16
+	// CommitTime schedules the commit at the given time. The zero value
+	// commits immediately. Only supported with OpenFlow 1.5.
+	CommitTime time.Time
+	// MaxMessages and MaxBytes are the limits of a single bundle on the
+	// switch. Zero means no limit. When a limit is reached, the remaining
+	// messages go to a new bundle, unless Flags has Atomic.
+	MaxMessages int
+	MaxBytes    int
+}
+
+// Error is a failure of a Transaction.
+type Error struct {
+	BundleID uint32
+	// Message is the message rejected by the switch, nil if the failure is
+	// about a bundle control request.
+	Message util.Message
+	// Type and Code are from the error message of the switch.
+	Type uint16
+	Code uint16
+	// Committed is the number of bundles of the Transaction committed before
+	// the failure. It can only be non-zero if the Transaction was split, and
+	// the switch is then left with part of the Transaction applied.
+	Committed int
+}
+
+func (e *Error) Error() string {
+	var s string
+	if e.Message != nil {
+		s = fmt.Sprintf("bundle %d: switch rejected message: type %d, code %d", e.BundleID, e.Type, e.Code)
+	} else {
+		s = fmt.Sprintf("bundle %d: switch returned error: type %d, code %d", e.BundleID, e.Type, e.Code)
+	}
+	if e.Committed > 0 {
+		s += fmt.Sprintf(" (transaction partially applied: %d bundles committed)", e.Committed)
+	}
+	return s
+}
+
+// dialect builds and recognizes the bundle messages of an OpenFlow version.
+type dialect interface {
+	control(id uint32, ctrlType uint16, flags uint16, commitTime time.Time) (util.Message, uint32)
+	add(id uint32, flags uint16, msg util.Message) (util.Message, uint32)
+	// controlReply returns the Xid and type of a bundle control reply.
+	controlReply(msg util.Message) (uint32, uint16, bool)
+	// switchError returns the Xid, type and code of an error message.
+	switchError(msg util.Message) (uint32, uint16, uint16, bool)
+}
+
+var bundleID uint32
+
+func nextBundleID() uint32 {
+	return atomic.AddUint32(&bundleID, 1)
+}
+
+type bundle struct {
+	id       uint32
+	messages int
+	bytes    int
+}
+
+// Transaction is a set of messages applied to the switch together. It is not
+// safe for concurrent use.
+type Transaction struct {
+	conn Conn
+	d    dialect
+	opts Options
+
+	bundles []*bundle
+	// err is the first error reported on a message added to the Transaction.
+	err  error
+	done bool
+}
+
+// Begin15 opens a Transaction on an OpenFlow 1.5 switch.
+func Begin15(conn Conn, opts Options) (*Transaction, error) {
+	return begin(conn, of15{}, opts)
+}
+
+// Begin13 opens a Transaction on an OpenFlow 1.3 switch with the ONF bundle
+// extension.
+func Begin13(conn Conn, opts Options) (*Transaction, error) {
+	if !opts.CommitTime.IsZero() {
+		return nil, ErrSchedUnsupported
+	}
+	return begin(conn, of13{}, opts)
+}
+
+func begin(conn Conn, d dialect, opts Options) (*Transaction, error) {
+	t := &Transaction{conn: conn, d: d, opts: opts}
+	if _, err := t.open(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Transaction) flags() uint16 {
+	flags := t.opts.Flags
+	if !t.opts.CommitTime.IsZero() {
+		flags |= openflow15.BF_TIME
+	}
+	return flags
+}
+
+func (t *Transaction) open() (*bundle, error) {
+	b := &bundle{id: nextBundleID()}
+	if err := t.control(b.id, ctrlOpen); err != nil {
+		return nil, err
+	}
+	t.bundles = append(t.bundles, b)
+	return b, nil
+}
+
+// control sends a bundle control request and waits for the matching reply.
+func (t *Transaction) control(id uint32, ctrlType uint16) error {
+	var commitTime time.Time
+	if ctrlType == ctrlCommit {
+		commitTime = t.opts.CommitTime
+	}
+	msg, xid := t.d.control(id, ctrlType, t.flags(), commitTime)
+	replies, err := t.conn.RoundTrip(msg)
+	if err != nil {
+		return err
+	}
+	for _, reply := range replies {
+		if replyXid, errType, code, ok := t.d.switchError(reply); ok && replyXid == xid {
+			return &Error{BundleID: id, Type: errType, Code: code}
+		}
+		if replyXid, replyType, ok := t.d.controlReply(reply); ok && replyXid == xid && replyType == ctrlType+1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("bundle %d: no reply to bundle control request %d", id, ctrlType)
+}
+
+func (b *bundle) full(opts Options, size int) bool {
+	if b.messages == 0 {
+		return false
+	}
+	return (opts.MaxMessages > 0 && b.messages+1 > opts.MaxMessages) ||
+		(opts.MaxBytes > 0 && b.bytes+size > opts.MaxBytes)
+}
+
+// Add adds msgs to the Transaction. It returns the first message rejected by
+// the switch as an *Error; the Transaction must then be discarded.
+func (t *Transaction) Add(msgs ...util.Message) error {
+	if t.done {
+		return ErrDone
+	}
+	if t.err != nil {
+		return t.err
+	}
+
+	type pending struct {
+		bundleID uint32
+		msg      util.Message
+	}
+	sent := make(map[uint32]pending, len(msgs))
+	adds := make([]util.Message, 0, len(msgs))
+	b := t.bundles[len(t.bundles)-1]
+	for _, msg := range msgs {
+		add, xid := t.d.add(b.id, t.flags(), msg)
+		size := int(add.Len())
+		if b.full(t.opts, size) {
+			if t.opts.Flags&Atomic != 0 {
+				t.err = ErrTooLarge
+				return t.err
+			}
+			var err error
+			if b, err = t.open(); err != nil {
+				t.err = err
+				return err
+			}
+			add, xid = t.d.add(b.id, t.flags(), msg)
+		}
+		b.messages++
+		b.bytes += size
+		sent[xid] = pending{bundleID: b.id, msg: msg}
+		adds = append(adds, add)
+	}
+
+	replies, err := t.conn.RoundTrip(adds...)
+	if err != nil {
+		t.err = err
+		return err
+	}
+	for _, reply := range replies {
+		xid, errType, code, ok := t.d.switchError(reply)
+		if !ok {
+			continue
+		}
+		if p, ok := sent[xid]; ok {
+			t.err = &Error{BundleID: p.bundleID, Message: p.msg, Type: errType, Code: code}
+			return t.err
+		}
+	}
+	return nil
+}
+
+// Commit commits the bundles of the Transaction in order. If a message was
+// rejected, the Transaction is discarded and the error is returned instead.
+func (t *Transaction) Commit() error {
+	if t.done {
+		return ErrDone
+	}
+	if t.err != nil {
+		if err := t.Discard(); err != nil {
+			klog.ErrorS(err, "Failed to discard transaction")
+		}
+		return t.err
+	}
+	t.done = true
+	for i, b := range t.bundles {
+		if err := t.control(b.id, ctrlCommit); err != nil {
+			if e, ok := err.(*Error); ok {
+				e.Committed = i
+			}
+			t.discard(t.bundles[i+1:])
+			return err
+		}
+	}
+	return nil
+}
+
+// Discard discards all bundles of the Transaction.
+func (t *Transaction) Discard() error {
+	if t.done {
+		return ErrDone
+	}
+	t.done = true
+	return t.discard(t.bundles)
+}
+
+func (t *Transaction) discard(bundles []*bundle) error {
+	var firstErr error
+	for _, b := range bundles {
+		if err := t.control(b.id, ctrlDiscard); err != nil {
+			klog.ErrorS(err, "Failed to discard bundle", "bundleID", b.id)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// xidMessage overrides the Xid of the message it wraps. A message added to a
+// bundle must have the Xid of the bundle add message.
+type xidMessage struct {
+	util.Message
+	xid uint32
+}
+
+func (m *xidMessage) MarshalBinary() (data []byte, err error) {
+	data, err = m.Message.MarshalBinary()
+	if err != nil {
+		return
+	}
+	if len(data) >= 8 {
+		binary.BigEndian.PutUint32(data[4:], m.xid)
+	}
+	return
+}
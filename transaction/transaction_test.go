@@ -0,0 +1,269 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/libOpenflow/openflow13"
+	"antrea.io/libOpenflow/openflow15"
+	"antrea.io/libOpenflow/util"
+)
+
+// fakeSwitch15 implements OpenFlow 1.5 bundles in memory.
+type fakeSwitch15 struct {
+	open      map[uint32][]util.Message
+	committed [][]util.Message
+	ctrls     []*openflow15.BundleCtrl
+	// rejectAdd rejects the n-th bundle add message, counting from 1.
+	rejectAdd int
+	adds      int
+	// rejectCommit rejects the n-th commit request, counting from 1.
+	rejectCommit int
+	commits      int
+}
+
+func newFakeSwitch15() *fakeSwitch15 {
+	return &fakeSwitch15{open: make(map[uint32][]util.Message)}
+}
+
+func errorReply15(xid uint32, errType, code uint16) util.Message {
+	e := openflow15.NewErrorMsg()
+	e.Header.Xid = xid
+	e.Type = errType
+	e.Code = code
+	return e
+}
+
+func (s *fakeSwitch15) RoundTrip(msgs ...util.Message) ([]util.Message, error) {
+	var replies []util.Message
+	for _, msg := range msgs {
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := openflow15.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		switch m := parsed.(type) {
+		case *openflow15.BundleCtrl:
+			s.ctrls = append(s.ctrls, m)
+			switch m.Type {
+			case openflow15.BCT_OPEN_REQUEST:
+				s.open[m.BundleId] = nil
+			case openflow15.BCT_COMMIT_REQUEST:
+				s.commits++
+				if s.commits == s.rejectCommit {
+					replies = append(replies, errorReply15(m.Header.Xid, openflow15.ET_BUNDLE_FAILED, openflow15.BFC_MSG_FAILED))
+					continue
+				}
+				s.committed = append(s.committed, s.open[m.BundleId])
+				delete(s.open, m.BundleId)
+			case openflow15.BCT_DISCARD_REQUEST:
+				delete(s.open, m.BundleId)
+			}
+			reply := openflow15.NewBundleCtrl(m.BundleId, m.Type+1, m.Flags)
+			reply.Header.Xid = m.Header.Xid
+			replies = append(replies, reply)
+		case *openflow15.BndleAdd:
+			s.adds++
+			if s.adds == s.rejectAdd {
+				replies = append(replies, errorReply15(m.Header.Xid, openflow15.ET_FLOW_MOD_FAILED, openflow15.FMFC_TABLE_FULL))
+				continue
+			}
+			if m.Message.(*openflow15.FlowMod).Header.Xid != m.Header.Xid {
+				replies = append(replies, errorReply15(m.Header.Xid, openflow15.ET_BUNDLE_FAILED, openflow15.BFC_MSG_BAD_XID))
+				continue
+			}
+			s.open[m.BundleId] = append(s.open[m.BundleId], m.Message)
+		}
+	}
+	return replies, nil
+}
+
+func newFlows(n int) []util.Message {
+	msgs := make([]util.Message, n)
+	for i := range msgs {
+		f := openflow15.NewFlowMod()
+		f.Priority = uint16(i)
+		msgs[i] = f
+	}
+	return msgs
+}
+
+func TestTransaction(t *testing.T) {
+	sw := newFakeSwitch15()
+	tx, err := Begin15(sw, Options{Flags: Atomic | Ordered})
+	require.NoError(t, err)
+	require.NoError(t, tx.Add(newFlows(3)...))
+	require.NoError(t, tx.Commit())
+
+	require.Len(t, sw.committed, 1)
+	assert.Len(t, sw.committed[0], 3)
+	assert.Empty(t, sw.open)
+	for _, ctrl := range sw.ctrls {
+		assert.Equal(t, uint16(openflow15.BF_ATOMIC|openflow15.BF_ORDERED), ctrl.Flags)
+		assert.Empty(t, ctrl.Properties)
+	}
+	assert.Equal(t, ErrDone, tx.Commit())
+}
+
+func TestTransactionScheduledCommit(t *testing.T) {
+	sw := newFakeSwitch15()
+	commitTime := time.Unix(1700000000, 500)
+	tx, err := Begin15(sw, Options{Flags: Atomic, CommitTime: commitTime})
+	require.NoError(t, err)
+	require.NoError(t, tx.Add(newFlows(1)...))
+	require.NoError(t, tx.Commit())
+
+	require.Len(t, sw.ctrls, 2)
+	commit := sw.ctrls[1]
+	assert.Equal(t, uint16(openflow15.BCT_COMMIT_REQUEST), commit.Type)
+	assert.Equal(t, uint16(openflow15.BF_ATOMIC|openflow15.BF_TIME), commit.Flags)
+	require.Len(t, commit.Properties, 1)
+	prop, ok := commit.Properties[0].(*openflow15.BundlePropTime)
+	require.True(t, ok)
+	assert.Equal(t, uint64(1700000000), prop.SchedTime.Seconds)
+	assert.Equal(t, uint32(500), prop.SchedTime.NanoSeconds)
+}
+
+func TestTransactionSplit(t *testing.T) {
+	sw := newFakeSwitch15()
+	tx, err := Begin15(sw, Options{Flags: Ordered, MaxMessages: 2})
+	require.NoError(t, err)
+	require.NoError(t, tx.Add(newFlows(3)...))
+	require.NoError(t, tx.Add(newFlows(2)...))
+	require.NoError(t, tx.Commit())
+
+	require.Len(t, sw.committed, 3)
+	assert.Len(t, sw.committed[0], 2)
+	assert.Len(t, sw.committed[1], 2)
+	assert.Len(t, sw.committed[2], 1)
+	assert.Equal(t, uint16(2), sw.committed[1][0].(*openflow15.FlowMod).Priority)
+
+	sw = newFakeSwitch15()
+	size := int(openflow15.NewBndleAdd(0, 0).Header.Len()) + 8 + int(openflow15.NewFlowMod().Len())
+	tx, err = Begin15(sw, Options{MaxBytes: 2*size + 1})
+	require.NoError(t, err)
+	require.NoError(t, tx.Add(newFlows(5)...))
+	require.NoError(t, tx.Commit())
+	assert.Len(t, sw.committed, 3)
+
+	// An atomic transaction is never split.
+	sw = newFakeSwitch15()
+	tx, err = Begin15(sw, Options{Flags: Atomic, MaxMessages: 2})
+	require.NoError(t, err)
+	require.NoError(t, tx.Add(newFlows(2)...))
+	assert.Equal(t, ErrTooLarge, tx.Add(newFlows(1)...))
+	assert.Equal(t, ErrTooLarge, tx.Commit())
+	assert.Empty(t, sw.committed)
+	assert.Empty(t, sw.open)
+}
+
+func TestTransactionAddError(t *testing.T) {
+	sw := newFakeSwitch15()
+	sw.rejectAdd = 3
+	tx, err := Begin15(sw, Options{MaxMessages: 2})
+	require.NoError(t, err)
+	msgs := newFlows(4)
+	err = tx.Add(msgs...)
+	require.Error(t, err)
+	txErr, ok := err.(*Error)
+	require.True(t, ok)
+	assert.Equal(t, msgs[2], txErr.Message)
+	assert.Equal(t, uint16(openflow15.ET_FLOW_MOD_FAILED), txErr.Type)
+
+	assert.Equal(t, err, tx.Commit())
+	assert.Empty(t, sw.committed)
+	assert.Empty(t, sw.open)
+}
+
+func TestTransactionCommitError(t *testing.T) {
+	sw := newFakeSwitch15()
+	sw.rejectCommit = 2
+	tx, err := Begin15(sw, Options{MaxMessages: 1})
+	require.NoError(t, err)
+	require.NoError(t, tx.Add(newFlows(3)...))
+	err = tx.Commit()
+	require.Error(t, err)
+	txErr, ok := err.(*Error)
+	require.True(t, ok)
+	assert.Equal(t, 1, txErr.Committed)
+	assert.Contains(t, txErr.Error(), "partially applied")
+	assert.Nil(t, txErr.Message)
+	assert.Len(t, sw.committed, 1)
+	// The bundle that failed to commit is left to the switch, the last one
+	// is discarded.
+	assert.Len(t, sw.open, 1)
+}
+
+// fakeSwitch13 implements the ONF bundle extension of OpenFlow 1.3 in memory.
+type fakeSwitch13 struct {
+	open      map[uint32][]util.Message
+	committed [][]util.Message
+}
+
+func (s *fakeSwitch13) RoundTrip(msgs ...util.Message) ([]util.Message, error) {
+	var replies []util.Message
+	for _, msg := range msgs {
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := openflow13.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		vh := parsed.(*openflow13.VendorHeader)
+		switch m := vh.VendorData.(type) {
+		case *openflow13.BundleControl:
+			switch m.Type {
+			case openflow13.OFPBCT_OPEN_REQUEST:
+				s.open[m.BundleID] = nil
+			case openflow13.OFPBCT_COMMIT_REQUEST:
+				s.committed = append(s.committed, s.open[m.BundleID])
+				delete(s.open, m.BundleID)
+			case openflow13.OFPBCT_DISCARD_REQUEST:
+				delete(s.open, m.BundleID)
+			}
+			reply := openflow13.NewBundleControl(&openflow13.BundleControl{BundleID: m.BundleID, Type: m.Type + 1, Flags: m.Flags})
+			reply.Header.Xid = vh.Header.Xid
+			replies = append(replies, reply)
+		case *openflow13.BundleAdd:
+			if _, ok := s.open[m.BundleID]; !ok {
+				e := openflow13.NewBundleError()
+				e.Header.Xid = vh.Header.Xid
+				e.Code = openflow13.BEC_BAD_ID
+				replies = append(replies, e)
+				continue
+			}
+			s.open[m.BundleID] = append(s.open[m.BundleID], m.Message)
+		}
+	}
+	return replies, nil
+}
+
+func TestTransaction13(t *testing.T) {
+	sw := &fakeSwitch13{open: make(map[uint32][]util.Message)}
+	_, err := Begin13(sw, Options{CommitTime: time.Now()})
+	assert.Equal(t, ErrSchedUnsupported, err)
+
+	tx, err := Begin13(sw, Options{Flags: Ordered, MaxMessages: 2})
+	require.NoError(t, err)
+	msgs := []util.Message{openflow13.NewFlowMod(), openflow13.NewFlowMod(), openflow13.NewFlowMod()}
+	require.NoError(t, tx.Add(msgs...))
+	require.NoError(t, tx.Commit())
+	require.Len(t, sw.committed, 2)
+	assert.Len(t, sw.committed[0], 2)
+	assert.Len(t, sw.committed[1], 1)
+
+	tx, err = Begin13(sw, Options{})
+	require.NoError(t, err)
+	require.NoError(t, tx.Add(openflow13.NewFlowMod()))
+	require.NoError(t, tx.Discard())
+	assert.Len(t, sw.committed, 2)
+	assert.Empty(t, sw.open)
+}
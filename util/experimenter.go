@@ -0,0 +1,210 @@
+package util
+
+// This file has the registry of the experimenter extensions
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// experimenterKey identifies an experimenter extension by the experimenter ID
+// and the type the experimenter defines it with.
+type experimenterKey struct {
+	experimenter uint32
+	expType      uint32
+}
+
+// ExperimenterRegistry holds the decoders registered for the experimenter
+// messages, actions, instructions, match fields, meter bands and properties.
+// They take precedence over the decoders of the library. The decoders return
+// the types of an OpenFlow version, so each version package has its registry.
+//
+// The decoders are called once when they are registered, so that the JSON
+// codec of the registry learns the types of their values.
+type ExperimenterRegistry struct {
+	codec        *JSONCodec
+	mu           sync.RWMutex
+	messages     map[experimenterKey]func() Message
+	actions      map[experimenterKey]func() Message
+	instructions map[uint32]func() Message
+	fields       map[experimenterKey]func(length uint8) Message
+	meterBands   map[uint32]func() Message
+	properties   map[experimenterKey]func() Message
+}
+
+// NewExperimenterRegistry returns an empty ExperimenterRegistry, which registers
+// the types of the values of its decoders with codec if it is not nil.
+func NewExperimenterRegistry(codec *JSONCodec) *ExperimenterRegistry {
+	return &ExperimenterRegistry{
+		codec:        codec,
+		messages:     make(map[experimenterKey]func() Message),
+		actions:      make(map[experimenterKey]func() Message),
+		instructions: make(map[uint32]func() Message),
+		fields:       make(map[experimenterKey]func(length uint8) Message),
+		meterBands:   make(map[uint32]func() Message),
+		properties:   make(map[experimenterKey]func() Message),
+	}
+}
+
+// RegisterMessage registers newMessage to decode the data of the experimenter
+// messages of the experimenter and of the type expType, which follows the
+// experimenter header. A nil newMessage removes the registration.
+func (r *ExperimenterRegistry) RegisterMessage(experimenter, expType uint32, newMessage func() Message) {
+	if newMessage != nil {
+		r.registerJSON(newMessage())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages[experimenterKey{experimenter, expType}] = newMessage
+}
+
+// RegisterAction registers newAction to decode the experimenter actions of the
+// experimenter and of the subtype, which is the 16 bits following the
+// experimenter ID as in the Nicira and ONF extensions. A nil newAction removes
+// the registration.
+func (r *ExperimenterRegistry) RegisterAction(experimenter uint32, subtype uint16, newAction func() Message) {
+	if newAction != nil {
+		r.registerJSON(newAction())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions[experimenterKey{experimenter, uint32(subtype)}] = newAction
+}
+
+// RegisterInstruction registers newInstr to decode the experimenter
+// instructions of the experimenter. A nil newInstr removes the registration.
+func (r *ExperimenterRegistry) RegisterInstruction(experimenter uint32, newInstr func() Message) {
+	if newInstr != nil {
+		r.registerJSON(newInstr())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.instructions[experimenter] = newInstr
+}
+
+// RegisterField registers newValue to decode the values and the masks of the
+// match fields of the experimenter class with the experimenter ID and the
+// field. newValue is called with the length of the value, which excludes the
+// experimenter ID. A nil newValue removes the registration.
+func (r *ExperimenterRegistry) RegisterField(experimenter uint32, field uint8, newValue func(length uint8) Message) {
+	if newValue != nil {
+		r.registerJSON(newValue(0))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fields[experimenterKey{experimenter, uint32(field)}] = newValue
+}
+
+// RegisterMeterBand registers newBand to decode the experimenter meter bands of
+// the experimenter. A nil newBand removes the registration.
+func (r *ExperimenterRegistry) RegisterMeterBand(experimenter uint32, newBand func() Message) {
+	if newBand != nil {
+		r.registerJSON(newBand())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.meterBands[experimenter] = newBand
+}
+
+// RegisterProperty registers newProp to decode the experimenter properties of
+// the experimenter and of the type expType, in any list of properties. A nil
+// newProp removes the registration.
+func (r *ExperimenterRegistry) RegisterProperty(experimenter, expType uint32, newProp func() Message) {
+	if newProp != nil {
+		r.registerJSON(newProp())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.properties[experimenterKey{experimenter, expType}] = newProp
+}
+
+// registerJSON registers the type of v with the JSON codec of r.
+func (r *ExperimenterRegistry) registerJSON(v Message) {
+	if r.codec != nil {
+		r.codec.Register(v)
+	}
+}
+
+// NewMessage returns the data of a message registered for the experimenter and
+// the type expType, or nil if there is none.
+func (r *ExperimenterRegistry) NewMessage(experimenter, expType uint32) Message {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if newMessage := r.messages[experimenterKey{experimenter, expType}]; newMessage != nil {
+		return newMessage()
+	}
+	return nil
+}
+
+// NewAction returns an action registered for the experimenter action in data,
+// or nil if there is none. data starts with the action header, followed by the
+// experimenter ID and the subtype.
+func (r *ExperimenterRegistry) NewAction(data []byte) Message {
+	if len(data) < 10 {
+		return nil
+	}
+	key := experimenterKey{binary.BigEndian.Uint32(data[4:]), uint32(binary.BigEndian.Uint16(data[8:]))}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if newAction := r.actions[key]; newAction != nil {
+		return newAction()
+	}
+	return nil
+}
+
+// NewInstruction returns an instruction registered for the experimenter
+// instruction in data, or nil if there is none. data starts with the
+// instruction header, followed by the experimenter ID.
+func (r *ExperimenterRegistry) NewInstruction(data []byte) Message {
+	if len(data) < 8 {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if newInstr := r.instructions[binary.BigEndian.Uint32(data[4:])]; newInstr != nil {
+		return newInstr()
+	}
+	return nil
+}
+
+// NewField returns the value or the mask of length bytes of a match field
+// registered for the experimenter and the field, or nil if there is none.
+func (r *ExperimenterRegistry) NewField(experimenter uint32, field uint8, length uint8) Message {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if newValue := r.fields[experimenterKey{experimenter, uint32(field)}]; newValue != nil {
+		return newValue(length)
+	}
+	return nil
+}
+
+// NewMeterBand returns a meter band registered for the experimenter meter band
+// in data, or nil if there is none. data starts with the 12 bytes of the meter
+// band header, followed by the experimenter ID.
+func (r *ExperimenterRegistry) NewMeterBand(data []byte) Message {
+	if len(data) < 16 {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if newBand := r.meterBands[binary.BigEndian.Uint32(data[12:])]; newBand != nil {
+		return newBand()
+	}
+	return nil
+}
+
+// NewProperty returns a property registered for the experimenter property in
+// data, or nil if there is none. data starts with the property header, followed
+// by the experimenter ID and the experimenter type.
+func (r *ExperimenterRegistry) NewProperty(data []byte) Message {
+	if len(data) < 12 {
+		return nil
+	}
+	key := experimenterKey{binary.BigEndian.Uint32(data[4:]), binary.BigEndian.Uint32(data[8:])}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if newProp := r.properties[key]; newProp != nil {
+		return newProp()
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+package util
+
+// This file encodes OpenFlow structs to JSON and back, including the concrete
+// type of the values held by interface fields.
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+var (
+	bufferType       = reflect.TypeOf(Buffer{})
+	hardwareAddrType = reflect.TypeOf(net.HardwareAddr{})
+	jsonMarshaler    = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshaler  = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshaler    = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshaler  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// JSONCodec converts structs to JSON and back without losing information, so
+// that the wire format of a decoded message is the one of the encoded message.
+//
+// Struct fields are written with their Go name. Unexported fields, which only
+// hold padding in most messages, are skipped: the types which keep their state
+// in unexported fields must have MarshalJSON and UnmarshalJSON methods, such as
+// the ones of MarshalBinaryJSON and UnmarshalBinaryJSON. A value held by an
+// interface field is written as {"<type name>": <value>}, where the type must
+// be registered with the codec. Byte slices are written as hex strings, IP and
+// MAC addresses in their text form.
+type JSONCodec struct {
+	home  string
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}
+
+// NewJSONCodec returns a JSONCodec for the types of values, which are usually
+// pointers. Types are named after the package of the first value: types of
+// that package by their name, others qualified with their package name.
+func NewJSONCodec(values ...interface{}) *JSONCodec {
+	c := &JSONCodec{
+		types: make(map[string]reflect.Type, len(values)),
+		names: make(map[reflect.Type]string, 2*len(values)),
+	}
+	c.Register(values...)
+	return c
+}
+
+// Register adds the types of values to the codec, such as the types of the
+// experimenter extensions registered by applications. A type keeps the name
+// it is first registered with, and a name the type it is first given to.
+func (c *JSONCodec) Register(values ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, v := range values {
+		t := reflect.TypeOf(v)
+		if t == nil {
+			continue
+		}
+		if _, ok := c.names[t]; ok {
+			continue
+		}
+		base := t
+		if base.Kind() == reflect.Ptr {
+			base = base.Elem()
+		}
+		if c.home == "" {
+			c.home = base.PkgPath()
+		}
+		name := base.Name()
+		if base.PkgPath() != c.home {
+			name = path.Base(base.PkgPath()) + "." + name
+		}
+		if _, ok := c.types[name]; ok {
+			continue
+		}
+		c.types[name] = t
+		c.names[t] = name
+		if _, ok := c.names[base]; !ok {
+			c.names[base] = name
+		}
+	}
+}
+
+func (c *JSONCodec) typeName(t reflect.Type) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	name, ok := c.names[t]
+	return name, ok
+}
+
+func (c *JSONCodec) namedType(name string) (reflect.Type, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	t, ok := c.types[name]
+	return t, ok
+}
+
+// Marshal returns the JSON encoding of v. When v is a pointer to a struct, its
+// fields are encoded even if it has a MarshalJSON method, so that the method
+// can call Marshal.
+func (c *JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	rv := addressable(reflect.ValueOf(v))
+	var err error
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		err = c.encodeStruct(&buf, rv.Elem())
+	} else {
+		err = c.encode(&buf, rv)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes data into v, which must be a pointer. When v is a pointer
+// to a struct, its fields are decoded even if it has an UnmarshalJSON method,
+// so that the method can call Unmarshal.
+func (c *JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cannot unmarshal JSON into %T", v)
+	}
+	if rv.Elem().Kind() == reflect.Struct && !isNull(data) {
+		return c.decodeStruct(data, rv.Elem())
+	}
+	return c.decode(data, rv.Elem())
+}
+
+// MarshalMessage returns the JSON encoding of msg with its type.
+func (c *JSONCodec) MarshalMessage(msg Message) ([]byte, error) {
+	return c.Marshal(&msg)
+}
+
+// UnmarshalMessage decodes a message encoded by MarshalMessage.
+func (c *JSONCodec) UnmarshalMessage(data []byte) (Message, error) {
+	var msg Message
+	if err := c.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// MarshalBinaryJSON returns the JSON encoding of m as the hex string of its wire
+// format. It implements the MarshalJSON method of the messages which keep their
+// state in unexported fields.
+func MarshalBinaryJSON(m Message) ([]byte, error) {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(hex.EncodeToString(data))
+}
+
+// UnmarshalBinaryJSON decodes data encoded by MarshalBinaryJSON into m.
+func UnmarshalBinaryJSON(data []byte, m Message) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return m.UnmarshalBinary(b)
+}
+
+// addressable returns an addressable copy of v, so that the methods of its
+// pointer can be called.
+func addressable(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.CanAddr() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+func isNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+func (c *JSONCodec) encode(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+	t := v.Type()
+	switch {
+	case t == bufferType:
+		b := v.Addr().Interface().(*Buffer)
+		return c.encodeJSON(buf, hex.EncodeToString(b.Bytes()))
+	case t == hardwareAddrType:
+		return c.encodeJSON(buf, v.Interface().(net.HardwareAddr).String())
+	case t.Kind() == reflect.Struct && v.CanAddr() && reflect.PtrTo(t).Implements(jsonMarshaler):
+		return c.encodeJSON(buf, v.Addr().Interface())
+	case t.Kind() != reflect.Struct && t.Implements(textMarshaler):
+		if (t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr) && v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return c.encodeJSON(buf, v.Interface())
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		concrete := v.Elem()
+		name, ok := c.typeName(concrete.Type())
+		if !ok {
+			return fmt.Errorf("cannot marshal unregistered type %s to JSON", concrete.Type())
+		}
+		buf.WriteString("{" + strconv.Quote(name) + ":")
+		if err := c.encode(buf, addressable(concrete)); err != nil {
+			return err
+		}
+		buf.WriteString("}")
+	case reflect.Ptr:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return c.encode(buf, v.Elem())
+	case reflect.Struct:
+		return c.encodeStruct(buf, v)
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return c.encodeJSON(buf, hex.EncodeToString(b))
+		}
+		buf.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := c.encode(buf, addressable(v.Index(i))); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return c.encodeJSON(buf, v.Interface())
+	default:
+		return fmt.Errorf("cannot marshal %s to JSON", t)
+	}
+	return nil
+}
+
+// encodeStruct writes the exported fields of the struct v.
+func (c *JSONCodec) encodeStruct(buf *bytes.Buffer, v reflect.Value) error {
+	t := v.Type()
+	buf.WriteString("{")
+	first := true
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		if !first {
+			buf.WriteString(",")
+		}
+		first = false
+		buf.WriteString(strconv.Quote(t.Field(i).Name) + ":")
+		if err := c.encode(buf, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("}")
+	return nil
+}
+
+func (c *JSONCodec) encodeJSON(buf *bytes.Buffer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+func (c *JSONCodec) decode(data []byte, v reflect.Value) error {
+	t := v.Type()
+	if isNull(data) {
+		v.Set(reflect.Zero(t))
+		return nil
+	}
+	switch {
+	case t == bufferType, t == hardwareAddrType:
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if t == hardwareAddrType {
+			addr, err := net.ParseMAC(s)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(addr))
+			return nil
+		}
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*NewBuffer(b)))
+		return nil
+	case t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(jsonUnmarshaler):
+		return v.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(data)
+	case t.Kind() != reflect.Struct && reflect.PtrTo(t).Implements(textUnmarshaler):
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		if len(obj) != 1 {
+			return fmt.Errorf("cannot unmarshal JSON into %s: expecting a single type name", t)
+		}
+		for name, value := range obj {
+			ct, ok := c.namedType(name)
+			if !ok {
+				return fmt.Errorf("cannot unmarshal JSON into %s: unknown type %q", t, name)
+			}
+			if !ct.Implements(t) {
+				return fmt.Errorf("cannot unmarshal JSON into %s: %s does not implement it", t, name)
+			}
+			concrete := reflect.New(ct).Elem()
+			if err := c.decode(value, concrete); err != nil {
+				return err
+			}
+			v.Set(concrete)
+		}
+	case reflect.Ptr:
+		elem := reflect.New(t.Elem())
+		if err := c.decode(data, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Struct:
+		return c.decodeStruct(data, v)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			var s string
+			if err := json.Unmarshal(data, &s); err != nil {
+				return err
+			}
+			b, err := hex.DecodeString(s)
+			if err != nil {
+				return err
+			}
+			if t.Kind() == reflect.Slice {
+				v.Set(reflect.MakeSlice(t, len(b), len(b)))
+			} else if len(b) != v.Len() {
+				return fmt.Errorf("cannot unmarshal %d bytes into %s", len(b), t)
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return err
+		}
+		if t.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(t, len(elems), len(elems)))
+		} else if len(elems) != v.Len() {
+			return fmt.Errorf("cannot unmarshal %d elements into %s", len(elems), t)
+		}
+		for i, elem := range elems {
+			if err := c.decode(elem, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+	return nil
+}
+
+// decodeStruct decodes the exported fields of the addressable struct v.
+func (c *JSONCodec) decodeStruct(data []byte, v reflect.Value) error {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		value, ok := obj[t.Field(i).Name]
+		if !ok {
+			continue
+		}
+		if err := c.decode(value, v.Field(i)); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), t.Field(i).Name, err)
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"net"
 	"strings"
 
@@ -164,7 +165,17 @@ func (m *MessageStream) inbound() {
 				if hdr >= 4 {
 					// MessageStream is not protocol agnostic. Reading length based
 					// on OpenFlow header field.
-					msgLen = int(binary.BigEndian.Uint16(hdrBuf[2:])) - 4
+					length := int(binary.BigEndian.Uint16(hdrBuf[2:]))
+					// A message shorter than the OpenFlow header cannot be
+					// framed, and the rest of the stream is unreadable.
+					if length < 8 {
+						err := fmt.Errorf("invalid OpenFlow message length %d", length)
+						klog.ErrorS(err, "InboundError")
+						m.Error <- err
+						m.Shutdown <- true
+						return
+					}
+					msgLen = length - 4
 				}
 				continue
 			}
@@ -187,6 +198,8 @@ func (m *MessageStream) dispatchMessage(b *bytes.Buffer) {
 	msgBytes := b.Bytes()
 	if len(msgBytes) < 8 {
 		klog.Error("Buffer too small to parse OpenFlow messages")
+		b.Reset()
+		m.pool.Empty <- b
 		return
 	}
 	xid := binary.BigEndian.Uint32(msgBytes[4:])
@@ -0,0 +1,100 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Unknown is implemented by the values which the decoders keep for the match
+// fields, actions, instructions, properties and experimenter payloads they
+// don't know. Those values hold the bytes of the items, so that a parsed
+// message is encoded again unchanged.
+type Unknown interface {
+	// UnknownError returns the error reported for the value when decoding
+	// is strict.
+	UnknownError() error
+}
+
+// BufferUnknown is an experimenter payload or a message body which is not
+// known.
+type BufferUnknown struct {
+	Buffer
+}
+
+// UnknownError implements Unknown.
+func (b *BufferUnknown) UnknownError() error {
+	return fmt.Errorf("unknown payload of %d bytes", b.Len())
+}
+
+// CheckKnown returns an error if msg holds Unknown values, that is if the
+// decoding of msg met items it doesn't know. Decoding a message and checking
+// it with CheckKnown is decoding it strictly.
+func CheckKnown(msg Message) error {
+	if msg == nil {
+		return nil
+	}
+	v := reflect.ValueOf(msg)
+	return checkKnown(v, reflect.Indirect(v).Type().Name())
+}
+
+func checkKnown(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if u, ok := v.Interface().(Unknown); ok {
+			return fmt.Errorf("%s: %w", path, u.UnknownError())
+		}
+		return checkKnown(v.Elem(), path)
+	case reflect.Struct:
+		if v.CanAddr() {
+			if u, ok := v.Addr().Interface().(Unknown); ok {
+				return fmt.Errorf("%s: %w", path, u.UnknownError())
+			}
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if err := checkKnown(v.Field(i), path+"."+t.Field(i).Name); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := checkKnown(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type strictParser struct {
+	parser Parser
+}
+
+// StrictParser returns a Parser which decodes messages with parser and rejects
+// those which hold Unknown values. A MessageStream created with it only
+// delivers the messages it fully knows:
+//
+//	stream := util.NewMessageStream(conn, util.StrictParser(parser))
+func StrictParser(parser Parser) Parser {
+	return &strictParser{parser}
+}
+
+func (p *strictParser) Parse(b []byte) (Message, error) {
+	msg, err := p.parser.Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckKnown(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}